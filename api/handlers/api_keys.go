@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/service"
+	coreErrors "github.com/lee-tech/core/errors"
+	coreMiddleware "github.com/lee-tech/core/middleware"
+	"github.com/lee-tech/core/utils"
+)
+
+type apiKeyContextKey struct{}
+
+// apiKeyCredential returns the key of an "Authorization: ApiKey <key>" header.
+func apiKeyCredential(r *http.Request) (string, bool) {
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(header) < len("ApiKey ") || !strings.EqualFold(header[:len("ApiKey ")], "ApiKey ") {
+		return "", false
+	}
+	return strings.TrimSpace(header[len("ApiKey "):]), true
+}
+
+// requestAPIKey returns the API key the request was authenticated with, or nil for bearer tokens.
+func requestAPIKey(r *http.Request) *models.APIKey {
+	key, _ := r.Context().Value(apiKeyContextKey{}).(*models.APIKey)
+	return key
+}
+
+// authenticateAPIKey resolves an ApiKey credential and places its owner and their token details in the
+// request context the same way authMiddleware does for access tokens, so hasPermission and
+// requireSuperAdmin judge the key by its owner's roles and scopes.
+func authenticateAPIKey(authService *service.AuthenticationService, plaintext string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, details, err := authService.AuthenticateAPIKey(plaintext)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidAPIKey) {
+				coreErrors.Unauthorized("Invalid API key").WriteHTTP(w)
+				return
+			}
+			writeInternalError(w, "failed to validate API key", err)
+			return
+		}
+		ctx := context.WithValue(r.Context(), coreMiddleware.UserIDKey, strconv.FormatUint(key.UserID, 10))
+		ctx = context.WithValue(ctx, tokenDetailsContextKey{}, details)
+		ctx = context.WithValue(ctx, apiKeyContextKey{}, key)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ListAPIKeys returns the caller's API keys without their secrets.
+func (h *AuthenticationHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := h.authenticationService.ListAPIKeys(userID)
+	if err != nil {
+		writeInternalError(w, "failed to list API keys", err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, keys)
+}
+
+// CreateAPIKey issues an API key to the caller, bound to the organization the caller's token was issued
+// for. The plaintext key is only returned in this response. Requests authenticated with an API key cannot
+// issue further keys.
+func (h *AuthenticationHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+	if requestAPIKey(r) != nil {
+		coreErrors.Forbidden("API keys cannot issue API keys; sign in with a password").WriteHTTP(w)
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := utils.DecodeJSON(r.Body, &req); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+
+	details, err := h.authenticationService.ValidateTokenDetailed(bearerToken(r))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			coreErrors.Unauthorized("Invalid or expired token").WriteHTTP(w)
+			return
+		}
+		writeInternalError(w, "failed to validate token", err)
+		return
+	}
+
+	key, plaintext, err := h.authenticationService.IssueAPIKey(userID, details.OrganizationID, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotOrganizationMember):
+			coreErrors.Forbidden("you are no longer a member of the token's organization").WriteHTTP(w)
+		case errors.Is(err, service.ErrInvalidAPIKeyRequest):
+			coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		case errors.Is(err, service.ErrUserNotFound):
+			coreErrors.Unauthorized("user no longer exists").WriteHTTP(w)
+		default:
+			writeInternalError(w, "failed to issue API key", err)
+		}
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, &models.CreateAPIKeyResponse{APIKey: key, Key: plaintext})
+}
+
+// DeleteAPIKey revokes one of the caller's API keys.
+func (h *AuthenticationHandler) DeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+	keyID, err := utils.ParseUint64(mux.Vars(r)["api_key_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid API key id").WriteHTTP(w)
+		return
+	}
+
+	if err := h.authenticationService.RevokeAPIKey(userID, keyID); err != nil {
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			coreErrors.NotFound("API key").WriteHTTP(w)
+			return
+		}
+		writeInternalError(w, "failed to revoke API key", err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]bool{
+		"revoked": true,
+	})
+}
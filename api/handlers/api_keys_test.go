@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestAPIKeyEndpoints(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	org := env.createOrganization(t, "Acme", nil)
+	owner := env.createUser(t, "owner", nil)
+	env.addMember(t, owner, org, "CEO")
+	intruder := env.createUser(t, "intruder", nil)
+	env.addMember(t, intruder, org, "CEO")
+	ownerToken := env.loginToken(t, owner, org)
+	intruderToken := env.loginToken(t, intruder, org)
+
+	rec := env.doAuthenticated(t, ownerToken, http.MethodPost, "/v1/auth/me/api-keys", `{"name":"billing-sync"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	var created models.CreateAPIKeyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode created key: %v", err)
+	}
+	if created.Key == "" || created.APIKey == nil {
+		t.Fatalf("create response = %s, want the key and its record", rec.Body.String())
+	}
+	if created.OrganizationID == nil || *created.OrganizationID != org.ID {
+		t.Errorf("created key organization = %v, want the token's organization %d", created.OrganizationID, org.ID)
+	}
+
+	// The key itself cannot issue further keys
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/me/api-keys", strings.NewReader(`{"name":"nested"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "ApiKey "+created.Key)
+	rec = httptest.NewRecorder()
+	env.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("create with an API key: status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	listKeys := func(token string) []*models.APIKey {
+		t.Helper()
+		rec := env.doAuthenticated(t, token, http.MethodGet, "/v1/auth/me/api-keys", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("list: status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+		}
+		if strings.Contains(rec.Body.String(), created.Key) {
+			t.Fatal("list response contains the key secret")
+		}
+		var keys []*models.APIKey
+		if err := json.Unmarshal(rec.Body.Bytes(), &keys); err != nil {
+			t.Fatalf("decode keys: %v", err)
+		}
+		return keys
+	}
+	if keys := listKeys(ownerToken); len(keys) != 1 || keys[0].ID != created.ID {
+		t.Fatalf("owner keys = %+v, want the created key", keys)
+	}
+	if keys := listKeys(intruderToken); len(keys) != 0 {
+		t.Fatalf("intruder keys = %+v, want none", keys)
+	}
+
+	target := fmt.Sprintf("/v1/auth/me/api-keys/%d", created.ID)
+	if rec := env.doAuthenticated(t, intruderToken, http.MethodDelete, target, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("delete another user's key: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := env.doAuthenticated(t, ownerToken, http.MethodDelete, "/v1/auth/me/api-keys/abc", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("delete invalid id: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if rec := env.do(t, http.MethodDelete, target, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous delete: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec := env.doAuthenticated(t, ownerToken, http.MethodDelete, target, ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete own key: status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if keys := listKeys(ownerToken); len(keys) != 0 {
+		t.Fatalf("owner keys after delete = %+v, want none", keys)
+	}
+}
+
+func TestDeleteAPIKeyRequiresStepUp(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	org := env.createOrganization(t, "Acme", nil)
+	user := env.createUser(t, "stepper", nil)
+	env.addMember(t, user, org, "CEO")
+	key, _, err := env.auth.IssueAPIKey(user.ID, nil, "billing-sync", nil, nil)
+	if err != nil {
+		t.Fatalf("issue API key: %v", err)
+	}
+	secret, _ := env.enableMFA(t, user)
+	login, err := env.auth.Login(&models.LoginRequest{Username: user.Username, Password: testPassword, OrganizationID: org.ID, MFACode: totp(t, secret)})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	stepUp, err := env.auth.ChallengeMFA(user.ID, totp(t, secret))
+	if err != nil {
+		t.Fatalf("challenge: %v", err)
+	}
+
+	target := fmt.Sprintf("/v1/auth/me/api-keys/%d", key.ID)
+	for _, tt := range []struct {
+		name   string
+		stepUp string
+		status int
+	}{
+		{"without step-up", "", http.StatusUnauthorized},
+		{"with step-up", stepUp.StepUpToken, http.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, target, nil)
+			req.Header.Set("Authorization", "Bearer "+login.AccessToken)
+			if tt.stepUp != "" {
+				req.Header.Set(StepUpTokenHeader, tt.stepUp)
+			}
+			rec := httptest.NewRecorder()
+			env.router.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	user := env.createUser(t, "machine", nil)
+	key, plaintext, err := env.auth.IssueAPIKey(user.ID, nil, "billing-sync", nil, nil)
+	if err != nil {
+		t.Fatalf("issue API key: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"api key", "ApiKey " + plaintext, http.StatusOK},
+		{"scheme is case-insensitive", "apikey " + plaintext, http.StatusOK},
+		{"unknown key", "ApiKey ak_unknown", http.StatusUnauthorized},
+		{"key as bearer token", "Bearer " + plaintext, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/auth/me/api-keys", nil)
+			req.Header.Set("Authorization", tt.header)
+			rec := httptest.NewRecorder()
+			env.router.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var keys []*models.APIKey
+			if err := json.Unmarshal(rec.Body.Bytes(), &keys); err != nil {
+				t.Fatalf("decode keys: %v", err)
+			}
+			if len(keys) != 1 || keys[0].ID != key.ID || keys[0].LastUsedAt == nil {
+				t.Errorf("keys = %+v, want the key with last_used_at recorded", keys)
+			}
+		})
+	}
+}
+
+func TestAPIKeyPermissions(t *testing.T) {
+	env := newHandlerEnv(t, func(cfg *config.AuthConfig) {
+		cfg.RoleScopes = map[string][]string{"AUDITOR": {"auth.users.read"}}
+	}, nil)
+	org := env.createOrganization(t, "Acme", nil)
+	admin := env.createUser(t, "admin", func(u *models.User) { u.IsSuperAdmin = true })
+	auditor := env.createUser(t, "auditor", func(u *models.User) {
+		u.IsSuperAdmin = true
+		u.PrimaryOrganizationID = &org.ID
+	})
+	env.addMember(t, auditor, org, "AUDITOR")
+	member := env.createUser(t, "member", nil)
+	env.addMember(t, member, org, "CEO")
+
+	issue := func(user *models.User, scopes ...string) string {
+		t.Helper()
+		_, plaintext, err := env.auth.IssueAPIKey(user.ID, nil, "key", scopes, nil)
+		if err != nil {
+			t.Fatalf("issue API key: %v", err)
+		}
+		return plaintext
+	}
+	unscoped := issue(admin)
+	readOnly := issue(admin, "auth.users.read")
+	wildcard := issue(admin, "auth.users.*")
+	roleScoped := issue(auditor)
+	plain := issue(member)
+
+	read := fmt.Sprintf("/v1/auth/admin/users/%d", member.ID)
+	security := read + "/security"
+	tests := []struct {
+		name   string
+		key    string
+		target string
+		status int
+	}{
+		{"unscoped super admin key reads", unscoped, read, http.StatusOK},
+		{"unscoped super admin key reads security", unscoped, security, http.StatusOK},
+		{"read scope reads", readOnly, read, http.StatusOK},
+		{"read scope is denied security", readOnly, security, http.StatusForbidden},
+		{"wildcard scope reads security", wildcard, security, http.StatusOK},
+		{"role scopes apply to the key", roleScoped, read, http.StatusOK},
+		{"role scopes deny security", roleScoped, security, http.StatusForbidden},
+		{"key of a regular user", plain, read, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.Header.Set("Authorization", "ApiKey "+tt.key)
+			rec := httptest.NewRecorder()
+			env.router.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+		})
+	}
+}
@@ -1,14 +1,21 @@
 package handlers
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/lee-tech/authentication/config"
 	"github.com/lee-tech/authentication/internal/constants"
 	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/ratelimit"
 	"github.com/lee-tech/authentication/internal/service"
 	coreErrors "github.com/lee-tech/core/errors"
 	coreMiddleware "github.com/lee-tech/core/middleware"
@@ -20,28 +27,74 @@ import (
 type AuthenticationHandler struct {
 	authenticationService *service.AuthenticationService
 	useAuthorization      bool
-	authorizationBuilder  coreMiddleware.AuthorizationRequestBuilder
+	// authorizationUnavailable makes admin routes fail closed when STRICT_AUTHORIZATION is set.
+	authorizationUnavailable bool
+	authorizationBuilder     coreMiddleware.AuthorizationRequestBuilder
+	loginLimiter             ratelimit.Limiter
+	limitLoginByUsername     bool
+	// router is the root router, kept to enumerate admin routes.
+	router *mux.Router
+	// routes documents the registered routes for the route inventory.
+	routes *RouteCatalog
 }
 
 // NewAuthenticationHandler creates a new auth handler
-func NewAuthenticationHandler(authService *service.AuthenticationService, useAuthorization bool, builder coreMiddleware.AuthorizationRequestBuilder) *AuthenticationHandler {
+func NewAuthenticationHandler(authService *service.AuthenticationService, useAuthorization, authorizationUnavailable bool, builder coreMiddleware.AuthorizationRequestBuilder) *AuthenticationHandler {
 	if builder == nil {
 		builder = NewAdminAuthorizationBuilder()
 	}
 	return &AuthenticationHandler{
-		authenticationService: authService,
-		useAuthorization:      useAuthorization,
-		authorizationBuilder:  builder,
+		authenticationService:    authService,
+		useAuthorization:         useAuthorization,
+		authorizationUnavailable: authorizationUnavailable,
+		authorizationBuilder:     builder,
+		routes:                   NewRouteCatalog(),
 	}
 }
 
+// WithRouteCatalog records the handler's routes in catalog, which handlers sharing a router share.
+func (h *AuthenticationHandler) WithRouteCatalog(catalog *RouteCatalog) *AuthenticationHandler {
+	if catalog != nil {
+		h.routes = catalog
+	}
+	return h
+}
+
+// WithLoginRateLimiter limits login attempts per client IP and, when byUsername is set, per submitted username.
+// The other anonymous credential endpoints share the limiter, each counting callers in its own buckets.
+func (h *AuthenticationHandler) WithLoginRateLimiter(limiter ratelimit.Limiter, byUsername bool) *AuthenticationHandler {
+	h.loginLimiter = limiter
+	h.limitLoginByUsername = byUsername
+	return h
+}
+
+// loginHandler applies the login rate limits to Login.
+func (h *AuthenticationHandler) loginHandler() http.HandlerFunc {
+	handler := http.HandlerFunc(h.Login)
+	if h.limitLoginByUsername {
+		handler = rateLimited(h.loginLimiter, endpointKey("login", loginUsernameKey), handler)
+	}
+	return rateLimited(h.loginLimiter, endpointKey("login", clientIP), handler)
+}
+
+// minimalResponseParam documents the minimal option of the token-issuing endpoints.
+var minimalResponseParam = coreServer.ParamMeta{
+	Name:        "minimal",
+	In:          coreServer.ParamInQuery,
+	Required:    false,
+	Description: "When true, return only the tokens and the user's id and username; fetch the rest from /v1/auth/me",
+}
+
 // RegisterRoutes registers all auth routes
 func (h *AuthenticationHandler) RegisterRoutes(router *mux.Router) {
+	h.router = router
+
 	// Public routes (no auth required)
-	coreServer.Route(router, "/v1/login",
-		h.Login,
+	h.routes.route(router, "/v1/login",
+		noStore(h.loginHandler()),
+		routeDoc{Summary: "Login", Tags: []string{"Authentication"}},
 		coreServer.WithMethods(http.MethodPost),
-		coreServer.WithSummary("Login"),
+		coreServer.WithParams(minimalResponseParam),
 		coreServer.WithRequestBody(&coreServer.BodyMeta{
 			Required: true,
 			ModelKey: "login-request",
@@ -65,43 +118,303 @@ func (h *AuthenticationHandler) RegisterRoutes(router *mux.Router) {
 					"logged_organization": map[string]any{},
 				},
 			},
+			http.StatusTooManyRequests: {
+				Description: "Too many login attempts from this client; see Retry-After",
+				Example: map[string]any{
+					"error":   "Too Many Requests",
+					"message": "rate limit exceeded, retry later",
+				},
+			},
+			http.StatusMultipleChoices: {
+				ModelKey:    "login-selection-response",
+				Description: "User belongs to several organizations; resubmit with selection_token and organization_id",
+				Example: map[string]any{
+					"selection_required": true,
+					"selection_token":    "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJ0eXBlIjoibG9naW5fc2VsZWN0aW9uIn0",
+					"expires_in":         300,
+					"organizations":      []any{},
+				},
+			},
+			http.StatusForbidden: {
+				IsIgnored: true,
+			},
+		}),
+		coreServer.WithDescription("Authenticate a user and return tokens. Users with MFA enabled who omit mfa_code receive {\"mfa_required\": true, \"challenge_token\": ...} instead; complete the login with /v1/login/mfa"),
+		coreServer.AllowAnonymous(),
+	)
+
+	h.routes.route(router, "/v1/login/mfa",
+		noStore(rateLimited(h.loginLimiter, endpointKey("login-mfa", clientIP), h.LoginMFA)),
+		routeDoc{Summary: "Complete MFA login", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithParams(minimalResponseParam),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required: true,
+			ModelKey: "mfa-login-request",
+			Example: map[string]any{
+				"challenge_token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJ0eXBlIjoibWZhX3BlbmRpbmcifQ",
+				"code":            "123456",
+			},
+		}),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "login-response",
+				Description: "Successful login response",
+			},
+			http.StatusMultipleChoices: {
+				ModelKey:    "login-selection-response",
+				Description: "User belongs to several organizations; resubmit /v1/login with selection_token and organization_id",
+			},
 			http.StatusForbidden: {
 				IsIgnored: true,
 			},
 		}),
-		coreServer.WithDescription("Authenticate a user and return tokens"),
-		coreServer.WithTags("Authentication"),
+		coreServer.WithDescription("Exchange the challenge token returned by /v1/login and a TOTP or recovery code for access and refresh tokens"),
+		coreServer.AllowAnonymous(),
+	)
+
+	// Registration responds 404 unless REGISTRATION_ENABLED is set
+	h.routes.route(router, "/v1/register",
+		rateLimited(h.loginLimiter, endpointKey("register", clientIP), h.Register),
+		routeDoc{Summary: "Register", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Register a new, unverified user account and send it an email verification token. No tokens are issued"),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required: true,
+			ModelKey: "register-request",
+			Example: map[string]any{
+				"email":      "jane@example.com",
+				"username":   "jane",
+				"password":   "ChangeMe123!",
+				"first_name": "Jane",
+				"last_name":  "Doe",
+			},
+		}),
+		coreServer.AllowAnonymous(),
+	)
+
+	h.routes.route(router, "/v1/federation/discover",
+		rateLimited(h.loginLimiter, endpointKey("federation-discovery", clientIP), h.DiscoverFederation),
+		routeDoc{Summary: "Discover login methods", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("List the organizations matching an email's domain and the login methods available to them. Unknown domains return an empty list"),
+		coreServer.WithParams(
+			coreServer.ParamMeta{
+				Name:        "email",
+				In:          coreServer.ParamInQuery,
+				Required:    true,
+				Description: "Email address whose domain is looked up",
+			},
+		),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required: true,
+				ModelKey: "federation-discovery",
+				Example: map[string]any{
+					"domain": "example.com",
+					"organizations": []any{
+						map[string]any{"id": 1, "name": "Example Corp", "login_methods": []string{"password"}},
+					},
+				},
+			},
+		}),
+		coreServer.AllowAnonymous(),
+	)
+
+	h.routes.route(router, "/v1/password/validate",
+		rateLimited(h.loginLimiter, endpointKey("password-validate", clientIP), h.ValidatePassword),
+		routeDoc{Summary: "Validate password", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Check a candidate password against the minimum length and, when configured, the minimum entropy. The breach check is not applied here"),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required: true,
+			ModelKey: "password-validate-request",
+		}),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "password-validate-response",
+				Description: "Whether the password is acceptable and why not",
+			},
+		}),
+		coreServer.AllowAnonymous(),
+	)
+
+	h.routes.route(router, "/v1/password/reset-request",
+		rateLimited(h.loginLimiter, endpointKey("password-reset-request", clientIP), h.RequestPasswordReset),
+		routeDoc{Summary: "Request password reset", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Send a password reset token to the account owning the email. Always responds 202 so accounts cannot be enumerated"),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required: true,
+			ModelKey: "password-reset-request",
+			Example: map[string]any{
+				"email": "jane@example.com",
+			},
+		}),
+		coreServer.AllowAnonymous(),
+	)
+
+	h.routes.route(router, "/v1/password/reset-confirm",
+		rateLimited(h.loginLimiter, endpointKey("password-reset-confirm", clientIP), h.ConfirmPasswordReset),
+		routeDoc{Summary: "Confirm password reset", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Set a new password with a reset token. The token is single use and existing sessions are revoked"),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required: true,
+			ModelKey: "password-reset-confirm-request",
+		}),
+		coreServer.AllowAnonymous(),
+	)
+
+	h.routes.route(router, "/v1/verify-email",
+		rateLimited(h.loginLimiter, endpointKey("verify-email", clientIP), h.VerifyEmail),
+		routeDoc{Summary: "Verify email", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Consume an email verification token and mark the account verified"),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required: true,
+			ModelKey: "verify-email-request",
+		}),
+		coreServer.AllowAnonymous(),
+	)
+
+	h.routes.route(router, "/v1/oauth/google/start", h.GoogleOAuthStart,
+		routeDoc{Summary: "Start Google login", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("Store the authorization request server-side and redirect to Google's consent screen with its state and a PKCE challenge. Only available when OAUTH_ENABLED and the Google client settings are configured"),
+		coreServer.WithParams(
+			coreServer.ParamMeta{
+				Name:        "return_url",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Where the callback sends the browser, checked against REDIRECT_ALLOWED_ORIGINS (default: /)",
+			},
+			coreServer.ParamMeta{
+				Name:        "client_type",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "public or confidential (default: confidential); public clients must send a PKCE challenge",
+			},
+			coreServer.ParamMeta{
+				Name:        "code_challenge",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "PKCE code challenge verified when the login code is redeemed",
+			},
+			coreServer.ParamMeta{
+				Name:        "code_challenge_method",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "S256 (default) or plain for confidential clients",
+			},
+		),
+		coreServer.AllowAnonymous(),
+	)
+
+	h.routes.route(router, "/v1/oauth/google/callback",
+		rateLimited(h.loginLimiter, endpointKey("oauth-google-callback", clientIP), h.GoogleOAuthCallback),
+		routeDoc{Summary: "Complete Google login", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("Consume the state, exchange the authorization code and find or create the account owning the verified Google email, then redirect to the return URL with a login code or an error"),
+		coreServer.WithParams(
+			coreServer.ParamMeta{
+				Name:        "code",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Authorization code issued by Google",
+			},
+			coreServer.ParamMeta{
+				Name:        "state",
+				In:          coreServer.ParamInQuery,
+				Required:    true,
+				Description: "State parameter issued by /v1/oauth/google/start",
+			},
+		),
 		coreServer.AllowAnonymous(),
 	)
 
-	// Registration endpoint is disabled for now
-	// coreServer.Route(router, "/v1/register", h.Register,
-	// 	coreServer.WithMethods(http.MethodPost),
-	// 	coreServer.WithSummary("Register"),
-	// 	coreServer.WithDescription("Register a new user account"),
-	// 	coreServer.WithTags("Authentication"),
-	// 	coreServer.AllowAnonymous(),
-	// )
+	h.routes.route(router, "/v1/oauth/google/token",
+		noStore(rateLimited(h.loginLimiter, endpointKey("oauth-google-token", clientIP), h.GoogleOAuthToken)),
+		routeDoc{Summary: "Redeem Google login code", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Exchange the login code from the Google callback, with the PKCE verifier when the flow was started with a challenge, for tokens. Responds like /v1/login"),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required: true,
+			ModelKey: "oauth-token-request",
+		}),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "login-response",
+				Description: "Tokens for the signed-in user, or an MFA challenge",
+			},
+		}),
+		coreServer.AllowAnonymous(),
+	)
 
 	// Health check endpoint
-	coreServer.Route(router, "/v1/health", h.Health,
+	h.routes.route(router, "/v1/health", h.Health,
+		routeDoc{Summary: "Authentication health", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.AllowAnonymous(),
+	)
+
+	// Registered before the authenticated /v1/auth subrouter so it stays anonymous
+	h.routes.route(router, "/v1/auth/config", h.ClientConfig,
+		routeDoc{Summary: "Client authentication config", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("Non-sensitive settings such as token TTLs, enabled features, and the password policy"),
+		coreServer.AllowAnonymous(),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "client-auth-config",
+				Description: "Client authentication settings",
+			},
+		}),
+	)
+
+	h.routes.route(router, "/.well-known/jwks.json", h.JWKS,
+		routeDoc{Summary: "JSON Web Key Set", Tags: []string{"Authentication"}},
 		coreServer.WithMethods(http.MethodGet),
-		coreServer.WithSummary("Authentication health"),
-		coreServer.WithTags("Authentication"),
+		coreServer.WithDescription("Public keys that verify RS256 tokens, matched by the kid token header. Empty when tokens are signed with HS256"),
 		coreServer.AllowAnonymous(),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "jwks",
+				Description: "Token verification keys",
+			},
+		}),
+	)
+
+	h.routes.route(router, "/v1/.well-known/keys", h.SigningKeys,
+		routeDoc{Summary: "Signing key metadata", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("kid and algorithm of the active signing key for HS256 and RS256, plus the JWKS for RS256. HMAC secrets are never included"),
+		coreServer.AllowAnonymous(),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "signing-keys",
+				Description: "Active signing keys",
+			},
+		}),
 	)
 
 	// Protected routes (authentication required)
-	authenticated := router.PathPrefix("/v1/auth").Subrouter()
-	authenticated.Use(coreMiddleware.AuthMiddlewareFunc(func() string {
-		return h.authenticationService.JWTSecret()
-	}))
+	authenticated := h.routes.subrouter(router, "/v1/auth")
+	authenticated.Use(authMiddleware(h.authenticationService))
+	authenticated.Use(requirePasswordChanged())
+	authenticated.Use(requireTokenOrganization(h.authenticationService))
+	authenticated.Use(attachScopes())
 
-	coreServer.Route(authenticated, "/me", h.Me,
+	h.routes.route(authenticated, "/me", h.Me,
+		routeDoc{Summary: "Current user", Tags: []string{"Authentication"}},
 		coreServer.WithMethods(http.MethodGet),
-		coreServer.WithSummary("Current user"),
 		coreServer.WithDescription("Retrieve the authenticated user's profile"),
-		coreServer.WithTags("Authentication"),
 		coreServer.RequireAuth(),
 		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
 			http.StatusOK: {
@@ -116,6 +429,8 @@ func (h *AuthenticationHandler) RegisterRoutes(router *mux.Router) {
 					"last_name":              "Administrator",
 					"primary_organization_id": 1,
 					"primary_department_id":   1,
+					"primary_organization_name": "Default Organization",
+					"primary_department_name":   "IT Department",
 					"is_super_admin":         true,
 					"mfa_enabled":            false,
 					"organizations": []any{
@@ -148,56 +463,129 @@ func (h *AuthenticationHandler) RegisterRoutes(router *mux.Router) {
 		}),
 	)
 
-	coreServer.Route(router, "/refresh", h.RefreshToken,
+	h.routes.route(authenticated, "/logout", h.Logout,
+		routeDoc{Summary: "Logout", Tags: []string{"Authentication"}},
 		coreServer.WithMethods(http.MethodPost),
-		coreServer.WithSummary("Refresh token"),
-		coreServer.WithDescription("Refresh the access token using a refresh token"),
-		coreServer.WithTags("Authentication"),
-		coreServer.AllowAnonymous(),
+		coreServer.WithDescription("Revoke the caller's access token, and the refresh token if supplied, so they are rejected before they expire"),
+		coreServer.RequireAuth(),
 		coreServer.WithRequestBody(&coreServer.BodyMeta{
-			Required:  true,
-			ModelKey:  "refresh-token-request",
-			Description: "Refresh token request containing the refresh token",
+			Required: false,
+			ModelKey: "logout-request",
 			Example: map[string]any{
-				"refresh_token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJhdWQiOiJzdXBhYmFzZSIsIn",
+				"refresh_token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...",
+			},
+		}),
+	)
+
+	h.routes.route(authenticated, "/switch-organization", noStore(h.SwitchOrganization),
+		routeDoc{Summary: "Switch organization", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Issue new access and refresh tokens whose org_id and membership claims point at another organization, and optionally department, of the caller. Refreshing the new refresh token stays in that context. Returns 403 when the caller is not a member"),
+		coreServer.RequireAuth(),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required: true,
+			ModelKey: "switch-organization-request",
+			Example: map[string]any{
+				"organization_id": 2,
+				"department_id":   5,
 			},
 		}),
 		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
 			http.StatusOK: {
 				Required:    true,
-				ModelKey:    "refresh-token-response",
-				Description: "Successful token refresh response",
-				Example: map[string]any{
-					"access_token":  "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJhdWQiOiJzdXBhYmFzZSIsIn",
-					"refresh_token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJhdWQiOiJzdXBhYmFzZSIsIn",
-					"expires_in":    3600,
-					"token_type":    "Bearer",
-				},
+				ModelKey:    "switch-organization-response",
+				Description: "Tokens for the new organization context",
 			},
-			http.StatusUnauthorized: {
+		}),
+	)
+
+	h.routes.route(authenticated, "/change-password",
+		rateLimited(h.loginLimiter, endpointKey("password-change", clientIP), h.stepUp(h.ChangePassword)),
+		routeDoc{Summary: "Change password", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Replace the caller's password after re-verifying the current one. Unless revoke_sessions is false, every token issued so far, including the caller's, stops working. Users with MFA enabled also need a step-up token in X-Step-Up-Token"),
+		coreServer.RequireAuth(),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required: true,
+			ModelKey: "change-password-request",
+			Example: map[string]any{
+				"current_password": "SecurePass123!",
+				"new_password":     "NewSecurePass123!",
+				"revoke_sessions":  true,
+			},
+		}),
+	)
+
+	h.routes.route(authenticated, "/me", h.UpdateMe,
+		routeDoc{Summary: "Update current user", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodPatch),
+		coreServer.WithDescription("Change the authenticated user's first_name and/or last_name. Any other field, such as email, username, is_super_admin or memberships, is refused with 403"),
+		coreServer.RequireAuth(),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required: true,
+			ModelKey: "update-profile-request",
+			Example: map[string]any{
+				"first_name": "Jane",
+				"last_name":  "Doe",
+			},
+		}),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
 				Required:    true,
-				ModelKey:    "invalid-refresh-token-response",
-				Description: "Invalid or expired refresh token",
-				Example: map[string]any{
-					"error":   "Unauthorized",
-					"message": "Invalid or expired refresh token",
-				},
+				ModelKey:    "user-profile-response",
+				Description: "Updated user profile",
 			},
 		}),
 	)
 
-	// Administrative routes (require elevated permissions)
-	adminRouter := authenticated.PathPrefix("/admin").Subrouter()
-	if h.useAuthorization {
-		adminRouter.Use(coreMiddleware.RequireAuthorization(h.authorizationBuilder))
-	} else {
-		adminRouter.Use(coreMiddleware.RequireSuperAdmin())
-	}
-	coreServer.Route(adminRouter, "/users", h.ListUsers,
+	h.routes.route(authenticated, "/me/api-keys", h.ListAPIKeys,
+		routeDoc{Summary: "List API keys", Tags: []string{"Authentication"}},
 		coreServer.WithMethods(http.MethodGet),
-		coreServer.WithSummary("List users (admin)"),
-		coreServer.WithDescription("List users with administrative privileges"),
-		coreServer.WithTags("Administration"),
+		coreServer.WithDescription("The caller's API keys with their name, scopes, creation time, last use and expiry. Secrets are never returned"),
+		coreServer.RequireAuth(),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "api-key",
+				Description: "API keys, newest first",
+			},
+		}),
+	)
+
+	h.routes.route(authenticated, "/me/api-keys", noStore(h.stepUp(h.CreateAPIKey)),
+		routeDoc{Summary: "Create API key", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Issue an API key for machine clients, sent as \"Authorization: ApiKey <key>\". The key is only returned in this response. Not available to requests authenticated with an API key. Users with MFA enabled also need a step-up token in X-Step-Up-Token"),
+		coreServer.RequireAuth(),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required: true,
+			ModelKey: "create-api-key-request",
+			Example: map[string]any{
+				"name":       "billing-sync",
+				"scopes":     []string{"auth.users.read"},
+				"expires_at": "2027-01-01T00:00:00Z",
+			},
+		}),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusCreated: {
+				Required:    true,
+				ModelKey:    "create-api-key-response",
+				Description: "Issued API key including the one-time plaintext key",
+			},
+		}),
+	)
+
+	h.routes.route(authenticated, "/me/api-keys/{api_key_id}", h.stepUp(h.DeleteAPIKey),
+		routeDoc{Summary: "Revoke API key", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodDelete),
+		coreServer.WithDescription("Delete one of the caller's API keys; it stops working immediately. Keys of other users are reported as not found. Users with MFA enabled also need a step-up token in X-Step-Up-Token"),
+		coreServer.RequireAuth(),
+	)
+
+	h.routes.route(authenticated, "/me/login-history", h.LoginHistory,
+		routeDoc{Summary: "Login history", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("The caller's recent successful and failed login attempts, newest first"),
 		coreServer.RequireAuth(),
 		coreServer.WithParams(
 			coreServer.ParamMeta{
@@ -210,7 +598,199 @@ func (h *AuthenticationHandler) RegisterRoutes(router *mux.Router) {
 				Name:        "page_size",
 				In:          coreServer.ParamInQuery,
 				Required:    false,
-				Description: "Number of users per page, max 100 (default: 20)",
+				Description: "Number of entries per page, max 100 (default: 20)",
+			},
+		),
+	)
+
+	h.routes.route(authenticated, "/me/departments", h.MyDepartments,
+		routeDoc{Summary: "My departments", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("The caller's department memberships, for department selection after login. Empty when the caller has none"),
+		coreServer.RequireAuth(),
+		coreServer.WithParams(
+			coreServer.ParamMeta{
+				Name:        "organization_id",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Only list departments of this organization",
+			},
+		),
+	)
+
+	h.routes.route(authenticated, "/me/mfa-status", h.MFAStatus,
+		routeDoc{Summary: "MFA status", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("Whether MFA is enabled, how many recovery codes remain and whether the step-up token sent in the "+StepUpTokenHeader+" header is still in effect. Secrets and recovery codes are never returned"),
+		coreServer.RequireAuth(),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "mfa-status",
+				Description: "The caller's MFA posture",
+			},
+		}),
+	)
+
+	h.routes.route(authenticated, "/mfa/enroll", noStore(h.MFAEnroll),
+		routeDoc{Summary: "Start MFA enrollment", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Generate a TOTP secret, otpauth URL and one-time recovery codes. MFA is enabled only after the code is verified."),
+		coreServer.RequireAuth(),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "mfa-enrollment",
+				Description: "Pending TOTP secret and otpauth URL",
+			},
+		}),
+	)
+
+	h.routes.route(authenticated, "/mfa/verify", h.MFAVerify,
+		routeDoc{Summary: "Verify MFA enrollment", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Confirm the pending TOTP secret with a code and enable MFA"),
+		coreServer.RequireAuth(),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required:    true,
+			ModelKey:    "mfa-challenge-request",
+			Description: "TOTP code from the authenticator app",
+			Example: map[string]any{
+				"code": "123456",
+			},
+		}),
+	)
+
+	h.routes.route(authenticated, "/mfa/challenge", noStore(h.MFAChallenge),
+		routeDoc{Summary: "MFA step-up challenge", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Verify a TOTP or recovery code and issue a short-lived step-up token. Sensitive endpoints expect it in the "+StepUpTokenHeader+" header."),
+		coreServer.RequireAuth(),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required:    true,
+			ModelKey:    "mfa-challenge-request",
+			Description: "TOTP or recovery code",
+			Example: map[string]any{
+				"code": "123456",
+			},
+		}),
+	)
+
+	h.routes.route(router, "/refresh", noStore(h.RefreshToken),
+		routeDoc{Summary: "Refresh token", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithParams(minimalResponseParam),
+		coreServer.WithDescription("Refresh the access token using a refresh token. The new tokens keep the organization and department the session was issued for, and the login checks apply again"),
+		coreServer.AllowAnonymous(),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required:  true,
+			ModelKey:  "refresh-token-request",
+			Description: "Refresh token request containing the refresh token",
+			Example: map[string]any{
+				"refresh_token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJhdWQiOiJzdXBhYmFzZSIsIn",
+			},
+		}),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "refresh-token-response",
+				Description: "Successful token refresh response",
+				Example: map[string]any{
+					"access_token":  "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJhdWQiOiJzdXBhYmFzZSIsIn",
+					"refresh_token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJhdWQiOiJzdXBhYmFzZSIsIn",
+					"expires_in":    3600,
+					"token_type":    "Bearer",
+				},
+			},
+			http.StatusUnauthorized: {
+				Required:    true,
+				ModelKey:    "invalid-refresh-token-response",
+				Description: "Invalid or expired refresh token",
+				Example: map[string]any{
+					"error":   "Unauthorized",
+					"message": "Invalid or expired refresh token",
+				},
+			},
+		}),
+	)
+
+	h.routes.route(authenticated, "/organizations/manageable", h.ListManageableOrganizations,
+		routeDoc{Summary: "List manageable organizations", Tags: []string{"Authentication"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("Active organizations the caller can act on, for organization switcher and impersonation pickers: all of them for super admins, otherwise those where the caller is ORG_ADMIN. Ordered by name"),
+		coreServer.RequireAuth(),
+		coreServer.WithParams(
+			coreServer.ParamMeta{
+				Name:        "page",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Page number (default: 1)",
+			},
+			coreServer.ParamMeta{
+				Name:        "page_size",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Number of organizations per page, max 100 (default: 20)",
+			},
+		),
+	)
+
+	// Administrative routes (require elevated permissions)
+	adminRouter := authenticated.PathPrefix("/admin").Subrouter()
+	adminRouter.Use(adminAccessMiddleware(h.useAuthorization, h.authorizationUnavailable, h.authorizationBuilder))
+	h.routes.route(adminRouter, "/users", h.ListUsers,
+		routeDoc{Summary: "List users (admin)", Tags: []string{"Administration"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("List users with administrative privileges"),
+		coreServer.RequireAuth(),
+		coreServer.WithParams(
+			coreServer.ParamMeta{
+				Name:        "page",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Page number (default: 1)",
+			},
+			coreServer.ParamMeta{
+				Name:        "page_size",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Number of users per page, max 100 (default: 20)",
+			},
+			coreServer.ParamMeta{
+				Name:        "organization_id",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Only list members of this organization; total counts its members",
+			},
+			coreServer.ParamMeta{
+				Name:        "q",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Case-insensitive search over email, username, first name and last name",
+			},
+			coreServer.ParamMeta{
+				Name:        "is_active",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Filter by active state (true or false)",
+			},
+			coreServer.ParamMeta{
+				Name:        "is_verified",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Filter by email verification state (true or false)",
+			},
+			coreServer.ParamMeta{
+				Name:        "sort",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Sort field: created_at, email or username (default: id)",
+			},
+			coreServer.ParamMeta{
+				Name:        "order",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Sort direction: asc or desc (default: asc)",
 			},
 		),
 		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
@@ -272,165 +852,1021 @@ func (h *AuthenticationHandler) RegisterRoutes(router *mux.Router) {
 			},
 		}),
 	)
+
+	h.routes.route(adminRouter, "/route-permissions", h.ListRoutePermissions,
+		routeDoc{Summary: "List admin route permissions (admin)", Tags: []string{"Administration"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("Enumerate admin routes with the authorization action and resource derived for each, for configuring the authorization policy (requires auth.authorization.read)"),
+		coreServer.RequireAuth(),
+	)
+
+	h.routes.route(adminRouter, "/routes", h.ListRoutes,
+		routeDoc{Summary: "List routes (admin)", Tags: []string{"Administration"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("Method, path template, summary, tags and authentication requirement of every registered route (requires auth.authorization.read)"),
+		coreServer.RequireAuth(),
+	)
+
+	h.routes.route(adminRouter, "/authz/preview", h.authzPreviewRoute(),
+		routeDoc{Summary: "Preview authorization decision (super admin)", Tags: []string{"Administration"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("Derive the authorization action and resource for an admin route and, when an authorization service is configured, report whether the caller would be allowed"),
+		coreServer.RequireAuth(),
+		coreServer.WithParams(
+			coreServer.ParamMeta{
+				Name:        "method",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "HTTP method of the previewed request (default: GET)",
+			},
+			coreServer.ParamMeta{
+				Name:        "path",
+				In:          coreServer.ParamInQuery,
+				Required:    true,
+				Description: "Request path of the previewed admin route, e.g. /v1/organizations/admin/organizations/1/tier",
+			},
+			coreServer.ParamMeta{
+				Name:        "trace",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Force decision tracing on or off",
+			},
+		),
+	)
+
+	h.routes.route(adminRouter, "/token/debug", h.tokenDebugRoute(),
+		routeDoc{Summary: "Debug token (super admin)", Tags: []string{"Administration"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Debugging tool: decodes any token and reports signature validity, expiry status and claims without requiring the token to be active. Rate limited."),
+		coreServer.RequireAuth(),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required:    true,
+			ModelKey:    "token-debug-request",
+			Description: "Token to inspect",
+		}),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "token-debug-result",
+				Description: "Decoded token and validity report",
+			},
+		}),
+	)
+
+	h.routes.route(adminRouter, "/tokens/revoke", h.RevokeToken,
+		routeDoc{Summary: "Revoke token (admin)", Tags: []string{"Administration"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Blacklist a single access or refresh token by JTI until it expires. A bare jti must belong to an unexpired token the service issued, and to user_id when given; otherwise 404 (requires auth.tokens.revoke)"),
+		coreServer.RequireAuth(),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required: true,
+			ModelKey: "revoke-token-request",
+			Example: map[string]any{
+				"jti":     "8d7f4a52-4b8e-4a53-9d55-0f6f8a3b5c21",
+				"user_id": 42,
+			},
+		}),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "revoked-token",
+				Description: "The blacklist entry",
+			},
+		}),
+	)
+
+	h.routes.route(adminRouter, "/stats/mfa", h.MFAAdoptionStats,
+		routeDoc{Summary: "MFA adoption stats (admin)", Tags: []string{"Administration"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("Count and percentage of users with MFA enabled, overall and per organization (requires auth.stats.read)"),
+		coreServer.RequireAuth(),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "mfa-adoption-stats",
+				Description: "MFA adoption counts",
+			},
+		}),
+	)
+
+	h.routes.route(adminRouter, "/users/by-role", h.ListUsersByRole,
+		routeDoc{Summary: "List users by organization role (admin)", Tags: []string{"Administration"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("Users holding an organization role such as CEO or SYSTEM_ADMIN, across organizations or within one, ordered by ID (requires auth.users.read)"),
+		coreServer.RequireAuth(),
+		coreServer.WithParams(
+			coreServer.ParamMeta{
+				Name:        "role",
+				In:          coreServer.ParamInQuery,
+				Required:    true,
+				Description: "Organization role code, matched exactly",
+			},
+			coreServer.ParamMeta{
+				Name:        "organization_id",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Only include members of this organization",
+			},
+			coreServer.ParamMeta{
+				Name:        "page",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Page number (default: 1)",
+			},
+			coreServer.ParamMeta{
+				Name:        "page_size",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Number of users per page, max 100 (default: 20)",
+			},
+		),
+	)
+
+	// Registered before /users/{user_id} so "unverified" is not taken as an identifier
+	h.routes.route(adminRouter, "/users/unverified", h.ListUnverifiedUsers,
+		routeDoc{Summary: "List unverified users (admin)", Tags: []string{"Administration"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("Users that have not verified their email, oldest registrations first, with whether a verification token is outstanding (requires auth.users.verification)"),
+		coreServer.RequireAuth(),
+		coreServer.WithParams(
+			coreServer.ParamMeta{
+				Name:        "page",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Page number (default: 1)",
+			},
+			coreServer.ParamMeta{
+				Name:        "page_size",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Number of users per page, max 100 (default: 20)",
+			},
+		),
+	)
+
+	h.routes.route(adminRouter, "/users/unverified/resend-verification", h.ResendVerification,
+		routeDoc{Summary: "Resend verification emails (admin)", Tags: []string{"Administration"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Send a fresh verification token to every active unverified user not contacted within the resend interval (requires auth.users.verification)"),
+		coreServer.RequireAuth(),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "verification-resend-result",
+				Description: "Number of users sent, throttled and failed",
+			},
+		}),
+	)
+
+	h.routes.route(adminRouter, "/users/{user_id}", h.GetUser,
+		routeDoc{Summary: "Get user (admin)", Tags: []string{"Administration"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("Retrieve a user's profile with account state such as lock status and the last password reset request"),
+		coreServer.RequireAuth(),
+		coreServer.WithParams(
+			coreServer.ParamMeta{
+				Name:        "user_id",
+				In:          coreServer.ParamInPath,
+				Required:    true,
+				Description: "ID of the user to retrieve",
+			},
+		),
+	)
+
+	h.routes.route(adminRouter, "/users/{user_id}/security", h.GetUserSecurity,
+		routeDoc{Summary: "Get user security posture (admin)", Tags: []string{"Administration"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("MFA status, last login, failed attempts, lock state, password change time, an estimate of live sessions and recent notable audit events for one user (requires auth.users.security)"),
+		coreServer.RequireAuth(),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "user-security-posture",
+				Description: "Consolidated security posture",
+			},
+		}),
+	)
+
+	h.routes.route(adminRouter, "/audit", h.ListAuditEvents,
+		routeDoc{Summary: "List audit events (admin)", Tags: []string{"Administration"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("Recorded authentication and administration events, newest first, such as logins, lockouts, password resets and membership changes"),
+		coreServer.RequireAuth(),
+		coreServer.WithParams(
+			coreServer.ParamMeta{
+				Name:        "actor",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Only include events by this actor: a user ID, or a reference such as user:42 or system",
+			},
+			coreServer.ParamMeta{
+				Name:        "action",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Only include events with this action, e.g. auth.login_failure",
+			},
+			coreServer.ParamMeta{
+				Name:        "from",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Only include events at or after this RFC 3339 timestamp",
+			},
+			coreServer.ParamMeta{
+				Name:        "to",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Only include events at or before this RFC 3339 timestamp",
+			},
+			coreServer.ParamMeta{
+				Name:        "page",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Page number (default: 1)",
+			},
+			coreServer.ParamMeta{
+				Name:        "page_size",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Number of events per page, max 100 (default: 20)",
+			},
+		),
+	)
+
+	h.routes.route(adminRouter, "/users/{user_id}/membership-history", h.GetMembershipHistory,
+		routeDoc{Summary: "Get membership history (admin)", Tags: []string{"Administration"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("Chronological organization and department membership grants, revocations, role changes and primary switches for a user, taken from the audit log"),
+		coreServer.RequireAuth(),
+		coreServer.WithParams(
+			coreServer.ParamMeta{
+				Name:        "from",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Only include changes at or after this RFC 3339 timestamp",
+			},
+			coreServer.ParamMeta{
+				Name:        "to",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Only include changes at or before this RFC 3339 timestamp",
+			},
+			coreServer.ParamMeta{
+				Name:        "page",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Page number (default: 1)",
+			},
+			coreServer.ParamMeta{
+				Name:        "page_size",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Number of events per page, max 100 (default: 20)",
+			},
+		),
+	)
+
+	h.routes.route(adminRouter, "/users/import", h.ImportUsers,
+		routeDoc{Summary: "Import users (admin)", Tags: []string{"Administration"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Bulk-create users from a CSV file (email, username, first_name, last_name, role) and assign them to an organization"),
+		coreServer.RequireAuth(),
+		coreServer.WithParams(
+			coreServer.ParamMeta{
+				Name:        "organization_id",
+				In:          coreServer.ParamInQuery,
+				Required:    true,
+				Description: "Organization the imported users are assigned to",
+			},
+		),
+	)
+}
+
+// Login handles user login
+func (h *AuthenticationHandler) Login(w http.ResponseWriter, r *http.Request) {
+	minimal, ok := parseMinimalResponse(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+
+	// Validate request
+	if req.SelectionToken != "" {
+		if req.OrganizationID == 0 {
+			coreErrors.ValidationError("Organization ID is required").WriteHTTP(w)
+			return
+		}
+	} else {
+		if req.Username == "" || req.Password == "" {
+			coreErrors.ValidationError("Username and password are required").WriteHTTP(w)
+			return
+		}
+		if req.OrganizationID != 0 && req.RoleID == 0 && req.DepartmentID == 0 {
+			coreErrors.ValidationError("Either Role ID or Department ID is required").WriteHTTP(w)
+			return
+		}
+	}
+
+	req.IPAddress = clientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	// Authenticate user
+	response, err := h.authenticationService.Login(&req)
+	if err != nil {
+		var mfaErr *service.MFARequiredError
+		if errors.As(err, &mfaErr) {
+			// Credentials were verified; the client must submit an MFA code with the challenge token
+			utils.RespondJSON(w, http.StatusOK, mfaErr.Challenge)
+			return
+		}
+		writeLoginError(w, r, err, "Invalid or expired selection token")
+		return
+	}
+
+	// Return success response
+	writeLoginResponse(w, response, minimal)
+}
+
+// LoginMFA completes a login that was paused because the user has MFA enabled.
+func (h *AuthenticationHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	minimal, ok := parseMinimalResponse(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.MFALoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+	if req.ChallengeToken == "" || req.Code == "" {
+		coreErrors.ValidationError("Challenge token and code are required").WriteHTTP(w)
+		return
+	}
+
+	req.IPAddress = clientIP(r)
+	req.UserAgent = r.UserAgent()
+
+	response, err := h.authenticationService.CompleteMFALogin(&req)
+	if err != nil {
+		writeLoginError(w, r, err, "Invalid or expired challenge token")
+		return
+	}
+
+	writeLoginResponse(w, response, minimal)
+}
+
+// parseMinimalResponse reads the optional minimal query parameter, writing a 400 when it is not a boolean.
+func parseMinimalResponse(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	raw := strings.TrimSpace(r.URL.Query().Get("minimal"))
+	if raw == "" {
+		return false, true
+	}
+	minimal, err := strconv.ParseBool(raw)
+	if err != nil {
+		coreErrors.BadRequest("minimal must be true or false").WriteHTTP(w)
+		return false, false
+	}
+	return minimal, true
+}
+
+// writeLoginResponse writes the full login response, or only its tokens and a user stub when minimal.
+func writeLoginResponse(w http.ResponseWriter, response *models.LoginResponse, minimal bool) {
+	if minimal {
+		utils.RespondJSON(w, http.StatusOK, response.Minimal())
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+// writeLoginError maps login failures to HTTP responses. invalidTokenMessage describes the token the
+// endpoint accepts when it is rejected.
+func writeLoginError(w http.ResponseWriter, r *http.Request, err error, invalidTokenMessage string) {
+	var selectionErr *service.OrganizationSelectionError
+	if errors.As(err, &selectionErr) {
+		// Credentials were verified; the client must pick an organization and retry with the selection token
+		utils.RespondJSON(w, http.StatusMultipleChoices, selectionErr.Selection)
+		return
+	}
+
+	switch err {
+	case service.ErrInvalidCredentials:
+		coreErrors.Unauthorized("Invalid username or password").WriteHTTP(w)
+	case service.ErrInvalidToken:
+		coreErrors.Unauthorized(invalidTokenMessage).WriteHTTP(w)
+	case service.ErrInvalidMFACode:
+		coreErrors.Unauthorized("Invalid MFA code").WriteHTTP(w)
+	case service.ErrOrganizationRequired:
+		coreErrors.ValidationError("Organization ID is required").WriteHTTP(w)
+	case service.ErrAccountLocked:
+		writeAccountLocked(w, r)
+	case service.ErrAccountInactive:
+		coreErrors.Forbidden("Account is not active").WriteHTTP(w)
+	case service.ErrEmailNotVerified:
+		coreErrors.Forbidden("Email address is not verified").WriteHTTP(w)
+	case service.ErrInsufficientRole:
+		coreErrors.Forbidden("Your role is not allowed to log into this organization yet").WriteHTTP(w)
+	case service.ErrOrganizationInactive:
+		coreErrors.Forbidden("Organization is not active").WriteHTTP(w)
+	case service.ErrDepartmentInactive:
+		coreErrors.Forbidden("Department is not active").WriteHTTP(w)
+	case service.ErrRoleNotAssigned:
+		coreErrors.Forbidden("The selected role is not assigned to you in this organization").WriteHTTP(w)
+	default:
+		writeInternalError(w, "An error occurred during login", err)
+	}
+}
+
+// Register handles user registration
+func (h *AuthenticationHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req models.RegisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+
+	// Basic validation
+	if req.Email == "" || req.Username == "" || req.Password == "" {
+		coreErrors.ValidationError("Email, username, and password are required").WriteHTTP(w)
+		return
+	}
+
+	// Validate email format
+	if !utils.IsEmail(req.Email) {
+		coreErrors.ValidationError("Invalid email format").WriteHTTP(w)
+		return
+	}
+
+	// Self-registered users must not attach themselves to an organization
+	req.PrimaryOrganizationID = nil
+
+	// Register user
+	user, err := h.authenticationService.Register(&req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRegistrationDisabled):
+			coreErrors.NotFound("registration").WriteHTTP(w)
+		case errors.Is(err, service.ErrEmailRegistered), errors.Is(err, service.ErrUsernameTaken):
+			coreErrors.Conflict(err.Error()).WriteHTTP(w)
+		case errors.Is(err, service.ErrPasswordTooShort), errors.Is(err, service.ErrPasswordTooWeak),
+			errors.Is(err, service.ErrPasswordComplexity), errors.Is(err, service.ErrPasswordCommon),
+			errors.Is(err, service.ErrPasswordBreached):
+			coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		case errors.Is(err, service.ErrBreachCheckUnavailable):
+			utils.RespondJSON(w, http.StatusServiceUnavailable, map[string]string{
+				"error":   "Service Unavailable",
+				"message": "password could not be checked, try again later",
+			})
+		default:
+			writeInternalError(w, "Failed to register user", err)
+		}
+		return
+	}
+
+	// Return user info (without password); tokens are only issued by login
+	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+		"message": "User registered successfully; check your email to verify the account",
+		"user":    user.ToUserInfo(),
+	})
+}
+
+// VerifyEmail consumes an email verification token
+func (h *AuthenticationHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req models.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+	if strings.TrimSpace(req.Token) == "" {
+		coreErrors.ValidationError("Token is required").WriteHTTP(w)
+		return
+	}
+
+	if err := h.authenticationService.VerifyEmail(req.Token); err != nil {
+		if errors.Is(err, service.ErrInvalidVerificationToken) {
+			coreErrors.BadRequest(err.Error()).WriteHTTP(w)
+			return
+		}
+		writeInternalError(w, "Failed to verify email", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]bool{
+		"verified": true,
+	})
+}
+
+// RefreshToken handles token refresh
+func (h *AuthenticationHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	minimal, ok := parseMinimalResponse(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+
+	if req.RefreshToken == "" {
+		coreErrors.ValidationError("Refresh token is required").WriteHTTP(w)
+		return
+	}
+
+	// Refresh tokens
+	response, err := h.authenticationService.RefreshToken(req.RefreshToken)
+	if err != nil {
+		// The login checks are applied again, so refusals map like a login
+		writeLoginError(w, r, err, "Invalid or expired refresh token")
+		return
+	}
+
+	// Return new tokens
+	writeLoginResponse(w, response, minimal)
+}
+
+// Health returns service health status
+func (h *AuthenticationHandler) Health(w http.ResponseWriter, r *http.Request) {
+	utils.RespondJSON(w, http.StatusOK, map[string]string{
+		"status":  "healthy",
+		"service": "auth-service",
+	})
+}
+
+// DiscoverFederation reports the organizations and login methods for an email's domain.
+func (h *AuthenticationHandler) DiscoverFederation(w http.ResponseWriter, r *http.Request) {
+	email := strings.TrimSpace(r.URL.Query().Get("email"))
+	if email == "" {
+		coreErrors.ValidationError("email is required").WriteHTTP(w)
+		return
+	}
+
+	discovery, err := h.authenticationService.DiscoverFederation(email)
+	if err != nil {
+		writeInternalError(w, "failed to discover login methods", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, discovery)
+}
+
+// ClientConfig returns the non-sensitive authentication settings for clients.
+func (h *AuthenticationHandler) ClientConfig(w http.ResponseWriter, r *http.Request) {
+	utils.RespondJSON(w, http.StatusOK, h.authenticationService.ClientConfig())
+}
+
+// JWKS publishes the public keys that verify issued tokens.
+func (h *AuthenticationHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	utils.RespondJSON(w, http.StatusOK, h.authenticationService.JWKS())
+}
+
+// SigningKeys publishes the kid and algorithm of the active signing key.
+func (h *AuthenticationHandler) SigningKeys(w http.ResponseWriter, r *http.Request) {
+	utils.RespondJSON(w, http.StatusOK, h.authenticationService.SigningKeys())
+}
+
+// Me returns details about the authenticated user.
+func (h *AuthenticationHandler) Me(w http.ResponseWriter, r *http.Request) {
+	userIDVal := r.Context().Value(coreMiddleware.UserIDKey)
+	userIDStr, ok := userIDVal.(string)
+	if !ok || userIDStr == "" {
+		coreErrors.Unauthorized("user context missing").WriteHTTP(w)
+		return
+	}
+
+	userID, err := utils.ParseUint64(userIDStr)
+	if err != nil {
+		coreErrors.Unauthorized("invalid user identifier").WriteHTTP(w)
+		return
+	}
+
+	userInfo, err := h.authenticationService.GetUserInfoByID(userID)
+	if err != nil {
+		writeInternalError(w, "failed to load user profile", err)
+		return
+	}
+	if userInfo == nil {
+		coreErrors.NotFound("user").WriteHTTP(w)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, userInfo)
+}
+
+// GetUser returns the administrative detail of a single user.
+func (h *AuthenticationHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	if !hasPermission(r, "auth.users.read") {
+		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
+		return
+	}
+
+	userID, err := utils.ParseUint64(mux.Vars(r)["user_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid user id").WriteHTTP(w)
+		return
+	}
+
+	detail, err := h.authenticationService.GetAdminUserDetail(userID)
+	if err != nil {
+		writeInternalError(w, "failed to load user", err)
+		return
+	}
+	if detail == nil {
+		coreErrors.NotFound("user").WriteHTTP(w)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, detail)
 }
 
-// Login handles user login
-func (h *AuthenticationHandler) Login(w http.ResponseWriter, r *http.Request) {
-	var req models.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+// GetUserSecurity returns the consolidated security posture of a user for account triage.
+func (h *AuthenticationHandler) GetUserSecurity(w http.ResponseWriter, r *http.Request) {
+	if !hasPermission(r, "auth.users.security") {
+		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
 		return
 	}
 
-	// Validate request
-	if req.Username == "" || req.Password == "" {
-		coreErrors.ValidationError("Username and password are required").WriteHTTP(w)
+	userID, err := utils.ParseUint64(mux.Vars(r)["user_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid user id").WriteHTTP(w)
 		return
 	}
-	if req.OrganizationID == 0 {
-		coreErrors.ValidationError("Organization ID is required").WriteHTTP(w)
+
+	posture, err := h.authenticationService.GetUserSecurityPosture(userID)
+	if err != nil {
+		writeInternalError(w, "failed to load user security posture", err)
+		return
+	}
+	if posture == nil {
+		coreErrors.NotFound("user").WriteHTTP(w)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, posture)
+}
+
+// ListUsers returns a paginated list of users. Super admin or explicit permission required.
+func (h *AuthenticationHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	if !hasPermission(r, "auth.users.read") {
+		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
 		return
 	}
-	if req.RoleID == 0 && req.DepartmentID == 0 {
-		coreErrors.ValidationError("Either Role ID or Department ID is required").WriteHTTP(w)
+
+	page, pageSize := parsePagination(r)
+	filter, err := parseUserListFilter(r)
+	if err != nil {
+		coreErrors.ValidationError(err.Error()).WriteHTTP(w)
 		return
 	}
+	filter.Offset = (page - 1) * pageSize
+	filter.Limit = pageSize
 
-	// Authenticate user
-	response, err := h.authenticationService.Login(&req)
+	userInfos, total, err := h.authenticationService.ListUsers(filter)
 	if err != nil {
-		switch err {
-		case service.ErrInvalidCredentials:
-			coreErrors.Unauthorized("Invalid username or password").WriteHTTP(w)
-		case service.ErrAccountLocked:
-			coreErrors.Forbidden("Account is locked due to too many failed attempts").WriteHTTP(w)
-		case service.ErrAccountInactive:
-			coreErrors.Forbidden("Account is not active").WriteHTTP(w)
-		default:
-			coreErrors.Internal("An error occurred during login").WriteHTTP(w)
+		writeInternalError(w, "failed to list users", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, paginatedResponse(userInfos, page, pageSize, total))
+}
+
+// parseUserListFilter reads the q, is_active, is_verified, organization_id, sort and order query parameters.
+func parseUserListFilter(r *http.Request) (models.UserListFilter, error) {
+	query := r.URL.Query()
+	filter := models.UserListFilter{
+		Query: strings.TrimSpace(query.Get("q")),
+	}
+
+	for name, target := range map[string]**bool{
+		"is_active":   &filter.IsActive,
+		"is_verified": &filter.IsVerified,
+	} {
+		raw := strings.TrimSpace(query.Get(name))
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be true or false", name)
+		}
+		*target = &value
+	}
+
+	if raw := strings.TrimSpace(query.Get("organization_id")); raw != "" {
+		orgID, err := utils.ParseUint64(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid organization id")
+		}
+		filter.OrganizationID = &orgID
+	}
+
+	switch sort := strings.ToLower(strings.TrimSpace(query.Get("sort"))); sort {
+	case "":
+	case models.UserSortCreatedAt, models.UserSortEmail, models.UserSortUsername:
+		filter.Sort = sort
+	default:
+		return filter, fmt.Errorf("sort must be one of created_at, email, username")
+	}
+
+	switch order := strings.ToLower(strings.TrimSpace(query.Get("order"))); order {
+	case "", "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		return filter, fmt.Errorf("order must be asc or desc")
+	}
+
+	return filter, nil
+}
+
+// ListManageableOrganizations returns a paginated list of the organizations the caller can act on.
+func (h *AuthenticationHandler) ListManageableOrganizations(w http.ResponseWriter, r *http.Request) {
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	page, pageSize := parsePagination(r)
+	orgs, total, err := h.authenticationService.ListManageableOrganizations(userID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			coreErrors.Unauthorized("User not found").WriteHTTP(w)
+			return
 		}
+		writeInternalError(w, "failed to list manageable organizations", err)
 		return
 	}
 
-	// Return success response
-	utils.RespondJSON(w, http.StatusOK, response)
+	utils.RespondJSON(w, http.StatusOK, paginatedResponse(orgs, page, pageSize, total))
 }
 
-// Register handles user registration
-// func (h *AuthenticationHandler) Register(w http.ResponseWriter, r *http.Request) {
-// 	var req models.RegisterRequest
-// 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-// 		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
-// 		return
-// 	}
-
-// 	// Basic validation
-// 	if req.Email == "" || req.Username == "" || req.Password == "" {
-// 		coreErrors.ValidationError("Email, username, and password are required").WriteHTTP(w)
-// 		return
-// 	}
-
-// 	// Validate email format
-// 	if !utils.IsEmail(req.Email) {
-// 		coreErrors.ValidationError("Invalid email format").WriteHTTP(w)
-// 		return
-// 	}
-
-// 	// Validate password strength
-// 	if len(req.Password) < 8 {
-// 		coreErrors.ValidationError("Password must be at least 8 characters long").WriteHTTP(w)
-// 		return
-// 	}
-
-// 	// Register user
-// 	user, err := h.authenticationService.Register(&req)
-// 	if err != nil {
-// 		if err.Error() == "email already registered" || err.Error() == "username already taken" {
-// 			coreErrors.Conflict(err.Error()).WriteHTTP(w)
-// 		} else {
-// 			coreErrors.Internal("Failed to register user").WriteHTTP(w)
-// 		}
-// 		return
-// 	}
-
-// 	// Return user info (without password)
-// 	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
-// 		"message": "User registered successfully",
-// 		"user":    user.ToUserInfo(),
-// 	})
-// }
+// ListUsersByRole returns a paginated list of users holding an organization role.
+func (h *AuthenticationHandler) ListUsersByRole(w http.ResponseWriter, r *http.Request) {
+	if !hasPermission(r, "auth.users.read") {
+		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
+		return
+	}
 
-// RefreshToken handles token refresh
-func (h *AuthenticationHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
-	var req models.RefreshTokenRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	query := r.URL.Query()
+	role := strings.TrimSpace(query.Get("role"))
+	if role == "" {
+		coreErrors.ValidationError("role is required").WriteHTTP(w)
+		return
+	}
+	var orgID *uint64
+	if raw := strings.TrimSpace(query.Get("organization_id")); raw != "" {
+		parsed, err := utils.ParseUint64(raw)
+		if err != nil {
+			coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
+			return
+		}
+		orgID = &parsed
+	}
+
+	page, pageSize := parsePagination(r)
+	userInfos, total, err := h.authenticationService.ListUsersByRole(models.OrganizationRole(role), orgID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		writeInternalError(w, "failed to list users by role", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, paginatedResponse(userInfos, page, pageSize, total))
+}
+
+// ListUnverifiedUsers returns a paginated list of users that have not verified their email.
+func (h *AuthenticationHandler) ListUnverifiedUsers(w http.ResponseWriter, r *http.Request) {
+	if !hasPermission(r, "auth.users.verification") {
+		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
+		return
+	}
+
+	page, pageSize := parsePagination(r)
+	users, total, err := h.authenticationService.ListUnverifiedUsers((page-1)*pageSize, pageSize)
+	if err != nil {
+		writeInternalError(w, "failed to list unverified users", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, paginatedResponse(users, page, pageSize, total))
+}
+
+// MFAAdoptionStats reports how many users have enabled MFA, overall and per organization.
+func (h *AuthenticationHandler) MFAAdoptionStats(w http.ResponseWriter, r *http.Request) {
+	if !hasPermission(r, "auth.stats.read") {
+		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
+		return
+	}
+
+	stats, err := h.authenticationService.MFAAdoptionStats()
+	if err != nil {
+		writeInternalError(w, "failed to compute MFA adoption stats", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, stats)
+}
+
+// ResendVerification sends fresh verification tokens to unverified users, skipping recently contacted ones.
+func (h *AuthenticationHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	if !hasPermission(r, "auth.users.verification") {
+		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
+		return
+	}
+
+	actorID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.authenticationService.ResendVerification(actorID)
+	if err != nil {
+		if errors.Is(err, service.ErrVerificationDeliveryUnavailable) {
+			utils.RespondJSON(w, http.StatusServiceUnavailable, map[string]string{
+				"error":   "Service Unavailable",
+				"message": "verification delivery is not configured",
+			})
+			return
+		}
+		writeInternalError(w, "failed to resend verification", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, result)
+}
+
+// RevokeToken blacklists a single token identified by the token itself or by its JTI and user ID.
+func (h *AuthenticationHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	if !hasPermission(r, "auth.tokens.revoke") {
+		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
+		return
+	}
+
+	var payload models.RevokeTokenInput
+	if err := utils.DecodeJSON(r.Body, &payload); err != nil {
 		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
 		return
 	}
+	if strings.TrimSpace(payload.Token) == "" && strings.TrimSpace(payload.JTI) == "" {
+		coreErrors.ValidationError("token or jti is required").WriteHTTP(w)
+		return
+	}
 
-	if req.RefreshToken == "" {
-		coreErrors.ValidationError("Refresh token is required").WriteHTTP(w)
+	actorID, ok := contextUserID(w, r)
+	if !ok {
 		return
 	}
+	payload.ActorID = actorID
 
-	// Refresh tokens
-	response, err := h.authenticationService.RefreshToken(req.RefreshToken)
+	revoked, err := h.authenticationService.RevokeToken(&payload)
 	if err != nil {
-		if err == service.ErrInvalidToken {
-			coreErrors.Unauthorized("Invalid or expired refresh token").WriteHTTP(w)
-		} else {
-			coreErrors.Internal("Failed to refresh token").WriteHTTP(w)
+		switch {
+		case errors.Is(err, service.ErrTokenNotRecognized):
+			coreErrors.NotFound("token").WriteHTTP(w)
+		case errors.Is(err, service.ErrTokenAlreadyRevoked):
+			coreErrors.Conflict(err.Error()).WriteHTTP(w)
+		default:
+			writeInternalError(w, "failed to revoke token", err)
 		}
 		return
 	}
 
-	// Return new tokens
-	utils.RespondJSON(w, http.StatusOK, response)
+	utils.RespondJSON(w, http.StatusOK, revoked)
 }
 
-// Health returns service health status
-func (h *AuthenticationHandler) Health(w http.ResponseWriter, r *http.Request) {
-	utils.RespondJSON(w, http.StatusOK, map[string]string{
-		"status":  "healthy",
-		"service": "auth-service",
-	})
+// LoginHistory returns the authenticated user's own login attempts.
+func (h *AuthenticationHandler) LoginHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	page, pageSize := parsePagination(r)
+	entries, total, err := h.authenticationService.ListLoginHistory(userID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		writeInternalError(w, "failed to load login history", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, paginatedResponse(entries, page, pageSize, total))
 }
 
-// Me returns details about the authenticated user.
-func (h *AuthenticationHandler) Me(w http.ResponseWriter, r *http.Request) {
-	userIDVal := r.Context().Value(coreMiddleware.UserIDKey)
-	userIDStr, ok := userIDVal.(string)
-	if !ok || userIDStr == "" {
-		coreErrors.Unauthorized("user context missing").WriteHTTP(w)
+// MyDepartments lists the caller's department memberships, optionally within one organization.
+func (h *AuthenticationHandler) MyDepartments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := contextUserID(w, r)
+	if !ok {
 		return
 	}
 
-	userID, err := utils.ParseUint64(userIDStr)
+	var orgID *uint64
+	if raw := strings.TrimSpace(r.URL.Query().Get("organization_id")); raw != "" {
+		parsed, err := utils.ParseUint64(raw)
+		if err != nil {
+			coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
+			return
+		}
+		orgID = &parsed
+	}
+
+	memberships, err := h.authenticationService.ListMyDepartments(userID, orgID)
 	if err != nil {
-		coreErrors.Unauthorized("invalid user identifier").WriteHTTP(w)
+		if errors.Is(err, service.ErrNotOrganizationMember) {
+			coreErrors.Forbidden("You are not a member of this organization").WriteHTTP(w)
+			return
+		}
+		writeInternalError(w, "failed to load departments", err)
 		return
 	}
 
-	userInfo, err := h.authenticationService.GetUserInfoByID(userID)
+	utils.RespondJSON(w, http.StatusOK, memberships)
+}
+
+// GetMembershipHistory returns a user's membership changes recorded in the audit log.
+func (h *AuthenticationHandler) GetMembershipHistory(w http.ResponseWriter, r *http.Request) {
+	if !hasPermission(r, "auth.audit.read") {
+		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
+		return
+	}
+
+	userID, err := utils.ParseUint64(mux.Vars(r)["user_id"])
 	if err != nil {
-		coreErrors.Internal("failed to load user profile").WithInternal(err).WriteHTTP(w)
+		coreErrors.BadRequest("invalid user id").WriteHTTP(w)
 		return
 	}
-	if userInfo == nil {
-		coreErrors.NotFound("user").WriteHTTP(w)
+
+	from, err := parseTimeQuery(r, "from")
+	if err != nil {
+		coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		return
+	}
+	to, err := parseTimeQuery(r, "to")
+	if err != nil {
+		coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		return
+	}
+	if from != nil && to != nil && to.Before(*from) {
+		coreErrors.ValidationError("to must not be before from").WriteHTTP(w)
 		return
 	}
 
-	utils.RespondJSON(w, http.StatusOK, userInfo)
+	page, pageSize := parsePagination(r)
+	events, total, err := h.authenticationService.ListMembershipHistory(userID, from, to, (page-1)*pageSize, pageSize)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			coreErrors.NotFound("user").WriteHTTP(w)
+			return
+		}
+		writeInternalError(w, "failed to load membership history", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, paginatedResponse(events, page, pageSize, total))
 }
 
-// ListUsers returns a paginated list of users. Super admin or explicit permission required.
-func (h *AuthenticationHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
-	if !coreMiddleware.HasPermission(r, "auth.users.read") {
+// ListAuditEvents returns recorded audit events, filtered by actor, action and time range.
+func (h *AuthenticationHandler) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	if !hasPermission(r, "auth.audit.read") {
 		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
 		return
 	}
 
+	actor := strings.TrimSpace(r.URL.Query().Get("actor"))
+	if userID, err := utils.ParseUint64(actor); err == nil {
+		actor = models.AuditUserRef(userID)
+	}
+	action := strings.TrimSpace(r.URL.Query().Get("action"))
+
+	from, err := parseTimeQuery(r, "from")
+	if err != nil {
+		coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		return
+	}
+	to, err := parseTimeQuery(r, "to")
+	if err != nil {
+		coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		return
+	}
+	if from != nil && to != nil && to.Before(*from) {
+		coreErrors.ValidationError("to must not be before from").WriteHTTP(w)
+		return
+	}
+
+	page, pageSize := parsePagination(r)
+	events, total, err := h.authenticationService.ListAuditEvents(actor, action, from, to, (page-1)*pageSize, pageSize)
+	if err != nil {
+		writeInternalError(w, "failed to load audit events", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, paginatedResponse(events, page, pageSize, total))
+}
+
+// parsePagination reads the page and page_size query parameters, defaulting to 1 and 20 and capping the size at 100.
+func parsePagination(r *http.Request) (int, int) {
 	page := 1
 	pageSize := 20
 
@@ -449,30 +1885,180 @@ func (h *AuthenticationHandler) ListUsers(w http.ResponseWriter, r *http.Request
 		}
 	}
 
-	offset := (page - 1) * pageSize
-
-	userInfos, total, err := h.authenticationService.ListUsers(offset, pageSize)
-	if err != nil {
-		coreErrors.Internal("failed to list users").WithInternal(err).WriteHTTP(w)
-		return
-	}
+	return page, pageSize
+}
 
+// paginatedResponse wraps a page of results with its pagination metadata.
+func paginatedResponse(data interface{}, page, pageSize int, total int64) map[string]interface{} {
 	totalPages := int64(0)
 	if pageSize > 0 {
 		totalPages = (total + int64(pageSize) - 1) / int64(pageSize)
 	}
 
-	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
-		"data": userInfos,
+	return map[string]interface{}{
+		"data": data,
 		"pagination": map[string]interface{}{
 			"page":        page,
 			"page_size":   pageSize,
 			"total":       total,
 			"total_pages": totalPages,
 		},
+	}
+}
+
+// parseTimeQuery reads an optional RFC 3339 timestamp from the query string.
+func parseTimeQuery(r *http.Request, name string) (*time.Time, error) {
+	raw := strings.TrimSpace(r.URL.Query().Get(name))
+	if raw == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+	}
+	return &parsed, nil
+}
+
+// maxUserImportSize caps the size of an uploaded user import file.
+const maxUserImportSize = 5 << 20
+
+// ImportUsers bulk-creates users from a CSV upload and reports the outcome per row.
+func (h *AuthenticationHandler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	if !hasPermission(r, "auth.users.import") {
+		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
+		return
+	}
+	actorID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	orgID, err := utils.ParseUint64(r.URL.Query().Get("organization_id"))
+	if err != nil || orgID == 0 {
+		coreErrors.ValidationError("organization_id is required").WriteHTTP(w)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUserImportSize)
+	var source io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			coreErrors.BadRequest("CSV file is required in the \"file\" field").WriteHTTP(w)
+			return
+		}
+		defer file.Close()
+		source = file
+	}
+
+	rows, err := parseUserImportCSV(source)
+	if err != nil {
+		coreErrors.BadRequest(err.Error()).WriteHTTP(w)
+		return
+	}
+	if len(rows) == 0 {
+		coreErrors.ValidationError("CSV file contains no users").WriteHTTP(w)
+		return
+	}
+
+	results, err := h.authenticationService.ImportUsers(actorID, orgID, rows)
+	if err != nil {
+		if errors.Is(err, service.ErrOrganizationNotFound) {
+			coreErrors.NotFound("organization").WriteHTTP(w)
+			return
+		}
+		writeInternalError(w, "failed to import users", err)
+		return
+	}
+
+	created := 0
+	for _, result := range results {
+		if result.Success {
+			created++
+		}
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"data": results,
+		"summary": map[string]interface{}{
+			"total":   len(results),
+			"created": created,
+			"failed":  len(results) - created,
+		},
 	})
 }
 
+// parseUserImportCSV reads user rows from CSV. A header row is optional; without it the
+// columns are expected in the order email, username, first_name, last_name, role.
+func parseUserImportCSV(source io.Reader) ([]models.UserImportRow, error) {
+	reader := csv.NewReader(source)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	// The reader skips blank lines, so the file line of each record is kept for the per-row results
+	var records [][]string
+	var lines []int
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{"email": 0, "username": 1, "first_name": 2, "last_name": 3, "role": 4}
+	start := 0
+	if len(records[0]) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "email") {
+		columns = map[string]int{}
+		for idx, name := range records[0] {
+			switch key := strings.ToLower(strings.TrimSpace(name)); key {
+			case "first", "firstname":
+				columns["first_name"] = idx
+			case "last", "lastname":
+				columns["last_name"] = idx
+			case "org_role", "organization_role":
+				columns["role"] = idx
+			default:
+				columns[key] = idx
+			}
+		}
+		start = 1
+	}
+
+	field := func(record []string, name string) string {
+		idx, ok := columns[name]
+		if !ok || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	rows := make([]models.UserImportRow, 0, len(records)-start)
+	for i := start; i < len(records); i++ {
+		record := records[i]
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		rows = append(rows, models.UserImportRow{
+			Line:      lines[i],
+			Email:     field(record, "email"),
+			Username:  field(record, "username"),
+			FirstName: field(record, "first_name"),
+			LastName:  field(record, "last_name"),
+			Role:      models.OrganizationRole(field(record, "role")),
+		})
+	}
+	return rows, nil
+}
+
 func init() {
 	coreServer.RegisterHandler(func(app *coreServer.HTTPApp) error {
 		serviceComponent, ok := app.GetComponent(constants.ComponentKey.AuthenticationService)
@@ -499,7 +2085,26 @@ func init() {
 			}
 		}
 
-		handler := NewAuthenticationHandler(authenticationService, useAuthorization, builder)
+		authorizationUnavailable := false
+		if flagComponent, ok := app.GetComponent(constants.ComponentKey.AuthorizationUnavailable); ok {
+			if unavailable, ok := flagComponent.(bool); ok {
+				authorizationUnavailable = unavailable
+			}
+		}
+
+		handler := NewAuthenticationHandler(authenticationService, useAuthorization, authorizationUnavailable, builder)
+		if cfgComponent, ok := app.GetComponent(constants.ComponentKey.AuthenticationConfig); ok {
+			if cfg, ok := cfgComponent.(*config.AuthConfig); ok {
+				if limiter := newLoginRateLimiter(cfg); limiter != nil {
+					handler.WithLoginRateLimiter(limiter, cfg.LoginRateLimitByUsername)
+				}
+			}
+		}
+		if catalogComponent, ok := app.GetComponent(constants.ComponentKey.RouteCatalog); ok {
+			if catalog, ok := catalogComponent.(*RouteCatalog); ok {
+				handler.WithRouteCatalog(catalog)
+			}
+		}
 		handler.RegisterRoutes(app.Router)
 		return nil
 	})
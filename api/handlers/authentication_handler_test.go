@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestParseUserImportCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    []models.UserImportRow
+		wantErr bool
+	}{
+		{
+			name: "positional columns",
+			csv:  "ada@example.com,ada,Ada,Lovelace,CEO\n",
+			want: []models.UserImportRow{{Line: 1, Email: "ada@example.com", Username: "ada", FirstName: "Ada", LastName: "Lovelace", Role: "CEO"}},
+		},
+		{
+			name: "header with aliases in any order",
+			csv:  "email,org_role,username,last,first\nada@example.com, CEO ,ada,Lovelace,Ada\n\nbob@example.com,CHAIRMAN,bob\n",
+			want: []models.UserImportRow{
+				{Line: 2, Email: "ada@example.com", Username: "ada", FirstName: "Ada", LastName: "Lovelace", Role: "CEO"},
+				{Line: 4, Email: "bob@example.com", Username: "bob", Role: "CHAIRMAN"},
+			},
+		},
+		{name: "empty file", csv: "", want: nil},
+		{name: "malformed quoting", csv: "\"ada@example.com,ada\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := parseUserImportCSV(strings.NewReader(tt.csv))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseUserImportCSV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(rows) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Errorf("rows = %+v, want %+v", rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportUsersEndpoint(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	token := env.superAdminToken(t)
+	org := env.createOrganization(t, "Acme", nil)
+	member := env.createUser(t, "member", nil)
+	env.addMember(t, member, org, "CEO")
+	memberToken := env.loginToken(t, member, org)
+
+	csv := "email,username,first_name,last_name,role\nada@example.com,ada,Ada,Lovelace,CEO\nbad,bob,,,CEO\n"
+	target := fmt.Sprintf("/v1/auth/admin/users/import?organization_id=%d", org.ID)
+
+	tests := []struct {
+		name      string
+		token     string
+		target    string
+		body      string
+		multipart bool
+		status    int
+		created   int
+		failed    int
+	}{
+		{name: "raw csv", token: token, target: target, body: csv, status: http.StatusOK, created: 1, failed: 1},
+		{name: "multipart upload", token: token, target: target, body: strings.ReplaceAll(csv, "ada", "grace"), multipart: true, status: http.StatusOK, created: 1, failed: 1},
+		{name: "missing organization", token: token, target: "/v1/auth/admin/users/import", body: csv, status: http.StatusUnprocessableEntity},
+		{name: "unknown organization", token: token, target: "/v1/auth/admin/users/import?organization_id=9999", body: csv, status: http.StatusNotFound},
+		{name: "no rows", token: token, target: target, body: "email,username\n", status: http.StatusUnprocessableEntity},
+		{name: "malformed csv", token: token, target: target, body: "\"unterminated", status: http.StatusBadRequest},
+		{name: "without permission", token: memberToken, target: target, body: csv, status: http.StatusForbidden},
+		{name: "anonymous", target: target, body: csv, status: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := &bytes.Buffer{}
+			contentType := "text/csv"
+			if tt.multipart {
+				writer := multipart.NewWriter(body)
+				part, err := writer.CreateFormFile("file", "users.csv")
+				if err != nil {
+					t.Fatalf("create form file: %v", err)
+				}
+				part.Write([]byte(tt.body))
+				writer.Close()
+				contentType = writer.FormDataContentType()
+			} else {
+				body.WriteString(tt.body)
+			}
+			req := httptest.NewRequest(http.MethodPost, tt.target, body)
+			req.Header.Set("Content-Type", contentType)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			env.router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var response struct {
+				Data    []models.UserImportResult `json:"data"`
+				Summary struct {
+					Total   int `json:"total"`
+					Created int `json:"created"`
+					Failed  int `json:"failed"`
+				} `json:"summary"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if response.Summary.Created != tt.created || response.Summary.Failed != tt.failed || len(response.Data) != tt.created+tt.failed {
+				t.Errorf("summary = %+v with %d rows, want %d created and %d failed", response.Summary, len(response.Data), tt.created, tt.failed)
+			}
+		})
+	}
+}
+
+func TestGetUserEndpoint(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	token := env.superAdminToken(t)
+	org := env.createOrganization(t, "Acme", nil)
+	member := env.createUser(t, "member", nil)
+	env.addMember(t, member, org, "CEO")
+	memberToken := env.loginToken(t, member, org)
+	target := fmt.Sprintf("/v1/auth/admin/users/%d", member.ID)
+
+	tests := []struct {
+		name   string
+		token  string
+		target string
+		status int
+	}{
+		{name: "super admin", token: token, target: target, status: http.StatusOK},
+		{name: "unknown user", token: token, target: "/v1/auth/admin/users/9999", status: http.StatusNotFound},
+		{name: "without permission", token: memberToken, target: target, status: http.StatusForbidden},
+		{name: "anonymous", target: target, status: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := env.doAuthenticated(t, tt.token, http.MethodGet, tt.target, "")
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var user models.User
+			if err := json.Unmarshal(rec.Body.Bytes(), &user); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if user.ID != member.ID {
+				t.Errorf("user id = %d, want %d", user.ID, member.ID)
+			}
+		})
+	}
+}
+
+func TestGetUserSecurityEndpoint(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	token := env.superAdminToken(t)
+	org := env.createOrganization(t, "Acme", nil)
+	member := env.createUser(t, "member", nil)
+	env.addMember(t, member, org, "CEO")
+	memberToken := env.loginToken(t, member, org)
+	_, recovery := env.enableMFA(t, member)
+	keep := false
+	if err := env.auth.ChangePassword(member.ID, &models.ChangePasswordRequest{CurrentPassword: testPassword, NewPassword: "another-horse-battery", RevokeSessions: &keep}); err != nil {
+		t.Fatalf("change password: %v", err)
+	}
+	if _, err := env.auth.Login(&models.LoginRequest{Username: member.Username, Password: "wrong-password", OrganizationID: org.ID}); err == nil {
+		t.Fatal("login with a wrong password succeeded")
+	}
+	lockedUntil := time.Now().Add(time.Hour)
+	if err := env.users.LockAccount(member.ID, lockedUntil); err != nil {
+		t.Fatalf("lock account: %v", err)
+	}
+	target := fmt.Sprintf("/v1/auth/admin/users/%d/security", member.ID)
+
+	tests := []struct {
+		name   string
+		token  string
+		target string
+		status int
+	}{
+		{name: "super admin", token: token, target: target, status: http.StatusOK},
+		{name: "unknown user", token: token, target: "/v1/auth/admin/users/9999/security", status: http.StatusNotFound},
+		{name: "invalid user id", token: token, target: "/v1/auth/admin/users/abc/security", status: http.StatusBadRequest},
+		{name: "without permission", token: memberToken, target: target, status: http.StatusForbidden},
+		{name: "anonymous", target: target, status: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := env.doAuthenticated(t, tt.token, http.MethodGet, tt.target, "")
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var posture models.UserSecurityPosture
+			if err := json.Unmarshal(rec.Body.Bytes(), &posture); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if posture.UserID != member.ID {
+				t.Errorf("user_id = %d, want %d", posture.UserID, member.ID)
+			}
+			if !posture.MFA.Enabled || posture.MFA.RecoveryCodesRemaining != len(recovery) {
+				t.Errorf("mfa = %+v, want enabled with %d recovery codes", posture.MFA, len(recovery))
+			}
+			if !posture.Login.IsActive || !posture.Login.Locked || posture.Login.LockedUntil == nil || posture.Login.FailedAttempts != 1 || posture.Login.LastLoginAt == nil {
+				t.Errorf("login = %+v, want a locked account with one failed attempt", posture.Login)
+			}
+			if posture.Password.ChangedAt == nil || posture.Password.MustChange {
+				t.Errorf("password = %+v, want the change recorded", posture.Password)
+			}
+			if posture.Sessions.Active != 1 || posture.Sessions.TokensValidAfter != nil {
+				t.Errorf("sessions = %+v, want one live session", posture.Sessions)
+			}
+			actions := make([]string, 0, len(posture.RecentEvents))
+			for _, event := range posture.RecentEvents {
+				actions = append(actions, event.Action)
+			}
+			if !slices.Contains(actions, models.AuditActionLoginFailure) || !slices.Contains(actions, models.AuditActionPasswordChange) || slices.Contains(actions, models.AuditActionLoginSuccess) {
+				t.Errorf("recent event actions = %v, want the failure and password change only", actions)
+			}
+		})
+	}
+}
+
+func TestLoginMinimalResponse(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	org := env.createOrganization(t, "Acme", nil)
+	dept := env.createDepartment(t, org, "Sales", nil)
+	user := env.createUser(t, "compact", nil)
+	env.addMember(t, user, org, "CEO")
+	if err := env.db.Create(&models.UserDepartment{UserID: user.ID, DepartmentID: dept.ID, Role: "LEAD"}).Error; err != nil {
+		t.Fatalf("add department member: %v", err)
+	}
+	body := fmt.Sprintf(`{"username":%q,"password":%q}`, user.Username, testPassword)
+
+	tests := []struct {
+		name    string
+		query   string
+		status  int
+		minimal bool
+	}{
+		{name: "default", status: http.StatusOK},
+		{name: "minimal", query: "?minimal=true", status: http.StatusOK, minimal: true},
+		{name: "explicitly full", query: "?minimal=false", status: http.StatusOK},
+		{name: "invalid value", query: "?minimal=maybe", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := env.do(t, http.MethodPost, "/v1/login"+tt.query, body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var got struct {
+				AccessToken  string         `json:"access_token"`
+				RefreshToken string         `json:"refresh_token"`
+				User         map[string]any `json:"user"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got.AccessToken == "" || got.RefreshToken == "" {
+				t.Errorf("response = %s, want both tokens", rec.Body.String())
+			}
+			if got.User["id"] != float64(user.ID) || got.User["username"] != user.Username {
+				t.Errorf("user = %v, want id %d and username %q", got.User, user.ID, user.Username)
+			}
+
+			keys := slices.Sorted(maps.Keys(got.User))
+			if tt.minimal {
+				if !slices.Equal(keys, []string{"id", "username"}) {
+					t.Errorf("minimal user fields = %v, want only id and username", keys)
+				}
+				return
+			}
+			for _, field := range []string{"email", "organizations", "departments"} {
+				if _, ok := got.User[field]; !ok {
+					t.Errorf("full user fields = %v, want %s", keys, field)
+				}
+			}
+			if organizations, _ := got.User["organizations"].([]any); len(organizations) != 1 {
+				t.Errorf("organizations = %v, want the membership", got.User["organizations"])
+			}
+			if departments, _ := got.User["departments"].([]any); len(departments) != 1 {
+				t.Errorf("departments = %v, want the membership", got.User["departments"])
+			}
+		})
+	}
+}
+
+func TestListManageableOrganizationsEndpoint(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	acme := env.createOrganization(t, "Acme", nil)
+	globex := env.createOrganization(t, "Globex", nil)
+	initech := env.createOrganization(t, "Initech", nil)
+	orgAdmin := env.createUser(t, "tenant-admin", nil)
+	env.addMember(t, orgAdmin, acme, "ORG_ADMIN")
+	env.addMember(t, orgAdmin, globex, "ORG_ADMIN")
+	env.addMember(t, orgAdmin, initech, "CEO")
+	member := env.createUser(t, "member", nil)
+	env.addMember(t, member, acme, "CEO")
+
+	tests := []struct {
+		name  string
+		token string
+		want  []string
+	}{
+		{name: "org admin", token: env.loginToken(t, orgAdmin, acme), want: []string{"Acme", "Globex"}},
+		{name: "member", token: env.loginToken(t, member, acme), want: []string{}},
+		{name: "super admin", token: env.superAdminToken(t), want: []string{"Acme", "Globex", "Initech"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := env.doAuthenticated(t, tt.token, http.MethodGet, "/v1/auth/organizations/manageable", "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+			}
+			var body struct {
+				Data []models.Organization `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			got := []string{}
+			for _, org := range body.Data {
+				if org.Name == "Acme" || org.Name == "Globex" || org.Name == "Initech" {
+					got = append(got, org.Name)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("organizations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if rec := env.do(t, http.MethodGet, "/v1/auth/organizations/manageable", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
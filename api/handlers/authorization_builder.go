@@ -1,20 +1,42 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/lee-tech/authentication/config"
 	coreMiddleware "github.com/lee-tech/core/middleware"
+	"github.com/lee-tech/core/utils"
 )
 
 type adminBuilderConfig struct {
 	BasePath  string
 	Namespace string
 	Overrides map[string]coreMiddleware.AuthorizationRequestBuilder
+	// ResourceIDVars lists the path variables that identify the resource instance being acted upon.
+	ResourceIDVars map[string]struct{}
+	// PropagateTrace requests a decision trace when the incoming W3C traceparent is sampled.
+	PropagateTrace bool
+	// FallbackSlug is used when no slug can be derived from the route; when empty such requests are
+	// rejected instead of being authorized against a generic action.
+	FallbackSlug string
+	// RouteOverrides are declarative overrides turned into builders once the namespace is known.
+	RouteOverrides map[string]config.AuthorizationRouteOverride
 }
 
+// ErrAuthorizationRouteUnresolved is returned by the admin builder when a request's route yields no
+// action slug and no fallback is configured.
+var ErrAuthorizationRouteUnresolved = errors.New("authorization action could not be derived for route")
+
+// defaultResourceIDVars are the path variables used to populate AuthorizationResource.ID.
+var defaultResourceIDVars = []string{"organization_id", "department_id", "user_id"}
+
 // AdminBuilderOption allows callers to customise the admin authorization builder.
 type AdminBuilderOption func(*adminBuilderConfig)
 
@@ -49,12 +71,54 @@ func WithAdminAuthorizationOverrides(overrides map[string]coreMiddleware.Authori
 	}
 }
 
+// WithAdminAuthorizationRouteOverrides registers declarative action/resource overrides keyed by route
+// name or template, typically loaded from AUTHORIZATION_OVERRIDES. Builders passed through
+// WithAdminAuthorizationOverrides take precedence for the same key.
+func WithAdminAuthorizationRouteOverrides(overrides map[string]config.AuthorizationRouteOverride) AdminBuilderOption {
+	return func(cfg *adminBuilderConfig) {
+		cfg.RouteOverrides = overrides
+	}
+}
+
+// WithAdminAuthorizationResourceIDVars overrides the path variables used to populate the resource ID.
+// Passing no variables disables instance IDs.
+func WithAdminAuthorizationResourceIDVars(vars ...string) AdminBuilderOption {
+	return func(cfg *adminBuilderConfig) {
+		cfg.ResourceIDVars = make(map[string]struct{}, len(vars))
+		for _, name := range vars {
+			if trimmed := strings.TrimSpace(name); trimmed != "" {
+				cfg.ResourceIDVars[trimmed] = struct{}{}
+			}
+		}
+	}
+}
+
+// WithAdminAuthorizationFallbackSlug sets the slug used when a route yields no path segments, producing
+// "<namespace>.<slug>.<method>". An empty slug keeps the strict default of rejecting such requests.
+func WithAdminAuthorizationFallbackSlug(slug string) AdminBuilderOption {
+	return func(cfg *adminBuilderConfig) {
+		cfg.FallbackSlug = strings.Trim(strings.TrimSpace(slug), ".")
+	}
+}
+
+// WithAdminAuthorizationTracePropagation enables tracing authorization decisions for requests whose
+// traceparent header is sampled. The ?trace= query parameter still overrides it either way.
+func WithAdminAuthorizationTracePropagation(enabled bool) AdminBuilderOption {
+	return func(cfg *adminBuilderConfig) {
+		cfg.PropagateTrace = enabled
+	}
+}
+
 // NewAdminAuthorizationBuilder returns a builder that turns admin routes into authorization requests.
 func NewAdminAuthorizationBuilder(opts ...AdminBuilderOption) coreMiddleware.AuthorizationRequestBuilder {
 	cfg := adminBuilderConfig{
-		BasePath:  "/api/v1/authentication",
-		Namespace: "authentication",
-		Overrides: map[string]coreMiddleware.AuthorizationRequestBuilder{},
+		BasePath:       "/api/v1/authentication",
+		Namespace:      "authentication",
+		Overrides:      map[string]coreMiddleware.AuthorizationRequestBuilder{},
+		ResourceIDVars: map[string]struct{}{},
+	}
+	for _, name := range defaultResourceIDVars {
+		cfg.ResourceIDVars[name] = struct{}{}
 	}
 	for _, opt := range opts {
 		if opt != nil {
@@ -71,6 +135,13 @@ func NewAdminAuthorizationBuilder(opts ...AdminBuilderOption) coreMiddleware.Aut
 	if cfg.Namespace == "" {
 		cfg.Namespace = "authentication"
 	}
+	for key, override := range cfg.RouteOverrides {
+		key = strings.TrimSpace(key)
+		if _, exists := cfg.Overrides[key]; key == "" || exists {
+			continue
+		}
+		cfg.Overrides[key] = routeOverrideBuilder(&cfg, override)
+	}
 
 	return func(r *http.Request, user *coreMiddleware.AuthContext) (*coreMiddleware.AuthorizationRequest, error) {
 		if r == nil {
@@ -84,35 +155,168 @@ func NewAdminAuthorizationBuilder(opts ...AdminBuilderOption) coreMiddleware.Aut
 		path := deriveRoutePath(r)
 		path = trimBasePath(path, cfg.BasePath)
 
-		segments := tokeniseSegments(path)
-		if len(segments) == 0 {
-			segments = []string{"admin"}
+		slug := strings.Join(tokeniseSegments(path), ".")
+		if slug == "" {
+			if cfg.FallbackSlug == "" {
+				return nil, fmt.Errorf("%w: %s %s", ErrAuthorizationRouteUnresolved, r.Method, deriveRoutePath(r))
+			}
+			slug = cfg.FallbackSlug
 		}
-		slug := strings.Join(segments, ".")
 		action := fmt.Sprintf("%s.%s.%s", cfg.Namespace, slug, strings.ToLower(r.Method))
 
 		req := &coreMiddleware.AuthorizationRequest{
 			Action: action,
 			Resource: coreMiddleware.AuthorizationResource{
 				Type: fmt.Sprintf("%s:%s", cfg.Namespace, slug),
+				ID:   resourceIDFromVars(r, path, cfg.ResourceIDVars),
 			},
 		}
 
-		switch strings.ToLower(r.URL.Query().Get("trace")) {
-		case "1", "true", "yes":
-			req.Trace = true
-		}
+		req.Trace = resolveTrace(r, cfg.PropagateTrace)
 
 		return req, nil
 	}
 }
 
+// routeOverrideBuilder returns a builder that authorizes against a fixed action and resource while
+// keeping the resource ID and trace behaviour of derived requests.
+func routeOverrideBuilder(cfg *adminBuilderConfig, override config.AuthorizationRouteOverride) coreMiddleware.AuthorizationRequestBuilder {
+	action := namespaced(cfg.Namespace, ".", override.Action)
+	resource := namespaced(cfg.Namespace, ":", override.Resource)
+	return func(r *http.Request, _ *coreMiddleware.AuthContext) (*coreMiddleware.AuthorizationRequest, error) {
+		path := trimBasePath(deriveRoutePath(r), cfg.BasePath)
+		return &coreMiddleware.AuthorizationRequest{
+			Action: action,
+			Resource: coreMiddleware.AuthorizationResource{
+				Type: resource,
+				ID:   resourceIDFromVars(r, path, cfg.ResourceIDVars),
+			},
+			Trace: resolveTrace(r, cfg.PropagateTrace),
+		}, nil
+	}
+}
+
+// namespaced prefixes value with the namespace unless it already carries it.
+func namespaced(namespace, separator, value string) string {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, namespace+separator) {
+		return value
+	}
+	return namespace + separator + value
+}
+
+// ValidateAuthorizationOverrides checks that every override key names or matches the template of a
+// registered admin route, so a typo cannot silently leave a route on its derived permission.
+func ValidateAuthorizationOverrides(router *mux.Router, overrides map[string]config.AuthorizationRouteOverride) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+	if router == nil {
+		return fmt.Errorf("router is required to validate authorization overrides")
+	}
+
+	known := make(map[string]struct{})
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil || !isAdminRoute(template) {
+			return nil
+		}
+		known[template] = struct{}{}
+		if name := route.GetName(); name != "" {
+			known[name] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var unknown []string
+	for key := range overrides {
+		if _, ok := known[strings.TrimSpace(key)]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("authorization overrides reference unknown admin routes: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// resolveTrace decides whether the authorization decision should be traced. An explicit ?trace= value
+// wins; otherwise the sampled flag of the W3C traceparent header is used when propagation is enabled.
+func resolveTrace(r *http.Request, propagate bool) bool {
+	switch strings.ToLower(r.URL.Query().Get("trace")) {
+	case "1", "true", "yes":
+		return true
+	case "0", "false", "no":
+		return false
+	}
+	return propagate && traceparentSampled(r.Header.Get("traceparent"))
+}
+
+// traceparentSampled reports whether a W3C traceparent ("00-<trace-id>-<parent-id>-<flags>") is valid
+// and has the sampled flag set.
+func traceparentSampled(header string) bool {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 {
+		return false
+	}
+	version, traceID, parentID, flags := parts[0], parts[1], parts[2], parts[3]
+	if len(version) != 2 || version == "ff" || (version == "00" && len(parts) != 4) {
+		return false
+	}
+	if len(traceID) != 32 || !isLowerHex(traceID) || strings.Trim(traceID, "0") == "" {
+		return false
+	}
+	if len(parentID) != 16 || !isLowerHex(parentID) || strings.Trim(parentID, "0") == "" {
+		return false
+	}
+	if len(flags) != 2 || !isLowerHex(flags) {
+		return false
+	}
+	value, err := strconv.ParseUint(flags, 16, 8)
+	return err == nil && value&0x01 == 0x01
+}
+
+func isLowerHex(value string) bool {
+	for _, c := range value {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// authorizationRouteKey carries the route of a synthesized request, which mux has not matched itself.
+type authorizationRouteKey struct{}
+
+// withAuthorizationRoute attaches route to a synthesized request so the builder resolves overrides and
+// the route template exactly as it would for a request routed by mux.
+func withAuthorizationRoute(r *http.Request, route *mux.Route) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), authorizationRouteKey{}, route))
+}
+
+// requestRoute returns the route mux matched for the request, or the one attached by
+// withAuthorizationRoute.
+func requestRoute(r *http.Request) *mux.Route {
+	if route := mux.CurrentRoute(r); route != nil {
+		return route
+	}
+	route, _ := r.Context().Value(authorizationRouteKey{}).(*mux.Route)
+	return route
+}
+
 func resolveOverride(r *http.Request, overrides map[string]coreMiddleware.AuthorizationRequestBuilder) coreMiddleware.AuthorizationRequestBuilder {
 	if len(overrides) == 0 || r == nil {
 		return nil
 	}
 
-	current := mux.CurrentRoute(r)
+	current := requestRoute(r)
 	if current == nil {
 		return nil
 	}
@@ -138,7 +342,7 @@ func deriveRoutePath(r *http.Request) string {
 	}
 
 	path := r.URL.Path
-	if route := mux.CurrentRoute(r); route != nil {
+	if route := requestRoute(r); route != nil {
 		if template, err := route.GetPathTemplate(); err == nil && template != "" {
 			path = template
 		}
@@ -174,6 +378,35 @@ func trimBasePath(path, base string) string {
 	return strings.Trim(path, "/")
 }
 
+// resourceIDFromVars returns the value of the last identifying variable in the route template, so
+// "/organizations/{organization_id}/departments/{department_id}" yields the department ID. It returns
+// an empty string when the route has no such variable.
+func resourceIDFromVars(r *http.Request, template string, allowed map[string]struct{}) string {
+	if len(allowed) == 0 {
+		return ""
+	}
+	vars := mux.Vars(r)
+	if len(vars) == 0 {
+		return ""
+	}
+
+	segments := strings.Split(template, "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		segment := strings.TrimSpace(segments[i])
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		name, _, _ := strings.Cut(strings.Trim(segment, "{}"), ":")
+		if _, ok := allowed[name]; !ok {
+			continue
+		}
+		if value := strings.TrimSpace(vars[name]); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
 func tokeniseSegments(path string) []string {
 	if strings.TrimSpace(path) == "" {
 		return nil
@@ -196,3 +429,25 @@ func tokeniseSegments(path string) []string {
 
 	return segments
 }
+
+// adminAccessMiddleware selects the guard applied to admin routes. When authorization is expected but
+// unavailable and strict mode is enabled, admin routes fail closed instead of falling back to super-admin only.
+func adminAccessMiddleware(useAuthorization, authorizationUnavailable bool, builder coreMiddleware.AuthorizationRequestBuilder) mux.MiddlewareFunc {
+	switch {
+	case useAuthorization:
+		return coreMiddleware.RequireAuthorization(builder)
+	case authorizationUnavailable:
+		return authorizationUnavailableMiddleware
+	default:
+		return requireSuperAdmin()
+	}
+}
+
+func authorizationUnavailableMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		utils.RespondJSON(w, http.StatusServiceUnavailable, map[string]string{
+			"error":   "Service Unavailable",
+			"message": "authorization service is unavailable",
+		})
+	})
+}
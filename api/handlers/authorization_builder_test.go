@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/lee-tech/authentication/config"
+	coreMiddleware "github.com/lee-tech/core/middleware"
+)
+
+const testAdminBase = "/api/v1/authentication/admin"
+
+// overrideRouter registers a named and an unnamed admin route next to a derived one.
+func overrideRouter() *mux.Router {
+	router := mux.NewRouter()
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router.HandleFunc(testAdminBase+"/users/{user_id}", noop).Methods(http.MethodDelete).Name("admin.users.delete")
+	router.HandleFunc(testAdminBase+"/organizations/{organization_id}/sessions/revoke", noop).Methods(http.MethodPost)
+	router.HandleFunc(testAdminBase+"/organizations/{organization_id}", noop).Methods(http.MethodGet)
+	return router
+}
+
+func overrideBuilder() coreMiddleware.AuthorizationRequestBuilder {
+	return NewAdminAuthorizationBuilder(WithAdminAuthorizationRouteOverrides(map[string]config.AuthorizationRouteOverride{
+		"admin.users.delete": {Action: "users.remove", Resource: "users"},
+		testAdminBase + "/organizations/{organization_id}/sessions/revoke": {Action: "sessions.revoke", Resource: "sessions"},
+	}))
+}
+
+var overrideCases = []struct {
+	name         string
+	method       string
+	path         string
+	template     string
+	wantAction   string
+	wantResource string
+	wantID       string
+}{
+	{
+		name:         "override by route name",
+		method:       http.MethodDelete,
+		path:         testAdminBase + "/users/7",
+		template:     testAdminBase + "/users/{user_id}",
+		wantAction:   "authentication.users.remove",
+		wantResource: "authentication:users",
+		wantID:       "7",
+	},
+	{
+		name:         "override by route template",
+		method:       http.MethodPost,
+		path:         testAdminBase + "/organizations/3/sessions/revoke",
+		template:     testAdminBase + "/organizations/{organization_id}/sessions/revoke",
+		wantAction:   "authentication.sessions.revoke",
+		wantResource: "authentication:sessions",
+		wantID:       "3",
+	},
+	{
+		name:         "derived permission",
+		method:       http.MethodGet,
+		path:         testAdminBase + "/organizations/3",
+		template:     testAdminBase + "/organizations/{organization_id}",
+		wantAction:   "authentication.admin.organizations.get",
+		wantResource: "authentication:admin.organizations",
+		wantID:       "3",
+	},
+}
+
+func TestAdminBuilderOverridesOnRoutedRequests(t *testing.T) {
+	builder := overrideBuilder()
+	for _, tt := range overrideCases {
+		t.Run(tt.name, func(t *testing.T) {
+			router := overrideRouter()
+			var got *coreMiddleware.AuthorizationRequest
+			router.Use(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					req, err := builder(r, nil)
+					if err != nil {
+						t.Fatalf("builder error = %v", err)
+					}
+					got = req
+				})
+			})
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			if got == nil || got.Action != tt.wantAction || got.Resource.Type != tt.wantResource || got.Resource.ID != tt.wantID {
+				t.Fatalf("builder = %+v, want %s %s %s", got, tt.wantAction, tt.wantResource, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestAdminRoutePermissionsApplyOverrides(t *testing.T) {
+	permissions, err := adminRoutePermissions(overrideRouter(), overrideBuilder())
+	if err != nil {
+		t.Fatalf("adminRoutePermissions() error = %v", err)
+	}
+	byRoute := make(map[string]RoutePermission, len(permissions))
+	for _, permission := range permissions {
+		byRoute[permission.Method+" "+permission.Path] = permission
+	}
+
+	for _, tt := range overrideCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := byRoute[tt.method+" "+tt.template]
+			if !ok {
+				t.Fatalf("route %s %s not listed in %+v", tt.method, tt.template, permissions)
+			}
+			if got.Action != tt.wantAction || got.Resource != tt.wantResource {
+				t.Fatalf("permission = %+v, want %s %s", got, tt.wantAction, tt.wantResource)
+			}
+		})
+	}
+}
+
+func TestPreviewAuthorizationAppliesOverrides(t *testing.T) {
+	h := &AuthenticationHandler{router: overrideRouter(), authorizationBuilder: overrideBuilder()}
+	for _, tt := range overrideCases {
+		t.Run(tt.name, func(t *testing.T) {
+			query := url.Values{"method": {tt.method}, "path": {tt.path}}
+			rec := httptest.NewRecorder()
+			h.PreviewAuthorization(rec, httptest.NewRequest(http.MethodGet, "/preview?"+query.Encode(), nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+			}
+
+			var preview AuthorizationPreview
+			if err := json.Unmarshal(rec.Body.Bytes(), &preview); err != nil {
+				t.Fatalf("decode preview: %v", err)
+			}
+			if preview.Route != tt.template || preview.Action != tt.wantAction || preview.Resource != tt.wantResource || preview.ResourceID != tt.wantID {
+				t.Fatalf("preview = %+v, want %s %s %s %s", preview, tt.template, tt.wantAction, tt.wantResource, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestAdminRoutesStrictAuthorization(t *testing.T) {
+	tests := []struct {
+		name        string
+		unavailable bool
+		superAdmin  bool
+		status      int
+	}{
+		{name: "fallback lets super admins in", superAdmin: true, status: http.StatusOK},
+		{name: "fallback rejects other users", status: http.StatusForbidden},
+		{name: "strict fails closed for super admins", unavailable: true, superAdmin: true, status: http.StatusServiceUnavailable},
+		{name: "strict fails closed for other users", unavailable: true, status: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newHandlerEnv(t, nil, nil)
+			env.router = mux.NewRouter()
+			catalog := NewRouteCatalog()
+			NewAuthenticationHandler(env.auth, false, tt.unavailable, nil).WithRouteCatalog(catalog).RegisterRoutes(env.router)
+			NewOrganizationHandler(env.org, env.auth, nil, false, tt.unavailable).WithRouteCatalog(catalog).RegisterRoutes(env.router)
+
+			token := env.superAdminToken(t)
+			if !tt.superAdmin {
+				org := env.createOrganization(t, "Acme", nil)
+				user := env.createUser(t, "member", nil)
+				env.addMember(t, user, org, "CEO")
+				token = env.loginToken(t, user, org)
+			}
+
+			for _, target := range []string{"/v1/auth/admin/users", testOrganizationAdminBase + "/organizations"} {
+				if rec := env.doAuthenticated(t, token, http.MethodGet, target, ""); rec.Code != tt.status {
+					t.Errorf("GET %s status = %d, want %d: %s", target, rec.Code, tt.status, rec.Body.String())
+				}
+			}
+			if rec := env.doAuthenticated(t, token, http.MethodGet, "/v1/auth/me", ""); rec.Code != http.StatusOK {
+				t.Errorf("non-admin route status = %d, want %d", rec.Code, http.StatusOK)
+			}
+		})
+	}
+}
+
+// buildRouted routes a request for path through a router holding only template and returns what the
+// builder produced for it.
+func buildRouted(t *testing.T, builder coreMiddleware.AuthorizationRequestBuilder, template string, req *http.Request) (*coreMiddleware.AuthorizationRequest, error) {
+	t.Helper()
+
+	var (
+		got    *coreMiddleware.AuthorizationRequest
+		err    error
+		routed bool
+	)
+	router := mux.NewRouter()
+	router.HandleFunc(template, func(http.ResponseWriter, *http.Request) {}).Methods(req.Method)
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, err = builder(r, nil)
+			routed = true
+		})
+	})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if !routed {
+		t.Fatalf("%s %s did not match %s", req.Method, req.URL.Path, template)
+	}
+	return got, err
+}
+
+func TestAdminBuilderResourceID(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []AdminBuilderOption
+		template string
+		path     string
+		wantID   string
+	}{
+		{name: "organization", template: testAdminBase + "/organizations/{organization_id}", path: testAdminBase + "/organizations/3", wantID: "3"},
+		{name: "innermost variable wins", template: testAdminBase + "/organizations/{organization_id}/departments/{department_id}", path: testAdminBase + "/organizations/3/departments/9", wantID: "9"},
+		{name: "user", template: testAdminBase + "/users/{user_id}/security", path: testAdminBase + "/users/7/security", wantID: "7"},
+		{name: "no id variable", template: testAdminBase + "/users", path: testAdminBase + "/users"},
+		{name: "unlisted variable", template: testAdminBase + "/roles/{role_id}", path: testAdminBase + "/roles/5"},
+		{name: "custom variable", opts: []AdminBuilderOption{WithAdminAuthorizationResourceIDVars("role_id")}, template: testAdminBase + "/roles/{role_id}", path: testAdminBase + "/roles/5", wantID: "5"},
+		{name: "instance ids disabled", opts: []AdminBuilderOption{WithAdminAuthorizationResourceIDVars()}, template: testAdminBase + "/users/{user_id}", path: testAdminBase + "/users/7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildRouted(t, NewAdminAuthorizationBuilder(tt.opts...), tt.template, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if err != nil {
+				t.Fatalf("builder error = %v", err)
+			}
+			if got.Resource.ID != tt.wantID {
+				t.Fatalf("resource id = %q, want %q", got.Resource.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestAdminBuilderTrace(t *testing.T) {
+	const (
+		sampled   = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+		unsampled = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"
+	)
+	tests := []struct {
+		name        string
+		propagate   bool
+		traceparent string
+		query       string
+		want        bool
+	}{
+		{name: "sampled traceparent", propagate: true, traceparent: sampled, want: true},
+		{name: "unsampled traceparent", propagate: true, traceparent: unsampled},
+		{name: "malformed traceparent", propagate: true, traceparent: "00-not-a-trace-01"},
+		{name: "zero trace id", propagate: true, traceparent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{name: "propagation disabled", traceparent: sampled},
+		{name: "query enables", query: "?trace=true", want: true},
+		{name: "query disables", propagate: true, traceparent: sampled, query: "?trace=false"},
+		{name: "nothing set", propagate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := NewAdminAuthorizationBuilder(WithAdminAuthorizationTracePropagation(tt.propagate))
+			req := httptest.NewRequest(http.MethodGet, testAdminBase+"/users"+tt.query, nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			got, err := buildRouted(t, builder, testAdminBase+"/users", req)
+			if err != nil {
+				t.Fatalf("builder error = %v", err)
+			}
+			if got.Trace != tt.want {
+				t.Fatalf("trace = %v, want %v", got.Trace, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdminBuilderUnresolvedRoute(t *testing.T) {
+	const base = "/api/v1/authentication"
+	tests := []struct {
+		name       string
+		opts       []AdminBuilderOption
+		wantAction string
+		wantErr    bool
+	}{
+		{name: "strict by default", wantErr: true},
+		{name: "fallback slug", opts: []AdminBuilderOption{WithAdminAuthorizationFallbackSlug(" .admin. ")}, wantAction: "authentication.admin.get"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The base path is stripped, leaving no segments to derive a slug from
+			got, err := buildRouted(t, NewAdminAuthorizationBuilder(tt.opts...), base+"/{organization_id}", httptest.NewRequest(http.MethodGet, base+"/3", nil))
+			if tt.wantErr {
+				if !errors.Is(err, ErrAuthorizationRouteUnresolved) {
+					t.Fatalf("builder error = %v, want %v", err, ErrAuthorizationRouteUnresolved)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("builder error = %v", err)
+			}
+			if got.Action != tt.wantAction || got.Resource.ID != "3" {
+				t.Fatalf("builder = %+v, want %s on resource 3", got, tt.wantAction)
+			}
+		})
+	}
+}
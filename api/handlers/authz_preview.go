@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+	coreErrors "github.com/lee-tech/core/errors"
+	coreMiddleware "github.com/lee-tech/core/middleware"
+	"github.com/lee-tech/core/utils"
+)
+
+// Authorization preview decisions.
+const (
+	authzDecisionAllow        = "allow"
+	authzDecisionDeny         = "deny"
+	authzDecisionNotEvaluated = "not_evaluated"
+)
+
+// AuthorizationPreview reports what the authorization layer would decide for an admin request.
+type AuthorizationPreview struct {
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	Route    string `json:"route"`
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+	// ResourceID is the instance identifier taken from the route's path variables, if any.
+	ResourceID string `json:"resource_id,omitempty"`
+	Trace      bool   `json:"trace"`
+	Decision   string `json:"decision"`
+	// Status and Message describe the response a denied request would receive.
+	Status  int    `json:"status,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// authzPreviewRoute returns the super-admin only authorization preview handler.
+func (h *AuthenticationHandler) authzPreviewRoute() http.HandlerFunc {
+	return requireSuperAdmin()(http.HandlerFunc(h.PreviewAuthorization)).ServeHTTP
+}
+
+// PreviewAuthorization runs the admin authorization builder for a synthesized request and, when an
+// authorization service is in use, evaluates it for the calling user.
+func (h *AuthenticationHandler) PreviewAuthorization(w http.ResponseWriter, r *http.Request) {
+	method := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("method")))
+	if method == "" {
+		method = http.MethodGet
+	}
+	target, err := url.Parse(strings.TrimSpace(r.URL.Query().Get("path")))
+	if err != nil || !strings.HasPrefix(target.Path, "/") {
+		coreErrors.ValidationError("path must be an absolute request path").WriteHTTP(w)
+		return
+	}
+	if h.router == nil {
+		writeInternalError(w, "route table is not available", nil)
+		return
+	}
+
+	// Carry the caller's context (identity and authorization checker) and the trace override
+	query := url.Values{}
+	if trace := r.URL.Query().Get("trace"); trace != "" {
+		query.Set("trace", trace)
+	}
+	synthesized, err := http.NewRequestWithContext(r.Context(), method, target.Path+"?"+query.Encode(), nil)
+	if err != nil {
+		coreErrors.ValidationError("invalid method or path").WriteHTTP(w)
+		return
+	}
+	synthesized.Header = r.Header.Clone()
+
+	var match mux.RouteMatch
+	if !h.router.Match(synthesized, &match) || match.Route == nil {
+		coreErrors.NotFound("route").WriteHTTP(w)
+		return
+	}
+	template, err := match.Route.GetPathTemplate()
+	if err != nil || !isAdminRoute(template) {
+		coreErrors.ValidationError("path does not match an admin route").WriteHTTP(w)
+		return
+	}
+
+	// Attach the matched route so overrides keyed by its name or template apply
+	synthesized = withAuthorizationRoute(mux.SetURLVars(synthesized, match.Vars), match.Route)
+
+	authzRequest, err := h.authorizationBuilder(synthesized, nil)
+	if err != nil {
+		coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		return
+	}
+	if authzRequest == nil {
+		writeInternalError(w, "authorization builder returned no request", nil)
+		return
+	}
+
+	preview := &AuthorizationPreview{
+		Method:     method,
+		Path:       target.Path,
+		Route:      template,
+		Action:     authzRequest.Action,
+		Resource:   authzRequest.Resource.Type,
+		ResourceID: authzRequest.Resource.ID,
+		Trace:      authzRequest.Trace,
+		Decision:   authzDecisionNotEvaluated,
+	}
+	if h.useAuthorization {
+		evaluateAuthorization(preview, h.authorizationBuilder, synthesized)
+	}
+
+	utils.RespondJSON(w, http.StatusOK, preview)
+}
+
+// evaluateAuthorization replays the request through the authorization middleware without invoking the
+// real handler, recording whether it would have been let through.
+func evaluateAuthorization(preview *AuthorizationPreview, builder coreMiddleware.AuthorizationRequestBuilder, r *http.Request) {
+	allowed := false
+	recorder := &statusRecorder{header: http.Header{}}
+	coreMiddleware.RequireAuthorization(builder)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		allowed = true
+	})).ServeHTTP(recorder, r)
+
+	if allowed {
+		preview.Decision = authzDecisionAllow
+		return
+	}
+
+	preview.Decision = authzDecisionDeny
+	preview.Status = recorder.status
+	var body struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(recorder.body.Bytes(), &body) == nil {
+		preview.Message = body.Message
+	}
+}
+
+// statusRecorder captures the response written by a middleware.
+type statusRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (s *statusRecorder) Header() http.Header {
+	return s.header
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.body.Write(b)
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+}
@@ -0,0 +1,13 @@
+package handlers
+
+import "net/http"
+
+// noStore marks every response of next, including errors, as not to be stored by browsers or
+// intermediaries. It wraps the endpoints that return tokens or other secrets.
+func noStore(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Pragma", "no-cache")
+		next(w, r)
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestTokenResponsesAreNotCached(t *testing.T) {
+	env := newOAuthHandlerEnv(t)
+	org := env.createOrganization(t, "Acme", nil)
+	user := env.createUser(t, "cached", nil)
+	env.addMember(t, user, org, "CEO")
+	login, err := env.auth.Login(&models.LoginRequest{Username: user.Username, Password: testPassword, OrganizationID: org.ID})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	loginBody := fmt.Sprintf(`{"username":%q,"password":%q}`, user.Username, testPassword)
+	refreshBody, _ := json.Marshal(map[string]string{"refresh_token": login.RefreshToken})
+
+	tests := []struct {
+		name    string
+		token   string
+		method  string
+		target  string
+		body    string
+		status  int
+		noStore bool
+	}{
+		{name: "login", method: http.MethodPost, target: "/v1/login", body: loginBody, status: http.StatusOK, noStore: true},
+		{name: "failed login", method: http.MethodPost, target: "/v1/login", body: `{"username":"cached","password":"wrong-password"}`, status: http.StatusUnauthorized, noStore: true},
+		{name: "refresh", method: http.MethodPost, target: "/refresh", body: string(refreshBody), status: http.StatusOK, noStore: true},
+		{name: "google token with unknown code", method: http.MethodPost, target: "/v1/oauth/google/token", body: `{"code":"unknown"}`, status: http.StatusUnauthorized, noStore: true},
+		{name: "profile", token: login.AccessToken, method: http.MethodGet, target: "/v1/auth/me", status: http.StatusOK},
+		{name: "health", method: http.MethodGet, target: "/v1/health", status: http.StatusOK},
+		{name: "client config", method: http.MethodGet, target: "/v1/auth/config", status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := env.doAuthenticated(t, tt.token, tt.method, tt.target, tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			cache := rec.Header().Get("Cache-Control")
+			if tt.noStore && (cache != "no-store" || rec.Header().Get("Pragma") != "no-cache") {
+				t.Errorf("Cache-Control = %q, Pragma = %q, want no-store and no-cache", cache, rec.Header().Get("Pragma"))
+			}
+			if !tt.noStore && cache == "no-store" {
+				t.Errorf("Cache-Control = %q, want the route's default", cache)
+			}
+		})
+	}
+}
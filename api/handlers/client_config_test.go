@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestClientConfigEndpoint(t *testing.T) {
+	secrets := []string{"jwt-secret-value", "google-secret-value", "mfa-key-value", "introspection-secret-value", "smtp-password-value", "trusted-key-value"}
+	env := newHandlerEnv(t, func(cfg *config.AuthConfig) {
+		cfg.JWTSecret = secrets[0]
+		cfg.OAuthEnabled = true
+		cfg.GoogleClientID = "client-id"
+		cfg.GoogleClientSecret = secrets[1]
+		cfg.GoogleRedirectURL = "https://auth.example.com/v1/oauth/google/callback"
+		cfg.MFAEncryptionKey = secrets[2]
+		cfg.IntrospectionSecret = secrets[3]
+		cfg.SMTPPassword = secrets[4]
+		cfg.TrustedClientKey = secrets[5]
+		cfg.RegistrationEnabled = true
+		cfg.RefreshExpiration = 48 * time.Hour
+	}, nil)
+
+	rec := env.do(t, http.MethodGet, "/v1/auth/config", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	body := rec.Body.String()
+	for _, secret := range secrets {
+		if strings.Contains(body, secret) {
+			t.Errorf("response exposes %q: %s", secret, body)
+		}
+	}
+	if strings.Contains(strings.ToLower(body), "secret") {
+		t.Errorf("response has a secret field: %s", body)
+	}
+
+	var got models.ClientAuthConfig
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.AccessTokenTTLSeconds != 900 || got.RefreshTokenTTLSeconds != 48*3600 {
+		t.Errorf("token ttls = %d, %d, want 900, %d", got.AccessTokenTTLSeconds, got.RefreshTokenTTLSeconds, 48*3600)
+	}
+	if !got.MFAEnabled || !got.RegistrationEnabled || !got.OAuthEnabled {
+		t.Errorf("feature flags = %+v, want mfa, registration and oauth enabled", got)
+	}
+	if got.PasswordPolicy.MinLength != 8 {
+		t.Errorf("password min length = %d, want 8", got.PasswordPolicy.MinLength)
+	}
+}
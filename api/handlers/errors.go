@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	coreErrors "github.com/lee-tech/core/errors"
+)
+
+const (
+	// ErrorVerbosityMinimal returns only the generic message to clients.
+	ErrorVerbosityMinimal = "minimal"
+	// ErrorVerbosityVerbose appends the internal error detail to the response message.
+	ErrorVerbosityVerbose = "verbose"
+)
+
+var verboseErrors bool
+
+// SetErrorVerbosity configures whether internal error details are exposed in response bodies.
+// Unknown values fall back to minimal.
+func SetErrorVerbosity(verbosity string) {
+	verboseErrors = strings.EqualFold(strings.TrimSpace(verbosity), ErrorVerbosityVerbose)
+}
+
+// writeInternalError logs the internal error and writes a 500 response whose detail depends on the verbosity.
+func writeInternalError(w http.ResponseWriter, message string, err error) {
+	if err == nil {
+		coreErrors.Internal(message).WriteHTTP(w)
+		return
+	}
+
+	log.Printf("%s: %v", message, err)
+	if verboseErrors {
+		coreErrors.Internal(message + ": " + err.Error()).WithInternal(err).WriteHTTP(w)
+		return
+	}
+	coreErrors.Internal(message).WriteHTTP(w)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteInternalErrorVerbosity(t *testing.T) {
+	tests := []struct {
+		verbosity  string
+		wantDetail bool
+	}{
+		{verbosity: ErrorVerbosityMinimal},
+		{verbosity: ErrorVerbosityVerbose, wantDetail: true},
+		{verbosity: " VERBOSE ", wantDetail: true},
+		{verbosity: "chatty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.verbosity, func(t *testing.T) {
+			SetErrorVerbosity(tt.verbosity)
+			t.Cleanup(func() { SetErrorVerbosity(ErrorVerbosityMinimal) })
+			var logged bytes.Buffer
+			previous := log.Writer()
+			log.SetOutput(&logged)
+			t.Cleanup(func() { log.SetOutput(previous) })
+
+			rec := httptest.NewRecorder()
+			writeInternalError(rec, "failed to load user", errors.New("connection reset by peer"))
+
+			if rec.Code != http.StatusInternalServerError {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+			}
+			body := rec.Body.String()
+			if !strings.Contains(body, "failed to load user") {
+				t.Errorf("body = %s, want the generic message", body)
+			}
+			if got := strings.Contains(body, "connection reset by peer"); got != tt.wantDetail {
+				t.Errorf("body = %s, detail included = %v, want %v", body, got, tt.wantDetail)
+			}
+			if !strings.Contains(logged.String(), "connection reset by peer") {
+				t.Errorf("log = %q, want the internal error logged", logged.String())
+			}
+		})
+	}
+}
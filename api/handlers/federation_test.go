@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/ratelimit"
+)
+
+func TestDiscoverFederation(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	acme := env.createOrganization(t, "Acme", func(o *models.Organization) {
+		o.Domain = "acme.example"
+		o.DomainVerified = true
+	})
+	env.createOrganization(t, "Unverified", func(o *models.Organization) { o.Domain = "unverified.example" })
+	dormant := env.createOrganization(t, "Dormant", func(o *models.Organization) {
+		o.Domain = "dormant.example"
+		o.DomainVerified = true
+	})
+	if err := env.db.Model(dormant).Update("is_active", false).Error; err != nil {
+		t.Fatalf("deactivate organization: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		email   string
+		domain  string
+		wantOrg uint64
+	}{
+		{name: "matched domain", email: "ada@acme.example", domain: "acme.example", wantOrg: acme.ID},
+		{name: "domain in any case", email: " Ada@ACME.example ", domain: "acme.example", wantOrg: acme.ID},
+		{name: "unknown domain", email: "ada@nowhere.example", domain: "nowhere.example"},
+		{name: "unverified domain", email: "ada@unverified.example", domain: "unverified.example"},
+		{name: "inactive organization", email: "ada@dormant.example", domain: "dormant.example"},
+		{name: "not an email", email: "ada"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := env.do(t, http.MethodGet, "/v1/federation/discover?email="+url.QueryEscape(tt.email), "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+			}
+			var got models.FederationDiscovery
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got.Domain != tt.domain {
+				t.Errorf("domain = %q, want %q", got.Domain, tt.domain)
+			}
+			if tt.wantOrg == 0 {
+				if got.Organizations == nil || len(got.Organizations) != 0 {
+					t.Fatalf("organizations = %+v, want an empty list", got.Organizations)
+				}
+				return
+			}
+			if len(got.Organizations) != 1 || got.Organizations[0].ID != tt.wantOrg {
+				t.Fatalf("organizations = %+v, want only %d", got.Organizations, tt.wantOrg)
+			}
+			if methods := got.Organizations[0].LoginMethods; len(methods) != 1 || methods[0] != "password" {
+				t.Errorf("login methods = %v, want [password]", methods)
+			}
+		})
+	}
+}
+
+func TestDiscoverFederationIsRateLimited(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	router := limitedRouter(env, ratelimit.NewMemoryLimiter(1, time.Minute), false)
+
+	const addr = "203.0.113.7:4000"
+	if rec := serve(router, http.MethodGet, "/v1/federation/discover?email=ada@acme.example", addr, ""); rec.Code != http.StatusOK {
+		t.Fatalf("first lookup: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec := serve(router, http.MethodGet, "/v1/federation/discover?email=bob@acme.example", addr, ""); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second lookup: status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+}
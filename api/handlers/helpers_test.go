@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/repository"
+	"github.com/lee-tech/authentication/internal/service"
+	"github.com/lee-tech/authentication/internal/testdb"
+	coreConfig "github.com/lee-tech/core/config"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const testPassword = "correct-horse-battery"
+
+// handlerEnv serves the authentication routes over an in-memory database.
+type handlerEnv struct {
+	db     *gorm.DB
+	cfg    *config.AuthConfig
+	users  *repository.UserRepository
+	orgs   *repository.OrganizationRepository
+	auth   *service.AuthenticationService
+	org    *service.OrganizationService
+	router *mux.Router
+}
+
+func testConfig() *config.AuthConfig {
+	return &config.AuthConfig{
+		Config:                 &coreConfig.Config{ServiceName: "authentication", JWTSecret: "test-secret"},
+		JWTAlgorithm:           "HS256",
+		JWTIssuer:              "authentication",
+		TokenExpiration:        15 * time.Minute,
+		RefreshExpiration:      24 * time.Hour,
+		MaxLoginAttempts:       5,
+		LockoutDuration:        15 * time.Minute,
+		BCryptCost:             bcrypt.MinCost,
+		PasswordMinLength:      8,
+		VerificationTokenTTL:   24 * time.Hour,
+		PasswordResetTTL:       time.Hour,
+		LoginSelectionEnabled:  true,
+		LoginSelectionTokenTTL: 5 * time.Minute,
+		MaxHierarchyDepth:      10,
+		DefaultTenantTier:      "basic",
+		MembershipRemovalMode:  config.MembershipRemovalHard,
+		MFAEnabled:             true,
+		MFAEncryptionKey:       "test-mfa-key",
+		MFAChallengeTokenTTL:   5 * time.Minute,
+		MaxMFAAttempts:         5,
+		StepUpTokenTTL:         5 * time.Minute,
+	}
+}
+
+// newHandlerEnv registers the authentication routes on a fresh router; configure adjusts the config and
+// setup the service before the routes are registered.
+func newHandlerEnv(t *testing.T, configure func(cfg *config.AuthConfig), setup func(auth *service.AuthenticationService)) *handlerEnv {
+	t.Helper()
+
+	cfg := testConfig()
+	if configure != nil {
+		configure(cfg)
+	}
+	db := testdb.Open(t)
+	env := &handlerEnv{
+		db:     db,
+		cfg:    cfg,
+		users:  repository.NewUserRepository(db),
+		orgs:   repository.NewOrganizationRepository(db),
+		router: mux.NewRouter(),
+	}
+	audit := repository.NewAuditRepository(db)
+	env.auth = service.NewAuthenticationService(env.users, env.orgs, audit, cfg)
+	env.org = service.NewOrganizationService(env.orgs, env.users, audit, cfg)
+	if setup != nil {
+		setup(env.auth)
+	}
+	catalog := NewRouteCatalog()
+	NewAuthenticationHandler(env.auth, false, false, nil).WithRouteCatalog(catalog).RegisterRoutes(env.router)
+	NewOrganizationHandler(env.org, env.auth, nil, false, false).WithRouteCatalog(catalog).RegisterRoutes(env.router)
+	return env
+}
+
+// createUser stores an active, verified user with testPassword; adjust tweaks it before insert.
+func (e *handlerEnv) createUser(t *testing.T, username string, adjust func(u *models.User)) *models.User {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	user := &models.User{
+		Email:      username + "@example.com",
+		Username:   username,
+		Password:   string(hash),
+		IsActive:   true,
+		IsVerified: true,
+	}
+	if adjust != nil {
+		adjust(user)
+	}
+	if err := e.users.Create(user); err != nil {
+		t.Fatalf("create user %s: %v", username, err)
+	}
+	return user
+}
+
+// createOrganization stores an active organization; adjust tweaks it before insert.
+func (e *handlerEnv) createOrganization(t *testing.T, name string, adjust func(o *models.Organization)) *models.Organization {
+	t.Helper()
+
+	org := &models.Organization{Name: name, IsActive: true}
+	if adjust != nil {
+		adjust(org)
+	}
+	if err := e.db.Create(org).Error; err != nil {
+		t.Fatalf("create organization %s: %v", name, err)
+	}
+	return org
+}
+
+// createDepartment stores an active department in the organization, under parent when one is given.
+func (e *handlerEnv) createDepartment(t *testing.T, org *models.Organization, name string, parent *models.Department) *models.Department {
+	t.Helper()
+
+	dept := &models.Department{OrganizationID: org.ID, Name: name, Kind: models.DepartmentKindDepartment, IsActive: true}
+	if parent != nil {
+		dept.ParentID = &parent.ID
+	}
+	if err := e.db.Create(dept).Error; err != nil {
+		t.Fatalf("create department %s: %v", name, err)
+	}
+	return dept
+}
+
+// addMember makes the user a member of the organization with the given role.
+func (e *handlerEnv) addMember(t *testing.T, user *models.User, org *models.Organization, role models.OrganizationRole) {
+	t.Helper()
+
+	membership := &models.UserOrganization{UserID: user.ID, OrganizationID: org.ID, Role: role, IsPrimary: true}
+	if err := e.db.Create(membership).Error; err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+}
+
+// superAdminToken signs in a super administrator of a fresh organization and returns the access token.
+func (e *handlerEnv) superAdminToken(t *testing.T) string {
+	t.Helper()
+
+	user := e.createUser(t, "root-admin", func(u *models.User) { u.IsSuperAdmin = true })
+	org := e.createOrganization(t, "Root", nil)
+	e.addMember(t, user, org, models.OrganizationRoleOrgAdmin)
+	return e.loginToken(t, user, org)
+}
+
+// loginToken signs the user in to the organization and returns the access token.
+func (e *handlerEnv) loginToken(t *testing.T, user *models.User, org *models.Organization) string {
+	t.Helper()
+
+	resp, err := e.auth.Login(&models.LoginRequest{Username: user.Username, Password: testPassword, OrganizationID: org.ID})
+	if err != nil {
+		t.Fatalf("login %s: %v", user.Username, err)
+	}
+	return resp.AccessToken
+}
+
+// doAuthenticated sends a request through the router, with the bearer token when one is given, and
+// returns the recorded response.
+func (e *handlerEnv) doAuthenticated(t *testing.T, token, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	e.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// do sends a request through the router and returns the recorded response.
+func (e *handlerEnv) do(t *testing.T, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	return e.doAuthenticated(t, "", method, target, body)
+}
+
+// enableMFA enrolls the user in TOTP MFA and returns the secret and recovery codes.
+func (e *handlerEnv) enableMFA(t *testing.T, user *models.User) (string, []string) {
+	t.Helper()
+
+	enrollment, err := e.auth.EnrollMFA(user.ID)
+	if err != nil {
+		t.Fatalf("enroll mfa: %v", err)
+	}
+	if err := e.auth.VerifyMFA(user.ID, totp(t, enrollment.Secret)); err != nil {
+		t.Fatalf("verify mfa: %v", err)
+	}
+	return enrollment.Secret, enrollment.RecoveryCodes
+}
+
+// totp computes the current RFC 6238 code for a base32 secret.
+func totp(t *testing.T, secret string) string {
+	t.Helper()
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatalf("decode totp secret: %v", err)
+	}
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(time.Now().Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
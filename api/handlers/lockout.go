@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	coreErrors "github.com/lee-tech/core/errors"
+)
+
+// trustedClientHeader carries the shared key that lets first-party clients see explicit lockout messages.
+const trustedClientHeader = "X-Trusted-Client-Key"
+
+var (
+	hideLockedAccounts bool
+	trustedClientKey   string
+)
+
+// SetLockoutDisclosure configures whether locked accounts are reported as invalid credentials so callers
+// cannot tell a locked account from a missing one. Requests presenting trustedKey in X-Trusted-Client-Key
+// still receive the explicit message.
+func SetLockoutDisclosure(hide bool, trustedKey string) {
+	hideLockedAccounts = hide
+	trustedClientKey = strings.TrimSpace(trustedKey)
+}
+
+// writeAccountLocked responds to a login against a locked account. The lockout itself is enforced
+// by the service either way.
+func writeAccountLocked(w http.ResponseWriter, r *http.Request) {
+	if hideLockedAccounts && !isTrustedClient(r) {
+		coreErrors.Unauthorized("Invalid username or password").WriteHTTP(w)
+		return
+	}
+	coreErrors.Forbidden("Account is locked due to too many failed attempts").WriteHTTP(w)
+}
+
+func isTrustedClient(r *http.Request) bool {
+	if trustedClientKey == "" {
+		return false
+	}
+	presented := strings.TrimSpace(r.Header.Get(trustedClientHeader))
+	return presented != "" && subtle.ConstantTimeCompare([]byte(presented), []byte(trustedClientKey)) == 1
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestLoginLockoutDisclosure(t *testing.T) {
+	tests := []struct {
+		name      string
+		hide      bool
+		clientKey string
+		username  string
+		status    int
+	}{
+		{name: "locked account shown", username: "locked", status: http.StatusForbidden},
+		{name: "locked account hidden", hide: true, username: "locked", status: http.StatusUnauthorized},
+		{name: "unknown account", hide: true, username: "nobody", status: http.StatusUnauthorized},
+		{name: "trusted client", hide: true, clientKey: "first-party", username: "locked", status: http.StatusForbidden},
+		{name: "wrong client key", hide: true, clientKey: "guess", username: "locked", status: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetLockoutDisclosure(tt.hide, "first-party")
+			t.Cleanup(func() { SetLockoutDisclosure(false, "") })
+
+			env := newHandlerEnv(t, nil, nil)
+			until := time.Now().Add(time.Hour)
+			env.createUser(t, "locked", func(u *models.User) { u.LockedUntil = &until })
+
+			body := fmt.Sprintf(`{"username":%q,"password":%q}`, tt.username, testPassword)
+			req := httptest.NewRequest(http.MethodPost, "/v1/login", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.clientKey != "" {
+				req.Header.Set(trustedClientHeader, tt.clientKey)
+			}
+			rec := httptest.NewRecorder()
+			env.router.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+
+			// The lockout holds either way
+			if _, err := env.auth.Login(&models.LoginRequest{Username: "locked", Password: testPassword}); err == nil {
+				t.Fatal("Login() succeeded for a locked account")
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/service"
+	coreErrors "github.com/lee-tech/core/errors"
+	"github.com/lee-tech/core/utils"
+)
+
+// bearerToken returns the token from the Authorization header, or "" when there is none.
+func bearerToken(r *http.Request) string {
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(header) < len("Bearer ") || !strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(header[len("Bearer "):])
+}
+
+// Logout revokes the caller's access token and, when supplied, their refresh token.
+func (h *AuthenticationHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	// The body is optional; an empty one logs out the access token only
+	var payload models.LogoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+
+	if err := h.authenticationService.Logout(userID, bearerToken(r), payload.RefreshToken); err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			coreErrors.Unauthorized("Invalid access or refresh token").WriteHTTP(w)
+			return
+		}
+		writeInternalError(w, "failed to log out", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]bool{
+		"logged_out": true,
+	})
+}
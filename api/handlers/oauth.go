@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/service"
+	coreErrors "github.com/lee-tech/core/errors"
+	"github.com/lee-tech/core/utils"
+)
+
+// GoogleOAuthStart stores the authorization request server-side and redirects the browser to Google's
+// consent screen.
+func (h *AuthenticationHandler) GoogleOAuthStart(w http.ResponseWriter, r *http.Request) {
+	if !h.authenticationService.GoogleOAuthEnabled() {
+		coreErrors.NotFound("oauth provider").WriteHTTP(w)
+		return
+	}
+
+	query := r.URL.Query()
+	consentURL, err := h.authenticationService.StartGoogleOAuth(service.OAuthStateInput{
+		ReturnURL:           query.Get("return_url"),
+		ClientType:          query.Get("client_type"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidReturnURL) {
+			coreErrors.BadRequest("return_url is not allowed").WriteHTTP(w)
+			return
+		}
+		coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		return
+	}
+	http.Redirect(w, r, consentURL, http.StatusFound)
+}
+
+// GoogleOAuthCallback consumes the state Google returns and sends the browser back to the return URL
+// given to the start route, with a login code on success or an error code otherwise.
+func (h *AuthenticationHandler) GoogleOAuthCallback(w http.ResponseWriter, r *http.Request) {
+	if !h.authenticationService.GoogleOAuthEnabled() {
+		coreErrors.NotFound("oauth provider").WriteHTTP(w)
+		return
+	}
+
+	query := r.URL.Query()
+	code := ""
+	if query.Get("error") == "" {
+		code = strings.TrimSpace(query.Get("code"))
+	}
+	result, err := h.authenticationService.CompleteGoogleOAuth(r.Context(), query.Get("state"), code)
+	if result == nil {
+		// Without an accepted state there is no trusted place to send the browser
+		switch {
+		case errors.Is(err, service.ErrInvalidOAuthState):
+			coreErrors.Unauthorized("Invalid or expired OAuth state").WriteHTTP(w)
+			return
+		case errors.Is(err, service.ErrInvalidReturnURL):
+			coreErrors.BadRequest("return_url is not allowed").WriteHTTP(w)
+			return
+		}
+		writeInternalError(w, "could not complete the Google sign-in", err)
+		return
+	}
+
+	params := url.Values{}
+	switch {
+	case err == nil:
+		params.Set("code", result.LoginCode)
+	case errors.Is(err, service.ErrOAuthDenied):
+		params.Set("error", "access_denied")
+	case errors.Is(err, service.ErrOAuthEmailUnverified):
+		params.Set("error", "email_unverified")
+	default:
+		log.Printf("google sign-in failed: %v", err)
+		params.Set("error", "server_error")
+	}
+	target, err := withQuery(result.ReturnURL, params)
+	if err != nil {
+		writeInternalError(w, "could not complete the Google sign-in", err)
+		return
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// GoogleOAuthToken redeems the login code from the callback, responding like the password login.
+func (h *AuthenticationHandler) GoogleOAuthToken(w http.ResponseWriter, r *http.Request) {
+	if !h.authenticationService.GoogleOAuthEnabled() {
+		coreErrors.NotFound("oauth provider").WriteHTTP(w)
+		return
+	}
+
+	var body models.OAuthTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+	if strings.TrimSpace(body.Code) == "" {
+		coreErrors.ValidationError("code is required").WriteHTTP(w)
+		return
+	}
+
+	req := &models.LoginRequest{
+		MFACode:   body.MFACode,
+		IPAddress: clientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+	response, err := h.authenticationService.RedeemGoogleLoginCode(body.Code, body.CodeVerifier, req)
+	if err != nil {
+		var mfaErr *service.MFARequiredError
+		switch {
+		case errors.As(err, &mfaErr):
+			utils.RespondJSON(w, http.StatusOK, mfaErr.Challenge)
+		case errors.Is(err, service.ErrInvalidOAuthLoginCode):
+			coreErrors.Unauthorized("Invalid or expired login code").WriteHTTP(w)
+		case errors.Is(err, service.ErrPKCERequired), errors.Is(err, service.ErrInvalidPKCEVerifier):
+			coreErrors.Unauthorized("PKCE verification failed").WriteHTTP(w)
+		case errors.Is(err, service.ErrOAuthNoOrganization):
+			coreErrors.Forbidden("No organization is available for this account").WriteHTTP(w)
+		default:
+			writeLoginError(w, r, err, "Invalid or expired token")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+// withQuery adds params to the query of a validated redirect target.
+func withQuery(target string, params url.Values) (string, error) {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+	query := parsed.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String(), nil
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/redirect"
+	"github.com/lee-tech/authentication/internal/service"
+)
+
+func newOAuthHandlerEnv(t *testing.T, origins ...string) *handlerEnv {
+	t.Helper()
+
+	return newHandlerEnv(t, func(cfg *config.AuthConfig) {
+		cfg.OAuthEnabled = true
+		cfg.GoogleClientID = "client"
+		cfg.GoogleClientSecret = "secret"
+		cfg.GoogleRedirectURL = "https://auth.example.com/v1/oauth/google/callback"
+		cfg.OAuthStateTTL = 10 * time.Minute
+	}, func(auth *service.AuthenticationService) {
+		policy, err := redirect.NewPolicy(origins)
+		if err != nil {
+			t.Fatalf("redirect policy: %v", err)
+		}
+		auth.WithRedirectPolicy(policy)
+	})
+}
+
+func TestGoogleOAuthStartReturnURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		returnURL  string
+		wantStatus int
+	}{
+		{name: "same origin path", returnURL: "/dashboard", wantStatus: http.StatusFound},
+		{name: "allowed origin", returnURL: "https://good.com/done", wantStatus: http.StatusFound},
+		{name: "disallowed origin", returnURL: "https://evil.com/", wantStatus: http.StatusBadRequest},
+		{name: "allowed origin only in query", returnURL: "https://evil.com?x=good.com", wantStatus: http.StatusBadRequest},
+		{name: "allowed origin as userinfo", returnURL: "https://good.com@evil.com/", wantStatus: http.StatusBadRequest},
+		{name: "protocol relative", returnURL: "//evil.com/", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newOAuthHandlerEnv(t, "https://good.com")
+			rec := env.do(t, http.MethodGet, "/v1/oauth/google/start?return_url="+url.QueryEscape(tt.returnURL), "")
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusFound && !strings.Contains(rec.Header().Get("Location"), "state=") {
+				t.Fatalf("Location = %q, want the Google consent URL", rec.Header().Get("Location"))
+			}
+		})
+	}
+}
+
+func TestGoogleOAuthCallbackRejectsDisallowedReturnURL(t *testing.T) {
+	env := newOAuthHandlerEnv(t, "https://good.com")
+	rec := env.do(t, http.MethodGet, "/v1/oauth/google/start?return_url="+url.QueryEscape("https://good.com/done"), "")
+	if rec.Code != http.StatusFound {
+		t.Fatalf("start status = %d: %s", rec.Code, rec.Body.String())
+	}
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("parse consent url: %v", err)
+	}
+	state := location.Query().Get("state")
+
+	// The origin is dropped from the allow-list before Google sends the browser back.
+	policy, err := redirect.NewPolicy([]string{"https://other.com"})
+	if err != nil {
+		t.Fatalf("redirect policy: %v", err)
+	}
+	env.auth.WithRedirectPolicy(policy)
+
+	rec = env.do(t, http.MethodGet, "/v1/oauth/google/callback?error=access_denied&state="+url.QueryEscape(state), "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("callback status = %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body.String())
+	}
+	if rec.Header().Get("Location") != "" {
+		t.Fatalf("callback redirected to %q", rec.Header().Get("Location"))
+	}
+}
+
+func TestGoogleOAuthCallbackRejectsUnknownState(t *testing.T) {
+	env := newOAuthHandlerEnv(t, "https://good.com")
+	rec := env.do(t, http.MethodGet, "/v1/oauth/google/callback?code=abc&state=forged", "")
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusUnauthorized, rec.Body.String())
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/lee-tech/authentication/internal/service"
+	coreErrors "github.com/lee-tech/core/errors"
+	"github.com/lee-tech/core/utils"
+)
+
+// requireTokenOrganization rejects requests whose organization_id path variable differs from the org_id
+// the access token was issued for, so a token minted for one organization cannot act on another even when
+// the user belongs to both. API keys are held to the organization they were issued for in the same way.
+// Super admins are exempt. It is a no-op unless TOKEN_ORGANIZATION_BINDING is set.
+func requireTokenOrganization(authService *service.AuthenticationService) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw, ok := mux.Vars(r)["organization_id"]
+			if !ok || !authService.TokenOrganizationBindingEnabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			orgID, err := utils.ParseUint64(raw)
+			if err != nil {
+				coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
+				return
+			}
+
+			details := requestTokenDetails(r)
+			if details == nil {
+				coreErrors.Unauthorized("Authentication required").WriteHTTP(w)
+				return
+			}
+			if !details.IsSuperAdmin && (details.OrganizationID == nil || *details.OrganizationID != orgID) {
+				if requestAPIKey(r) != nil {
+					coreErrors.Forbidden("API key was issued for a different organization").WriteHTTP(w)
+					return
+				}
+				coreErrors.Forbidden("token was issued for a different organization; switch organization first").WriteHTTP(w)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
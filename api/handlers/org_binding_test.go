@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/lee-tech/authentication/config"
+)
+
+func TestRequireTokenOrganization(t *testing.T) {
+	tests := []struct {
+		name    string
+		binding bool
+		caller  string
+		org     string
+		status  int
+	}{
+		{name: "matching organization", binding: true, caller: "member", org: "home", status: http.StatusOK},
+		{name: "other organization", binding: true, caller: "member", org: "other", status: http.StatusForbidden},
+		{name: "super admin", binding: true, caller: "admin", org: "other", status: http.StatusOK},
+		{name: "invalid organization id", binding: true, caller: "member", org: "abc", status: http.StatusBadRequest},
+		{name: "invalid token", binding: true, caller: "garbage", org: "home", status: http.StatusUnauthorized},
+		{name: "binding disabled", caller: "member", org: "other", status: http.StatusOK},
+		{name: "api key for matching organization", binding: true, caller: "key", org: "home", status: http.StatusOK},
+		{name: "api key for other organization", binding: true, caller: "key", org: "other", status: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newHandlerEnv(t, func(cfg *config.AuthConfig) { cfg.TokenOrganizationBinding = tt.binding }, nil)
+			home := env.createOrganization(t, "Home", nil)
+			other := env.createOrganization(t, "Other", nil)
+			member := env.createUser(t, "member", nil)
+			env.addMember(t, member, home, "CEO")
+			env.addMember(t, member, other, "CEO")
+
+			_, key, err := env.auth.IssueAPIKey(member.ID, &home.ID, "sync", nil, nil)
+			if err != nil {
+				t.Fatalf("IssueAPIKey() error = %v", err)
+			}
+			credentials := map[string]string{
+				"member":  "Bearer " + env.loginToken(t, member, home),
+				"admin":   "Bearer " + env.superAdminToken(t),
+				"garbage": "Bearer not-a-token",
+				"key":     "ApiKey " + key,
+			}
+			orgs := map[string]string{
+				"home":  fmt.Sprint(home.ID),
+				"other": fmt.Sprint(other.ID),
+				"abc":   "abc",
+			}
+
+			router := mux.NewRouter()
+			router.Handle("/organizations/{organization_id}", authMiddleware(env.auth)(requireTokenOrganization(env.auth)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))))
+			req := httptest.NewRequest(http.MethodGet, "/organizations/"+orgs[tt.org], nil)
+			req.Header.Set("Authorization", credentials[tt.caller])
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+		})
+	}
+}
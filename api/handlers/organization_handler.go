@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/lee-tech/authentication/internal/constants"
@@ -20,159 +22,936 @@ type OrganizationHandler struct {
 	organizationService   *service.OrganizationService
 	authenticationService *service.AuthenticationService
 	useAuthorization      bool
-	authorizationBuilder  coreMiddleware.AuthorizationRequestBuilder
+	// authorizationUnavailable makes admin routes fail closed when STRICT_AUTHORIZATION is set.
+	authorizationUnavailable bool
+	authorizationBuilder     coreMiddleware.AuthorizationRequestBuilder
+	// routes documents the registered routes for the route inventory.
+	routes *RouteCatalog
 }
 
 // NewOrganizationHandler constructs a new handler instance.
-func NewOrganizationHandler(orgSvc *service.OrganizationService, authSvc *service.AuthenticationService, builder coreMiddleware.AuthorizationRequestBuilder, useAuthorization bool) *OrganizationHandler {
+func NewOrganizationHandler(orgSvc *service.OrganizationService, authSvc *service.AuthenticationService, builder coreMiddleware.AuthorizationRequestBuilder, useAuthorization, authorizationUnavailable bool) *OrganizationHandler {
 	if builder == nil {
 		builder = NewAdminAuthorizationBuilder()
 	}
 	return &OrganizationHandler{
-		organizationService:   orgSvc,
-		authenticationService: authSvc,
-		useAuthorization:      useAuthorization,
-		authorizationBuilder:  builder,
+		organizationService:      orgSvc,
+		authenticationService:    authSvc,
+		useAuthorization:         useAuthorization,
+		authorizationUnavailable: authorizationUnavailable,
+		authorizationBuilder:     builder,
+		routes:                   NewRouteCatalog(),
 	}
 }
 
+// WithRouteCatalog records the handler's routes in catalog, which handlers sharing a router share.
+func (h *OrganizationHandler) WithRouteCatalog(catalog *RouteCatalog) *OrganizationHandler {
+	if catalog != nil {
+		h.routes = catalog
+	}
+	return h
+}
+
 // RegisterRoutes wires the routes for organization management.
 func (h *OrganizationHandler) RegisterRoutes(router *mux.Router) {
 	if h.organizationService == nil || h.authenticationService == nil {
 		return
 	}
 
-	authenticated := router.PathPrefix("/v1/organizations").Subrouter()
-	authenticated.Use(coreMiddleware.AuthMiddlewareFunc(func() string {
-		return h.authenticationService.JWTSecret()
-	}))
+	authenticated := h.routes.subrouter(router, "/v1/organizations")
+	authenticated.Use(authMiddleware(h.authenticationService))
+	authenticated.Use(requirePasswordChanged())
+	authenticated.Use(requireTokenOrganization(h.authenticationService))
+	authenticated.Use(attachScopes())
+
+	admin := authenticated.PathPrefix("/admin").Subrouter()
+	admin.Use(adminAccessMiddleware(h.useAuthorization, h.authorizationUnavailable, h.authorizationBuilder))
+
+	// Both slash variants are registered; a StrictSlash redirect would make clients drop POST bodies
+	for _, path := range []string{"/organizations", "/organizations/"} {
+		h.routes.route(admin, path, h.CreateOrganization,
+			routeDoc{Summary: "Create organization", Tags: []string{"Organization"}},
+			coreServer.WithMethods(http.MethodPost),
+		)
+
+		h.routes.route(admin, path, h.ListOrganizations,
+			routeDoc{Summary: "List organizations", Tags: []string{"Organization"}},
+			coreServer.WithMethods(http.MethodGet),
+		)
+	}
+
+	h.routes.route(admin, "/organizations/{organization_id}", h.GetOrganization,
+		routeDoc{Summary: "Get organization", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("Organization with its child organizations expanded to the requested depth"),
+		coreServer.WithParams(
+			coreServer.ParamMeta{
+				Name:        "depth",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Levels of child organizations to expand (default 1, 0 for none)",
+			},
+			coreServer.ParamMeta{
+				Name:        "include",
+				In:          coreServer.ParamInQuery,
+				Required:    false,
+				Description: "Comma-separated extras: departments, members (default departments)",
+			},
+		),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "organization-detail",
+				Description: "Organization with its hierarchy",
+			},
+		}),
+	)
+
+	h.routes.route(admin, "/organizations/{organization_id}/tier", h.UpdateOrganizationTier,
+		routeDoc{Summary: "Update organization tier", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodPut),
+		coreServer.WithDescription("Change the plan emitted as the tenant_tier token claim"),
+	)
+
+	h.routes.route(admin, "/organizations/{organization_id}/login-policy", h.UpdateOrganizationLoginPolicy,
+		routeDoc{Summary: "Update organization login policy", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodPut),
+		coreServer.WithDescription("Set the minimum role level (lower = higher authority) required to log into the organization; null removes it"),
+	)
+
+	h.routes.route(admin, "/organizations/{organization_id}/contact", h.GetOrganizationContact,
+		routeDoc{Summary: "Get organization contact", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("Contact email, phone and address stored for the organization"),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "organization-contact",
+				Description: "Organization contact details",
+			},
+		}),
+	)
+
+	h.routes.route(admin, "/organizations/{organization_id}/contact", h.UpdateOrganizationContact,
+		routeDoc{Summary: "Update organization contact", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodPatch),
+		coreServer.WithDescription("Change the supplied contact fields; omitted fields are kept and empty strings clear them (requires auth.organizations.update)"),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required: true,
+			ModelKey: "update-organization-contact-input",
+			Example: map[string]any{
+				"contact_email": "billing@example.com",
+				"contact_phone": "+84 24 1234 5678",
+				"address":       "1 Example Street, Hanoi",
+			},
+		}),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "organization-contact",
+				Description: "Updated organization contact details",
+			},
+		}),
+	)
+
+	h.routes.route(admin, "/organizations/{organization_id}/domain/verify-start", h.StartDomainVerification,
+		routeDoc{Summary: "Start domain verification", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Issue the DNS TXT record that proves the organization controls its domain; repeated calls return the same record (requires auth.organizations.update)"),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "domain-verification",
+				Description: "TXT record to publish",
+				Example: map[string]any{
+					"organization_id": 1,
+					"domain":          "example.com",
+					"verified":        false,
+					"record_type":     "TXT",
+					"record_name":     "_authentication-verification.example.com",
+					"record_value":    "authentication-verification=5f2b9c...",
+				},
+			},
+		}),
+	)
+
+	h.routes.route(admin, "/organizations/{organization_id}/domain/verify-check", h.CheckDomainVerification,
+		routeDoc{Summary: "Check domain verification", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Look up the organization's TXT record and mark the domain verified when it matches; only verified domains are used for login discovery (requires auth.organizations.update)"),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "domain-verification",
+				Description: "Verified domain",
+			},
+		}),
+	)
+
+	h.routes.route(admin, "/organizations/{organization_id}/revoke-sessions", h.RevokeOrganizationSessions,
+		routeDoc{Summary: "Revoke organization sessions", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Invalidate every token issued to members of the organization (requires auth.organizations.revoke_sessions)"),
+	)
+
+	h.routes.route(admin, "/organizations/{organization_id}/deactivate", h.DeactivateOrganization,
+		routeDoc{Summary: "Deactivate organization", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Block logins for every member except super admins. Set revoke_sessions to also invalidate existing tokens (requires auth.organizations.revoke_sessions)"),
+	)
+
+	h.routes.route(admin, "/organizations/{organization_id}/reactivate", h.ReactivateOrganization,
+		routeDoc{Summary: "Reactivate organization", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Allow members of a deactivated organization to log in again"),
+	)
+
+	h.routes.route(admin, "/organizations/{organization_id}/provision-roles", h.ProvisionOrganizationRoles,
+		routeDoc{Summary: "Provision default roles", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Seed the organization's role templates from the platform defaults, skipping codes it already has. Returns the templates created"),
+	)
+
+	h.routes.route(admin, "/organizations/{organization_id}/departments", h.CreateDepartment,
+		routeDoc{Summary: "Create department", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodPost),
+	)
+
+	h.routes.route(admin, "/organizations/{organization_id}/departments", h.ListDepartments,
+		routeDoc{Summary: "List departments", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodGet),
+	)
+
+	h.routes.route(admin, "/organizations/{organization_id}/departments/by-code/{code}", h.GetDepartmentByCode,
+		routeDoc{Summary: "Get department by code", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("Look up a department by its stable code, such as those of the default structure, within the organization"),
+	)
+
+	h.routes.route(admin, "/organizations/{organization_id}/departments/set-active", h.SetDepartmentsActive,
+		routeDoc{Summary: "Bulk activate or deactivate departments", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Set is_active on the listed departments in one transaction. Every department must belong to the organization, otherwise nothing changes and the per-department results say why. Members cannot log into an inactive department (requires auth.departments.activate)"),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required: true,
+			ModelKey: "set-departments-active-input",
+			Example: map[string]any{
+				"department_ids": []uint64{12, 13},
+				"is_active":      false,
+			},
+		}),
+		coreServer.WithResponseMeta(map[int]coreServer.BodyMeta{
+			http.StatusOK: {
+				Required:    true,
+				ModelKey:    "set-departments-active-result",
+				Description: "Per-department outcome",
+			},
+		}),
+	)
+
+	h.routes.route(admin, "/organizations/{organization_id}/structure/export", h.ExportOrganizationStructure,
+		routeDoc{Summary: "Export organization structure", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodGet),
+		coreServer.WithDescription("Export the organization, its department tree, and role templates without user data"),
+	)
+
+	h.routes.route(admin, "/organizations/{organization_id}/structure/import", h.ImportOrganizationStructure,
+		routeDoc{Summary: "Import organization structure", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Create the departments of an exported structure document under the organization"),
+	)
+
+	h.routes.route(admin, "/organizations/{organization_id}/members", h.AssignUserToOrganization,
+		routeDoc{Summary: "Assign user to organization", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodPost),
+	)
+
+	h.routes.route(admin, "/organizations/{organization_id}/members/{user_id}", h.RemoveUserFromOrganization,
+		routeDoc{Summary: "Remove user from organization", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodDelete),
+		coreServer.WithDescription("Delete a user's organization membership. The last SYSTEM_ADMIN of the bootstrap organization cannot be removed"),
+	)
+
+	h.routes.route(admin, "/organizations/{organization_id}/admins", h.AssignOrganizationAdmin,
+		routeDoc{Summary: "Assign organization admin", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Grant the ORG_ADMIN role to a user, adding the membership when needed. The organization becomes the user's primary one if they have none (requires auth.organizations.assign_admin)"),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required: true,
+			Example: map[string]any{
+				"user_id": 42,
+			},
+		}),
+	)
+
+	h.routes.route(admin, "/departments/{department_id}", h.UpdateDepartment,
+		routeDoc{Summary: "Update department", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodPatch),
+		coreServer.WithDescription("Change the supplied department fields and optionally re-parent it within its organization; parent_id 0 makes it top-level (requires auth.departments.update)"),
+		coreServer.WithRequestBody(&coreServer.BodyMeta{
+			Required: true,
+			ModelKey: "update-department-input",
+			Example: map[string]any{
+				"name":      "Ban Hang (Sales)",
+				"code":      "SALES",
+				"kind":      "DIVISION",
+				"parent_id": 3,
+			},
+		}),
+	)
+
+	h.routes.route(admin, "/departments/{department_id}/move", h.MoveDepartment,
+		routeDoc{Summary: "Move department", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodPost),
+		coreServer.WithDescription("Re-parent a department and its descendants, optionally into another organization. Cross-organization moves refuse members outside the target organization unless clear_memberships is set"),
+	)
+
+	h.routes.route(admin, "/departments/{department_id}/members", h.AssignUserToDepartment,
+		routeDoc{Summary: "Assign user to department", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodPost),
+	)
+
+	h.routes.route(admin, "/departments/{department_id}/members/{user_id}", h.RemoveUserFromDepartment,
+		routeDoc{Summary: "Remove user from department", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodDelete),
+		coreServer.WithDescription("Delete a user's department membership"),
+	)
+
+	h.routes.route(admin, "/users/{user_id}/organizations", h.ListUserOrganizations,
+		routeDoc{Summary: "List user organizations", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodGet),
+	)
+
+	h.routes.route(admin, "/users/{user_id}/departments", h.ListUserDepartments,
+		routeDoc{Summary: "List user departments", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodGet),
+	)
+	h.routes.route(admin, "/users/{user_id}/assignable-departments", h.ListAssignableDepartments,
+		routeDoc{Summary: "List departments a user can be assigned to", Tags: []string{"Organization"}},
+		coreServer.WithMethods(http.MethodGet),
+	)
+}
+
+func (h *OrganizationHandler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	var payload models.CreateOrganizationInput
+	if err := utils.DecodeJSON(r.Body, &payload); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+
+	org, err := h.organizationService.CreateOrganization(&payload)
+	if err != nil {
+		// Validation failures, including ErrOrganizationCycle, are reported as 422
+		coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, org)
+}
+
+func (h *OrganizationHandler) ListOrganizations(w http.ResponseWriter, _ *http.Request) {
+	orgs, err := h.organizationService.ListOrganizations()
+	if err != nil {
+		writeInternalError(w, "failed to list organizations", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, orgs)
+}
+
+func (h *OrganizationHandler) UpdateOrganizationTier(w http.ResponseWriter, r *http.Request) {
+	orgID, err := utils.ParseUint64(mux.Vars(r)["organization_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
+		return
+	}
+
+	var payload models.UpdateOrganizationTierInput
+	if err := utils.DecodeJSON(r.Body, &payload); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+
+	org, err := h.organizationService.UpdateOrganizationTier(orgID, &payload)
+	if err != nil {
+		if errors.Is(err, service.ErrOrganizationNotFound) {
+			coreErrors.NotFound("organization").WriteHTTP(w)
+			return
+		}
+		coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, org)
+}
+
+// UpdateOrganizationLoginPolicy changes the minimum role level required to log into the organization.
+func (h *OrganizationHandler) UpdateOrganizationLoginPolicy(w http.ResponseWriter, r *http.Request) {
+	orgID, err := utils.ParseUint64(mux.Vars(r)["organization_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
+		return
+	}
+
+	var payload models.UpdateOrganizationLoginPolicyInput
+	if err := utils.DecodeJSON(r.Body, &payload); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+
+	org, err := h.organizationService.UpdateOrganizationLoginPolicy(orgID, &payload)
+	if err != nil {
+		if errors.Is(err, service.ErrOrganizationNotFound) {
+			coreErrors.NotFound("organization").WriteHTTP(w)
+			return
+		}
+		coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, org)
+}
+
+// GetOrganization returns an organization with its child hierarchy and the requested extras.
+func (h *OrganizationHandler) GetOrganization(w http.ResponseWriter, r *http.Request) {
+	orgID, err := utils.ParseUint64(mux.Vars(r)["organization_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
+		return
+	}
+
+	depth := 1
+	if raw := strings.TrimSpace(r.URL.Query().Get("depth")); raw != "" {
+		if depth, err = strconv.Atoi(raw); err != nil {
+			coreErrors.ValidationError("depth must be an integer").WriteHTTP(w)
+			return
+		}
+	}
+
+	includeDepartments, includeMembers := true, false
+	if raw, ok := r.URL.Query()["include"]; ok {
+		includeDepartments = false
+		for _, value := range strings.Split(strings.Join(raw, ","), ",") {
+			switch strings.ToLower(strings.TrimSpace(value)) {
+			case "":
+			case "departments":
+				includeDepartments = true
+			case "members":
+				includeMembers = true
+			default:
+				coreErrors.ValidationError(fmt.Sprintf("unknown include value %q", value)).WriteHTTP(w)
+				return
+			}
+		}
+	}
+
+	detail, err := h.organizationService.GetOrganizationDetail(orgID, depth, includeDepartments, includeMembers)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrganizationNotFound):
+			coreErrors.NotFound("organization").WriteHTTP(w)
+		case errors.Is(err, service.ErrInvalidHierarchyDepth):
+			coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		default:
+			writeInternalError(w, "failed to load organization", err)
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, detail)
+}
+
+// GetOrganizationContact returns the organization's contact details.
+func (h *OrganizationHandler) GetOrganizationContact(w http.ResponseWriter, r *http.Request) {
+	orgID, err := utils.ParseUint64(mux.Vars(r)["organization_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
+		return
+	}
+
+	contact, err := h.organizationService.GetOrganizationContact(orgID)
+	if err != nil {
+		if errors.Is(err, service.ErrOrganizationNotFound) {
+			coreErrors.NotFound("organization").WriteHTTP(w)
+			return
+		}
+		writeInternalError(w, "failed to load organization contact", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, contact)
+}
+
+// UpdateOrganizationContact changes the organization's contact details.
+func (h *OrganizationHandler) UpdateOrganizationContact(w http.ResponseWriter, r *http.Request) {
+	if !hasPermission(r, "auth.organizations.update") {
+		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
+		return
+	}
+
+	orgID, err := utils.ParseUint64(mux.Vars(r)["organization_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
+		return
+	}
+
+	var payload models.UpdateOrganizationContactInput
+	if err := utils.DecodeJSON(r.Body, &payload); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+
+	actorID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+	payload.ActorID = actorID
+
+	contact, err := h.organizationService.UpdateOrganizationContact(orgID, &payload)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrganizationNotFound):
+			coreErrors.NotFound("organization").WriteHTTP(w)
+		case errors.Is(err, service.ErrInvalidOrganizationContact):
+			coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		default:
+			writeInternalError(w, "failed to update organization contact", err)
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, contact)
+}
+
+// StartDomainVerification issues the TXT record that verifies the organization's domain.
+func (h *OrganizationHandler) StartDomainVerification(w http.ResponseWriter, r *http.Request) {
+	h.domainVerification(w, r, h.organizationService.StartDomainVerification)
+}
+
+// CheckDomainVerification verifies the organization's domain against its published TXT record.
+func (h *OrganizationHandler) CheckDomainVerification(w http.ResponseWriter, r *http.Request) {
+	h.domainVerification(w, r, h.organizationService.CheckDomainVerification)
+}
+
+// domainVerification runs one step of the domain verification flow and maps its errors.
+func (h *OrganizationHandler) domainVerification(w http.ResponseWriter, r *http.Request, step func(orgID, actorID uint64) (*models.DomainVerification, error)) {
+	if !hasPermission(r, "auth.organizations.update") {
+		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
+		return
+	}
+
+	orgID, err := utils.ParseUint64(mux.Vars(r)["organization_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
+		return
+	}
+
+	actorID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	status, err := step(orgID, actorID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrganizationNotFound):
+			coreErrors.NotFound("organization").WriteHTTP(w)
+		case errors.Is(err, service.ErrOrganizationDomainMissing),
+			errors.Is(err, service.ErrDomainVerificationRecordMissing):
+			coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		case errors.Is(err, service.ErrDomainAlreadyVerified),
+			errors.Is(err, service.ErrDomainVerificationNotStarted):
+			coreErrors.Conflict(err.Error()).WriteHTTP(w)
+		default:
+			writeInternalError(w, "failed to verify organization domain", err)
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, status)
+}
+
+// RevokeOrganizationSessions logs every member of the organization out by invalidating their tokens.
+func (h *OrganizationHandler) RevokeOrganizationSessions(w http.ResponseWriter, r *http.Request) {
+	if !hasPermission(r, "auth.organizations.revoke_sessions") {
+		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
+		return
+	}
+
+	orgID, err := utils.ParseUint64(mux.Vars(r)["organization_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
+		return
+	}
+
+	actorID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.authenticationService.RevokeOrganizationSessions(orgID, actorID)
+	if err != nil {
+		if errors.Is(err, service.ErrOrganizationNotFound) {
+			coreErrors.NotFound("organization").WriteHTTP(w)
+			return
+		}
+		writeInternalError(w, "failed to revoke organization sessions", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]any{
+		"organization_id": orgID,
+		"revoked_users":   revoked,
+	})
+}
+
+// DeactivateOrganization marks the organization inactive so its members can no longer log in.
+func (h *OrganizationHandler) DeactivateOrganization(w http.ResponseWriter, r *http.Request) {
+	orgID, err := utils.ParseUint64(mux.Vars(r)["organization_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
+		return
+	}
+
+	var payload struct {
+		RevokeSessions bool `json:"revoke_sessions"`
+	}
+	if r.ContentLength != 0 {
+		if err := utils.DecodeJSON(r.Body, &payload); err != nil {
+			coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+			return
+		}
+	}
+	if payload.RevokeSessions && !hasPermission(r, "auth.organizations.revoke_sessions") {
+		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
+		return
+	}
+
+	actorID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	org, err := h.organizationService.SetOrganizationActive(orgID, false, actorID)
+	if err != nil {
+		if errors.Is(err, service.ErrOrganizationNotFound) {
+			coreErrors.NotFound("organization").WriteHTTP(w)
+			return
+		}
+		writeInternalError(w, "failed to deactivate organization", err)
+		return
+	}
+
+	response := map[string]any{
+		"organization": org,
+	}
+	if payload.RevokeSessions {
+		revoked, err := h.authenticationService.RevokeOrganizationSessions(orgID, actorID)
+		if err != nil {
+			writeInternalError(w, "organization deactivated but revoking sessions failed", err)
+			return
+		}
+		response["revoked_users"] = revoked
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+// ReactivateOrganization lets members of a deactivated organization log in again.
+func (h *OrganizationHandler) ReactivateOrganization(w http.ResponseWriter, r *http.Request) {
+	orgID, err := utils.ParseUint64(mux.Vars(r)["organization_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
+		return
+	}
+
+	actorID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	org, err := h.organizationService.SetOrganizationActive(orgID, true, actorID)
+	if err != nil {
+		if errors.Is(err, service.ErrOrganizationNotFound) {
+			coreErrors.NotFound("organization").WriteHTTP(w)
+			return
+		}
+		writeInternalError(w, "failed to reactivate organization", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, org)
+}
+
+func (h *OrganizationHandler) CreateDepartment(w http.ResponseWriter, r *http.Request) {
+	orgID, err := utils.ParseUint64(mux.Vars(r)["organization_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
+		return
+	}
+
+	var payload models.CreateDepartmentInput
+	if err := utils.DecodeJSON(r.Body, &payload); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+	payload.OrganizationID = orgID
+
+	dept, err := h.organizationService.CreateDepartment(&payload)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrganizationNotFound):
+			coreErrors.NotFound("organization").WriteHTTP(w)
+		case errors.Is(err, service.ErrDepartmentNotFound):
+			coreErrors.NotFound("department").WriteHTTP(w)
+		default:
+			coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, dept)
+}
 
-	admin := authenticated.PathPrefix("/admin").Subrouter()
-	if h.useAuthorization {
-		admin.Use(coreMiddleware.RequireAuthorization(h.authorizationBuilder))
-	} else {
-		admin.Use(coreMiddleware.RequireSuperAdmin())
+func (h *OrganizationHandler) ListDepartments(w http.ResponseWriter, r *http.Request) {
+	orgID, err := utils.ParseUint64(mux.Vars(r)["organization_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
+		return
 	}
 
-	coreServer.Route(admin, "/", h.CreateOrganization,
-		coreServer.WithMethods(http.MethodPost),
-		coreServer.WithSummary("Create organization"),
-		coreServer.WithTags("Organization"),
-	)
+	departments, err := h.organizationService.ListDepartments(&orgID)
+	if err != nil {
+		writeInternalError(w, "failed to list departments", err)
+		return
+	}
 
-	coreServer.Route(admin, "/organizations", h.ListOrganizations,
-		coreServer.WithMethods(http.MethodGet),
-		coreServer.WithSummary("List organizations"),
-		coreServer.WithTags("Organization"),
-	)
+	utils.RespondJSON(w, http.StatusOK, departments)
+}
 
-	coreServer.Route(admin, "/organizations/{organization_id}/departments", h.CreateDepartment,
-		coreServer.WithMethods(http.MethodPost),
-		coreServer.WithSummary("Create department"),
-		coreServer.WithTags("Organization"),
-	)
+func (h *OrganizationHandler) GetDepartmentByCode(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	orgID, err := utils.ParseUint64(vars["organization_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
+		return
+	}
 
-	coreServer.Route(admin, "/organizations/{organization_id}/departments", h.ListDepartments,
-		coreServer.WithMethods(http.MethodGet),
-		coreServer.WithSummary("List departments"),
-		coreServer.WithTags("Organization"),
-	)
+	dept, err := h.organizationService.GetDepartmentByCode(orgID, vars["code"])
+	if err != nil {
+		if errors.Is(err, service.ErrDepartmentNotFound) {
+			coreErrors.NotFound("department").WriteHTTP(w)
+			return
+		}
+		writeInternalError(w, "failed to get department", err)
+		return
+	}
 
-	coreServer.Route(admin, "/organizations/{organization_id}/members", h.AssignUserToOrganization,
-		coreServer.WithMethods(http.MethodPost),
-		coreServer.WithSummary("Assign user to organization"),
-		coreServer.WithTags("Organization"),
-	)
+	utils.RespondJSON(w, http.StatusOK, dept)
+}
 
-	coreServer.Route(admin, "/departments/{department_id}/members", h.AssignUserToDepartment,
-		coreServer.WithMethods(http.MethodPost),
-		coreServer.WithSummary("Assign user to department"),
-		coreServer.WithTags("Organization"),
-	)
+func (h *OrganizationHandler) ExportOrganizationStructure(w http.ResponseWriter, r *http.Request) {
+	orgID, err := utils.ParseUint64(mux.Vars(r)["organization_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
+		return
+	}
 
-	coreServer.Route(admin, "/users/{user_id}/organizations", h.ListUserOrganizations,
-		coreServer.WithMethods(http.MethodGet),
-		coreServer.WithSummary("List user organizations"),
-		coreServer.WithTags("Organization"),
-	)
+	structure, err := h.organizationService.ExportStructure(orgID)
+	if err != nil {
+		if errors.Is(err, service.ErrOrganizationNotFound) {
+			coreErrors.NotFound("organization").WriteHTTP(w)
+			return
+		}
+		writeInternalError(w, "failed to export organization structure", err)
+		return
+	}
 
-	coreServer.Route(admin, "/users/{user_id}/departments", h.ListUserDepartments,
-		coreServer.WithMethods(http.MethodGet),
-		coreServer.WithSummary("List user departments"),
-		coreServer.WithTags("Organization"),
-	)
+	utils.RespondJSON(w, http.StatusOK, structure)
 }
 
-func (h *OrganizationHandler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
-	var payload models.CreateOrganizationInput
+func (h *OrganizationHandler) ImportOrganizationStructure(w http.ResponseWriter, r *http.Request) {
+	orgID, err := utils.ParseUint64(mux.Vars(r)["organization_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
+		return
+	}
+
+	var payload models.OrganizationStructureExport
 	if err := utils.DecodeJSON(r.Body, &payload); err != nil {
 		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
 		return
 	}
 
-	org, err := h.organizationService.CreateOrganization(&payload)
-	if err != nil {
-		coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+	if _, err := h.organizationService.ImportStructure(orgID, &payload); err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrganizationNotFound):
+			coreErrors.NotFound("organization").WriteHTTP(w)
+		case errors.Is(err, service.ErrInvalidDepartment), errors.Is(err, service.ErrInvalidDepartmentKind):
+			coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		default:
+			writeInternalError(w, "failed to import organization structure", err)
+		}
 		return
 	}
 
-	utils.RespondJSON(w, http.StatusCreated, org)
-}
-
-func (h *OrganizationHandler) ListOrganizations(w http.ResponseWriter, _ *http.Request) {
-	orgs, err := h.organizationService.ListOrganizations()
+	structure, err := h.organizationService.ExportStructure(orgID)
 	if err != nil {
-		coreErrors.Internal("failed to list organizations").WithInternal(err).WriteHTTP(w)
+		writeInternalError(w, "failed to load organization structure", err)
 		return
 	}
 
-	utils.RespondJSON(w, http.StatusOK, orgs)
+	utils.RespondJSON(w, http.StatusCreated, structure)
 }
 
-func (h *OrganizationHandler) CreateDepartment(w http.ResponseWriter, r *http.Request) {
+// SetDepartmentsActive activates or deactivates several departments of an organization at once.
+func (h *OrganizationHandler) SetDepartmentsActive(w http.ResponseWriter, r *http.Request) {
+	if !hasPermission(r, "auth.departments.activate") {
+		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
+		return
+	}
+
 	orgID, err := utils.ParseUint64(mux.Vars(r)["organization_id"])
 	if err != nil {
 		coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
 		return
 	}
 
-	var payload models.CreateDepartmentInput
+	var payload models.SetDepartmentsActiveInput
 	if err := utils.DecodeJSON(r.Body, &payload); err != nil {
 		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
 		return
 	}
-	payload.OrganizationID = orgID
 
-	dept, err := h.organizationService.CreateDepartment(&payload)
+	actorID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+	payload.ActorID = actorID
+
+	result, err := h.organizationService.SetDepartmentsActive(orgID, &payload)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrOrganizationNotFound):
 			coreErrors.NotFound("organization").WriteHTTP(w)
+		case errors.Is(err, service.ErrDepartmentOrganizationMismatch):
+			utils.RespondJSON(w, http.StatusUnprocessableEntity, result)
+		default:
+			coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, result)
+}
+
+// UpdateDepartment changes a department's details and parent within its organization.
+func (h *OrganizationHandler) UpdateDepartment(w http.ResponseWriter, r *http.Request) {
+	if !hasPermission(r, "auth.departments.update") {
+		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
+		return
+	}
+
+	deptID, err := utils.ParseUint64(mux.Vars(r)["department_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid department id").WriteHTTP(w)
+		return
+	}
+
+	var payload models.UpdateDepartmentInput
+	if err := utils.DecodeJSON(r.Body, &payload); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+
+	actorID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+	payload.ActorID = actorID
+
+	dept, err := h.organizationService.UpdateDepartment(deptID, &payload)
+	if err != nil {
+		switch {
 		case errors.Is(err, service.ErrDepartmentNotFound):
 			coreErrors.NotFound("department").WriteHTTP(w)
+		case errors.Is(err, service.ErrDepartmentCodeConflict):
+			coreErrors.Conflict(err.Error()).WriteHTTP(w)
 		default:
 			coreErrors.ValidationError(err.Error()).WriteHTTP(w)
 		}
 		return
 	}
 
-	utils.RespondJSON(w, http.StatusCreated, dept)
+	utils.RespondJSON(w, http.StatusOK, dept)
 }
 
-func (h *OrganizationHandler) ListDepartments(w http.ResponseWriter, r *http.Request) {
+// MoveDepartment re-parents a department subtree, possibly across organizations.
+func (h *OrganizationHandler) MoveDepartment(w http.ResponseWriter, r *http.Request) {
+	deptID, err := utils.ParseUint64(mux.Vars(r)["department_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid department id").WriteHTTP(w)
+		return
+	}
+
+	var payload models.MoveDepartmentInput
+	if err := utils.DecodeJSON(r.Body, &payload); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+
+	actorID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+	payload.ActorID = actorID
+
+	result, err := h.organizationService.MoveDepartment(deptID, &payload)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDepartmentNotFound):
+			coreErrors.NotFound("department").WriteHTTP(w)
+		case errors.Is(err, service.ErrOrganizationNotFound):
+			coreErrors.NotFound("organization").WriteHTTP(w)
+		case errors.Is(err, service.ErrDepartmentMembershipConflict), errors.Is(err, service.ErrPrimaryDepartmentOrphaned):
+			coreErrors.Conflict(err.Error()).WriteHTTP(w)
+		case errors.Is(err, service.ErrInvalidDepartment), errors.Is(err, service.ErrInvalidDepartmentKind), errors.Is(err, service.ErrHierarchyTooDeep):
+			coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		default:
+			writeInternalError(w, "failed to move department", err)
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, result)
+}
+
+// ProvisionOrganizationRoles seeds the organization's role templates from the platform defaults.
+func (h *OrganizationHandler) ProvisionOrganizationRoles(w http.ResponseWriter, r *http.Request) {
 	orgID, err := utils.ParseUint64(mux.Vars(r)["organization_id"])
 	if err != nil {
 		coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
 		return
 	}
 
-	departments, err := h.organizationService.ListDepartments(&orgID)
+	actorID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.organizationService.ProvisionOrganizationRoles(orgID, actorID)
 	if err != nil {
-		coreErrors.Internal("failed to list departments").WithInternal(err).WriteHTTP(w)
+		if errors.Is(err, service.ErrOrganizationNotFound) {
+			coreErrors.NotFound("organization").WriteHTTP(w)
+			return
+		}
+		writeInternalError(w, "failed to provision organization roles", err)
 		return
 	}
 
-	utils.RespondJSON(w, http.StatusOK, departments)
+	utils.RespondJSON(w, http.StatusOK, map[string]any{
+		"created": created,
+	})
 }
 
 func (h *OrganizationHandler) AssignUserToOrganization(w http.ResponseWriter, r *http.Request) {
@@ -192,11 +971,17 @@ func (h *OrganizationHandler) AssignUserToOrganization(w http.ResponseWriter, r
 		return
 	}
 
+	actorID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
 	input := &models.AssignUserOrganizationInput{
 		UserID:         payload.UserID,
 		OrganizationID: orgID,
 		Role:           payload.Role,
 		IsPrimary:      payload.IsPrimary,
+		ActorID:        actorID,
 	}
 
 	membership, err := h.organizationService.AssignUserToOrganization(input)
@@ -206,6 +991,8 @@ func (h *OrganizationHandler) AssignUserToOrganization(w http.ResponseWriter, r
 			coreErrors.NotFound("user").WriteHTTP(w)
 		case errors.Is(err, service.ErrOrganizationNotFound):
 			coreErrors.NotFound("organization").WriteHTTP(w)
+		case errors.Is(err, service.ErrLastOrganizationAdmin):
+			coreErrors.Conflict(err.Error()).WriteHTTP(w)
 		default:
 			coreErrors.ValidationError(err.Error()).WriteHTTP(w)
 		}
@@ -215,6 +1002,120 @@ func (h *OrganizationHandler) AssignUserToOrganization(w http.ResponseWriter, r
 	utils.RespondJSON(w, http.StatusCreated, membership)
 }
 
+// AssignOrganizationAdmin promotes a user to ORG_ADMIN of the organization.
+func (h *OrganizationHandler) AssignOrganizationAdmin(w http.ResponseWriter, r *http.Request) {
+	if !hasPermission(r, "auth.organizations.assign_admin") {
+		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
+		return
+	}
+
+	orgID, err := utils.ParseUint64(mux.Vars(r)["organization_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
+		return
+	}
+
+	var payload struct {
+		UserID uint64 `json:"user_id"`
+	}
+	if err := utils.DecodeJSON(r.Body, &payload); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+
+	actorID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	membership, err := h.organizationService.AssignOrganizationAdmin(orgID, payload.UserID, actorID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			coreErrors.NotFound("user").WriteHTTP(w)
+		case errors.Is(err, service.ErrOrganizationNotFound):
+			coreErrors.NotFound("organization").WriteHTTP(w)
+		default:
+			coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, membership)
+}
+
+// RemoveUserFromOrganization deletes a user's membership in an organization.
+func (h *OrganizationHandler) RemoveUserFromOrganization(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	orgID, err := utils.ParseUint64(vars["organization_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid organization id").WriteHTTP(w)
+		return
+	}
+	userID, err := utils.ParseUint64(vars["user_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid user id").WriteHTTP(w)
+		return
+	}
+
+	actorID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.organizationService.RemoveUserOrganization(&userID, &orgID, actorID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrMembershipNotFound):
+			coreErrors.NotFound("membership").WriteHTTP(w)
+		case errors.Is(err, service.ErrLastSystemAdmin), errors.Is(err, service.ErrLastOrganizationAdmin):
+			coreErrors.Conflict(err.Error()).WriteHTTP(w)
+		default:
+			writeInternalError(w, "failed to remove organization membership", err)
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]bool{
+		"removed": true,
+	})
+}
+
+// RemoveUserFromDepartment deletes a user's membership in a department.
+func (h *OrganizationHandler) RemoveUserFromDepartment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	deptID, err := utils.ParseUint64(vars["department_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid department id").WriteHTTP(w)
+		return
+	}
+	userID, err := utils.ParseUint64(vars["user_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid user id").WriteHTTP(w)
+		return
+	}
+
+	actorID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.organizationService.RemoveUserDepartment(&userID, &deptID, actorID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrDepartmentNotFound):
+			coreErrors.NotFound("department").WriteHTTP(w)
+		case errors.Is(err, service.ErrMembershipNotFound):
+			coreErrors.NotFound("membership").WriteHTTP(w)
+		default:
+			writeInternalError(w, "failed to remove department membership", err)
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]bool{
+		"removed": true,
+	})
+}
+
 func (h *OrganizationHandler) AssignUserToDepartment(w http.ResponseWriter, r *http.Request) {
 	deptID, err := utils.ParseUint64(mux.Vars(r)["department_id"])
 	if err != nil {
@@ -232,11 +1133,17 @@ func (h *OrganizationHandler) AssignUserToDepartment(w http.ResponseWriter, r *h
 		return
 	}
 
+	actorID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
 	input := &models.AssignUserDepartmentInput{
 		UserID:       &payload.UserID,
 		DepartmentID: &deptID,
 		Role:         payload.Role,
 		IsPrimary:    payload.IsPrimary,
+		ActorID:      actorID,
 	}
 
 	membership, err := h.organizationService.AssignUserToDepartment(input)
@@ -264,7 +1171,7 @@ func (h *OrganizationHandler) ListUserOrganizations(w http.ResponseWriter, r *ht
 
 	memberships, err := h.organizationService.ListUserOrganizations(&userID)
 	if err != nil {
-		coreErrors.Internal("failed to load memberships").WithInternal(err).WriteHTTP(w)
+		writeInternalError(w, "failed to load memberships", err)
 		return
 	}
 
@@ -280,13 +1187,34 @@ func (h *OrganizationHandler) ListUserDepartments(w http.ResponseWriter, r *http
 
 	memberships, err := h.organizationService.ListUserDepartments(&userID)
 	if err != nil {
-		coreErrors.Internal("failed to load memberships").WithInternal(err).WriteHTTP(w)
+		writeInternalError(w, "failed to load memberships", err)
 		return
 	}
 
 	utils.RespondJSON(w, http.StatusOK, memberships)
 }
 
+// ListAssignableDepartments lists departments in the user's organizations that they can still be assigned to.
+func (h *OrganizationHandler) ListAssignableDepartments(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUint64(mux.Vars(r)["user_id"])
+	if err != nil {
+		coreErrors.BadRequest("invalid user id").WriteHTTP(w)
+		return
+	}
+
+	departments, err := h.organizationService.ListAssignableDepartments(&userID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			coreErrors.NotFound("user").WriteHTTP(w)
+			return
+		}
+		writeInternalError(w, "failed to load assignable departments", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, departments)
+}
+
 func init() {
 	coreServer.RegisterHandler(func(app *coreServer.HTTPApp) error {
 		orgServiceComponent, ok := app.GetComponent(constants.ComponentKey.OrganizationService)
@@ -321,7 +1249,19 @@ func init() {
 			}
 		}
 
-		handler := NewOrganizationHandler(orgService, authService, builder, useAuthorization)
+		authorizationUnavailable := false
+		if flagComponent, ok := app.GetComponent(constants.ComponentKey.AuthorizationUnavailable); ok {
+			if unavailable, ok := flagComponent.(bool); ok {
+				authorizationUnavailable = unavailable
+			}
+		}
+
+		handler := NewOrganizationHandler(orgService, authService, builder, useAuthorization, authorizationUnavailable)
+		if catalogComponent, ok := app.GetComponent(constants.ComponentKey.RouteCatalog); ok {
+			if catalog, ok := catalogComponent.(*RouteCatalog); ok {
+				handler.WithRouteCatalog(catalog)
+			}
+		}
 		handler.RegisterRoutes(app.Router)
 		return nil
 	})
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+const testOrganizationAdminBase = "/v1/organizations/admin"
+
+func TestOrganizationCollectionRoutes(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	token := env.superAdminToken(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"create", http.MethodPost, "/organizations", `{"name":"Acme","domain":"acme.example"}`, http.StatusCreated},
+		{"create with trailing slash", http.MethodPost, "/organizations/", `{"name":"Globex","domain":"globex.example"}`, http.StatusCreated},
+		{"list", http.MethodGet, "/organizations", "", http.StatusOK},
+		{"list with trailing slash", http.MethodGet, "/organizations/", "", http.StatusOK},
+		{"bare admin path", http.MethodPost, "/", `{"name":"Initech"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := env.doAuthenticated(t, token, tt.method, testOrganizationAdminBase+tt.path, tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestMoveDepartmentErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  func(env *handlerEnv, org *models.Organization) (uint64, string)
+		breakDB func(t *testing.T, env *handlerEnv)
+		status  int
+	}{
+		{
+			name: "unknown department",
+			target: func(env *handlerEnv, org *models.Organization) (uint64, string) {
+				return 9999, `{}`
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name: "under its own descendant",
+			target: func(env *handlerEnv, org *models.Organization) (uint64, string) {
+				parent := env.createDepartment(t, org, "Engineering", nil)
+				child := env.createDepartment(t, org, "Platform", parent)
+				return parent.ID, fmt.Sprintf(`{"parent_id":%d}`, child.ID)
+			},
+			status: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "database failure",
+			target: func(env *handlerEnv, org *models.Organization) (uint64, string) {
+				return env.createDepartment(t, org, "Engineering", nil).ID, `{}`
+			},
+			breakDB: dropTable("departments"),
+			status:  http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newHandlerEnv(t, nil, nil)
+			token := env.superAdminToken(t)
+			org := env.createOrganization(t, "Acme", nil)
+			deptID, body := tt.target(env, org)
+			if tt.breakDB != nil {
+				tt.breakDB(t, env)
+			}
+
+			rec := env.doAuthenticated(t, token, http.MethodPost, fmt.Sprintf("%s/departments/%d/move", testOrganizationAdminBase, deptID), body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status == http.StatusInternalServerError && !strings.Contains(rec.Body.String(), "failed to move department") {
+				t.Errorf("body = %s, want the handler's internal error", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestImportOrganizationStructureErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		breakDB func(t *testing.T, env *handlerEnv)
+		status  int
+	}{
+		{name: "valid structure", body: `{"departments":[{"name":"Engineering","children":[{"name":"Platform"}]}]}`, status: http.StatusCreated},
+		{name: "department without a name", body: `{"departments":[{"name":" "}]}`, status: http.StatusUnprocessableEntity},
+		{name: "database failure", body: `{"departments":[{"name":"Engineering"}]}`, breakDB: dropTable("departments"), status: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newHandlerEnv(t, nil, nil)
+			token := env.superAdminToken(t)
+			org := env.createOrganization(t, "Acme", nil)
+			if tt.breakDB != nil {
+				tt.breakDB(t, env)
+			}
+
+			rec := env.doAuthenticated(t, token, http.MethodPost, fmt.Sprintf("%s/organizations/%d/structure/import", testOrganizationAdminBase, org.ID), tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status == http.StatusInternalServerError && !strings.Contains(rec.Body.String(), "failed to import organization structure") {
+				t.Errorf("body = %s, want the handler's internal error", rec.Body.String())
+			}
+		})
+	}
+}
+
+// dropTable simulates a database failure for queries against the table.
+func dropTable(table string) func(t *testing.T, env *handlerEnv) {
+	return func(t *testing.T, env *handlerEnv) {
+		t.Helper()
+		if err := env.db.Migrator().DropTable(table); err != nil {
+			t.Fatalf("drop %s: %v", table, err)
+		}
+	}
+}
+
+func TestGetOrganizationDetail(t *testing.T) {
+	env := newHandlerEnv(t, func(cfg *config.AuthConfig) { cfg.MaxHierarchyDepth = 3 }, nil)
+	token := env.superAdminToken(t)
+	root := env.createOrganization(t, "Holding", nil)
+	child := env.createOrganization(t, "Subsidiary", func(o *models.Organization) { o.ParentID = &root.ID })
+	env.createOrganization(t, "Branch", func(o *models.Organization) { o.ParentID = &child.ID })
+	env.createDepartment(t, root, "Finance", nil)
+	env.addMember(t, env.createUser(t, "member", nil), root, models.OrganizationRoleOrgAdmin)
+
+	type organization struct {
+		Name        string              `json:"name"`
+		Children    []organization      `json:"children"`
+		Departments []models.Department `json:"departments"`
+		Members     []json.RawMessage   `json:"members"`
+	}
+	// levels counts how many levels of children were expanded below org.
+	var levels func(org organization) int
+	levels = func(org organization) int {
+		deepest := 0
+		for _, child := range org.Children {
+			if below := 1 + levels(child); below > deepest {
+				deepest = below
+			}
+		}
+		return deepest
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		status      int
+		levels      int
+		departments bool
+		members     bool
+	}{
+		{name: "defaults", status: http.StatusOK, levels: 1, departments: true},
+		{name: "no children", query: "?depth=0", status: http.StatusOK, levels: 0, departments: true},
+		{name: "two levels", query: "?depth=2", status: http.StatusOK, levels: 2, departments: true},
+		{name: "at the cap", query: "?depth=3", status: http.StatusOK, levels: 2, departments: true},
+		{name: "members only", query: "?include=members", status: http.StatusOK, levels: 1, members: true},
+		{name: "departments and members", query: "?include=departments,members", status: http.StatusOK, levels: 1, departments: true, members: true},
+		{name: "repeated include", query: "?include=members&include=departments", status: http.StatusOK, levels: 1, departments: true, members: true},
+		{name: "empty include", query: "?include=", status: http.StatusOK, levels: 1},
+		{name: "negative depth", query: "?depth=-1", status: http.StatusUnprocessableEntity},
+		{name: "depth above the cap", query: "?depth=4", status: http.StatusUnprocessableEntity},
+		{name: "non-numeric depth", query: "?depth=all", status: http.StatusUnprocessableEntity},
+		{name: "unknown include", query: "?include=roles", status: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := env.doAuthenticated(t, token, http.MethodGet, fmt.Sprintf("%s/organizations/%d%s", testOrganizationAdminBase, root.ID, tt.query), "")
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var body organization
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body.Name != root.Name {
+				t.Errorf("name = %q, want %q", body.Name, root.Name)
+			}
+			if got := levels(body); got != tt.levels {
+				t.Errorf("expanded %d levels of children, want %d", got, tt.levels)
+			}
+			if got := len(body.Departments) > 0; got != tt.departments {
+				t.Errorf("departments included = %v, want %v", got, tt.departments)
+			}
+			if got := len(body.Members) > 0; got != tt.members {
+				t.Errorf("members included = %v, want %v", got, tt.members)
+			}
+		})
+	}
+
+	t.Run("unknown organization", func(t *testing.T) {
+		rec := env.doAuthenticated(t, token, http.MethodGet, testOrganizationAdminBase+"/organizations/9999", "")
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusNotFound, rec.Body.String())
+		}
+	})
+	t.Run("invalid organization id", func(t *testing.T) {
+		rec := env.doAuthenticated(t, token, http.MethodGet, testOrganizationAdminBase+"/organizations/abc", "")
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body.String())
+		}
+	})
+}
+
+func TestUpdateOrganizationTier(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	token := env.superAdminToken(t)
+	org := env.createOrganization(t, "Acme", nil)
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		status int
+		tier   string
+	}{
+		{"update", fmt.Sprintf("/organizations/%d/tier", org.ID), `{"tier":"Enterprise"}`, http.StatusOK, "enterprise"},
+		{"blank tier", fmt.Sprintf("/organizations/%d/tier", org.ID), `{"tier":""}`, http.StatusUnprocessableEntity, ""},
+		{"malformed body", fmt.Sprintf("/organizations/%d/tier", org.ID), `{`, http.StatusBadRequest, ""},
+		{"unknown organization", "/organizations/9999/tier", `{"tier":"pro"}`, http.StatusNotFound, ""},
+		{"invalid id", "/organizations/abc/tier", `{"tier":"pro"}`, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := env.doAuthenticated(t, token, http.MethodPut, testOrganizationAdminBase+tt.target, tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var body models.Organization
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body.Tier != tt.tier {
+				t.Errorf("tier = %q, want %q", body.Tier, tt.tier)
+			}
+		})
+	}
+}
+
+func TestOrganizationContact(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	token := env.superAdminToken(t)
+	org := env.createOrganization(t, "Acme", func(o *models.Organization) {
+		o.ContactEmail = "billing@acme.example"
+		o.Address = "1 Example Street"
+	})
+	member := env.createUser(t, "member", nil)
+	env.addMember(t, member, org, "CEO")
+	memberToken := env.loginToken(t, member, org)
+	target := fmt.Sprintf("%s/organizations/%d/contact", testOrganizationAdminBase, org.ID)
+
+	tests := []struct {
+		name   string
+		token  string
+		target string
+		body   string
+		status int
+		want   models.OrganizationContact
+	}{
+		{"update", token, target, `{"contact_email":" ops@acme.example ","contact_phone":"+84 (24) 1234-5678"}`, http.StatusOK, models.OrganizationContact{ContactEmail: "ops@acme.example", ContactPhone: "+84 (24) 1234-5678", Address: "1 Example Street"}},
+		{"clear address", token, target, `{"address":""}`, http.StatusOK, models.OrganizationContact{ContactEmail: "ops@acme.example", ContactPhone: "+84 (24) 1234-5678"}},
+		{"invalid email", token, target, `{"contact_email":"not-an-email"}`, http.StatusUnprocessableEntity, models.OrganizationContact{}},
+		{"invalid phone", token, target, `{"contact_phone":"call me"}`, http.StatusUnprocessableEntity, models.OrganizationContact{}},
+		{"address too long", token, target, `{"address":"` + strings.Repeat("a", 1025) + `"}`, http.StatusUnprocessableEntity, models.OrganizationContact{}},
+		{"malformed body", token, target, `{`, http.StatusBadRequest, models.OrganizationContact{}},
+		{"unknown organization", token, testOrganizationAdminBase + "/organizations/9999/contact", `{"address":""}`, http.StatusNotFound, models.OrganizationContact{}},
+		{"without permission", memberToken, target, `{"address":""}`, http.StatusForbidden, models.OrganizationContact{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := env.doAuthenticated(t, tt.token, http.MethodPatch, tt.target, tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			rec = env.doAuthenticated(t, token, http.MethodGet, target, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("get: status = %d: %s", rec.Code, rec.Body.String())
+			}
+			var got models.OrganizationContact
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			want := tt.want
+			want.OrganizationID = org.ID
+			if got != want {
+				t.Errorf("contact = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/service"
+	coreErrors "github.com/lee-tech/core/errors"
+	"github.com/lee-tech/core/utils"
+)
+
+// SwitchOrganization reissues the caller's access token for another of their organizations.
+func (h *AuthenticationHandler) SwitchOrganization(w http.ResponseWriter, r *http.Request) {
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var payload models.SwitchOrganizationRequest
+	if err := utils.DecodeJSON(r.Body, &payload); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+
+	response, err := h.authenticationService.SwitchOrganization(userID, &payload)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotOrganizationMember):
+			coreErrors.Forbidden("You are not a member of this organization").WriteHTTP(w)
+		case errors.Is(err, service.ErrNotDepartmentMember):
+			coreErrors.Forbidden("You are not a member of this department in the organization").WriteHTTP(w)
+		default:
+			writeLoginError(w, r, err, "Invalid token")
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	coreErrors "github.com/lee-tech/core/errors"
+)
+
+// passwordChangeRoutes are the routes a user who must replace a temporary password can still reach,
+// with the method allowed on each.
+var passwordChangeRoutes = map[string]string{
+	"/v1/auth/me":              http.MethodGet,
+	"/v1/auth/change-password": http.MethodPost,
+	"/v1/auth/logout":          http.MethodPost,
+}
+
+// requirePasswordChanged rejects requests made with tokens issued while the user still has to replace a
+// temporary password, such as one handed out by the user import, except on passwordChangeRoutes.
+func requirePasswordChanged() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if requestAPIKey(r) != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if route := mux.CurrentRoute(r); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					if method, ok := passwordChangeRoutes[template]; ok && method == r.Method {
+						next.ServeHTTP(w, r)
+						return
+					}
+				}
+			}
+			if details := requestTokenDetails(r); details != nil && details.MustChangePassword {
+				coreErrors.Forbidden("password change required").WriteHTTP(w)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/service"
+	coreErrors "github.com/lee-tech/core/errors"
+	"github.com/lee-tech/core/utils"
+)
+
+// ValidatePassword reports whether a candidate password meets the local password policy.
+func (h *AuthenticationHandler) ValidatePassword(w http.ResponseWriter, r *http.Request) {
+	var req models.PasswordValidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+
+	response := models.PasswordValidateResponse{Valid: true}
+	if err := h.authenticationService.ValidatePassword(req.Password); err != nil {
+		response = models.PasswordValidateResponse{Valid: false, Message: err.Error()}
+	}
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+// RequestPasswordReset sends a reset token to the account owning the email. The response is the same
+// whether or not such an account exists.
+func (h *AuthenticationHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req models.PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+	if !utils.IsEmail(strings.TrimSpace(req.Email)) {
+		coreErrors.ValidationError("A valid email is required").WriteHTTP(w)
+		return
+	}
+
+	if err := h.authenticationService.RequestPasswordReset(req.Email); err != nil {
+		writeInternalError(w, "failed to request password reset", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusAccepted, map[string]string{
+		"message": "If an account exists for this email, a password reset link has been sent",
+	})
+}
+
+// ConfirmPasswordReset sets a new password using a reset token.
+func (h *AuthenticationHandler) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req models.PasswordResetConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+	if strings.TrimSpace(req.Token) == "" || req.NewPassword == "" {
+		coreErrors.ValidationError("Token and new password are required").WriteHTTP(w)
+		return
+	}
+
+	if err := h.authenticationService.ConfirmPasswordReset(req.Token, req.NewPassword); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidResetToken):
+			coreErrors.BadRequest(err.Error()).WriteHTTP(w)
+		case errors.Is(err, service.ErrPasswordTooShort), errors.Is(err, service.ErrPasswordTooWeak),
+			errors.Is(err, service.ErrPasswordComplexity), errors.Is(err, service.ErrPasswordCommon),
+			errors.Is(err, service.ErrPasswordReused),
+			errors.Is(err, service.ErrPasswordBreached):
+			coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		case errors.Is(err, service.ErrBreachCheckUnavailable):
+			utils.RespondJSON(w, http.StatusServiceUnavailable, map[string]string{
+				"error":   "Service Unavailable",
+				"message": "password could not be checked, try again later",
+			})
+		default:
+			writeInternalError(w, "failed to reset password", err)
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]bool{
+		"password_reset": true,
+	})
+}
+
+// ChangePassword replaces the caller's password after re-verifying the current one.
+func (h *AuthenticationHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		coreErrors.ValidationError("Current and new password are required").WriteHTTP(w)
+		return
+	}
+
+	if err := h.authenticationService.ChangePassword(userID, &req); err != nil {
+		switch {
+		case errors.Is(err, service.ErrCurrentPasswordIncorrect):
+			coreErrors.BadRequest(err.Error()).WriteHTTP(w)
+		case errors.Is(err, service.ErrPasswordTooShort), errors.Is(err, service.ErrPasswordTooWeak),
+			errors.Is(err, service.ErrPasswordComplexity), errors.Is(err, service.ErrPasswordCommon),
+			errors.Is(err, service.ErrPasswordReused),
+			errors.Is(err, service.ErrPasswordUnchanged), errors.Is(err, service.ErrPasswordBreached):
+			coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		case errors.Is(err, service.ErrBreachCheckUnavailable):
+			utils.RespondJSON(w, http.StatusServiceUnavailable, map[string]string{
+				"error":   "Service Unavailable",
+				"message": "password could not be checked, try again later",
+			})
+		case errors.Is(err, service.ErrUserNotFound):
+			coreErrors.Unauthorized("user no longer exists").WriteHTTP(w)
+		default:
+			writeInternalError(w, "failed to change password", err)
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]bool{
+		"password_changed": true,
+		"sessions_revoked": req.ShouldRevokeSessions(),
+	})
+}
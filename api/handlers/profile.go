@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/service"
+	coreErrors "github.com/lee-tech/core/errors"
+	"github.com/lee-tech/core/utils"
+)
+
+// UpdateMe lets the signed-in user change their own first and last name. Requests touching any other
+// field are refused with 403 instead of being partially applied.
+func (h *AuthenticationHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+	var protected []string
+	for field := range fields {
+		if !slices.Contains(models.ProfileEditableFields, field) {
+			protected = append(protected, field)
+		}
+	}
+	if len(protected) > 0 {
+		sort.Strings(protected)
+		coreErrors.Forbidden(fmt.Sprintf("fields cannot be changed through this endpoint: %s", strings.Join(protected, ", "))).WriteHTTP(w)
+		return
+	}
+
+	var req models.UpdateProfileRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		coreErrors.ValidationError("first_name and last_name must be strings").WriteHTTP(w)
+		return
+	}
+
+	userInfo, err := h.authenticationService.UpdateProfile(userID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidProfile):
+			coreErrors.ValidationError(err.Error()).WriteHTTP(w)
+		case errors.Is(err, service.ErrUserNotFound):
+			coreErrors.Unauthorized("user no longer exists").WriteHTTP(w)
+		default:
+			writeInternalError(w, "failed to update profile", err)
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, userInfo)
+}
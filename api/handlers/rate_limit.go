@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/ratelimit"
+	"github.com/lee-tech/core/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+// maxLoginBodyBytes bounds how much of a login body is buffered to read the username.
+const maxLoginBodyBytes = 64 << 10
+
+var trustProxyHeaders bool
+
+// SetTrustProxyHeaders controls whether X-Forwarded-For/X-Real-IP are used to identify clients.
+// Only enable it behind a proxy that overwrites these headers.
+func SetTrustProxyHeaders(trust bool) {
+	trustProxyHeaders = trust
+}
+
+// rateLimitPrefix namespaces rate-limit buckets in a shared Redis. Keys carry the endpoint after it; see
+// endpointKey.
+const rateLimitPrefix = "authentication:ratelimit:"
+
+// newLoginRateLimiter builds the login limiter for the configured store, or nil when limiting is disabled.
+func newLoginRateLimiter(cfg *config.AuthConfig) ratelimit.Limiter {
+	if cfg.LoginRateLimit <= 0 || cfg.LoginRateWindow <= 0 {
+		return nil
+	}
+	if cfg.LoginRateLimitStore == config.RateLimitStoreRedis {
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		})
+		if limiter := ratelimit.NewRedisLimiter(client, rateLimitPrefix, cfg.LoginRateLimit, cfg.LoginRateWindow); limiter != nil {
+			return limiter
+		}
+		return nil
+	}
+	if limiter := ratelimit.NewMemoryLimiter(cfg.LoginRateLimit, cfg.LoginRateWindow); limiter != nil {
+		return limiter
+	}
+	return nil
+}
+
+// rateLimited wraps next with limiter. Rate-limit headers reflect the caller's bucket on every response
+// and requests over the limit are rejected with 429. Requests for which key returns "" are not counted.
+func rateLimited(limiter ratelimit.Limiter, key func(*http.Request) string, next http.HandlerFunc) http.HandlerFunc {
+	if limiter == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		k := key(r)
+		if k == "" {
+			next(w, r)
+			return
+		}
+		result := limiter.Allow(k)
+		writeRateLimitHeaders(w, result)
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			utils.RespondJSON(w, http.StatusTooManyRequests, map[string]string{
+				"error":   "Too Many Requests",
+				"message": "rate limit exceeded, retry later",
+			})
+			return
+		}
+		next(w, r)
+	}
+}
+
+func writeRateLimitHeaders(w http.ResponseWriter, result ratelimit.Result) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+}
+
+// endpointKey namespaces the keys returned by key under endpoint, so endpoints sharing a limiter count
+// each caller in separate buckets.
+func endpointKey(endpoint string, key func(*http.Request) string) func(*http.Request) string {
+	return func(r *http.Request) string {
+		k := key(r)
+		if k == "" {
+			return ""
+		}
+		return endpoint + ":" + k
+	}
+}
+
+// clientIP returns the caller's address, honouring proxy headers only when trusted.
+func clientIP(r *http.Request) string {
+	if trustProxyHeaders {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			if ip := strings.TrimSpace(first); ip != "" {
+				return ip
+			}
+		}
+		if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+			return realIP
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// loginUsernameKey keys a login request by the submitted username so attempts against one account
+// are counted across client IPs. The body is restored for the handler.
+func loginUsernameKey(r *http.Request) string {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxLoginBodyBytes))
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+	var req struct {
+		Username string `json:"username"`
+	}
+	if json.Unmarshal(body, &req) != nil {
+		return ""
+	}
+	username := strings.ToLower(strings.TrimSpace(req.Username))
+	if username == "" {
+		return ""
+	}
+	return "username:" + username
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
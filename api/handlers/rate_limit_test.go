@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lee-tech/authentication/internal/ratelimit"
+)
+
+// limitedRouter registers the authentication routes of env on a new router with limiter as the login
+// rate limiter.
+func limitedRouter(env *handlerEnv, limiter ratelimit.Limiter, byUsername bool) *mux.Router {
+	router := mux.NewRouter()
+	NewAuthenticationHandler(env.auth, false, false, nil).WithLoginRateLimiter(limiter, byUsername).RegisterRoutes(router)
+	return router
+}
+
+// serve sends a JSON request from remoteAddr through router and returns the recorded response.
+func serve(router *mux.Router, method, target, remoteAddr, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPasswordValidationDoesNotSpendLoginBudget(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	org := env.createOrganization(t, "Acme", nil)
+	user := env.createUser(t, "typist", nil)
+	env.addMember(t, user, org, "CEO")
+	router := limitedRouter(env, ratelimit.NewMemoryLimiter(2, time.Minute), false)
+
+	const addr = "203.0.113.7:4000"
+	for i := 0; i < 2; i++ {
+		if rec := serve(router, http.MethodPost, "/v1/password/validate", addr, `{"password":"abc"}`); rec.Code != http.StatusOK {
+			t.Fatalf("validate %d: status = %d, want %d", i+1, rec.Code, http.StatusOK)
+		}
+	}
+	if rec := serve(router, http.MethodPost, "/v1/password/validate", addr, `{"password":"abc"}`); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("validate over the limit: status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+
+	login := fmt.Sprintf(`{"username":%q,"password":%q}`, user.Username, testPassword)
+	if rec := serve(router, http.MethodPost, "/v1/login", addr, login); rec.Code != http.StatusOK {
+		t.Fatalf("login after validating: status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+}
+
+// recordingLimiter allows every request and records the keys it was asked about.
+type recordingLimiter struct {
+	keys []string
+}
+
+func (l *recordingLimiter) Allow(key string) ratelimit.Result {
+	l.keys = append(l.keys, key)
+	return ratelimit.Result{Allowed: true, Limit: 1, Remaining: 1}
+}
+
+func TestRateLimitKeysArePerEndpoint(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	limiter := &recordingLimiter{}
+	router := limitedRouter(env, limiter, true)
+
+	const addr = "203.0.113.7:4000"
+	serve(router, http.MethodPost, "/v1/login", addr, `{"username":" Ada ","password":"wrong-password"}`)
+	serve(router, http.MethodPost, "/v1/password/reset-request", addr, `{"email":"ada@example.com"}`)
+
+	want := []string{"login:203.0.113.7", "login:username:ada", "password-reset-request:203.0.113.7"}
+	if !slices.Equal(limiter.keys, want) {
+		t.Fatalf("limiter keys = %q, want %q", limiter.keys, want)
+	}
+}
+
+func TestLoginRateLimitByUsername(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	user := env.createUser(t, "target", nil)
+	router := limitedRouter(env, ratelimit.NewMemoryLimiter(2, time.Minute), true)
+
+	login := fmt.Sprintf(`{"username":%q,"password":"wrong-password"}`, user.Username)
+	for i, addr := range []string{"198.51.100.1:4000", "198.51.100.2:4000"} {
+		if rec := serve(router, http.MethodPost, "/v1/login", addr, login); rec.Code == http.StatusTooManyRequests {
+			t.Fatalf("attempt %d: rate limited too early", i+1)
+		}
+	}
+	if rec := serve(router, http.MethodPost, "/v1/login", "198.51.100.3:4000", login); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("third attempt from a new IP: status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+
+	other := `{"username":"someone-else","password":"wrong-password"}`
+	if rec := serve(router, http.MethodPost, "/v1/login", "198.51.100.3:4000", other); rec.Code == http.StatusTooManyRequests {
+		t.Fatal("another username from the same IP was rate limited")
+	}
+}
+
+func TestLoginRateLimitHeaders(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	router := limitedRouter(env, ratelimit.NewMemoryLimiter(2, time.Minute), false)
+
+	const addr = "203.0.113.7:4000"
+	login := `{"username":"nobody","password":"wrong-password"}`
+	for i, remaining := range []string{"1", "0"} {
+		rec := serve(router, http.MethodPost, "/v1/login", addr, login)
+		if rec.Code == http.StatusTooManyRequests {
+			t.Fatalf("attempt %d: rate limited too early", i+1)
+		}
+		if got := rec.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("attempt %d: X-RateLimit-Limit = %q, want 2", i+1, got)
+		}
+		if got := rec.Header().Get("X-RateLimit-Remaining"); got != remaining {
+			t.Errorf("attempt %d: X-RateLimit-Remaining = %q, want %s", i+1, got, remaining)
+		}
+		if rec.Header().Get("X-RateLimit-Reset") == "" {
+			t.Errorf("attempt %d: X-RateLimit-Reset missing", i+1)
+		}
+		if got := rec.Header().Get("Retry-After"); got != "" {
+			t.Errorf("attempt %d: Retry-After = %q, want none before the limit", i+1, got)
+		}
+	}
+
+	rec := serve(router, http.MethodPost, "/v1/login", addr, login)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("attempt over the limit: status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if got := rec.Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("X-RateLimit-Remaining = %q, want 0", got)
+	}
+	if retry, err := strconv.Atoi(rec.Header().Get("Retry-After")); err != nil || retry <= 0 {
+		t.Errorf("Retry-After = %q, want a positive number of seconds", rec.Header().Get("Retry-After"))
+	}
+}
+
+func TestLoginRateLimitRetryAfter(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	router := limitedRouter(env, ratelimit.NewMemoryLimiter(1, time.Minute), false)
+
+	const addr = "203.0.113.7:4000"
+	login := `{"username":"nobody","password":"wrong-password"}`
+	if rec := serve(router, http.MethodPost, "/v1/login", addr, login); rec.Code == http.StatusTooManyRequests {
+		t.Fatal("first attempt: rate limited too early")
+	}
+	rec := serve(router, http.MethodPost, "/v1/login", addr, login)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("second attempt: status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if retry, err := strconv.Atoi(rec.Header().Get("Retry-After")); err != nil || retry <= 0 || retry > 60 {
+		t.Errorf("Retry-After = %q, want seconds within the window", rec.Header().Get("Retry-After"))
+	}
+}
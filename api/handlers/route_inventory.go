@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	coreErrors "github.com/lee-tech/core/errors"
+	coreServer "github.com/lee-tech/core/server"
+	"github.com/lee-tech/core/utils"
+)
+
+// RouteInfo describes a registered route in the route inventory.
+type RouteInfo struct {
+	Method       string   `json:"method"`
+	Path         string   `json:"path"`
+	Name         string   `json:"name,omitempty"`
+	Summary      string   `json:"summary,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	RequiresAuth bool     `json:"requires_auth"`
+	Admin        bool     `json:"admin"`
+}
+
+// routeDoc is the summary and tags a route is documented with, both in the OpenAPI document and in
+// the route inventory.
+type routeDoc struct {
+	Summary string
+	Tags    []string
+}
+
+// RouteCatalog records the documentation of registered routes and which subrouters are guarded by
+// the auth middleware. Handlers that register on the same router share one catalog. Routes are
+// registered before the server starts, so the catalog is not synchronised.
+type RouteCatalog struct {
+	docs          map[*mux.Route]routeDoc
+	authenticated map[*mux.Route]struct{}
+}
+
+// NewRouteCatalog returns an empty route catalog.
+func NewRouteCatalog() *RouteCatalog {
+	return &RouteCatalog{
+		docs:          map[*mux.Route]routeDoc{},
+		authenticated: map[*mux.Route]struct{}{},
+	}
+}
+
+// route registers a route through coreServer.Route with the summary and tags of doc and records doc
+// for the route inventory.
+func (c *RouteCatalog) route(router *mux.Router, path string, handler http.HandlerFunc, doc routeDoc, opts ...coreServer.RouteOption) {
+	opts = append([]coreServer.RouteOption{coreServer.WithSummary(doc.Summary), coreServer.WithTags(doc.Tags...)}, opts...)
+	coreServer.Route(router, path, handler, opts...)
+	if route := lastRoute(router); route != nil {
+		c.docs[route] = doc
+	}
+}
+
+// subrouter returns the subrouter for prefix and records that its routes require authentication.
+func (c *RouteCatalog) subrouter(router *mux.Router, prefix string) *mux.Router {
+	route := router.PathPrefix(prefix)
+	c.authenticated[route] = struct{}{}
+	return route.Subrouter()
+}
+
+// lastRoute returns the route most recently added directly to router.
+func lastRoute(router *mux.Router) *mux.Route {
+	var last *mux.Route
+	_ = router.Walk(func(route *mux.Route, parent *mux.Router, _ []*mux.Route) error {
+		if parent == router {
+			last = route
+		}
+		return nil
+	})
+	return last
+}
+
+// ListRoutes returns the method, path template, summary and tags of every registered route together
+// with whether it requires authentication.
+func (h *AuthenticationHandler) ListRoutes(w http.ResponseWriter, r *http.Request) {
+	if !hasPermission(r, "auth.authorization.read") {
+		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
+		return
+	}
+	if h.router == nil {
+		writeInternalError(w, "route table is not available", nil)
+		return
+	}
+
+	routes, err := h.routes.inventory(h.router)
+	if err != nil {
+		writeInternalError(w, "failed to enumerate routes", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]any{
+		"routes": routes,
+	})
+}
+
+// inventory walks the router and lists each method of every route that has a handler.
+func (c *RouteCatalog) inventory(router *mux.Router) ([]RouteInfo, error) {
+	routes := make([]RouteInfo, 0)
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, ancestors []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			// Routes without a method matcher accept any method
+			methods = []string{"*"}
+		}
+
+		requiresAuth := false
+		for _, ancestor := range ancestors {
+			if _, ok := c.authenticated[ancestor]; ok {
+				requiresAuth = true
+				break
+			}
+		}
+
+		doc := c.docs[route]
+		for _, method := range methods {
+			routes = append(routes, RouteInfo{
+				Method:       method,
+				Path:         template,
+				Name:         route.GetName(),
+				Summary:      doc.Summary,
+				Tags:         doc.Tags,
+				RequiresAuth: requiresAuth,
+				Admin:        isAdminRoute(template),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes, nil
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestRouteInventory(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	handler := NewAuthenticationHandler(env.auth, false, false, nil)
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	routes, err := handler.routes.inventory(router)
+	if err != nil {
+		t.Fatalf("inventory: %v", err)
+	}
+
+	tests := []struct {
+		method       string
+		path         string
+		summary      string
+		tag          string
+		requiresAuth bool
+		admin        bool
+	}{
+		{http.MethodGet, "/v1/health", "Authentication health", "Authentication", false, false},
+		{http.MethodPost, "/v1/login", "", "", false, false},
+		{http.MethodGet, "/v1/auth/me", "", "", true, false},
+		{http.MethodGet, "/v1/auth/admin/users", "List users (admin)", "Administration", true, true},
+		{http.MethodGet, "/v1/auth/admin/routes", "List routes (admin)", "Administration", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			idx := slices.IndexFunc(routes, func(route RouteInfo) bool {
+				return route.Method == tt.method && route.Path == tt.path
+			})
+			if idx < 0 {
+				t.Fatalf("route %s %s not listed", tt.method, tt.path)
+			}
+			route := routes[idx]
+			if tt.summary != "" && route.Summary != tt.summary {
+				t.Errorf("summary = %q, want %q", route.Summary, tt.summary)
+			}
+			if tt.tag != "" && !slices.Contains(route.Tags, tt.tag) {
+				t.Errorf("tags = %v, want %q", route.Tags, tt.tag)
+			}
+			if route.Summary == "" {
+				t.Errorf("route has no summary")
+			}
+			if route.RequiresAuth != tt.requiresAuth {
+				t.Errorf("requires_auth = %v, want %v", route.RequiresAuth, tt.requiresAuth)
+			}
+			if route.Admin != tt.admin {
+				t.Errorf("admin = %v, want %v", route.Admin, tt.admin)
+			}
+		})
+	}
+
+	if slices.ContainsFunc(routes, func(route RouteInfo) bool { return route.Path == "/v1/meta/routes" }) {
+		t.Errorf("anonymous /v1/meta/routes is still registered")
+	}
+}
+
+func TestRouteCatalogSharedAcrossHandlers(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	catalog := NewRouteCatalog()
+	router := mux.NewRouter()
+	NewAuthenticationHandler(env.auth, false, false, nil).WithRouteCatalog(catalog).RegisterRoutes(router)
+
+	routes, err := catalog.inventory(router)
+	if err != nil {
+		t.Fatalf("inventory: %v", err)
+	}
+	if !slices.ContainsFunc(routes, func(route RouteInfo) bool { return route.Path == "/v1/health" && route.Summary != "" }) {
+		t.Errorf("shared catalog is missing the health route summary")
+	}
+}
+
+func TestListRoutesPermission(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	handler := NewAuthenticationHandler(env.auth, false, false, nil)
+	handler.RegisterRoutes(mux.NewRouter())
+
+	tests := []struct {
+		name    string
+		details *models.TokenDetails
+		status  int
+	}{
+		{"no permission", &models.TokenDetails{UserID: 1}, http.StatusForbidden},
+		{"authorization read", &models.TokenDetails{UserID: 1, Permissions: []string{"auth.authorization.read"}}, http.StatusOK},
+		{"super admin", &models.TokenDetails{UserID: 1, IsSuperAdmin: true}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/auth/admin/routes", nil)
+			req = req.WithContext(context.WithValue(req.Context(), tokenDetailsContextKey{}, tt.details))
+			rec := httptest.NewRecorder()
+			handler.ListRoutes(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var body struct {
+				Routes []RouteInfo `json:"routes"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(body.Routes) == 0 {
+				t.Errorf("no routes listed")
+			}
+		})
+	}
+}
+
+func TestAnonymousRouteInventoryRemoved(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	if rec := env.do(t, http.MethodGet, "/v1/meta/routes", ""); rec.Code == http.StatusOK {
+		t.Fatalf("anonymous route inventory answered %d", rec.Code)
+	}
+}
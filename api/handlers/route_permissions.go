@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	coreErrors "github.com/lee-tech/core/errors"
+	coreMiddleware "github.com/lee-tech/core/middleware"
+	"github.com/lee-tech/core/utils"
+)
+
+// RoutePermission describes the authorization request generated for an admin route.
+type RoutePermission struct {
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+}
+
+// ListRoutePermissions enumerates the registered admin routes with the action and resource the
+// authorization builder derives for each, so operators can configure their authorization policy.
+func (h *AuthenticationHandler) ListRoutePermissions(w http.ResponseWriter, r *http.Request) {
+	if !hasPermission(r, "auth.authorization.read") {
+		coreErrors.Forbidden("insufficient permissions").WriteHTTP(w)
+		return
+	}
+	if h.router == nil {
+		writeInternalError(w, "route table is not available", nil)
+		return
+	}
+
+	permissions, err := adminRoutePermissions(h.router, h.authorizationBuilder)
+	if err != nil {
+		writeInternalError(w, "failed to enumerate admin routes", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]any{
+		"enforced": h.useAuthorization,
+		"routes":   permissions,
+	})
+}
+
+// adminRoutePermissions walks the router and runs the builder against every admin route, with the route
+// attached so per-route overrides keyed by route name or template apply as they do at request time.
+func adminRoutePermissions(router *mux.Router, builder coreMiddleware.AuthorizationRequestBuilder) ([]RoutePermission, error) {
+	permissions := make([]RoutePermission, 0)
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil || !isAdminRoute(template) {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		for _, method := range methods {
+			synthesized := withAuthorizationRoute(&http.Request{Method: method, URL: &url.URL{Path: template}, Header: http.Header{}}, route)
+			req, err := builder(synthesized, nil)
+			if err != nil {
+				return err
+			}
+			if req == nil {
+				continue
+			}
+			permissions = append(permissions, RoutePermission{
+				Method:   method,
+				Path:     template,
+				Action:   req.Action,
+				Resource: req.Resource.Type,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(permissions, func(i, j int) bool {
+		if permissions[i].Path != permissions[j].Path {
+			return permissions[i].Path < permissions[j].Path
+		}
+		return permissions[i].Method < permissions[j].Method
+	})
+	return permissions, nil
+}
+
+func isAdminRoute(template string) bool {
+	for _, segment := range strings.Split(template, "/") {
+		if segment == "admin" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type scopeContextKey struct{}
+
+// attachScopes records the scopes the request's credential is restricted to: the scopes claim of an
+// access token or the scopes of an API key. Credentials without scopes are not restricted.
+func attachScopes() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var scopes []string
+			if key := requestAPIKey(r); key != nil {
+				scopes = key.Scopes
+			} else if details := requestTokenDetails(r); details != nil {
+				scopes = details.Scopes
+			}
+			if len(scopes) > 0 {
+				r = r.WithContext(context.WithValue(r.Context(), scopeContextKey{}, scopes))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// hasPermission reports whether the caller holds permission and, when their credential is restricted to
+// scopes, whether one of them grants it. Access tokens and API keys hold a permission when they belong
+// to a super admin or list it in their permissions; the scopes of the owner's role apply to an API key on
+// top of the key's own.
+func hasPermission(r *http.Request, permission string) bool {
+	details := requestTokenDetails(r)
+	if details == nil {
+		return false
+	}
+	if !details.IsSuperAdmin && !slices.Contains(details.Permissions, permission) {
+		return false
+	}
+	if len(details.Scopes) > 0 && !scopeGrants(details.Scopes, permission) {
+		return false
+	}
+	scopes, restricted := r.Context().Value(scopeContextKey{}).([]string)
+	return !restricted || scopeGrants(scopes, permission)
+}
+
+// scopeGrants reports whether one of the scopes names permission exactly, or covers it with a trailing
+// wildcard such as "auth.users.*". A lone "*" grants everything.
+func scopeGrants(scopes []string, permission string) bool {
+	if slices.Contains(scopes, permission) {
+		return true
+	}
+	for _, scope := range scopes {
+		if prefix, ok := strings.CutSuffix(scope, "*"); ok && strings.HasPrefix(permission, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/service"
+	coreErrors "github.com/lee-tech/core/errors"
+	coreMiddleware "github.com/lee-tech/core/middleware"
+	"github.com/lee-tech/core/utils"
+)
+
+// StepUpTokenHeader carries the step-up token on requests to sensitive endpoints.
+const StepUpTokenHeader = "X-Step-Up-Token"
+
+// MFAChallenge verifies a TOTP or recovery code and issues a short-lived step-up token.
+func (h *AuthenticationHandler) MFAChallenge(w http.ResponseWriter, r *http.Request) {
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.MFAChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+	if strings.TrimSpace(req.Code) == "" {
+		coreErrors.ValidationError("code is required").WriteHTTP(w)
+		return
+	}
+
+	response, err := h.authenticationService.ChallengeMFA(userID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMFANotEnabled):
+			coreErrors.BadRequest("MFA is not enabled for this account").WriteHTTP(w)
+		case errors.Is(err, service.ErrInvalidMFACode):
+			coreErrors.Unauthorized("Invalid MFA code").WriteHTTP(w)
+		case errors.Is(err, service.ErrAccountLocked):
+			coreErrors.Forbidden("Account is locked due to too many failed attempts").WriteHTTP(w)
+		case errors.Is(err, service.ErrAccountInactive), errors.Is(err, service.ErrInvalidToken):
+			coreErrors.Unauthorized("user is not allowed to authenticate").WriteHTTP(w)
+		default:
+			writeInternalError(w, "Failed to verify MFA code", err)
+		}
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, response)
+}
+
+// MFAEnroll starts TOTP enrollment for the authenticated user.
+func (h *AuthenticationHandler) MFAEnroll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.authenticationService.EnrollMFA(userID)
+	if err != nil {
+		writeMFAError(w, "Failed to start MFA enrollment", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, enrollment)
+}
+
+// MFAVerify completes TOTP enrollment by checking a code from the pending secret.
+func (h *AuthenticationHandler) MFAVerify(w http.ResponseWriter, r *http.Request) {
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.MFAChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+	if strings.TrimSpace(req.Code) == "" {
+		coreErrors.ValidationError("code is required").WriteHTTP(w)
+		return
+	}
+
+	if err := h.authenticationService.VerifyMFA(userID, req.Code); err != nil {
+		writeMFAError(w, "Failed to verify MFA code", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]bool{
+		"mfa_enabled": true,
+	})
+}
+
+func writeMFAError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, service.ErrMFADisabled):
+		coreErrors.Forbidden("MFA is disabled").WriteHTTP(w)
+	case errors.Is(err, service.ErrMFAAlreadyEnabled):
+		coreErrors.Conflict("MFA is already enabled for this account").WriteHTTP(w)
+	case errors.Is(err, service.ErrMFANotEnrolled):
+		coreErrors.BadRequest("MFA enrollment has not been started").WriteHTTP(w)
+	case errors.Is(err, service.ErrInvalidMFACode):
+		coreErrors.Unauthorized("Invalid MFA code").WriteHTTP(w)
+	case errors.Is(err, service.ErrAccountLocked):
+		coreErrors.Forbidden("Account is locked due to too many failed attempts").WriteHTTP(w)
+	case errors.Is(err, service.ErrAccountInactive), errors.Is(err, service.ErrInvalidToken):
+		coreErrors.Unauthorized("user is not allowed to authenticate").WriteHTTP(w)
+	default:
+		writeInternalError(w, message, err)
+	}
+}
+
+// MFAStatus reports whether MFA is enabled, how many recovery codes remain and whether the step-up
+// token sent in the X-Step-Up-Token header is still in effect.
+func (h *AuthenticationHandler) MFAStatus(w http.ResponseWriter, r *http.Request) {
+	userID, ok := contextUserID(w, r)
+	if !ok {
+		return
+	}
+
+	status, err := h.authenticationService.MFAStatus(userID, r.Header.Get(StepUpTokenHeader))
+	if err != nil {
+		writeMFAError(w, "failed to load mfa status", err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, status)
+}
+
+// RequireRecentMFA guards sensitive endpoints so users with MFA enabled need a step-up token issued to
+// them. Users without MFA cannot obtain one and are let through. It must run after the authentication
+// middleware.
+func RequireRecentMFA(authService *service.AuthenticationService) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := contextUserID(w, r)
+			if !ok {
+				return
+			}
+			required, err := authService.StepUpRequired(userID)
+			if err != nil {
+				writeInternalError(w, "failed to check mfa state", err)
+				return
+			}
+			if !required {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token := strings.TrimSpace(r.Header.Get(StepUpTokenHeader))
+			if token == "" {
+				coreErrors.Unauthorized("step-up authentication required").WriteHTTP(w)
+				return
+			}
+			if err := authService.ValidateStepUpToken(token, userID); err != nil {
+				coreErrors.Unauthorized("invalid or expired step-up token").WriteHTTP(w)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// stepUp wraps a sensitive handler in RequireRecentMFA.
+func (h *AuthenticationHandler) stepUp(next http.HandlerFunc) http.HandlerFunc {
+	return RequireRecentMFA(h.authenticationService)(next).ServeHTTP
+}
+
+// contextUserID extracts the authenticated user ID, writing a 401 when it is missing.
+func contextUserID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	userIDStr, ok := r.Context().Value(coreMiddleware.UserIDKey).(string)
+	if !ok || userIDStr == "" {
+		coreErrors.Unauthorized("user context missing").WriteHTTP(w)
+		return 0, false
+	}
+
+	userID, err := utils.ParseUint64(userIDStr)
+	if err != nil {
+		coreErrors.Unauthorized("invalid user identifier").WriteHTTP(w)
+		return 0, false
+	}
+	return userID, true
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestMFAChallengeEndpoint(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	org := env.createOrganization(t, "Acme", nil)
+	user := env.createUser(t, "stepper", nil)
+	env.addMember(t, user, org, "CEO")
+	plain := env.createUser(t, "plain", nil)
+	env.addMember(t, plain, org, "CEO")
+	plainToken := env.loginToken(t, plain, org)
+	secret, recovery := env.enableMFA(t, user)
+	login, err := env.auth.Login(&models.LoginRequest{Username: user.Username, Password: testPassword, OrganizationID: org.ID, MFACode: recovery[1]})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		body   string
+		status int
+	}{
+		{"totp code", login.AccessToken, `{"code":"` + totp(t, secret) + `"}`, http.StatusOK},
+		{"recovery code", login.AccessToken, `{"code":"` + recovery[0] + `"}`, http.StatusOK},
+		{"wrong code", login.AccessToken, `{"code":"000000"}`, http.StatusUnauthorized},
+		{"missing code", login.AccessToken, `{}`, http.StatusUnprocessableEntity},
+		{"malformed body", login.AccessToken, `{`, http.StatusBadRequest},
+		{"mfa not enabled", plainToken, `{"code":"123456"}`, http.StatusBadRequest},
+		{"anonymous", "", `{"code":"123456"}`, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := env.doAuthenticated(t, tt.token, http.MethodPost, "/v1/auth/mfa/challenge", tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if cache := rec.Header().Get("Cache-Control"); !strings.Contains(cache, "no-store") {
+				t.Errorf("Cache-Control = %q, want no-store", cache)
+			}
+			var body models.StepUpTokenResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body.StepUpToken == "" || body.ExpiresIn <= 0 {
+				t.Errorf("response = %+v, want a step-up token", body)
+			}
+		})
+	}
+}
+
+func TestRequireRecentMFA(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	org := env.createOrganization(t, "Acme", nil)
+	user := env.createUser(t, "stepper", nil)
+	env.addMember(t, user, org, "CEO")
+	other := env.createUser(t, "other", nil)
+	env.addMember(t, other, org, "CEO")
+	plain := env.createUser(t, "plain", nil)
+	env.addMember(t, plain, org, "CEO")
+
+	secret, _ := env.enableMFA(t, user)
+	login, err := env.auth.Login(&models.LoginRequest{Username: user.Username, Password: testPassword, OrganizationID: org.ID, MFACode: totp(t, secret)})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	stepUp, err := env.auth.ChallengeMFA(user.ID, totp(t, secret))
+	if err != nil {
+		t.Fatalf("challenge: %v", err)
+	}
+	otherSecret, _ := env.enableMFA(t, other)
+	otherStepUp, err := env.auth.ChallengeMFA(other.ID, totp(t, otherSecret))
+	if err != nil {
+		t.Fatalf("challenge other: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		stepUp string
+		status int
+	}{
+		{"mfa user without step-up", login.AccessToken, "", http.StatusUnauthorized},
+		{"mfa user with step-up", login.AccessToken, stepUp.StepUpToken, http.StatusCreated},
+		{"step-up of another user", login.AccessToken, otherStepUp.StepUpToken, http.StatusUnauthorized},
+		{"access token as step-up", login.AccessToken, login.AccessToken, http.StatusUnauthorized},
+		{"user without mfa", env.loginToken(t, plain, org), "", http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/auth/me/api-keys", strings.NewReader(`{"name":"`+strings.ReplaceAll(tt.name, " ", "-")+`"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			if tt.stepUp != "" {
+				req.Header.Set(StepUpTokenHeader, tt.stepUp)
+			}
+			rec := httptest.NewRecorder()
+			env.router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestMFAStatusEndpoint(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	org := env.createOrganization(t, "Acme", nil)
+	user := env.createUser(t, "stepper", nil)
+	env.addMember(t, user, org, "CEO")
+	plain := env.createUser(t, "plain", nil)
+	env.addMember(t, plain, org, "CEO")
+	secret, recovery := env.enableMFA(t, user)
+	login, err := env.auth.Login(&models.LoginRequest{Username: user.Username, Password: testPassword, OrganizationID: org.ID, MFACode: recovery[0]})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	stepUp, err := env.auth.ChallengeMFA(user.ID, totp(t, secret))
+	if err != nil {
+		t.Fatalf("challenge: %v", err)
+	}
+	stored, err := env.users.GetByID(user.ID)
+	if err != nil {
+		t.Fatalf("reload user: %v", err)
+	}
+	// Nothing that could recreate a second factor may appear in the response
+	hidden := append([]string{secret, *stored.MFASecret}, recovery...)
+	hidden = append(hidden, stored.MFARecoveryCodes...)
+
+	tests := []struct {
+		name   string
+		token  string
+		stepUp string
+		want   models.MFAStatus
+	}{
+		{"mfa user", login.AccessToken, "", models.MFAStatus{MFAEnabled: true, RecoveryCodesRemaining: len(recovery) - 1}},
+		{"with step-up", login.AccessToken, stepUp.StepUpToken, models.MFAStatus{MFAEnabled: true, RecoveryCodesRemaining: len(recovery) - 1, StepUpActive: true}},
+		{"invalid step-up", login.AccessToken, "not-a-token", models.MFAStatus{MFAEnabled: true, RecoveryCodesRemaining: len(recovery) - 1}},
+		{"user without mfa", env.loginToken(t, plain, org), "", models.MFAStatus{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/auth/me/mfa-status", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			if tt.stepUp != "" {
+				req.Header.Set(StepUpTokenHeader, tt.stepUp)
+			}
+			rec := httptest.NewRecorder()
+			env.router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+			}
+
+			body := rec.Body.String()
+			for _, value := range hidden {
+				if strings.Contains(body, value) {
+					t.Fatalf("response exposes MFA material: %s", body)
+				}
+			}
+			var got models.MFAStatus
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got.MFAEnabled != tt.want.MFAEnabled || got.RecoveryCodesRemaining != tt.want.RecoveryCodesRemaining || got.StepUpActive != tt.want.StepUpActive {
+				t.Errorf("status = %+v, want %+v", got, tt.want)
+			}
+			if got.StepUpActive != (got.StepUpExpiresAt != nil) {
+				t.Errorf("step_up_expires_at = %v with step_up_active %v", got.StepUpExpiresAt, got.StepUpActive)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/service"
+	coreErrors "github.com/lee-tech/core/errors"
+	coreMiddleware "github.com/lee-tech/core/middleware"
+)
+
+// authMiddleware authenticates requests to the protected subrouters. "Authorization: ApiKey" credentials
+// are resolved to their owner; everything else is treated as an access token. Either way the credential
+// is verified once here and its details are placed in tokenDetailsContextKey, where the rest of the chain
+// reads them instead of parsing the token again.
+func authMiddleware(authService *service.AuthenticationService) mux.MiddlewareFunc {
+	coreAuth := coreAuthContext(authService)
+	return func(next http.Handler) http.Handler {
+		viaCore := coreAuth(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key, ok := apiKeyCredential(r); ok {
+				authenticateAPIKey(authService, key, next).ServeHTTP(w, r)
+				return
+			}
+			token := bearerToken(r)
+			details, err := authService.VerifyAccessToken(token)
+			if err != nil {
+				switch {
+				case errors.Is(err, service.ErrTokenRevoked):
+					coreErrors.Unauthorized("Token has been revoked").WriteHTTP(w)
+				case errors.Is(err, service.ErrInvalidToken):
+					coreErrors.Unauthorized("Invalid or expired token").WriteHTTP(w)
+				default:
+					writeInternalError(w, "failed to verify token", err)
+				}
+				return
+			}
+			ctx := context.WithValue(r.Context(), coreMiddleware.UserIDKey, strconv.FormatUint(details.UserID, 10))
+			ctx = context.WithValue(ctx, tokenDetailsContextKey{}, details)
+			r = r.WithContext(ctx)
+			if authService.SignedWithActiveKey(token) {
+				viaCore.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// coreAuthContext fills in the core auth context for HS256 tokens signed with the current shared secret,
+// which the core authorization middleware on the admin routers reads. The token was already verified by
+// authMiddleware; the core middleware only knows the current secret, so RS256 tokens and tokens signed
+// with a retired JWT_SECRETS entry skip it.
+func coreAuthContext(authService *service.AuthenticationService) mux.MiddlewareFunc {
+	if authService.UsesAsymmetricSigning() {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return coreMiddleware.AuthMiddlewareFunc(func() string {
+		return authService.JWTSecret()
+	})
+}
+
+type tokenDetailsContextKey struct{}
+
+// requestTokenDetails returns the claims of the access token verified by authMiddleware, or the auth
+// context of the owner of an API key, or nil outside the authenticated routers.
+func requestTokenDetails(r *http.Request) *models.TokenDetails {
+	details, _ := r.Context().Value(tokenDetailsContextKey{}).(*models.TokenDetails)
+	return details
+}
+
+// requireSuperAdmin admits super administrators, judged by the is_super_admin claim authMiddleware
+// verified.
+func requireSuperAdmin() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if details := requestTokenDetails(r); details == nil || !details.IsSuperAdmin {
+				coreErrors.Forbidden("super admin access required").WriteHTTP(w)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/ratelimit"
+	"github.com/lee-tech/authentication/internal/service"
+	coreErrors "github.com/lee-tech/core/errors"
+	"github.com/lee-tech/core/utils"
+)
+
+const (
+	tokenDebugRateLimit  = 30
+	tokenDebugRateWindow = time.Minute
+)
+
+// tokenDebugLimiter is shared by all handler instances so the limit is per process.
+var tokenDebugLimiter = ratelimit.NewMemoryLimiter(tokenDebugRateLimit, tokenDebugRateWindow)
+
+// tokenDebugRoute returns the super-admin only, rate-limited token debug handler.
+func (h *AuthenticationHandler) tokenDebugRoute() http.HandlerFunc {
+	return requireSuperAdmin()(rateLimited(tokenDebugLimiter, clientIP, h.DebugToken)).ServeHTTP
+}
+
+// DebugToken decodes a supplied token and reports signature validity, expiry and claims.
+// This is a debugging tool: the token is never logged.
+func (h *AuthenticationHandler) DebugToken(w http.ResponseWriter, r *http.Request) {
+	var req models.TokenDebugRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
+		return
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(req.Token), "Bearer "))
+	if token == "" {
+		coreErrors.ValidationError("token is required").WriteHTTP(w)
+		return
+	}
+
+	result, err := h.authenticationService.DebugToken(token)
+	if err != nil {
+		if errors.Is(err, service.ErrMalformedToken) {
+			coreErrors.ValidationError("token is malformed").WriteHTTP(w)
+			return
+		}
+		coreErrors.Internal("failed to decode token").WriteHTTP(w)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestDebugTokenEndpoint(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	adminToken := env.superAdminToken(t)
+	org := env.createOrganization(t, "Acme", nil)
+	member := env.createUser(t, "member", nil)
+	env.addMember(t, member, org, "CEO")
+	memberToken := env.loginToken(t, member, org)
+
+	// Re-sign the member's claims, keeping the kid so the service's key is chosen
+	resign := func(adjust func(claims jwt.MapClaims), secret string) string {
+		t.Helper()
+		parsed, _, err := jwt.NewParser().ParseUnverified(memberToken, jwt.MapClaims{})
+		if err != nil {
+			t.Fatalf("parse member token: %v", err)
+		}
+		claims := parsed.Claims.(jwt.MapClaims)
+		adjust(claims)
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		token.Header["kid"] = parsed.Header["kid"]
+		signed, err := token.SignedString([]byte(secret))
+		if err != nil {
+			t.Fatalf("sign token: %v", err)
+		}
+		return signed
+	}
+	expiredToken := resign(func(claims jwt.MapClaims) {
+		claims["iat"] = time.Now().Add(-time.Hour).Unix()
+		claims["exp"] = time.Now().Add(-time.Minute).Unix()
+	}, env.cfg.JWTSecret)
+	tamperedToken := resign(func(jwt.MapClaims) {}, "another-secret")
+
+	tests := []struct {
+		name           string
+		caller         string
+		token          string
+		status         int
+		signatureValid bool
+		expired        bool
+	}{
+		{name: "valid token", caller: adminToken, token: memberToken, status: http.StatusOK, signatureValid: true},
+		{name: "expired token", caller: adminToken, token: expiredToken, status: http.StatusOK, signatureValid: true, expired: true},
+		{name: "tampered token", caller: adminToken, token: tamperedToken, status: http.StatusOK},
+		{name: "not a super admin", caller: memberToken, token: memberToken, status: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(models.TokenDebugRequest{Token: tt.token})
+			rec := env.doAuthenticated(t, tt.caller, http.MethodPost, "/v1/auth/admin/token/debug", string(body))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var result models.TokenDebugResult
+			if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if result.SignatureValid != tt.signatureValid || result.Expired != tt.expired {
+				t.Errorf("signature_valid = %v, expired = %v, want %v, %v", result.SignatureValid, result.Expired, tt.signatureValid, tt.expired)
+			}
+			if want := tt.signatureValid && !tt.expired; result.Valid != want {
+				t.Errorf("valid = %v, want %v", result.Valid, want)
+			}
+			if result.Claims["user_id"] == nil {
+				t.Errorf("claims = %v, want the token's claims", result.Claims)
+			}
+		})
+	}
+}
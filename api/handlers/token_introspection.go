@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"crypto/subtle"
 	"encoding/json"
-	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -11,6 +14,7 @@ import (
 	"github.com/lee-tech/authentication/internal/service"
 	coreErrors "github.com/lee-tech/core/errors"
 	coreServer "github.com/lee-tech/core/server"
+	"github.com/lee-tech/core/utils"
 )
 
 // TokenIntrospectionRequest represents a token introspection request
@@ -37,25 +41,109 @@ type TokenIntrospectionResponse struct {
 
 // TokenIntrospectionHandler handles token introspection requests
 type TokenIntrospectionHandler struct {
-	authService         *service.AuthenticationService
-	introspectionSecret string
+	authService *service.AuthenticationService
+
+	// The previous secret keeps verifying tokens until previousUntil so the secret can be rotated
+	// without rejecting tokens signed before the switch.
+	secretsMu      sync.RWMutex
+	currentSecret  string
+	previousSecret string
+	previousUntil  time.Time
+
+	// Client credentials callers must present, as RFC 7662 requires introspection callers to authenticate.
+	clientID     string
+	clientSecret string
+
+	// routes documents the registered routes for the route inventory.
+	routes *RouteCatalog
 }
 
 // NewTokenIntrospectionHandler creates a new token introspection handler
 func NewTokenIntrospectionHandler(authService *service.AuthenticationService, introspectionSecret string) *TokenIntrospectionHandler {
 	return &TokenIntrospectionHandler{
-		authService:         authService,
-		introspectionSecret: introspectionSecret,
+		authService:   authService,
+		currentSecret: introspectionSecret,
+		routes:        NewRouteCatalog(),
+	}
+}
+
+// WithRouteCatalog records the handler's routes in catalog, which handlers sharing a router share.
+func (h *TokenIntrospectionHandler) WithRouteCatalog(catalog *RouteCatalog) *TokenIntrospectionHandler {
+	if catalog != nil {
+		h.routes = catalog
 	}
+	return h
+}
+
+// WithPreviousSecret keeps accepting tokens signed with secret for the grace period, e.g. after a
+// restart that rotated the secret.
+func (h *TokenIntrospectionHandler) WithPreviousSecret(secret string, grace time.Duration) *TokenIntrospectionHandler {
+	h.secretsMu.Lock()
+	defer h.secretsMu.Unlock()
+	h.setPreviousSecret(secret, grace)
+	return h
+}
+
+// WithClientCredentials sets the credentials introspection callers authenticate with. Callers send
+// them as HTTP Basic auth, or send the secret alone as a bearer token; clientID is only checked when
+// set. Without a secret every request is refused.
+func (h *TokenIntrospectionHandler) WithClientCredentials(clientID, clientSecret string) *TokenIntrospectionHandler {
+	h.clientID = clientID
+	h.clientSecret = clientSecret
+	return h
+}
+
+// authenticateClient reports whether the request carries the configured client credentials.
+func (h *TokenIntrospectionHandler) authenticateClient(r *http.Request) bool {
+	if clientID, secret, ok := r.BasicAuth(); ok {
+		if h.clientID != "" && subtle.ConstantTimeCompare([]byte(clientID), []byte(h.clientID)) != 1 {
+			return false
+		}
+		return subtle.ConstantTimeCompare([]byte(secret), []byte(h.clientSecret)) == 1
+	}
+	secret := bearerToken(r)
+	return secret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(h.clientSecret)) == 1
+}
+
+// RotateSecret makes secret the current introspection secret. The secret it replaces stays valid for
+// the grace period; rotating to the secret already in use changes nothing.
+func (h *TokenIntrospectionHandler) RotateSecret(secret string, grace time.Duration) {
+	h.secretsMu.Lock()
+	defer h.secretsMu.Unlock()
+	if secret == "" || secret == h.currentSecret {
+		return
+	}
+	h.setPreviousSecret(h.currentSecret, grace)
+	h.currentSecret = secret
+}
+
+func (h *TokenIntrospectionHandler) setPreviousSecret(secret string, grace time.Duration) {
+	if secret == "" || grace <= 0 {
+		h.previousSecret = ""
+		h.previousUntil = time.Time{}
+		return
+	}
+	h.previousSecret = secret
+	h.previousUntil = time.Now().Add(grace)
+}
+
+// verificationSecrets returns the secrets tokens are checked against, current first.
+func (h *TokenIntrospectionHandler) verificationSecrets() []string {
+	h.secretsMu.RLock()
+	defer h.secretsMu.RUnlock()
+	secrets := []string{h.currentSecret}
+	if h.previousSecret != "" && time.Now().Before(h.previousUntil) {
+		secrets = append(secrets, h.previousSecret)
+	}
+	return secrets
 }
 
 // RegisterRoutes registers token introspection routes
 func (h *TokenIntrospectionHandler) RegisterRoutes(router *mux.Router) {
-	coreServer.Route(router, "/v1/token/introspect", h.Introspect,
+	h.routes.route(router, "/v1/token/introspect", h.Introspect,
+		routeDoc{Summary: "Token Introspection", Tags: []string{"Authentication"}},
 		coreServer.WithMethods(http.MethodPost),
-		coreServer.WithSummary("Token Introspection"),
-		coreServer.WithDescription("Introspect an access or refresh token to validate and retrieve metadata"),
-		coreServer.WithTags("Authentication"),
+		coreServer.WithDescription("Introspect an access or refresh token to validate and retrieve metadata. Callers authenticate with INTROSPECTION_CLIENT_SECRET as HTTP Basic auth or a bearer secret"),
 		coreServer.WithRequestBody(&coreServer.BodyMeta{
 			Required: true,
 			ModelKey: "token-introspection-request",
@@ -84,39 +172,89 @@ func (h *TokenIntrospectionHandler) RegisterRoutes(router *mux.Router) {
 
 // Introspect validates a token and returns its metadata
 func (h *TokenIntrospectionHandler) Introspect(w http.ResponseWriter, r *http.Request) {
+	if h.clientSecret == "" {
+		utils.RespondJSON(w, http.StatusServiceUnavailable, map[string]string{
+			"error":   "Service Unavailable",
+			"message": "token introspection is not configured",
+		})
+		return
+	}
+	if !h.authenticateClient(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="token-introspection"`)
+		coreErrors.Unauthorized("client authentication required").WriteHTTP(w)
+		return
+	}
+
 	var req TokenIntrospectionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		coreErrors.BadRequest("Invalid request body").WriteHTTP(w)
 		return
 	}
 
-	// Parse and validate the token
-	claims := jwt.MapClaims{}
-	token, err := jwt.ParseWithClaims(req.Token, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, coreErrors.Unauthorized("Invalid signing method")
+	// Tokens are verified with the service's signing keys, which pick the JWT_SECRETS entry named by the
+	// kid header. HS256 tokens the service keys reject are then tried against the introspection secret
+	// and the previous one during its grace window.
+	var claims jwt.MapClaims
+	var token *jwt.Token
+	var err error
+	var options []jwt.ParserOption
+	if h.authService != nil {
+		options = append(options, jwt.WithIssuer(h.authService.Issuer()))
+		claims = jwt.MapClaims{}
+		token, err = jwt.ParseWithClaims(req.Token, claims, h.authService.TokenKeyFunc, options...)
+	}
+	if h.authService == nil || (!h.authService.UsesAsymmetricSigning() && (err != nil || !token.Valid)) {
+		for _, secret := range h.verificationSecrets() {
+			claims = jwt.MapClaims{}
+			token, err = jwt.ParseWithClaims(req.Token, claims, func(token *jwt.Token) (interface{}, error) {
+				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+					return nil, coreErrors.Unauthorized("Invalid signing method")
+				}
+				return []byte(secret), nil
+			}, options...)
+			if err == nil && token.Valid {
+				break
+			}
 		}
-		return []byte(h.introspectionSecret), nil
-	})
+	}
 
 	response := &TokenIntrospectionResponse{
 		Active: false,
 	}
 
-	if err != nil || !token.Valid {
+	if err != nil || token == nil || !token.Valid {
 		// Token is invalid or expired
 		h.writeResponse(w, response)
 		return
 	}
 
+	// Tokens revoked individually or through a session revocation are inactive
+	if h.authService != nil {
+		revoked, err := h.authService.IsTokenRevoked(claims)
+		if err != nil {
+			writeInternalError(w, "failed to check token revocation", err)
+			return
+		}
+		if revoked {
+			h.writeResponse(w, response)
+			return
+		}
+	}
+
+	// Only access and refresh tokens are meaningful to resource servers; the service's internal
+	// challenge and state tokens are reported inactive
+	tokenType, _ := claims["type"].(string)
+	if tokenType != "access" && tokenType != "refresh" {
+		h.writeResponse(w, response)
+		return
+	}
+
 	// Token is valid - populate response
 	response.Active = true
-	response.TokenType = "access"
+	response.TokenType = tokenType
 
 	// Extract standard claims
-	if sub, ok := claims["sub"].(string); ok {
-		response.Sub = sub
-	}
+	response.Sub = numericClaimString(claims["sub"])
 
 	if username, ok := claims["username"].(string); ok {
 		response.Username = username
@@ -126,12 +264,19 @@ func (h *TokenIntrospectionHandler) Introspect(w http.ResponseWriter, r *http.Re
 		response.Email = email
 	}
 
-	if orgID, ok := claims["org_id"]; ok {
-		if uint64Val, ok := orgID.(uint64); ok {
-			response.OrganizationID = uint64ToString(uint64Val)
-		} else if strVal, ok := orgID.(string); ok {
-			response.OrganizationID = strVal
+	response.OrganizationID = numericClaimString(claims["org_id"])
+
+	// Membership claims are only written into access tokens and may be renamed through CLAIM_NAMES
+	if tokenType == "access" {
+		rolesClaim, departmentsClaim := "roles", "departments"
+		if h.authService != nil {
+			rolesClaim, departmentsClaim = h.authService.ClaimName(rolesClaim), h.authService.ClaimName(departmentsClaim)
+		}
+		if roles := stringListClaim(claims[rolesClaim]); len(roles) > 0 {
+			response.RoleIDs = strings.Join(roles, ",")
 		}
+		response.Scopes = stringListClaim(claims["scopes"])
+		response.DepartmentID = primaryMembershipID(claims[departmentsClaim])
 	}
 
 	// Extract timestamps
@@ -170,7 +315,57 @@ func int64Ptr(i int64) *int64 {
 	return &i
 }
 
-func uint64ToString(u uint64) string {
-	// Convert uint64 to string
-	return fmt.Sprintf("%d", u)
+// numericClaimString formats an ID claim, which decodes as float64, as a decimal string.
+func numericClaimString(claim any) string {
+	switch value := claim.(type) {
+	case float64:
+		if value > 0 {
+			return strconv.FormatUint(uint64(value), 10)
+		}
+	case string:
+		return value
+	}
+	return ""
+}
+
+// stringListClaim returns the string entries of an array claim.
+func stringListClaim(claim any) []string {
+	items, ok := claim.([]any)
+	if !ok {
+		return nil
+	}
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		if value, ok := item.(string); ok && value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// primaryMembershipID returns the ID of the primary entry of a membership claim, falling back to the
+// first entry when none is marked primary.
+func primaryMembershipID(claim any) string {
+	items, ok := claim.([]any)
+	if !ok {
+		return ""
+	}
+	fallback := ""
+	for _, item := range items {
+		membership, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		id := numericClaimString(membership["id"])
+		if id == "" {
+			continue
+		}
+		if primary, _ := membership["is_primary"].(bool); primary {
+			return id
+		}
+		if fallback == "" {
+			fallback = id
+		}
+	}
+	return fallback
 }
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lee-tech/authentication/config"
+)
+
+func TestIntrospectVerifiesKeyedSecrets(t *testing.T) {
+	env := newHandlerEnv(t, func(cfg *config.AuthConfig) {
+		cfg.JWTSecrets = map[string]string{"2024-10": "old-secret", "2025-04": "new-secret"}
+		cfg.JWTSecretKID = "2025-04"
+		cfg.Config.JWTSecret = "new-secret"
+	}, nil)
+	user := env.createUser(t, "introspected", nil)
+	handler := NewTokenIntrospectionHandler(env.auth, "introspection-secret").
+		WithPreviousSecret("previous-introspection-secret", time.Hour).
+		WithClientCredentials("", "client-secret")
+
+	sign := func(kid, secret string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"user_id": user.ID,
+			"sub":     user.ID,
+			"type":    "access",
+			"iss":     env.auth.Issuer(),
+			"iat":     time.Now().Add(-time.Minute).Unix(),
+			"exp":     time.Now().Add(time.Hour).Unix(),
+		})
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString([]byte(secret))
+		if err != nil {
+			t.Fatalf("sign token: %v", err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		active bool
+	}{
+		{"current kid", sign("2025-04", "new-secret"), true},
+		{"retired kid", sign("2024-10", "old-secret"), true},
+		{"kid with wrong secret", sign("2024-10", "new-secret"), false},
+		{"unknown kid", sign("2023-01", "old-secret"), false},
+		{"no kid uses the current secret", sign("", "new-secret"), true},
+		{"introspection secret", sign("", "introspection-secret"), true},
+		{"previous introspection secret", sign("", "previous-introspection-secret"), true},
+		{"unrelated secret", sign("", "unrelated-secret"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/token/introspect", strings.NewReader(`{"token":"`+tt.token+`"}`))
+			req.Header.Set("Authorization", "Bearer client-secret")
+			rec := httptest.NewRecorder()
+			handler.Introspect(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+			}
+			var body TokenIntrospectionResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body.Active != tt.active {
+				t.Errorf("active = %v, want %v", body.Active, tt.active)
+			}
+		})
+	}
+}
+
+func TestIntrospectionSecretRotation(t *testing.T) {
+	env := newHandlerEnv(t, nil, nil)
+	user := env.createUser(t, "introspected", nil)
+	handler := NewTokenIntrospectionHandler(env.auth, "first-secret").WithClientCredentials("", "client-secret")
+
+	active := func(secret string) bool {
+		t.Helper()
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"user_id": user.ID,
+			"sub":     user.ID,
+			"type":    "access",
+			"iss":     env.auth.Issuer(),
+			"iat":     time.Now().Add(-time.Minute).Unix(),
+			"exp":     time.Now().Add(time.Hour).Unix(),
+		})
+		signed, err := token.SignedString([]byte(secret))
+		if err != nil {
+			t.Fatalf("sign token: %v", err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/v1/token/introspect", strings.NewReader(`{"token":"`+signed+`"}`))
+		req.Header.Set("Authorization", "Bearer client-secret")
+		rec := httptest.NewRecorder()
+		handler.Introspect(rec, req)
+		var body TokenIntrospectionResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return body.Active
+	}
+
+	handler.RotateSecret("second-secret", time.Hour)
+	if !active("second-secret") || !active("first-secret") {
+		t.Fatal("during the grace window both the new and the previous secret should verify")
+	}
+
+	// Rotating to the secret in use keeps the grace window of the previous one
+	handler.RotateSecret("second-secret", time.Hour)
+	if !active("first-secret") {
+		t.Fatal("re-applying the current secret dropped the previous one")
+	}
+
+	handler.secretsMu.Lock()
+	handler.previousUntil = time.Now().Add(-time.Second)
+	handler.secretsMu.Unlock()
+	if active("first-secret") {
+		t.Fatal("previous secret still verifies after the grace window")
+	}
+	if !active("second-secret") {
+		t.Fatal("current secret stopped verifying")
+	}
+
+	handler.RotateSecret("third-secret", 0)
+	if active("second-secret") {
+		t.Fatal("replaced secret verifies without a grace period")
+	}
+	if !active("third-secret") {
+		t.Fatal("rotated secret does not verify")
+	}
+}
+
+func TestIntrospectUsesConfiguredIssuer(t *testing.T) {
+	env := newHandlerEnv(t, func(cfg *config.AuthConfig) { cfg.JWTIssuer = "https://auth.example.com" }, nil)
+	user := env.createUser(t, "introspected", nil)
+	handler := NewTokenIntrospectionHandler(env.auth, "").WithClientCredentials("", "client-secret")
+
+	for _, tt := range []struct {
+		issuer string
+		active bool
+	}{
+		{"https://auth.example.com", true},
+		{"authentication", false},
+	} {
+		t.Run(tt.issuer, func(t *testing.T) {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+				"user_id": user.ID,
+				"sub":     user.ID,
+				"type":    "access",
+				"iss":     tt.issuer,
+				"iat":     time.Now().Add(-time.Minute).Unix(),
+				"exp":     time.Now().Add(time.Hour).Unix(),
+			})
+			signed, err := token.SignedString([]byte(env.cfg.JWTSecret))
+			if err != nil {
+				t.Fatalf("sign token: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/v1/token/introspect", strings.NewReader(`{"token":"`+signed+`"}`))
+			req.Header.Set("Authorization", "Bearer client-secret")
+			rec := httptest.NewRecorder()
+			handler.Introspect(rec, req)
+
+			var body TokenIntrospectionResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body.Active != tt.active {
+				t.Fatalf("active = %v, want %v", body.Active, tt.active)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Command bootstrap creates or rotates the root organization and super-administrator without starting
+// the HTTP server. Flags override the BOOTSTRAP_* settings for a single run. The administrator password
+// is never taken from a flag, where it would show up in the process list and shell history; it comes from
+// BOOTSTRAP_ADMIN_PASSWORD or, with --admin-password-stdin, from standard input.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/constants"
+	"github.com/lee-tech/authentication/internal/models"
+	authService "github.com/lee-tech/authentication/internal/service"
+	coreServer "github.com/lee-tech/core/server"
+
+	_ "github.com/lee-tech/authentication/internal/repository"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// bootstrapError is printed in JSON mode when the bootstrap fails.
+type bootstrapError struct {
+	Error string `json:"error"`
+}
+
+func main() {
+	var (
+		output         = flag.String("output", outputText, "output format: text or json")
+		orgName        = flag.String("org-name", "", "root organization name (default BOOTSTRAP_ORG_NAME)")
+		orgDescription = flag.String("org-description", "", "root organization description (default BOOTSTRAP_ORG_DESCRIPTION)")
+		orgDomain      = flag.String("org-domain", "", "root organization domain (default BOOTSTRAP_ORG_DOMAIN)")
+		adminEmail     = flag.String("admin-email", "", "administrator email (default BOOTSTRAP_ADMIN_EMAIL)")
+		adminUsername  = flag.String("admin-username", "", "administrator username (default BOOTSTRAP_ADMIN_USERNAME)")
+		passwordStdin  = flag.Bool("admin-password-stdin", false, "read the administrator password from the first line of stdin (default BOOTSTRAP_ADMIN_PASSWORD)")
+		adminFirstName = flag.String("admin-first-name", "", "administrator first name (default BOOTSTRAP_ADMIN_FIRST_NAME)")
+		adminLastName  = flag.String("admin-last-name", "", "administrator last name (default BOOTSTRAP_ADMIN_LAST_NAME)")
+		forcePassword  = flag.Bool("force-password", false, "store the password even when it already matches")
+	)
+	flag.Parse()
+
+	if *output != outputText && *output != outputJSON {
+		fmt.Fprintf(os.Stderr, "invalid --output %q: want %s or %s\n", *output, outputText, outputJSON)
+		os.Exit(2)
+	}
+
+	var adminPassword string
+	if *passwordStdin {
+		password, err := readPassword(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid --admin-password-stdin: %v\n", err)
+			os.Exit(2)
+		}
+		adminPassword = password
+	}
+
+	result, err := run(func(input *authService.BootstrapAdminInput) {
+		override(&input.OrganizationName, *orgName)
+		override(&input.OrganizationDescription, *orgDescription)
+		override(&input.OrganizationDomain, *orgDomain)
+		override(&input.AdminEmail, *adminEmail)
+		override(&input.AdminUsername, *adminUsername)
+		override(&input.AdminPassword, adminPassword)
+		override(&input.AdminFirstName, *adminFirstName)
+		override(&input.AdminLastName, *adminLastName)
+		input.ForcePasswordReset = *forcePassword
+	})
+	if err != nil {
+		if *output == outputJSON {
+			writeJSON(os.Stdout, bootstrapError{Error: err.Error()})
+			os.Exit(1)
+		}
+		log.Fatalf("failed to bootstrap administrator: %v", err)
+	}
+
+	if *output == outputJSON {
+		writeJSON(os.Stdout, result)
+		return
+	}
+	fmt.Println(describe(result))
+}
+
+// run initialises the service the same way the HTTP server does and bootstraps the administrator from
+// the configured input after adjust applied the flags.
+func run(adjust func(input *authService.BootstrapAdminInput)) (*models.BootstrapResult, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+
+	app, err := coreServer.InitializeHTTPApp(cfg.Config, &coreServer.HTTPAppOptions{
+		Migrations: []any{&models.User{}},
+		InitialComponents: map[string]any{
+			constants.ComponentKey.AuthenticationConfig: cfg,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("initialize application: %w", err)
+	}
+
+	serviceComponent, ok := app.GetComponent(constants.ComponentKey.AuthenticationService)
+	if !ok {
+		return nil, fmt.Errorf("component %s not found", constants.ComponentKey.AuthenticationService)
+	}
+	authSvc, ok := serviceComponent.(*authService.AuthenticationService)
+	if !ok {
+		return nil, fmt.Errorf("component %s has unexpected type %T", constants.ComponentKey.AuthenticationService, serviceComponent)
+	}
+
+	input := authSvc.DefaultBootstrapInput()
+	adjust(input)
+	return authSvc.BootstrapAdmin(input)
+}
+
+// override replaces the configured value when the flag was given.
+func override(value *string, flagValue string) {
+	if flagValue != "" {
+		*value = flagValue
+	}
+}
+
+// readPassword reads the password from the first line of r, without its line ending.
+func readPassword(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("no password on stdin")
+	}
+	return password, nil
+}
+
+// describe summarises the result for a person reading the terminal.
+func describe(result *models.BootstrapResult) string {
+	action := "updated"
+	if result.Created {
+		action = "created"
+	}
+	summary := fmt.Sprintf("Administrator %s (id %d) %s in organization %s (id %d)",
+		result.User.Email, result.User.ID, action, result.Organization.Name, result.Organization.ID)
+	if result.PasswordRotated {
+		summary += "; password rotated"
+	}
+	return summary
+}
+
+func writeJSON(w io.Writer, value any) {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		log.Fatalf("failed to write output: %v", err)
+	}
+}
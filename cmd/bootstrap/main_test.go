@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadPassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "line", input: "MySecurePassw0rd!\n", want: "MySecurePassw0rd!"},
+		{name: "windows line ending", input: "MySecurePassw0rd!\r\n", want: "MySecurePassw0rd!"},
+		{name: "no line ending", input: "MySecurePassw0rd!", want: "MySecurePassw0rd!"},
+		{name: "first line only", input: "MySecurePassw0rd!\nextra\n", want: "MySecurePassw0rd!"},
+		{name: "surrounding spaces kept", input: " pass word \n", want: " pass word "},
+		{name: "empty line", input: "\n", wantErr: true},
+		{name: "empty input", input: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readPassword(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readPassword() error = %v, want error: %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("readPassword() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
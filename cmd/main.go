@@ -24,9 +24,19 @@ func main() {
 		log.Fatalf("failed to load config: %v", err)
 	}
 
+	handlers.SetErrorVerbosity(cfg.ErrorVerbosity)
+	handlers.SetTrustProxyHeaders(cfg.TrustProxyHeaders)
+	handlers.SetLockoutDisclosure(cfg.HideLockedAccounts, cfg.TrustedClientKey)
+
 	var (
 		additionalMiddleware      []mux.MiddlewareFunc
-		adminAuthorizationBuilder = handlers.NewAdminAuthorizationBuilder()
+		adminAuthorizationBuilder = handlers.NewAdminAuthorizationBuilder(
+			handlers.WithAdminAuthorizationTracePropagation(cfg.AuthorizationTracePropagation),
+			handlers.WithAdminAuthorizationRouteOverrides(cfg.AuthorizationOverrides),
+			handlers.WithAdminAuthorizationFallbackSlug(cfg.AuthorizationFallbackSlug),
+		)
+		// Shared by every handler so the route inventory covers all routes
+		routeCatalog = handlers.NewRouteCatalog()
 	)
 
 	checker, authorizationEnabled, err := coreMiddleware.NewAuthorizationCheckerFromConfig(cfg.Config, nil, nil)
@@ -38,10 +48,22 @@ func main() {
 		log.Println("authorization middleware disabled via DISABLE_AUTHORIZATION flag")
 	}
 
+	authorizationUnavailable := false
+	if !cfg.DisableAuthorization && !(authorizationEnabled && checker != nil) {
+		if cfg.StrictAuthorization {
+			authorizationUnavailable = true
+			log.Println("authorization service unavailable and STRICT_AUTHORIZATION is set: admin routes will respond with 503")
+		} else {
+			log.Println("authorization service unavailable: admin routes fall back to super-admin only access")
+		}
+	}
+
 	initialComponents := map[string]any{
 		constants.ComponentKey.AuthenticationConfig:      cfg,
 		constants.ComponentKey.AuthorizationEnabled:      authorizationEnabled,
+		constants.ComponentKey.AuthorizationUnavailable:  authorizationUnavailable,
 		constants.ComponentKey.AdminAuthorizationBuilder: adminAuthorizationBuilder,
+		constants.ComponentKey.RouteCatalog:              routeCatalog,
 	}
 
 	appOptions := &coreServer.HTTPAppOptions{
@@ -78,12 +100,37 @@ func main() {
 		log.Fatalf("component %s has unexpected type %T", constants.ComponentKey.AuthenticationService, serviceComponent)
 	}
 
-	if _, _, err := authSvc.BootstrapDefaultAdmin(); err != nil {
+	if _, err := authSvc.BootstrapDefaultAdmin(); err != nil {
 		log.Fatalf("failed to bootstrap default administrator: %v", err)
 	}
 
-	handler := handlers.NewAuthenticationHandler(authSvc, authorizationEnabled, adminAuthorizationBuilder)
+	stopInactivityLock := authSvc.StartInactivityLock()
+	defer stopInactivityLock()
+
+	stopRevokedTokenCleanup := authSvc.StartRevokedTokenCleanup()
+	defer stopRevokedTokenCleanup()
+
+	handler := handlers.NewAuthenticationHandler(authSvc, authorizationEnabled, authorizationUnavailable, adminAuthorizationBuilder).
+		WithRouteCatalog(routeCatalog)
 	handler.RegisterRoutes(app.Router)
 
+	introspection := handlers.NewTokenIntrospectionHandler(authSvc, cfg.IntrospectionSecret).
+		WithPreviousSecret(cfg.IntrospectionPreviousSecret, cfg.IntrospectionSecretGracePeriod).
+		WithClientCredentials(cfg.IntrospectionClientID, cfg.IntrospectionClientSecret).
+		WithRouteCatalog(routeCatalog)
+	if cfg.IntrospectionClientSecret == "" {
+		log.Printf("INTROSPECTION_CLIENT_SECRET is not set; token introspection is disabled")
+	}
+	introspection.RegisterRoutes(app.Router)
+	cfg.RegisterOnConfigChange(func(*coreConfig.Config) {
+		// A changed INTROSPECTION_SECRET becomes current; the replaced one is honoured for the grace period
+		current, _ := config.LoadIntrospectionSecrets(cfg.JWTSecret)
+		introspection.RotateSecret(current, cfg.IntrospectionSecretGracePeriod)
+	})
+
+	if err := handlers.ValidateAuthorizationOverrides(app.Router, cfg.AuthorizationOverrides); err != nil {
+		log.Fatalf("invalid authorization overrides: %v", err)
+	}
+
 	app.Run()
 }
@@ -0,0 +1,161 @@
+// Command rotate-secret prepares the next HS256 signing secret. It reads the current JWT_SECRET,
+// JWT_SECRETS and JWT_SECRET_KID, adds a freshly generated secret under a new kid and prints the
+// JWT_SECRETS and JWT_SECRET_KID values to deploy. The previous secrets stay listed so outstanding tokens
+// keep verifying; retire them with --retire in a later rotation once REFRESH_EXPIRATION has passed.
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lee-tech/authentication/config"
+)
+
+const (
+	outputEnv  = "env"
+	outputJSON = "json"
+)
+
+// legacyKID names the JWT_SECRET entry when it is first moved into JWT_SECRETS. Tokens it signed carry
+// the kid derived from the secret, which the service still matches under any name.
+const legacyKID = "legacy"
+
+// validKID keeps kids safe to paste into environment files and JWT headers.
+var validKID = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// rotation is the configuration to deploy after a rotation.
+type rotation struct {
+	Secrets    map[string]string `json:"jwt_secrets"`
+	CurrentKID string            `json:"jwt_secret_kid"`
+	Retired    []string          `json:"retired,omitempty"`
+}
+
+func main() {
+	var (
+		output = flag.String("output", outputEnv, "output format: env or json")
+		kid    = flag.String("kid", "", "kid of the new secret (default the current UTC time, e.g. 20250401-120000)")
+		retire = flag.String("retire", "", "comma-separated kids to drop; the secret signing tokens until now cannot be retired")
+	)
+	flag.Parse()
+
+	if *output != outputEnv && *output != outputJSON {
+		fmt.Fprintf(os.Stderr, "invalid --output %q: want %s or %s\n", *output, outputEnv, outputJSON)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if strings.ToUpper(cfg.JWTAlgorithm) == "RS256" {
+		log.Fatalf("JWT_SIGNING_METHOD is RS256; rotate the key pair instead of the shared secret")
+	}
+
+	newKID := strings.TrimSpace(*kid)
+	if newKID == "" {
+		newKID = time.Now().UTC().Format("20060102-150405")
+	}
+	secret, err := generateSecret()
+	if err != nil {
+		log.Fatalf("failed to generate secret: %v", err)
+	}
+
+	result, err := rotate(cfg.JWTSecrets, cfg.JWTSecretKID, cfg.JWTSecret, newKID, secret, splitKIDs(*retire))
+	if err != nil {
+		log.Fatalf("failed to rotate secret: %v", err)
+	}
+
+	if *output == outputJSON {
+		writeJSON(os.Stdout, result)
+		return
+	}
+	writeEnv(os.Stdout, result)
+}
+
+// rotate returns the secrets to deploy: the configured ones, with the single JWT_SECRET listed as
+// legacyKID when JWT_SECRETS is empty, plus secret under newKID, minus the retired kids. The kid that
+// signed tokens until now is kept so those tokens keep verifying.
+func rotate(secrets map[string]string, currentKID, currentSecret, newKID, secret string, retire []string) (*rotation, error) {
+	if !validKID.MatchString(newKID) {
+		return nil, fmt.Errorf("kid %q must only contain letters, digits, '.', '_' and '-'", newKID)
+	}
+
+	next := make(map[string]string, len(secrets)+1)
+	for kid, value := range secrets {
+		next[kid] = value
+	}
+	if len(next) == 0 {
+		if currentSecret == "" {
+			return nil, fmt.Errorf("JWT_SECRET is not set")
+		}
+		currentKID = legacyKID
+		next[currentKID] = currentSecret
+	}
+	if _, exists := next[newKID]; exists {
+		return nil, fmt.Errorf("kid %q is already in JWT_SECRETS; pass another --kid", newKID)
+	}
+
+	for _, kid := range retire {
+		if kid == currentKID {
+			return nil, fmt.Errorf("kid %q still signs tokens; retire it in a later rotation once REFRESH_EXPIRATION has passed", kid)
+		}
+		if _, ok := next[kid]; !ok {
+			return nil, fmt.Errorf("kid %q is not in JWT_SECRETS", kid)
+		}
+		delete(next, kid)
+	}
+	next[newKID] = secret
+
+	return &rotation{Secrets: next, CurrentKID: newKID, Retired: retire}, nil
+}
+
+// generateSecret returns 256 random bits, matching the strength of an HS256 key.
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// splitKIDs parses the --retire list, ignoring blanks.
+func splitKIDs(raw string) []string {
+	var kids []string
+	for _, kid := range strings.Split(raw, ",") {
+		if kid = strings.TrimSpace(kid); kid != "" {
+			kids = append(kids, kid)
+		}
+	}
+	return kids
+}
+
+// writeEnv prints the settings in .env form with JWT_SECRETS single-quoted. Generated secrets are
+// base64url, but a configured secret containing a quote cannot be written that way.
+func writeEnv(w io.Writer, result *rotation) {
+	secrets, err := json.Marshal(result.Secrets)
+	if err != nil {
+		log.Fatalf("failed to write output: %v", err)
+	}
+	if strings.Contains(string(secrets), "'") {
+		log.Fatalf("JWT_SECRETS contains a single quote; use --output=json")
+	}
+	fmt.Fprintf(w, "JWT_SECRETS='%s'\n", secrets)
+	fmt.Fprintf(w, "JWT_SECRET_KID=%s\n", result.CurrentKID)
+}
+
+func writeJSON(w io.Writer, value any) {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		log.Fatalf("failed to write output: %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestRotate(t *testing.T) {
+	tests := []struct {
+		name       string
+		secrets    map[string]string
+		currentKID string
+		newKID     string
+		retire     []string
+		want       map[string]string
+		wantErr    string
+	}{
+		{
+			name:   "moves JWT_SECRET into the set",
+			newKID: "2025-04",
+			want:   map[string]string{legacyKID: "current", "2025-04": "next"},
+		},
+		{
+			name:       "keeps the signing secret",
+			secrets:    map[string]string{"2024-10": "older", "2025-01": "current"},
+			currentKID: "2025-01",
+			newKID:     "2025-04",
+			want:       map[string]string{"2024-10": "older", "2025-01": "current", "2025-04": "next"},
+		},
+		{
+			name:       "retires older secrets",
+			secrets:    map[string]string{"2024-10": "older", "2025-01": "current"},
+			currentKID: "2025-01",
+			newKID:     "2025-04",
+			retire:     []string{"2024-10"},
+			want:       map[string]string{"2025-01": "current", "2025-04": "next"},
+		},
+		{
+			name:       "refuses to retire the signing secret",
+			secrets:    map[string]string{"2025-01": "current"},
+			currentKID: "2025-01",
+			newKID:     "2025-04",
+			retire:     []string{"2025-01"},
+			wantErr:    "still signs tokens",
+		},
+		{
+			name:       "refuses unknown kids",
+			secrets:    map[string]string{"2025-01": "current"},
+			currentKID: "2025-01",
+			newKID:     "2025-04",
+			retire:     []string{"2023-01"},
+			wantErr:    "not in JWT_SECRETS",
+		},
+		{
+			name:       "refuses an existing kid",
+			secrets:    map[string]string{"2025-01": "current"},
+			currentKID: "2025-01",
+			newKID:     "2025-01",
+			wantErr:    "already in JWT_SECRETS",
+		},
+		{
+			name:    "refuses kids unsafe for env files",
+			newKID:  "it's",
+			wantErr: "must only contain",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := maps.Clone(tt.secrets)
+			got, err := rotate(tt.secrets, tt.currentKID, "current", tt.newKID, "next", tt.retire)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("rotate() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("rotate() error = %v", err)
+			}
+			if !maps.Equal(got.Secrets, tt.want) || got.CurrentKID != tt.newKID {
+				t.Errorf("rotate() = %v signing with %q, want %v signing with %q", got.Secrets, got.CurrentKID, tt.want, tt.newKID)
+			}
+			if !maps.Equal(tt.secrets, before) {
+				t.Errorf("rotate() modified the configured secrets: %v", tt.secrets)
+			}
+		})
+	}
+}
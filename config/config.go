@@ -2,19 +2,35 @@ package config
 
 import (
 	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"log"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	coreConfig "github.com/lee-tech/core/config"
 	"github.com/lee-tech/core/secret"
 )
 
+// AuthorizationRouteOverride is the action and resource an admin route is authorized against instead
+// of the ones derived from its path. Values without the service namespace are prefixed with it.
+type AuthorizationRouteOverride struct {
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+}
+
 // AuthConfig extends the core configuration with auth-specific settings
 type AuthConfig struct {
 	*coreConfig.Config
 
-	// Auth specific settings
+	// Auth specific settings. JWTAlgorithm selects how tokens are signed: HS256 with JWT_SECRET, or
+	// RS256 with the key pair loaded from JWT_PRIVATE_KEY_PATH and JWT_PUBLIC_KEY_PATH.
+	JWTAlgorithm      string
 	TokenExpiration   time.Duration `env:"TOKEN_EXPIRATION" envDefault:"15m"`
 	RefreshExpiration time.Duration `env:"REFRESH_EXPIRATION" envDefault:"7d"`
 	PasswordMinLength int           `env:"PASSWORD_MIN_LENGTH" envDefault:"8"`
@@ -22,14 +38,167 @@ type AuthConfig struct {
 	LockoutDuration   time.Duration `env:"LOCKOUT_DURATION" envDefault:"15m"`
 	BCryptCost        int           `env:"BCRYPT_COST" envDefault:"10"`
 
+	// PasswordMinEntropyBits rejects new passwords whose estimated entropy is lower; 0 disables it.
+	PasswordMinEntropyBits int
+
+	// Character classes new passwords must contain, and whether well-known common passwords are rejected.
+	PasswordRequireUpper  bool
+	PasswordRequireDigit  bool
+	PasswordRequireSymbol bool
+	PasswordRejectCommon  bool
+
+	// JWTIssuer is the iss claim written into and required of issued tokens; defaults to ServiceName.
+	JWTIssuer string
+
+	// HS256 secrets by kid, for rotating JWT_SECRET without invalidating outstanding tokens. The
+	// JWTSecretKID entry signs new tokens and becomes JWTSecret; every entry verifies tokens.
+	JWTSecrets   map[string]string `json:"-"`
+	JWTSecretKID string
+
+	// RS256 key pair, loaded at startup when JWTAlgorithm is RS256.
+	JWTPrivateKeyPath string
+	JWTPublicKeyPath  string
+	JWTPrivateKey     *rsa.PrivateKey `json:"-"`
+	JWTPublicKey      *rsa.PublicKey  `json:"-"`
+
 	// OAuth settings (optional)
 	OAuthEnabled       bool   `env:"OAUTH_ENABLED" envDefault:"false"`
 	GoogleClientID     string `env:"GOOGLE_CLIENT_ID"`
 	GoogleClientSecret string `env:"GOOGLE_CLIENT_SECRET"`
+	// GoogleRedirectURL is the public URL of /v1/oauth/google/callback registered with Google.
+	GoogleRedirectURL string
+	// OAuthStateTTL bounds how long a stored OAuth state is accepted on callback.
+	OAuthStateTTL time.Duration
+	// OAuthRequirePKCE extends the PKCE requirement from public clients to confidential clients.
+	OAuthRequirePKCE bool
+	// RedirectAllowedOrigins lists the origins OAuth and magic-link flows may redirect to besides
+	// the service itself. Entries may use a leading "*." wildcard for subdomains.
+	RedirectAllowedOrigins []string
+
+	// Login organization selection settings
+	LoginSelectionEnabled  bool
+	LoginSelectionTokenTTL time.Duration
+
+	// HideLockedAccounts reports locked accounts as invalid credentials unless the request carries
+	// TrustedClientKey in the X-Trusted-Client-Key header.
+	HideLockedAccounts bool
+	TrustedClientKey   string
+
+	// LoginHistoryWindow caps how far back users can review their own login attempts.
+	LoginHistoryWindow time.Duration
+
+	// Concurrent login detection flags a successful login from a different network than another
+	// successful login of the same user within ConcurrentLoginWindow. Networks are compared by prefix.
+	ConcurrentLoginDetection  bool
+	ConcurrentLoginWindow     time.Duration
+	ConcurrentLoginIPv4Prefix int
+	ConcurrentLoginIPv6Prefix int
+
+	// TokenOrganizationBinding rejects requests whose path organization_id differs from the token's
+	// org_id, except for super admins.
+	TokenOrganizationBinding bool
+
+	// Login rate limiting (LoginRateLimit <= 0 disables it)
+	LoginRateLimit    int
+	LoginRateWindow   time.Duration
+	TrustProxyHeaders bool
+
+	// LoginRateLimitByUsername adds a bucket per submitted username on top of the per-IP bucket.
+	// LoginRateLimitStore is "memory" (per replica) or "redis" (shared via RedisAddr).
+	LoginRateLimitByUsername bool
+	LoginRateLimitStore      string
+	RedisAddr                string
+	RedisPassword            string
+	RedisDB                  int
+
+	// Authorization settings. AuthorizationTracePropagation traces decisions for sampled traceparent requests.
+	StrictAuthorization           bool
+	AuthorizationTracePropagation bool
+	// AuthorizationFallbackSlug is the action slug for routes that yield none; empty denies them.
+	AuthorizationFallbackSlug string
+	// AuthorizationOverrides remaps the action and resource checked for specific admin routes, keyed
+	// by route template or route name.
+	AuthorizationOverrides map[string]AuthorizationRouteOverride
+
+	// Error reporting settings ("minimal" or "verbose")
+	ErrorVerbosity string
+
+	// LoginEmailCaseInsensitive matches email-shaped login identifiers and registration emails without
+	// regard to case and stores new emails lowercased. Usernames always match exactly.
+	LoginEmailCaseInsensitive bool
+
+	// MembershipRemovalMode controls whether removed organization and department memberships are
+	// deleted ("hard") or kept as soft-deleted history ("soft").
+	MembershipRemovalMode string
+
+	// Password breach check settings. Only a 5 character SHA-1 prefix is sent to the range API;
+	// PasswordBreachFailClosed rejects passwords when the API cannot be reached.
+	PasswordBreachCheckEnabled bool
+	PasswordBreachAPIURL       string
+	PasswordBreachFailClosed   bool
+	PasswordBreachCacheTTL     time.Duration
+
+	// Email verification settings. VerificationResendInterval is the minimum time between
+	// verification emails to the same user.
+	VerificationResendInterval time.Duration
+
+	// Tenant settings
+	DefaultTenantTier string
+
+	// Department hierarchy settings. DepartmentKindRules maps a parent kind (or ROOT for top-level
+	// departments) to the kinds allowed directly beneath it.
+	DepartmentKindValidation bool
+	DepartmentKindRules      map[string][]string
+	// MaxHierarchyDepth caps recursive organization/department traversals.
+	MaxHierarchyDepth int
+
+	// DepartmentListingRequiresOrgMembership hides a user's departments in organizations they are no
+	// longer a member of from GET /v1/auth/me/departments.
+	DepartmentListingRequiresOrgMembership bool
+
+	// OrganizationDeactivationCascade deactivates an organization's active departments along with it
+	// and restores those departments when the organization is reactivated.
+	OrganizationDeactivationCascade bool
+
+	// PasswordResetTTL bounds how long a password reset token can be used.
+	PasswordResetTTL time.Duration
+
+	// PasswordHistorySize is how many previous passwords are remembered and refused; 0 disables it.
+	PasswordHistorySize int
+
+	// RegistrationEnabled exposes the self-service registration endpoint.
+	RegistrationEnabled bool
+	// VerificationTokenTTL bounds how long an email verification token can be used.
+	VerificationTokenTTL time.Duration
+	// RequireEmailVerification refuses password logins until the user's email is verified.
+	RequireEmailVerification bool
+
+	// MailDelivery selects how verification and password reset tokens reach users: "smtp", "log"
+	// (written to the service log, for development) or "none".
+	MailDelivery string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// ClaimNames renames membership claims in access tokens, keyed by their default name.
+	ClaimNames map[string]string
+
+	// RoleScopes lists the scopes written into access tokens for each organization role.
+	RoleScopes map[string][]string
 
 	// MFA settings
 	MFAEnabled bool   `env:"MFA_ENABLED" envDefault:"false"`
 	TOTPIssuer string `env:"TOTP_ISSUER" envDefault:"Lee-Tech"`
+	// MFAChallengeTokenTTL bounds how long a login paused for MFA can be completed.
+	MFAChallengeTokenTTL time.Duration
+	// MaxMFAAttempts locks the account for LockoutDuration after this many consecutive wrong MFA codes.
+	MaxMFAAttempts int
+	// MFAEncryptionKey protects stored TOTP secrets. It is required when MFA is enabled.
+	MFAEncryptionKey string
+	// StepUpTokenTTL bounds how long a step-up token from an MFA challenge stays valid.
+	StepUpTokenTTL time.Duration
 
 	// Bootstrap settings
 	BootstrapOrganizationName        string
@@ -40,6 +209,37 @@ type AuthConfig struct {
 	BootstrapAdminPassword           string
 	BootstrapAdminFirstName          string
 	BootstrapAdminLastName           string
+	// BootstrapRequireCustomEmail refuses to bootstrap in production with the shipped admin email.
+	BootstrapRequireCustomEmail bool
+
+	// Inactivity lock settings
+	InactivityLockDays        int
+	InactivityLockGraceDays   int
+	InactivityLockInterval    time.Duration
+	InactivityLockSuperAdmins bool
+
+	// Token introspection settings
+	// IntrospectionSecret verifies tokens presented to the introspection endpoint; defaults to the JWT secret.
+	IntrospectionSecret string
+	// IntrospectionPreviousSecret is still accepted for IntrospectionSecretGracePeriod after a rotation.
+	IntrospectionPreviousSecret    string
+	IntrospectionSecretGracePeriod time.Duration
+	// Callers of the introspection endpoint authenticate with these client credentials, either as
+	// HTTP Basic auth or as a bearer secret. IntrospectionClientID is optional.
+	IntrospectionClientID     string
+	IntrospectionClientSecret string
+
+	// RevokedTokenCleanupInterval is how often expired entries are purged from the token blacklist.
+	RevokedTokenCleanupInterval time.Duration
+
+	// Security notification settings
+	SecurityWebhookURL string
+
+	// Vault secret loading. VaultRetryAttempts tries are made, waiting VaultRetryBackoff (doubling)
+	// between them; VaultStrict fails startup when JWT_SECRET cannot be loaded from Vault.
+	VaultRetryAttempts int
+	VaultRetryBackoff  time.Duration
+	VaultStrict        bool
 }
 
 // Load loads the configuration from environment variables
@@ -56,35 +256,474 @@ func Load() (*AuthConfig, error) {
 	}
 
 	// Load secrets from Vault if configured
+	authConfig.VaultRetryAttempts = getEnvInt("VAULT_RETRY_ATTEMPTS", 1)
+	authConfig.VaultRetryBackoff = getEnvDuration("VAULT_RETRY_BACKOFF", time.Second)
+	authConfig.VaultStrict = getEnvBool("VAULT_STRICT", false)
 	if coreConfig.VaultAddr != "" && coreConfig.VaultToken != "" {
+		var secrets map[string]string
 		provider, err := secret.NewVaultProvider(coreConfig.VaultAddr, coreConfig.VaultToken)
 		if err == nil {
-			ctx := context.Background()
-			secrets, err := provider.GetSecrets(ctx, []string{
-				"JWT_SECRET",
-				"GOOGLE_CLIENT_SECRET",
-			})
-			if err == nil {
-				if jwtSecret, ok := secrets["JWT_SECRET"]; ok {
-					authConfig.JWTSecret = jwtSecret
-				}
-				if googleSecret, ok := secrets["GOOGLE_CLIENT_SECRET"]; ok {
-					authConfig.GoogleClientSecret = googleSecret
-				}
+			secrets, err = loadVaultSecrets(provider, authConfig.VaultRetryAttempts, authConfig.VaultRetryBackoff, time.Sleep)
+		}
+		if err != nil {
+			if authConfig.VaultStrict {
+				return nil, fmt.Errorf("failed to load secrets from vault: %w", err)
 			}
+			log.Printf("continuing without vault secrets: %v", err)
+		}
+		if jwtSecret, ok := secrets["JWT_SECRET"]; ok {
+			authConfig.JWTSecret = jwtSecret
+		}
+		if googleSecret, ok := secrets["GOOGLE_CLIENT_SECRET"]; ok {
+			authConfig.GoogleClientSecret = googleSecret
+		}
+		if mfaKey, ok := secrets["MFA_ENCRYPTION_KEY"]; ok {
+			authConfig.MFAEncryptionKey = mfaKey
 		}
+		if authConfig.VaultStrict && strings.TrimSpace(secrets["JWT_SECRET"]) == "" {
+			return nil, fmt.Errorf("VAULT_STRICT is set but vault returned no JWT_SECRET")
+		}
+	} else if authConfig.VaultStrict {
+		return nil, fmt.Errorf("VAULT_STRICT requires VAULT_ADDR and VAULT_TOKEN")
+	}
+
+	authConfig.MFAEnabled = getEnvBool("MFA_ENABLED", false)
+	authConfig.TOTPIssuer = getEnvDefault("TOTP_ISSUER", "Lee-Tech")
+	authConfig.MaxLoginAttempts = getEnvInt("MAX_LOGIN_ATTEMPTS", 5)
+	authConfig.LockoutDuration = getEnvDuration("LOCKOUT_DURATION", 15*time.Minute)
+	authConfig.MaxMFAAttempts = getEnvInt("MAX_MFA_ATTEMPTS", 5)
+	authConfig.MFAChallengeTokenTTL = getEnvDuration("MFA_CHALLENGE_TOKEN_TTL", 5*time.Minute)
+	if authConfig.MFAEncryptionKey == "" {
+		authConfig.MFAEncryptionKey = getEnvDefault("MFA_ENCRYPTION_KEY", "")
+	}
+	if authConfig.MFAEnabled && authConfig.MFAEncryptionKey == "" {
+		return nil, fmt.Errorf("MFA_ENABLED requires MFA_ENCRYPTION_KEY")
 	}
 
 	applyBootstrapDefaults(authConfig)
+	applyInactivityLockDefaults(authConfig)
+	if err := applyDepartmentKindDefaults(authConfig); err != nil {
+		return nil, err
+	}
+	authConfig.DefaultTenantTier = getEnvDefault("DEFAULT_TENANT_TIER", "basic")
+	authConfig.StrictAuthorization = getEnvBool("STRICT_AUTHORIZATION", false)
+	authConfig.AuthorizationTracePropagation = getEnvBool("AUTHORIZATION_TRACE_PROPAGATION", false)
+	authConfig.AuthorizationFallbackSlug = getEnvDefault("AUTHORIZATION_FALLBACK_SLUG", "")
+	overrides, err := parseAuthorizationOverrides(os.Getenv("AUTHORIZATION_OVERRIDES"))
+	if err != nil {
+		return nil, err
+	}
+	authConfig.AuthorizationOverrides = overrides
+	claimNames, err := parseClaimNames(os.Getenv("CLAIM_NAMES"))
+	if err != nil {
+		return nil, err
+	}
+	authConfig.ClaimNames = claimNames
+	roleScopes, err := parseRoleScopes(os.Getenv("ROLE_SCOPES"))
+	if err != nil {
+		return nil, err
+	}
+	authConfig.RoleScopes = roleScopes
+	authConfig.StepUpTokenTTL = getEnvDuration("STEP_UP_TOKEN_TTL", 5*time.Minute)
+	authConfig.PasswordBreachCheckEnabled = getEnvBool("PASSWORD_BREACH_CHECK_ENABLED", false)
+	authConfig.PasswordBreachAPIURL = getEnvDefault("PASSWORD_BREACH_API_URL", "https://api.pwnedpasswords.com/range")
+	authConfig.PasswordBreachFailClosed = getEnvBool("PASSWORD_BREACH_FAIL_CLOSED", false)
+	authConfig.PasswordBreachCacheTTL = getEnvDuration("PASSWORD_BREACH_CACHE_TTL", time.Hour)
+	authConfig.VerificationResendInterval = getEnvDuration("VERIFICATION_RESEND_INTERVAL", time.Hour)
+	authConfig.PasswordResetTTL = getEnvDuration("PASSWORD_RESET_TTL", time.Hour)
+	authConfig.PasswordHistorySize = getEnvInt("PASSWORD_HISTORY_SIZE", 5)
+	authConfig.PasswordMinLength = getEnvInt("PASSWORD_MIN_LENGTH", 8)
+	authConfig.PasswordMinEntropyBits = getEnvInt("PASSWORD_MIN_ENTROPY_BITS", 0)
+	authConfig.PasswordRequireUpper = getEnvBool("PASSWORD_REQUIRE_UPPER", false)
+	authConfig.PasswordRequireDigit = getEnvBool("PASSWORD_REQUIRE_DIGIT", false)
+	authConfig.PasswordRequireSymbol = getEnvBool("PASSWORD_REQUIRE_SYMBOL", false)
+	authConfig.PasswordRejectCommon = getEnvBool("PASSWORD_REJECT_COMMON", false)
+	authConfig.BCryptCost = getEnvInt("BCRYPT_COST", 10)
+	authConfig.VerificationTokenTTL = getEnvDuration("VERIFICATION_TOKEN_TTL", 24*time.Hour)
+	authConfig.RegistrationEnabled = getEnvBool("REGISTRATION_ENABLED", false)
+	authConfig.RequireEmailVerification = getEnvBool("REQUIRE_EMAIL_VERIFICATION", authConfig.RegistrationEnabled)
+	authConfig.SMTPHost = getEnvDefault("SMTP_HOST", "")
+	authConfig.SMTPPort = getEnvInt("SMTP_PORT", 587)
+	authConfig.SMTPUsername = getEnvDefault("SMTP_USERNAME", "")
+	authConfig.SMTPPassword = getEnvDefault("SMTP_PASSWORD", "")
+	authConfig.SMTPFrom = getEnvDefault("SMTP_FROM", "")
+	authConfig.MailDelivery = strings.ToLower(strings.TrimSpace(getEnvDefault("MAIL_DELIVERY", defaultMailDelivery(authConfig))))
+	authConfig.ErrorVerbosity = getEnvDefault("ERROR_VERBOSITY", "minimal")
+	authConfig.LoginEmailCaseInsensitive = getEnvBool("LOGIN_EMAIL_CASE_INSENSITIVE", false)
+	authConfig.MembershipRemovalMode = strings.ToLower(strings.TrimSpace(getEnvDefault("MEMBERSHIP_REMOVAL_MODE", MembershipRemovalHard)))
+	authConfig.LoginSelectionEnabled = getEnvBool("LOGIN_SELECTION_ENABLED", false)
+	authConfig.LoginSelectionTokenTTL = getEnvDuration("LOGIN_SELECTION_TOKEN_TTL", 5*time.Minute)
+	authConfig.OAuthEnabled = getEnvBool("OAUTH_ENABLED", false)
+	authConfig.GoogleClientID = getEnvDefault("GOOGLE_CLIENT_ID", "")
+	if authConfig.GoogleClientSecret == "" {
+		authConfig.GoogleClientSecret = getEnvDefault("GOOGLE_CLIENT_SECRET", "")
+	}
+	authConfig.GoogleRedirectURL = getEnvDefault("GOOGLE_REDIRECT_URL", "")
+	authConfig.OAuthStateTTL = getEnvDuration("OAUTH_STATE_TTL", 10*time.Minute)
+	authConfig.OAuthRequirePKCE = getEnvBool("OAUTH_REQUIRE_PKCE", false)
+	authConfig.RedirectAllowedOrigins = getEnvList("REDIRECT_ALLOWED_ORIGINS")
+	authConfig.HideLockedAccounts = getEnvBool("HIDE_LOCKED_ACCOUNTS", false)
+	authConfig.TrustedClientKey = getEnvDefault("TRUSTED_CLIENT_KEY", "")
+	authConfig.LoginHistoryWindow = getEnvDuration("LOGIN_HISTORY_WINDOW", 30*24*time.Hour)
+	authConfig.ConcurrentLoginDetection = getEnvBool("CONCURRENT_LOGIN_DETECTION", false)
+	authConfig.ConcurrentLoginWindow = getEnvDuration("CONCURRENT_LOGIN_WINDOW", time.Hour)
+	authConfig.ConcurrentLoginIPv4Prefix = getEnvInt("CONCURRENT_LOGIN_IPV4_PREFIX", 16)
+	authConfig.ConcurrentLoginIPv6Prefix = getEnvInt("CONCURRENT_LOGIN_IPV6_PREFIX", 48)
+	authConfig.TokenOrganizationBinding = getEnvBool("TOKEN_ORGANIZATION_BINDING", false)
+	authConfig.LoginRateLimit = getEnvInt("LOGIN_RATE_LIMIT", 10)
+	authConfig.LoginRateWindow = getEnvDuration("LOGIN_RATE_WINDOW", time.Minute)
+	authConfig.TrustProxyHeaders = getEnvBool("TRUST_PROXY_HEADERS", false)
+	authConfig.LoginRateLimitByUsername = getEnvBool("LOGIN_RATE_LIMIT_BY_USERNAME", false)
+	authConfig.LoginRateLimitStore = strings.ToLower(strings.TrimSpace(getEnvDefault("LOGIN_RATE_LIMIT_STORE", RateLimitStoreMemory)))
+	authConfig.RedisAddr = getEnvDefault("REDIS_ADDR", "")
+	authConfig.RedisPassword = getEnvDefault("REDIS_PASSWORD", "")
+	authConfig.RedisDB = getEnvInt("REDIS_DB", 0)
+	authConfig.JWTAlgorithm = strings.ToUpper(getEnvDefault("JWT_SIGNING_METHOD", getEnvDefault("JWT_ALGORITHM", "HS256")))
+	authConfig.JWTIssuer = getEnvDefault("JWT_ISSUER", authConfig.Config.ServiceName)
+	authConfig.JWTPrivateKeyPath = getEnvDefault("JWT_PRIVATE_KEY_PATH", "")
+	authConfig.JWTPublicKeyPath = getEnvDefault("JWT_PUBLIC_KEY_PATH", "")
+	jwtSecrets, err := parseJWTSecrets(os.Getenv("JWT_SECRETS"))
+	if err != nil {
+		return nil, err
+	}
+	authConfig.JWTSecrets = jwtSecrets
+	authConfig.JWTSecretKID = strings.TrimSpace(getEnvDefault("JWT_SECRET_KID", ""))
+	if len(jwtSecrets) > 0 {
+		current, ok := jwtSecrets[authConfig.JWTSecretKID]
+		if !ok {
+			return nil, fmt.Errorf("JWT_SECRET_KID %q must name one of the JWT_SECRETS entries", authConfig.JWTSecretKID)
+		}
+		authConfig.JWTSecret = current
+	}
+	authConfig.TokenExpiration = getEnvDuration("TOKEN_EXPIRATION", 15*time.Minute)
+	authConfig.RefreshExpiration = getEnvDuration("REFRESH_EXPIRATION", 7*24*time.Hour)
+	authConfig.IntrospectionSecret, authConfig.IntrospectionPreviousSecret = LoadIntrospectionSecrets(authConfig.JWTSecret)
+	authConfig.RevokedTokenCleanupInterval = getEnvDuration("REVOKED_TOKEN_CLEANUP_INTERVAL", time.Hour)
+	authConfig.IntrospectionSecretGracePeriod = getEnvDuration("INTROSPECTION_SECRET_GRACE_PERIOD", 24*time.Hour)
+	authConfig.IntrospectionClientID = getEnvDefault("INTROSPECTION_CLIENT_ID", "")
+	if authConfig.IntrospectionClientSecret == "" {
+		authConfig.IntrospectionClientSecret = getEnvDefault("INTROSPECTION_CLIENT_SECRET", "")
+	}
+
+	if err := validateSigningConfig(authConfig); err != nil {
+		return nil, err
+	}
+	switch authConfig.MembershipRemovalMode {
+	case MembershipRemovalHard, MembershipRemovalSoft:
+	default:
+		return nil, fmt.Errorf("unknown MEMBERSHIP_REMOVAL_MODE %q: use %q or %q", authConfig.MembershipRemovalMode, MembershipRemovalHard, MembershipRemovalSoft)
+	}
+	switch authConfig.LoginRateLimitStore {
+	case RateLimitStoreMemory:
+	case RateLimitStoreRedis:
+		if authConfig.RedisAddr == "" {
+			return nil, fmt.Errorf("LOGIN_RATE_LIMIT_STORE=%s requires REDIS_ADDR", RateLimitStoreRedis)
+		}
+	default:
+		return nil, fmt.Errorf("unknown LOGIN_RATE_LIMIT_STORE %q: use %q or %q", authConfig.LoginRateLimitStore, RateLimitStoreMemory, RateLimitStoreRedis)
+	}
+	switch authConfig.MailDelivery {
+	case MailDeliveryLog, MailDeliveryNone:
+	case MailDeliverySMTP:
+		if authConfig.SMTPHost == "" || authConfig.SMTPFrom == "" {
+			return nil, fmt.Errorf("MAIL_DELIVERY=%s requires SMTP_HOST and SMTP_FROM", MailDeliverySMTP)
+		}
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DELIVERY %q: use %q, %q or %q", authConfig.MailDelivery, MailDeliverySMTP, MailDeliveryLog, MailDeliveryNone)
+	}
 
 	return authConfig, nil
 }
 
+// validateSigningConfig fails fast on token signing settings the service cannot honour and loads the
+// RS256 key pair.
+func validateSigningConfig(cfg *AuthConfig) error {
+	switch cfg.JWTAlgorithm {
+	case "HS256":
+		if strings.TrimSpace(cfg.JWTSecret) == "" {
+			return fmt.Errorf("JWT_SECRET is required for HS256 token signing")
+		}
+		return nil
+	case "RS256":
+		return loadRSAKeys(cfg)
+	case "ES256":
+		return fmt.Errorf("JWT_SIGNING_METHOD %s is not supported: use HS256 or RS256", cfg.JWTAlgorithm)
+	default:
+		return fmt.Errorf("unknown JWT_SIGNING_METHOD %q", cfg.JWTAlgorithm)
+	}
+}
+
+// minRSAKeyBits is the smallest RSA modulus accepted for RS256 signing.
+const minRSAKeyBits = 2048
+
+// loadRSAKeys reads the PEM encoded RS256 key pair and checks that the keys belong together.
+func loadRSAKeys(cfg *AuthConfig) error {
+	if cfg.JWTPrivateKeyPath == "" || cfg.JWTPublicKeyPath == "" {
+		return fmt.Errorf("JWT_PRIVATE_KEY_PATH and JWT_PUBLIC_KEY_PATH are required for RS256 token signing")
+	}
+
+	privatePEM, err := os.ReadFile(cfg.JWTPrivateKeyPath)
+	if err != nil {
+		return fmt.Errorf("read JWT private key: %w", err)
+	}
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+	if err != nil {
+		return fmt.Errorf("parse JWT private key: %w", err)
+	}
+	if bits := privateKey.N.BitLen(); bits < minRSAKeyBits {
+		return fmt.Errorf("JWT private key is %d bits; RS256 signing requires at least %d", bits, minRSAKeyBits)
+	}
+
+	publicPEM, err := os.ReadFile(cfg.JWTPublicKeyPath)
+	if err != nil {
+		return fmt.Errorf("read JWT public key: %w", err)
+	}
+	publicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicPEM)
+	if err != nil {
+		return fmt.Errorf("parse JWT public key: %w", err)
+	}
+
+	if !privateKey.PublicKey.Equal(publicKey) {
+		return fmt.Errorf("JWT_PUBLIC_KEY_PATH does not hold the public key of JWT_PRIVATE_KEY_PATH")
+	}
+	cfg.JWTPrivateKey = privateKey
+	cfg.JWTPublicKey = publicKey
+	return nil
+}
+
+// parseAuthorizationOverrides decodes AUTHORIZATION_OVERRIDES, a JSON object mapping route templates
+// or names to {"action": ..., "resource": ...}.
+func parseAuthorizationOverrides(raw string) (map[string]AuthorizationRouteOverride, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var decoded map[string]AuthorizationRouteOverride
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+		return nil, fmt.Errorf("invalid AUTHORIZATION_OVERRIDES: %w", err)
+	}
+
+	overrides := make(map[string]AuthorizationRouteOverride, len(decoded))
+	for route, override := range decoded {
+		route = strings.TrimSpace(route)
+		override.Action = strings.TrimSpace(override.Action)
+		override.Resource = strings.TrimSpace(override.Resource)
+		if route == "" {
+			return nil, fmt.Errorf("invalid AUTHORIZATION_OVERRIDES: empty route key")
+		}
+		if override.Action == "" || override.Resource == "" {
+			return nil, fmt.Errorf("invalid AUTHORIZATION_OVERRIDES: route %q requires both action and resource", route)
+		}
+		overrides[route] = override
+	}
+	return overrides, nil
+}
+
+// vaultSecrets are the keys read from Vault at startup.
+var vaultSecrets = []string{
+	"JWT_SECRET",
+	"GOOGLE_CLIENT_SECRET",
+	"MFA_ENCRYPTION_KEY",
+}
+
+// secretsProvider is the part of the Vault client used at startup.
+type secretsProvider interface {
+	GetSecrets(ctx context.Context, keys []string) (map[string]string, error)
+}
+
+// loadVaultSecrets reads vaultSecrets from provider, making up to attempts tries. The wait between tries
+// starts at backoff and doubles after each failure. Every attempt is logged.
+func loadVaultSecrets(provider secretsProvider, attempts int, backoff time.Duration, sleep func(time.Duration)) (map[string]string, error) {
+	if attempts < 1 {
+		attempts = 1
+	}
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var secrets map[string]string
+		secrets, err = provider.GetSecrets(context.Background(), vaultSecrets)
+		if err == nil {
+			log.Printf("loaded secrets from vault (attempt %d/%d)", attempt, attempts)
+			return secrets, nil
+		}
+		log.Printf("vault secret loading failed (attempt %d/%d): %v", attempt, attempts, err)
+		if attempt < attempts && backoff > 0 {
+			sleep(backoff)
+			backoff *= 2
+		}
+	}
+	return nil, err
+}
+
+// LoadIntrospectionSecrets reads the current and previous introspection secrets from the environment.
+// The current secret falls back to jwtSecret. It is also called on configuration reloads so a rotated
+// secret reaches the running introspection handler.
+func LoadIntrospectionSecrets(jwtSecret string) (current, previous string) {
+	return getEnvDefault("INTROSPECTION_SECRET", jwtSecret), getEnvDefault("INTROSPECTION_PREVIOUS_SECRET", "")
+}
+
+// RenamableClaims are the access token membership claims CLAIM_NAMES may rename.
+var RenamableClaims = []string{"organizations", "departments", "roles"}
+
+// reservedClaims are emitted or read by the service and cannot be used as a claim name.
+var reservedClaims = map[string]struct{}{
+	"iss": {}, "sub": {}, "aud": {}, "exp": {}, "iat": {}, "iat_us": {}, "nbf": {}, "jti": {}, "type": {}, "amr": {},
+	"user_id": {}, "email": {}, "username": {}, "org_id": {}, "tenant_tier": {}, "is_super_admin": {},
+	"scopes": {},
+}
+
+// parseJWTSecrets decodes JWT_SECRETS, a JSON object mapping kids to HS256 secrets,
+// e.g. {"2024-10": "old-secret", "2025-04": "new-secret"}.
+func parseJWTSecrets(raw string) (map[string]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+		return nil, fmt.Errorf("invalid JWT_SECRETS: %w", err)
+	}
+
+	secrets := make(map[string]string, len(decoded))
+	for kid, secret := range decoded {
+		kid = strings.TrimSpace(kid)
+		if kid == "" || strings.TrimSpace(secret) == "" {
+			return nil, fmt.Errorf("invalid JWT_SECRETS: kids and secrets must not be empty")
+		}
+		secrets[kid] = secret
+	}
+	return secrets, nil
+}
+
+// parseClaimNames decodes CLAIM_NAMES, a JSON object mapping default membership claim names to the
+// names emitted instead, e.g. {"organizations": "orgs", "roles": "groups"}.
+func parseClaimNames(raw string) (map[string]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+		return nil, fmt.Errorf("invalid CLAIM_NAMES: %w", err)
+	}
+
+	names := make(map[string]string, len(decoded))
+	for claim, name := range decoded {
+		claim = strings.TrimSpace(claim)
+		name = strings.TrimSpace(name)
+		if !slices.Contains(RenamableClaims, claim) {
+			return nil, fmt.Errorf("invalid CLAIM_NAMES: claim %q cannot be renamed (allowed: %s)", claim, strings.Join(RenamableClaims, ", "))
+		}
+		if name == "" {
+			return nil, fmt.Errorf("invalid CLAIM_NAMES: claim %q requires a name", claim)
+		}
+		if _, reserved := reservedClaims[name]; reserved {
+			return nil, fmt.Errorf("invalid CLAIM_NAMES: %q is a reserved claim", name)
+		}
+		names[claim] = name
+	}
+
+	// Every membership claim must still end up with a distinct name
+	seen := make(map[string]string, len(RenamableClaims))
+	for _, claim := range RenamableClaims {
+		name := claim
+		if renamed, ok := names[claim]; ok {
+			name = renamed
+		}
+		if other, taken := seen[name]; taken {
+			return nil, fmt.Errorf("invalid CLAIM_NAMES: %q and %q would both be emitted as %q", other, claim, name)
+		}
+		seen[name] = claim
+	}
+	return names, nil
+}
+
+// parseRoleScopes decodes ROLE_SCOPES, a JSON object mapping organization role codes to the scopes granted
+// to tokens issued for an organization where the user holds that role, e.g. {"AUDITOR": ["auth.audit.read"]}.
+func parseRoleScopes(raw string) (map[string][]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var decoded map[string][]string
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+		return nil, fmt.Errorf("invalid ROLE_SCOPES: %w", err)
+	}
+
+	roleScopes := make(map[string][]string, len(decoded))
+	for role, scopes := range decoded {
+		role = strings.TrimSpace(role)
+		if role == "" {
+			return nil, fmt.Errorf("invalid ROLE_SCOPES: role codes must not be empty")
+		}
+		cleaned := make([]string, 0, len(scopes))
+		for _, scope := range scopes {
+			scope = strings.TrimSpace(scope)
+			if scope == "" || strings.ContainsAny(scope, " \t") {
+				return nil, fmt.Errorf("invalid ROLE_SCOPES: role %q has an empty scope or one containing spaces", role)
+			}
+			if !slices.Contains(cleaned, scope) {
+				cleaned = append(cleaned, scope)
+			}
+		}
+		roleScopes[role] = cleaned
+	}
+	return roleScopes, nil
+}
+
 // NewWatcher creates a configuration watcher for the auth service
 func NewWatcher(cfg *coreConfig.Config) (*coreConfig.Watcher, error) {
 	return coreConfig.NewWatcher(cfg)
 }
 
+// DefaultBootstrapAdminEmail is the shipped bootstrap admin address, which receives no mail.
+const DefaultBootstrapAdminEmail = "admin@root.local"
+
+// IsProduction reports whether the service runs with ENVIRONMENT set to production.
+func (c *AuthConfig) IsProduction() bool {
+	switch strings.ToLower(strings.TrimSpace(c.Environment)) {
+	case "production", "prod":
+		return true
+	}
+	return false
+}
+
+// Membership removal modes accepted by MEMBERSHIP_REMOVAL_MODE.
+const (
+	MembershipRemovalHard = "hard"
+	MembershipRemovalSoft = "soft"
+)
+
+// Rate limit stores accepted by LOGIN_RATE_LIMIT_STORE.
+const (
+	RateLimitStoreMemory = "memory"
+	RateLimitStoreRedis  = "redis"
+)
+
+// Mail delivery channels accepted by MAIL_DELIVERY.
+const (
+	MailDeliverySMTP = "smtp"
+	MailDeliveryLog  = "log"
+	MailDeliveryNone = "none"
+)
+
+// defaultMailDelivery uses SMTP when a host is configured and otherwise logs tokens, except in
+// production where nothing is delivered until a channel is chosen.
+func defaultMailDelivery(cfg *AuthConfig) string {
+	switch {
+	case strings.TrimSpace(cfg.SMTPHost) != "":
+		return MailDeliverySMTP
+	case cfg.IsProduction():
+		return MailDeliveryNone
+	default:
+		return MailDeliveryLog
+	}
+}
+
+// SoftDeleteMemberships reports whether removed memberships are kept as soft-deleted rows.
+func (c *AuthConfig) SoftDeleteMemberships() bool {
+	return c.MembershipRemovalMode == MembershipRemovalSoft
+}
+
 func applyBootstrapDefaults(cfg *AuthConfig) {
 	if cfg == nil {
 		return
@@ -93,16 +732,110 @@ func applyBootstrapDefaults(cfg *AuthConfig) {
 	cfg.BootstrapOrganizationName = getEnvDefault("BOOTSTRAP_ORG_NAME", "Root Organization")
 	cfg.BootstrapOrganizationDescription = getEnvDefault("BOOTSTRAP_ORG_DESCRIPTION", "System root organization")
 	cfg.BootstrapOrganizationDomain = getEnvDefault("BOOTSTRAP_ORG_DOMAIN", "root.local")
-	cfg.BootstrapAdminEmail = getEnvDefault("BOOTSTRAP_ADMIN_EMAIL", "admin@root.local")
+	cfg.BootstrapAdminEmail = getEnvDefault("BOOTSTRAP_ADMIN_EMAIL", DefaultBootstrapAdminEmail)
+	cfg.BootstrapRequireCustomEmail = getEnvBool("BOOTSTRAP_REQUIRE_CUSTOM_EMAIL", true)
 	cfg.BootstrapAdminUsername = getEnvDefault("BOOTSTRAP_ADMIN_USERNAME", "root-admin")
 	cfg.BootstrapAdminPassword = getEnvDefault("BOOTSTRAP_ADMIN_PASSWORD", "ChangeMe123!")
 	cfg.BootstrapAdminFirstName = getEnvDefault("BOOTSTRAP_ADMIN_FIRST_NAME", "System")
 	cfg.BootstrapAdminLastName = getEnvDefault("BOOTSTRAP_ADMIN_LAST_NAME", "Administrator")
 }
 
+func applyInactivityLockDefaults(cfg *AuthConfig) {
+	if cfg == nil {
+		return
+	}
+
+	cfg.InactivityLockDays = getEnvInt("INACTIVITY_LOCK_DAYS", 0)
+	cfg.InactivityLockGraceDays = getEnvInt("INACTIVITY_LOCK_GRACE_DAYS", cfg.InactivityLockDays)
+	cfg.InactivityLockInterval = getEnvDuration("INACTIVITY_LOCK_INTERVAL", time.Hour)
+	cfg.InactivityLockSuperAdmins = getEnvBool("INACTIVITY_LOCK_SUPER_ADMINS", false)
+	cfg.SecurityWebhookURL = getEnvDefault("SECURITY_WEBHOOK_URL", "")
+}
+
+func applyDepartmentKindDefaults(cfg *AuthConfig) error {
+	if cfg == nil {
+		return nil
+	}
+
+	cfg.DepartmentKindValidation = getEnvBool("DEPARTMENT_KIND_VALIDATION", false)
+	cfg.MaxHierarchyDepth = getEnvInt("MAX_HIERARCHY_DEPTH", 10)
+	cfg.OrganizationDeactivationCascade = getEnvBool("ORGANIZATION_DEACTIVATION_CASCADE", false)
+	cfg.DepartmentListingRequiresOrgMembership = getEnvBool("DEPARTMENT_LISTING_REQUIRE_ORG_MEMBERSHIP", true)
+	rules, err := parseKindRules(getEnvDefault("DEPARTMENT_KIND_RULES", "ROOT=DEPARTMENT;DEPARTMENT=DIVISION,TEAM;DIVISION=TEAM;TEAM="))
+	if err != nil {
+		return err
+	}
+	cfg.DepartmentKindRules = rules
+	return nil
+}
+
+// parseKindRules parses "PARENT=CHILD,CHILD;PARENT=..." into a lookup of allowed child kinds. Empty
+// entries are ignored; an entry without "=" or a parent, or a parent listed twice, is an error.
+func parseKindRules(value string) (map[string][]string, error) {
+	rules := make(map[string][]string)
+	for _, entry := range strings.Split(value, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		parent, children, found := strings.Cut(entry, "=")
+		parent = strings.ToUpper(strings.TrimSpace(parent))
+		if !found || parent == "" {
+			return nil, fmt.Errorf("invalid DEPARTMENT_KIND_RULES: entry %q must be PARENT=CHILD,CHILD", strings.TrimSpace(entry))
+		}
+		if _, exists := rules[parent]; exists {
+			return nil, fmt.Errorf("invalid DEPARTMENT_KIND_RULES: parent %q is listed twice", parent)
+		}
+		allowed := make([]string, 0)
+		for _, child := range strings.Split(children, ",") {
+			if child = strings.ToUpper(strings.TrimSpace(child)); child != "" {
+				allowed = append(allowed, child)
+			}
+		}
+		rules[parent] = allowed
+	}
+	return rules, nil
+}
+
 func getEnvDefault(key, fallback string) string {
 	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
 		return value
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeRSAKeyPair writes a freshly generated key pair of the given size as PEM files.
+func writeRSAKeyPair(t *testing.T, bits int) (string, string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatalf("generate %d-bit key: %v", bits, err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "private.pem")
+	publicPath := filepath.Join(dir, "public.pem")
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	if err := os.WriteFile(privatePath, privatePEM, 0o600); err != nil {
+		t.Fatalf("write private key: %v", err)
+	}
+	if err := os.WriteFile(publicPath, publicPEM, 0o600); err != nil {
+		t.Fatalf("write public key: %v", err)
+	}
+	return privatePath, publicPath
+}
+
+func TestLoadRSAKeys(t *testing.T) {
+	weakPrivate, weakPublic := writeRSAKeyPair(t, 1024)
+	strongPrivate, strongPublic := writeRSAKeyPair(t, 2048)
+
+	tests := []struct {
+		name        string
+		privatePath string
+		publicPath  string
+		wantErr     string
+	}{
+		{name: "missing paths", wantErr: "are required"},
+		{name: "1024-bit key rejected", privatePath: weakPrivate, publicPath: weakPublic, wantErr: "at least 2048"},
+		{name: "mismatched pair", privatePath: strongPrivate, publicPath: weakPublic, wantErr: "does not hold the public key"},
+		{name: "2048-bit key accepted", privatePath: strongPrivate, publicPath: strongPublic},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &AuthConfig{JWTPrivateKeyPath: tt.privatePath, JWTPublicKeyPath: tt.publicPath}
+			err := loadRSAKeys(cfg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadRSAKeys() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadRSAKeys() error = %v", err)
+			}
+			if cfg.JWTPrivateKey == nil || cfg.JWTPublicKey == nil {
+				t.Fatal("loadRSAKeys() did not store the key pair")
+			}
+		})
+	}
+}
+
+func TestParseJWTSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    map[string]string
+		wantErr string
+	}{
+		{name: "unset"},
+		{name: "keyed secrets", raw: `{"2024-10":"old-secret"," 2025-04 ":"new-secret"}`, want: map[string]string{"2024-10": "old-secret", "2025-04": "new-secret"}},
+		{name: "invalid JSON", raw: `["old-secret"]`, wantErr: "invalid JWT_SECRETS"},
+		{name: "empty kid", raw: `{" ":"old-secret"}`, wantErr: "must not be empty"},
+		{name: "empty secret", raw: `{"2024-10":""}`, wantErr: "must not be empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJWTSecrets(tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for kid, secret := range tt.want {
+				if got[kid] != secret {
+					t.Errorf("secret %q = %q, want %q", kid, got[kid], secret)
+				}
+			}
+		})
+	}
+}
+
+// flakyProvider fails its first `failures` calls, each with a distinct error, and then returns secrets.
+type flakyProvider struct {
+	failures int
+	calls    int
+	secrets  map[string]string
+}
+
+func (p *flakyProvider) GetSecrets(_ context.Context, _ []string) (map[string]string, error) {
+	p.calls++
+	if p.calls <= p.failures {
+		return nil, fmt.Errorf("vault unavailable (call %d)", p.calls)
+	}
+	return p.secrets, nil
+}
+
+func TestLoadVaultSecretsRetries(t *testing.T) {
+	provider := &flakyProvider{failures: 2, secrets: map[string]string{"JWT_SECRET": "vault-secret"}}
+	var waits []time.Duration
+
+	secrets, err := loadVaultSecrets(provider, 3, time.Second, func(d time.Duration) { waits = append(waits, d) })
+	if err != nil {
+		t.Fatalf("loadVaultSecrets() error = %v", err)
+	}
+	if secrets["JWT_SECRET"] != "vault-secret" {
+		t.Errorf("secrets = %v, want the provider's secrets", secrets)
+	}
+	if provider.calls != 3 {
+		t.Errorf("calls = %d, want 3", provider.calls)
+	}
+	if want := []time.Duration{time.Second, 2 * time.Second}; !slices.Equal(waits, want) {
+		t.Errorf("waits = %v, want %v", waits, want)
+	}
+}
+
+func TestLoadVaultSecretsExhaustsRetries(t *testing.T) {
+	provider := &flakyProvider{failures: 5}
+	var waits int
+
+	_, err := loadVaultSecrets(provider, 3, time.Second, func(time.Duration) { waits++ })
+	if err == nil || err.Error() != "vault unavailable (call 3)" {
+		t.Fatalf("loadVaultSecrets() error = %v, want the last attempt's error", err)
+	}
+	if provider.calls != 3 || waits != 2 {
+		t.Errorf("calls = %d, waits = %d, want 3 and 2", provider.calls, waits)
+	}
+}
+
+func TestParseKindRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    map[string][]string
+		wantErr string
+	}{
+		{name: "empty", raw: "", want: map[string][]string{}},
+		{
+			name: "defaults",
+			raw:  "ROOT=DEPARTMENT;DEPARTMENT=DIVISION,TEAM;DIVISION=TEAM;TEAM=",
+			want: map[string][]string{"ROOT": {"DEPARTMENT"}, "DEPARTMENT": {"DIVISION", "TEAM"}, "DIVISION": {"TEAM"}, "TEAM": {}},
+		},
+		{name: "case and spacing", raw: " root = department , team ;; ", want: map[string][]string{"ROOT": {"DEPARTMENT", "TEAM"}}},
+		{name: "missing separator", raw: "ROOT=DEPARTMENT;DEPARTMENT DIVISION", wantErr: `entry "DEPARTMENT DIVISION"`},
+		{name: "missing parent", raw: "=TEAM", wantErr: `entry "=TEAM"`},
+		{name: "parent listed twice", raw: "ROOT=DEPARTMENT;root=TEAM", wantErr: `parent "ROOT" is listed twice`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseKindRules(tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !maps.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseClaimNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    map[string]string
+		wantErr string
+	}{
+		{name: "empty", raw: " "},
+		{name: "renamed", raw: `{" organizations ":" orgs ","roles":"groups"}`, want: map[string]string{"organizations": "orgs", "roles": "groups"}},
+		{name: "swapped names", raw: `{"organizations":"roles","roles":"organizations"}`, want: map[string]string{"organizations": "roles", "roles": "organizations"}},
+		{name: "malformed", raw: `{"organizations":`, wantErr: "invalid CLAIM_NAMES"},
+		{name: "not renamable", raw: `{"email":"mail"}`, wantErr: `claim "email" cannot be renamed`},
+		{name: "blank name", raw: `{"roles":" "}`, wantErr: `claim "roles" requires a name`},
+		{name: "reserved name", raw: `{"roles":"sub"}`, wantErr: `"sub" is a reserved claim`},
+		{name: "scopes is reserved", raw: `{"roles":"scopes"}`, wantErr: `"scopes" is a reserved claim`},
+		{name: "collision", raw: `{"roles":"departments"}`, wantErr: `would both be emitted as "departments"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseClaimNames(tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,15 +5,21 @@ var ComponentKey = struct {
 	AuthenticationConfig      string
 	AuthenticationUserRepo    string
 	OrganizationRepository    string
+	AuditRepository           string
 	OrganizationService       string
 	AdminAuthorizationBuilder string
 	AuthorizationEnabled      string
+	AuthorizationUnavailable  string
+	RouteCatalog              string
 }{
 	AuthenticationService:     "authentication.service.authentication",
 	AuthenticationConfig:      "config.authentication",
 	AuthenticationUserRepo:    "authentication.repository.user",
 	OrganizationRepository:    "authentication.repository.organization",
+	AuditRepository:           "authentication.repository.audit",
 	OrganizationService:       "authentication.service.organization",
 	AdminAuthorizationBuilder: "authentication.authorization.builder.admin",
 	AuthorizationEnabled:      "authentication.authorization.enabled",
+	AuthorizationUnavailable:  "authentication.authorization.unavailable",
+	RouteCatalog:              "authentication.routes.catalog",
 }
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	coreServer "github.com/lee-tech/core/server"
+)
+
+// APIKey lets a machine client authenticate as its owner without a password. Only a SHA-256 digest
+// of the key is stored; Prefix is the leading part of the key, kept so owners can tell keys apart.
+// OrganizationID is the organization the key acts in, like the org_id of an access token.
+type APIKey struct {
+	ID             uint64     `gorm:"type:bigint;primaryKey;autoIncrement" json:"id"`
+	UserID         uint64     `gorm:"type:bigint;index;not null" json:"user_id"`
+	OrganizationID *uint64    `gorm:"type:bigint;index" json:"organization_id,omitempty"`
+	Name           string     `gorm:"size:100;not null" json:"name"`
+	Prefix         string     `gorm:"size:16;not null" json:"prefix"`
+	KeyHash        string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	Scopes         []string   `gorm:"serializer:json" json:"scopes"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt      *time.Time `gorm:"index" json:"expires_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// TableName pins the API key table name.
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+func init() {
+	coreServer.RegisterMigration(func() interface{} { return &APIKey{} })
+}
+
+// CreateAPIKeyRequest names a new API key and lists the scopes it is restricted to. Keys without
+// ExpiresAt do not expire.
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateAPIKeyResponse returns a new API key. Key is only ever shown in this response.
+type CreateAPIKeyResponse struct {
+	*APIKey
+	Key string `json:"key"`
+}
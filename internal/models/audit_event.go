@@ -0,0 +1,134 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	coreServer "github.com/lee-tech/core/server"
+)
+
+// Audit actions recorded by the service.
+const (
+	AuditActionInactivityLock             = "user.inactivity_lock"
+	AuditActionVerificationResend         = "user.verification_resend"
+	AuditActionPasswordReset              = "user.password_reset"
+	AuditActionPasswordResetRequest       = "user.password_reset_request"
+	AuditActionAccountLockout             = "user.account_lockout"
+	AuditActionBootstrapAdmin             = "user.bootstrap_admin"
+	AuditActionPasswordChange             = "user.password_change"
+	AuditActionOrganizationSessionsRevoke = "organization.sessions_revoke"
+	AuditActionOrganizationDeactivate     = "organization.deactivate"
+	AuditActionOrganizationReactivate     = "organization.reactivate"
+	AuditActionOrganizationRolesProvision = "organization.roles_provision"
+	AuditActionOrganizationAdminAssign    = "organization.admin_assign"
+	AuditActionOrganizationContactUpdate  = "organization.contact_update"
+	AuditActionOrganizationDomainVerify   = "organization.domain_verify"
+	AuditActionDepartmentMove             = "department.move"
+	AuditActionDepartmentUpdate           = "department.update"
+	AuditActionDepartmentDeactivate       = "department.deactivate"
+	AuditActionDepartmentReactivate       = "department.reactivate"
+	AuditActionTokenRevoke                = "token.revoke"
+	AuditActionAPIKeyIssue                = "api_key.issue"
+	AuditActionAPIKeyRevoke               = "api_key.revoke"
+	AuditActionLogout                     = "auth.logout"
+	AuditActionLoginSuccess               = "auth.login_success"
+	AuditActionLoginFailure               = "auth.login_failure"
+	AuditActionLoginPending               = "auth.login_pending"
+	AuditActionConcurrentLogin            = "auth.concurrent_login"
+	AuditActionOrganizationSwitch         = "auth.organization_switch"
+
+	AuditActionMembershipOrganizationGrant  = "membership.organization_grant"
+	AuditActionMembershipOrganizationRevoke = "membership.organization_revoke"
+	AuditActionMembershipDepartmentGrant    = "membership.department_grant"
+	AuditActionMembershipDepartmentRevoke   = "membership.department_revoke"
+	AuditActionMembershipRoleChange         = "membership.role_change"
+	AuditActionMembershipPrimaryChange      = "membership.primary_change"
+)
+
+// SecurityAuditActions lists the actions shown as notable events in a user's security posture.
+var SecurityAuditActions = []string{
+	AuditActionLoginFailure,
+	AuditActionAccountLockout,
+	AuditActionInactivityLock,
+	AuditActionConcurrentLogin,
+	AuditActionPasswordResetRequest,
+	AuditActionPasswordReset,
+	AuditActionPasswordChange,
+	AuditActionTokenRevoke,
+}
+
+// PasswordAuditActions lists the actions that set a new password.
+var PasswordAuditActions = []string{
+	AuditActionPasswordReset,
+	AuditActionPasswordChange,
+}
+
+// LoginAuditActions lists the actions that make up a user's login history.
+var LoginAuditActions = []string{
+	AuditActionLoginSuccess,
+	AuditActionLoginFailure,
+	AuditActionLoginPending,
+}
+
+// MembershipAuditActions lists the actions that make up a user's membership history.
+var MembershipAuditActions = []string{
+	AuditActionMembershipOrganizationGrant,
+	AuditActionMembershipOrganizationRevoke,
+	AuditActionMembershipDepartmentGrant,
+	AuditActionMembershipDepartmentRevoke,
+	AuditActionMembershipRoleChange,
+	AuditActionMembershipPrimaryChange,
+}
+
+// AuditActorSystem identifies actions performed by background jobs rather than a user.
+const AuditActorSystem = "system"
+
+// AuditEvent records a security-relevant action for later review.
+type AuditEvent struct {
+	ID        uint64         `gorm:"primaryKey;autoIncrement;type:bigint" json:"id"`
+	Actor     string         `gorm:"size:255;index" json:"actor"`
+	Action    string         `gorm:"size:128;index" json:"action"`
+	Target    string         `gorm:"size:255;index" json:"target,omitempty"`
+	OrgID     *uint64        `gorm:"type:bigint;index" json:"org_id,omitempty"`
+	IP        string         `gorm:"size:64" json:"ip,omitempty"`
+	Success   bool           `gorm:"not null;default:false" json:"success"`
+	Timestamp time.Time      `gorm:"index" json:"timestamp"`
+	Metadata  map[string]any `gorm:"serializer:json" json:"metadata,omitempty"`
+}
+
+// AuditEventFilter narrows an audit event query. Zero values are ignored.
+type AuditEventFilter struct {
+	Actor   string
+	Target  string
+	Actions []string
+	From    *time.Time
+	To      *time.Time
+	Offset  int
+	Limit   int
+	// Descending returns the newest events first.
+	Descending bool
+}
+
+// TableName pins the audit table name.
+func (AuditEvent) TableName() string {
+	return "audit_logs"
+}
+
+// AuditUserRef formats a user identifier for the Actor/Target columns.
+func AuditUserRef(userID uint64) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// AuditOrganizationRef formats an organization identifier for the Target column.
+func AuditOrganizationRef(orgID uint64) string {
+	return fmt.Sprintf("organization:%d", orgID)
+}
+
+// AuditDepartmentRef formats a department identifier for the Target column.
+func AuditDepartmentRef(deptID uint64) string {
+	return fmt.Sprintf("department:%d", deptID)
+}
+
+func init() {
+	coreServer.RegisterMigration(func() interface{} { return &AuditEvent{} })
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	coreServer "github.com/lee-tech/core/server"
+)
+
+// IssuedToken records the JTI of an access or refresh token the service has issued, so an administrator
+// can revoke a live session by its JTI alone. Entries are purged once the token has expired.
+type IssuedToken struct {
+	ID        uint64    `gorm:"type:bigint;primaryKey;autoIncrement" json:"id"`
+	JTI       string    `gorm:"size:64;uniqueIndex;not null" json:"jti"`
+	UserID    uint64    `gorm:"type:bigint;index;not null" json:"user_id"`
+	Type      string    `gorm:"size:16;not null" json:"type"`
+	IssuedAt  time.Time `gorm:"not null" json:"issued_at"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+}
+
+func init() {
+	coreServer.RegisterMigration(func() interface{} { return &IssuedToken{} })
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	coreServer "github.com/lee-tech/core/server"
+)
+
+// OAuthAuthorization is the server-side, single-use record of a Google sign-in in progress. The start
+// route keys it by the digest of the state sent to Google; once the callback identifies the user it is
+// replaced by a record keyed by the digest of the login code handed back to the client.
+type OAuthAuthorization struct {
+	ID      uint64  `gorm:"type:bigint;primaryKey;autoIncrement" json:"id"`
+	KeyHash string  `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	UserID  *uint64 `gorm:"type:bigint;index" json:"user_id,omitempty"`
+
+	ReturnURL           string `gorm:"size:2048;not null" json:"return_url"`
+	ClientType          string `gorm:"size:16;not null" json:"client_type"`
+	CodeChallenge       string `gorm:"size:128" json:"-"`
+	CodeChallengeMethod string `gorm:"size:8" json:"-"`
+	// ProviderVerifier is the PKCE verifier presented to Google when exchanging its authorization code.
+	ProviderVerifier string `gorm:"size:128" json:"-"`
+
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func init() {
+	coreServer.RegisterMigration(func() interface{} { return &OAuthAuthorization{} })
+}
@@ -12,8 +12,22 @@ type Organization struct {
 	ID          uint64 `json:"id" gorm:"primaryKey;autoIncrement;type:bigint"`
 	Name        string `gorm:"size:255;not null" json:"name"`
 	Description string `gorm:"size:1024" json:"description"`
-	Domain      string `gorm:"size:255;uniqueIndex" json:"domain"`
+	Domain      string `gorm:"size:255;uniqueIndex;serializer:emptyasnull" json:"domain"`
 	IsActive    bool   `gorm:"default:true" json:"is_active"`
+	Tier        string `gorm:"size:64" json:"tier,omitempty"`
+	// MinLoginRoleLevel restricts login to members whose role level is at or above this authority
+	// (lower level = higher authority). Nil disables the restriction.
+	MinLoginRoleLevel *int `json:"min_login_role_level,omitempty"`
+	// Contact details are only served by the contact endpoints, so they never leak into login responses.
+	ContactEmail string `gorm:"size:255" json:"-"`
+	ContactPhone string `gorm:"size:32" json:"-"`
+	Address      string `gorm:"size:1024" json:"-"`
+
+	// DomainVerified is set once the organization proved control of Domain through a DNS TXT record.
+	// Only verified domains are used for domain-based login discovery.
+	DomainVerified          bool       `gorm:"default:false" json:"domain_verified"`
+	DomainVerifiedAt        *time.Time `json:"domain_verified_at,omitempty"`
+	DomainVerificationToken string     `gorm:"size:128" json:"-"`
 
 	ParentID *uint64        `gorm:"type:bigint;index" json:"parent_id,omitempty"`
 	Parent   *Organization  `gorm:"constraint:OnDelete:SET NULL" json:"parent,omitempty"`
@@ -27,11 +41,21 @@ type Organization struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// Contact returns the organization's contact details.
+func (o *Organization) Contact() *OrganizationContact {
+	return &OrganizationContact{
+		OrganizationID: o.ID,
+		ContactEmail:   o.ContactEmail,
+		ContactPhone:   o.ContactPhone,
+		Address:        o.Address,
+	}
+}
+
 // Department represents a sub-division within an organization.
 type Department struct {
 	ID             uint64          `json:"id" gorm:"primaryKey;autoIncrement;type:bigint"`
-	OrganizationID uint64          `gorm:"type:bigint;index" json:"organization_id"`
-	Code           *DepartmentCode `gorm:"size:64" json:"code,omitempty"`
+	OrganizationID uint64          `gorm:"type:bigint;index;index:idx_department_organization_code" json:"organization_id"`
+	Code           *DepartmentCode `gorm:"size:64;index:idx_department_organization_code" json:"code,omitempty"`
 	Name           string          `gorm:"size:255;not null" json:"name"`
 	Kind           DepartmentKind  `gorm:"size:32;default:'DEPARTMENT'" json:"kind"`
 	Description    string          `gorm:"size:1024" json:"description"`
@@ -43,11 +67,43 @@ type Department struct {
 	Children       []Department    `gorm:"foreignKey:ParentID" json:"children,omitempty"`
 	Users          []User          `gorm:"many2many:user_departments;joinForeignKey:DepartmentID;joinReferences:UserID;constraint:OnDelete:CASCADE" json:"users,omitempty"`
 
+	// DeactivatedByOrganization marks departments switched off by an organization deactivation
+	// cascade, so reactivating the organization restores only those.
+	DeactivatedByOrganization bool `gorm:"default:false" json:"deactivated_by_organization,omitempty"`
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// OrganizationRoleDefinition is a role template provisioned for a single organization.
+type OrganizationRoleDefinition struct {
+	ID             uint64           `json:"id" gorm:"primaryKey;autoIncrement;type:bigint"`
+	OrganizationID uint64           `gorm:"type:bigint;uniqueIndex:idx_organization_role_code" json:"organization_id"`
+	Code           OrganizationRole `gorm:"size:128;not null;uniqueIndex:idx_organization_role_code" json:"code"`
+	Name           string           `gorm:"size:255;not null" json:"name"`
+	Description    string           `gorm:"size:1024" json:"description,omitempty"`
+	Level          int              `json:"level"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName pins the organization role table name.
+func (OrganizationRoleDefinition) TableName() string {
+	return "organization_roles"
+}
+
+// Template returns the role as a template without organization bookkeeping.
+func (d *OrganizationRoleDefinition) Template() OrganizationRoleTemplate {
+	return OrganizationRoleTemplate{
+		Code:        d.Code,
+		Name:        d.Name,
+		Description: d.Description,
+		Level:       d.Level,
+	}
+}
+
 // BeforeCreate ensures Kind are present on insert.
 func (d *Department) BeforeCreate(tx *gorm.DB) error {
 	if d.Kind == "" {
@@ -59,4 +115,5 @@ func (d *Department) BeforeCreate(tx *gorm.DB) error {
 func init() {
 	coreServer.RegisterMigration(func() interface{} { return &Organization{} })
 	coreServer.RegisterMigration(func() interface{} { return &Department{} })
+	coreServer.RegisterMigration(func() interface{} { return &OrganizationRoleDefinition{} })
 }
@@ -7,14 +7,16 @@ type OrganizationRole string
 const (
 	// OrganizationRoleSystemAdmin is reserved for the platform-level administrator.
 	OrganizationRoleSystemAdmin OrganizationRole = "SYSTEM_ADMIN"
+	// OrganizationRoleOrgAdmin administers a single tenant on behalf of its organization.
+	OrganizationRoleOrgAdmin OrganizationRole = "ORG_ADMIN"
 )
 
 // OrganizationRoleTemplate provides descriptive context for leadership roles.
 type OrganizationRoleTemplate struct {
-	Code        OrganizationRole
-	Name        string
-	Description string
-	Level       int // Lower value implies higher authority.
+	Code        OrganizationRole `json:"code"`
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Level       int              `json:"level"` // Lower value implies higher authority.
 }
 
 // DefaultOrganizationRoles suggests baseline leadership roles for new tenants.
@@ -33,6 +35,20 @@ var DefaultOrganizationRoles = []OrganizationRoleTemplate{
 	},
 }
 
+// RoleLevel resolves the authority level of a role from the role templates.
+// SYSTEM_ADMIN and ORG_ADMIN rank above every template role.
+func RoleLevel(role OrganizationRole) (int, bool) {
+	if role == OrganizationRoleSystemAdmin || role == OrganizationRoleOrgAdmin {
+		return 0, true
+	}
+	for _, template := range DefaultOrganizationRoles {
+		if template.Code == role {
+			return template.Level, true
+		}
+	}
+	return 0, false
+}
+
 // DepartmentKind classifies departments versus their child units.
 type DepartmentKind string
 
@@ -51,13 +67,32 @@ type DepartmentCode string
 
 // DepartmentDefinition captures the canonical structure expected for tenants.
 type DepartmentDefinition struct {
-	Code        DepartmentCode
-	Name        string
-	Kind        DepartmentKind
-	Description string
-	Function    string
-	Parent      *DepartmentCode
-	Children    []DepartmentDefinition
+	Code        DepartmentCode         `json:"code,omitempty"`
+	Name        string                 `json:"name"`
+	Kind        DepartmentKind         `json:"kind"`
+	Description string                 `json:"description,omitempty"`
+	Function    string                 `json:"function,omitempty"`
+	IsActive    *bool                  `json:"is_active,omitempty"`
+	Parent      *DepartmentCode        `json:"parent,omitempty"`
+	Children    []DepartmentDefinition `json:"children,omitempty"`
+}
+
+// OrganizationStructureExport is a portable snapshot of an organization's structure.
+// It deliberately excludes users and memberships so it can be imported into another tenant.
+type OrganizationStructureExport struct {
+	Organization OrganizationStructureInfo  `json:"organization"`
+	Departments  []DepartmentDefinition     `json:"departments"`
+	Roles        []OrganizationRoleTemplate `json:"roles"`
+	// Truncated is set when the department tree was cut at the configured maximum depth.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// OrganizationStructureInfo describes the exported organization itself.
+type OrganizationStructureInfo struct {
+	ID          uint64 `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Domain      string `json:"domain,omitempty"`
 }
 
 // DefaultDepartmentStructure enumerates the recommended departments and their functions
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	coreServer "github.com/lee-tech/core/server"
+)
+
+// PasswordHistory keeps a previous bcrypt password hash so recently used passwords can be refused.
+type PasswordHistory struct {
+	ID           uint64    `gorm:"type:bigint;primaryKey;autoIncrement" json:"id"`
+	UserID       uint64    `gorm:"type:bigint;index;not null" json:"user_id"`
+	PasswordHash string    `gorm:"size:255;not null" json:"-"`
+	CreatedAt    time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName pins the password history table name.
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
+
+func init() {
+	coreServer.RegisterMigration(func() interface{} { return &PasswordHistory{} })
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	coreServer "github.com/lee-tech/core/server"
 )
 
@@ -22,26 +24,99 @@ type DepartmentMembershipInfo struct {
 
 // UserInfo represents public user information
 type UserInfo struct {
-	ID                    uint64                       `json:"id"`
-	Email                 string                       `json:"email"`
-	Username              string                       `json:"username"`
-	FirstName             string                       `json:"first_name"`
-	LastName              string                       `json:"last_name"`
-	PrimaryOrganizationID *uint64                      `json:"primary_organization_id,omitempty"`
-	PrimaryDepartmentID   *uint64                      `json:"primary_department_id,omitempty"`
-	IsSuperAdmin          bool                         `json:"is_super_admin"`
-	MFAEnabled            bool                         `json:"mfa_enabled"`
-	Organizations         []OrganizationMembershipInfo `json:"organizations,omitempty"`
-	Departments           []DepartmentMembershipInfo   `json:"departments,omitempty"`
+	ID                    uint64  `json:"id"`
+	Email                 string  `json:"email"`
+	Username              string  `json:"username"`
+	FirstName             string  `json:"first_name"`
+	LastName              string  `json:"last_name"`
+	PrimaryOrganizationID *uint64 `json:"primary_organization_id,omitempty"`
+	PrimaryDepartmentID   *uint64 `json:"primary_department_id,omitempty"`
+	// PrimaryOrganizationName and PrimaryDepartmentName are only set when the relations were loaded.
+	PrimaryOrganizationName string                       `json:"primary_organization_name,omitempty"`
+	PrimaryDepartmentName   string                       `json:"primary_department_name,omitempty"`
+	IsSuperAdmin            bool                         `json:"is_super_admin"`
+	MFAEnabled              bool                         `json:"mfa_enabled"`
+	MustChangePassword      bool                         `json:"must_change_password,omitempty"`
+	Organizations           []OrganizationMembershipInfo `json:"organizations,omitempty"`
+	Departments             []DepartmentMembershipInfo   `json:"departments,omitempty"`
+}
+
+// AdminUserDetail extends UserInfo with account state visible to administrators only.
+type AdminUserDetail struct {
+	*UserInfo
+	IsActive                     bool       `json:"is_active"`
+	IsVerified                   bool       `json:"is_verified"`
+	LastLogin                    *time.Time `json:"last_login,omitempty"`
+	LoginAttempts                int        `json:"login_attempts"`
+	LockedUntil                  *time.Time `json:"locked_until,omitempty"`
+	LastPasswordResetRequestedAt *time.Time `json:"last_password_reset_requested_at,omitempty"`
+	CreatedAt                    time.Time  `json:"created_at"`
+	UpdatedAt                    time.Time  `json:"updated_at"`
+}
+
+// UserSecurityPosture gathers the security-relevant state of an account for admin triage.
+type UserSecurityPosture struct {
+	UserID       uint64               `json:"user_id"`
+	MFA          UserSecurityMFA      `json:"mfa"`
+	Login        UserSecurityLogin    `json:"login"`
+	Password     UserSecurityPassword `json:"password"`
+	Sessions     UserSecuritySessions `json:"sessions"`
+	RecentEvents []*AuditEvent        `json:"recent_events"`
+}
+
+// UserSecurityMFA describes the account's second factor.
+type UserSecurityMFA struct {
+	Enabled                bool `json:"enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+	FailedAttempts         int  `json:"failed_attempts"`
+}
+
+// UserSecurityLogin describes sign-in activity and lock state.
+type UserSecurityLogin struct {
+	IsActive       bool       `json:"is_active"`
+	LastLoginAt    *time.Time `json:"last_login_at,omitempty"`
+	FailedAttempts int        `json:"failed_attempts"`
+	Locked         bool       `json:"locked"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`
+}
+
+// UserSecurityPassword describes the password's history. ChangedAt comes from the audit log and is
+// empty when no change or reset was recorded.
+type UserSecurityPassword struct {
+	ChangedAt        *time.Time `json:"changed_at,omitempty"`
+	MustChange       bool       `json:"must_change"`
+	ResetRequestedAt *time.Time `json:"reset_requested_at,omitempty"`
+}
+
+// UserSecuritySessions estimates the account's live sessions. Tokens are stateless, so Active counts
+// successful logins since CountedSince whose refresh tokens may still be valid; logouts of individual
+// tokens are not subtracted, making it an upper bound.
+type UserSecuritySessions struct {
+	Active           int64      `json:"active"`
+	CountedSince     time.Time  `json:"counted_since"`
+	TokensValidAfter *time.Time `json:"tokens_valid_after,omitempty"`
 }
 
 // LoginRequest represents login credentials
 type LoginRequest struct {
 	Username       string `json:"username" validate:"required"`
 	Password       string `json:"password" validate:"required"`
-	OrganizationID uint64 `json:"organization_id" validate:"required"`
-	DepartmentID   uint64 `json:"department_id,omitempty" validate:"omitempty"` // CEO seems doesn't need department_id.
-	RoleID         uint64 `json:"role_id,omitempty" validate:"omitempty"`       // You can Select Role with departments, at least role_id or department_id is required.
+	OrganizationID uint64 `json:"organization_id,omitempty" validate:"omitempty"`
+	DepartmentID   uint64 `json:"department_id,omitempty" validate:"omitempty"`   // CEO seems doesn't need department_id.
+	RoleID         uint64 `json:"role_id,omitempty" validate:"omitempty"`         // You can Select Role with departments, at least role_id or department_id is required.
+	SelectionToken string `json:"selection_token,omitempty" validate:"omitempty"` // Completes a login that required organization selection.
+	MFACode        string `json:"mfa_code,omitempty" validate:"omitempty"`        // TOTP or recovery code for users with MFA enabled.
+
+	// Client details recorded in the login history; set by the handler, never read from the body.
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// OAuthTokenRequest redeems the login code returned to the client by the Google OAuth callback.
+type OAuthTokenRequest struct {
+	Code         string `json:"code" validate:"required"`
+	CodeVerifier string `json:"code_verifier,omitempty" validate:"omitempty"` // PKCE verifier for the challenge sent to the start route.
+	MFACode      string `json:"mfa_code,omitempty" validate:"omitempty"`
 }
 
 // LoginResponse represents the response after successful login
@@ -55,13 +130,280 @@ type LoginResponse struct {
 	LoggedDepartment   *Department   `json:"logged_department,omitempty"`
 }
 
+// DomainVerification describes the DNS TXT record that proves an organization controls its domain.
+type DomainVerification struct {
+	OrganizationID uint64     `json:"organization_id"`
+	Domain         string     `json:"domain"`
+	Verified       bool       `json:"verified"`
+	VerifiedAt     *time.Time `json:"verified_at,omitempty"`
+	RecordType     string     `json:"record_type,omitempty"`
+	RecordName     string     `json:"record_name,omitempty"`
+	RecordValue    string     `json:"record_value,omitempty"`
+}
+
+// BootstrapResult reports what a bootstrap run did to the root organization and administrator.
+type BootstrapResult struct {
+	Organization    BootstrapOrganization `json:"organization"`
+	User            BootstrapUser         `json:"user"`
+	Created         bool                  `json:"created"`
+	PasswordRotated bool                  `json:"password_rotated"`
+}
+
+// BootstrapOrganization identifies the organization the administrator was bootstrapped into.
+type BootstrapOrganization struct {
+	ID     uint64 `json:"id"`
+	Name   string `json:"name"`
+	Domain string `json:"domain"`
+}
+
+// BootstrapUser identifies the bootstrapped administrator.
+type BootstrapUser struct {
+	ID       uint64 `json:"id"`
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+// TokenDetails describes a validated access token. OrganizationID is the org_id the token was issued
+// for, or nil when it carries none.
+type TokenDetails struct {
+	UserID         uint64  `json:"user_id"`
+	OrganizationID *uint64 `json:"organization_id,omitempty"`
+	IsSuperAdmin   bool    `json:"is_super_admin"`
+	// Scopes restricts the token to the listed scopes; nil means it carries none and is not restricted.
+	Scopes []string `json:"scopes,omitempty"`
+	// Roles and Permissions are the role and permission claims of the token.
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// MustChangePassword is set for tokens issued while the user had to replace a temporary password they
+	// still have not replaced.
+	MustChangePassword bool `json:"must_change_password,omitempty"`
+}
+
+// UserStub identifies the signed-in user in a minimal login response.
+type UserStub struct {
+	ID       uint64 `json:"id"`
+	Username string `json:"username"`
+}
+
+// MinimalLoginResponse carries the tokens without the user's memberships, for clients that fetch
+// them from /me when needed.
+type MinimalLoginResponse struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresIn    int       `json:"expires_in"`
+	TokenType    string    `json:"token_type"`
+	User         *UserStub `json:"user,omitempty"`
+}
+
+// Minimal strips the response down to its tokens and a user stub.
+func (r *LoginResponse) Minimal() *MinimalLoginResponse {
+	minimal := &MinimalLoginResponse{
+		AccessToken:  r.AccessToken,
+		RefreshToken: r.RefreshToken,
+		ExpiresIn:    r.ExpiresIn,
+		TokenType:    r.TokenType,
+	}
+	if r.User != nil {
+		minimal.User = &UserStub{ID: r.User.ID, Username: r.User.Username}
+	}
+	return minimal
+}
+
+// MFALoginChallenge is returned when a user with MFA enabled logs in without a code.
+type MFALoginChallenge struct {
+	MFARequired    bool   `json:"mfa_required"`
+	ChallengeToken string `json:"challenge_token"`
+	ExpiresIn      int    `json:"expires_in"`
+}
+
+// MFALoginRequest completes a login paused for MFA.
+type MFALoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// LoginSelectionResponse is returned when the user must choose which organization to log into.
+type LoginSelectionResponse struct {
+	SelectionRequired bool                         `json:"selection_required"`
+	SelectionToken    string                       `json:"selection_token"`
+	ExpiresIn         int                          `json:"expires_in"`
+	Organizations     []OrganizationMembershipInfo `json:"organizations"`
+	Departments       []DepartmentMembershipInfo   `json:"departments,omitempty"`
+}
+
+// PasswordPolicyInfo describes the password requirements enforced by the service.
+type PasswordPolicyInfo struct {
+	MinLength      int  `json:"min_length"`
+	MinEntropyBits int  `json:"min_entropy_bits,omitempty"`
+	RequireUpper   bool `json:"require_upper,omitempty"`
+	RequireDigit   bool `json:"require_digit,omitempty"`
+	RequireSymbol  bool `json:"require_symbol,omitempty"`
+	RejectCommon   bool `json:"reject_common,omitempty"`
+}
+
+// PasswordValidateRequest checks a candidate password against the password policy
+type PasswordValidateRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// PasswordValidateResponse reports whether a candidate password meets the password policy.
+type PasswordValidateResponse struct {
+	Valid   bool   `json:"valid"`
+	Message string `json:"message,omitempty"`
+}
+
+// ClientAuthConfig exposes non-sensitive authentication settings so clients can adapt their UI.
+type ClientAuthConfig struct {
+	AccessTokenTTLSeconds        int                `json:"access_token_ttl_seconds"`
+	RefreshTokenTTLSeconds       int                `json:"refresh_token_ttl_seconds"`
+	StepUpTokenTTLSeconds        int                `json:"step_up_token_ttl_seconds"`
+	MFAEnabled                   bool               `json:"mfa_enabled"`
+	RegistrationEnabled          bool               `json:"registration_enabled"`
+	OAuthEnabled                 bool               `json:"oauth_enabled"`
+	OrganizationSelectionEnabled bool               `json:"organization_selection_enabled"`
+	PasswordPolicy               PasswordPolicyInfo `json:"password_policy"`
+	LoginRequiredFields          []string           `json:"login_required_fields"`
+	LoginOptionalFields          []string           `json:"login_optional_fields"`
+}
+
+// FederationDiscovery lists the organizations an email domain belongs to and how their users log in.
+type FederationDiscovery struct {
+	Domain        string                  `json:"domain,omitempty"`
+	Organizations []FederatedOrganization `json:"organizations"`
+}
+
+// FederatedOrganization is an organization matched by email domain during login discovery.
+type FederatedOrganization struct {
+	ID           uint64   `json:"id"`
+	Name         string   `json:"name"`
+	LoginMethods []string `json:"login_methods"`
+}
+
+// JWKS is a JSON Web Key Set publishing the public keys that verify issued tokens.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// SigningKeys lists the keys that currently sign tokens so validators can match the kid header.
+// JWKS carries the public keys of asymmetric algorithms and is empty for HS256.
+type SigningKeys struct {
+	Keys []SigningKeyInfo `json:"keys"`
+	JWKS *JWKS            `json:"jwks"`
+}
+
+// SigningKeyInfo identifies a signing key without any key material.
+type SigningKeyInfo struct {
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+}
+
+// JWK is a single RSA public key in a JWKS document.
+type JWK struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// TokenDebugRequest carries a token to inspect.
+type TokenDebugRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// TokenDebugResult reports what the service can tell about a token. It is a debugging aid only.
+type TokenDebugResult struct {
+	SignatureValid bool           `json:"signature_valid"`
+	Expired        bool           `json:"expired"`
+	Valid          bool           `json:"valid"`
+	Algorithm      string         `json:"algorithm,omitempty"`
+	TokenType      string         `json:"token_type,omitempty"`
+	IssuedAt       *time.Time     `json:"issued_at,omitempty"`
+	ExpiresAt      *time.Time     `json:"expires_at,omitempty"`
+	Header         map[string]any `json:"header,omitempty"`
+	Claims         map[string]any `json:"claims,omitempty"`
+	Error          string         `json:"error,omitempty"`
+}
+
+// MFAChallengeRequest carries the TOTP or recovery code used for step-up authentication.
+type MFAChallengeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// MFAEnrollment carries a pending TOTP secret for the user to add to an authenticator app, and the
+// one-time recovery codes that replace it when the device is lost. Neither is shown again.
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+	// QRCode is the otpauth URL rendered as a PNG data URL for display in an <img> tag.
+	QRCode        string   `json:"qr_code"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// StepUpTokenResponse is returned after a successful MFA challenge.
+type StepUpTokenResponse struct {
+	StepUpToken string `json:"step_up_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+// MFAStatus summarises a user's MFA posture. It never carries the TOTP secret or recovery codes.
+type MFAStatus struct {
+	MFAEnabled             bool       `json:"mfa_enabled"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+	StepUpActive           bool       `json:"step_up_active"`
+	StepUpExpiresAt        *time.Time `json:"step_up_expires_at,omitempty"`
+}
+
 // CreateOrganizationInput captures the data required to create a new organization.
 type CreateOrganizationInput struct {
 	Name        string  `json:"name"`
 	Description string  `json:"description"`
 	Domain      string  `json:"domain"`
+	Tier        string  `json:"tier,omitempty"`
 	ParentID    *uint64 `json:"parent_id,omitempty"`
 	IsActive    *bool   `json:"is_active,omitempty"`
+
+	// SeedDefaultDepartments creates DefaultDepartmentStructure inside the new organization.
+	SeedDefaultDepartments bool `json:"seed_default_departments,omitempty"`
+}
+
+// UpdateOrganizationTierInput changes the plan an organization is subscribed to.
+type UpdateOrganizationTierInput struct {
+	Tier string `json:"tier"`
+}
+
+// UpdateOrganizationLoginPolicyInput changes which members may log into an organization.
+type UpdateOrganizationLoginPolicyInput struct {
+	MinLoginRoleLevel *int `json:"min_login_role_level"`
+}
+
+// OrganizationContact holds an organization's contact and billing details.
+type OrganizationContact struct {
+	OrganizationID uint64 `json:"organization_id"`
+	ContactEmail   string `json:"contact_email"`
+	ContactPhone   string `json:"contact_phone"`
+	Address        string `json:"address"`
+}
+
+// OrganizationDetail is an organization with its child hierarchy and, on request, its members.
+type OrganizationDetail struct {
+	*Organization
+	Members []*UserOrganization `json:"members,omitempty"`
+}
+
+// UpdateOrganizationContactInput changes the supplied contact fields; omitted fields are kept and
+// empty strings clear them.
+type UpdateOrganizationContactInput struct {
+	ContactEmail *string `json:"contact_email,omitempty"`
+	ContactPhone *string `json:"contact_phone,omitempty"`
+	Address      *string `json:"address,omitempty"`
+	ActorID      uint64  `json:"-"`
 }
 
 // CreateDepartmentInput captures the data required to create a new department.
@@ -76,12 +418,67 @@ type CreateDepartmentInput struct {
 	IsActive       *bool           `json:"is_active,omitempty"`
 }
 
+// UpdateDepartmentInput changes the supplied fields of a department; omitted fields are kept. A
+// ParentID of 0 makes the department top-level and an empty code clears it.
+type UpdateDepartmentInput struct {
+	Name        *string         `json:"name,omitempty"`
+	Description *string         `json:"description,omitempty"`
+	Function    *string         `json:"function,omitempty"`
+	Kind        *DepartmentKind `json:"kind,omitempty"`
+	Code        *DepartmentCode `json:"code,omitempty"`
+	IsActive    *bool           `json:"is_active,omitempty"`
+	ParentID    *uint64         `json:"parent_id,omitempty"`
+	ActorID     uint64          `json:"-"`
+}
+
+// MoveDepartmentInput describes where a department subtree should be moved. A nil ParentID makes the
+// department top-level; a different OrganizationID moves the whole subtree to that organization.
+type MoveDepartmentInput struct {
+	ParentID       *uint64 `json:"parent_id"`
+	OrganizationID *uint64 `json:"organization_id,omitempty"`
+	// ClearMemberships removes department memberships of users who do not belong to the target organization.
+	ClearMemberships bool   `json:"clear_memberships"`
+	ActorID          uint64 `json:"-"`
+}
+
+// MoveDepartmentResult reports the outcome of a department move.
+type MoveDepartmentResult struct {
+	Department         *Department `json:"department"`
+	MovedDepartments   int         `json:"moved_departments"`
+	ClearedMemberships int         `json:"cleared_memberships"`
+}
+
+// SetDepartmentsActiveInput activates or deactivates several departments of an organization at once.
+type SetDepartmentsActiveInput struct {
+	DepartmentIDs []uint64 `json:"department_ids"`
+	IsActive      *bool    `json:"is_active"`
+	ActorID       uint64   `json:"-"`
+}
+
+// SetDepartmentsActiveResult reports the outcome of a bulk department activation change. Updated is
+// false when validation failed and no department was changed.
+type SetDepartmentsActiveResult struct {
+	OrganizationID uint64                        `json:"organization_id"`
+	IsActive       bool                          `json:"is_active"`
+	Updated        bool                          `json:"updated"`
+	Results        []*DepartmentActivationResult `json:"results"`
+}
+
+// DepartmentActivationResult reports the outcome for a single department.
+type DepartmentActivationResult struct {
+	DepartmentID uint64 `json:"department_id"`
+	Success      bool   `json:"success"`
+	Changed      bool   `json:"changed"`
+	Error        string `json:"error,omitempty"`
+}
+
 // AssignUserOrganizationInput represents a request to associate a user with an organization.
 type AssignUserOrganizationInput struct {
 	UserID         uint64           `json:"user_id"`
 	OrganizationID uint64           `json:"organization_id"`
 	Role           OrganizationRole `json:"role"`
 	IsPrimary      bool             `json:"is_primary"`
+	ActorID        uint64           `json:"-"`
 }
 
 // AssignUserDepartmentInput represents a request to associate a user with a department.
@@ -90,9 +487,153 @@ type AssignUserDepartmentInput struct {
 	DepartmentID *uint64 `json:"department_id"`
 	Role         string  `json:"role"`
 	IsPrimary    bool    `json:"is_primary"`
+	ActorID      uint64  `json:"-"`
+}
+
+// UserImportRow is a single user parsed from a bulk import file.
+type UserImportRow struct {
+	Line      int              `json:"line"`
+	Email     string           `json:"email"`
+	Username  string           `json:"username"`
+	FirstName string           `json:"first_name"`
+	LastName  string           `json:"last_name"`
+	Role      OrganizationRole `json:"role"`
+}
+
+// UnverifiedUser is an account that has not completed email verification.
+type UnverifiedUser struct {
+	ID                  uint64     `json:"id"`
+	Email               string     `json:"email"`
+	Username            string     `json:"username"`
+	FirstName           string     `json:"first_name"`
+	LastName            string     `json:"last_name"`
+	IsActive            bool       `json:"is_active"`
+	RegisteredAt        time.Time  `json:"registered_at"`
+	VerificationPending bool       `json:"verification_pending"`
+	VerificationSentAt  *time.Time `json:"verification_sent_at,omitempty"`
+}
+
+// VerificationResendResult summarises a bulk resend of verification emails.
+type VerificationResendResult struct {
+	Sent      int `json:"sent"`
+	Throttled int `json:"throttled"`
+	Failed    int `json:"failed"`
+}
+
+// MFAAdoptionStats reports how many users have MFA enabled, overall and per organization.
+type MFAAdoptionStats struct {
+	TotalUsers    int64               `json:"total_users"`
+	MFAEnabled    int64               `json:"mfa_enabled"`
+	Percentage    float64             `json:"percentage"`
+	Organizations []*MFAAdoptionCount `json:"organizations"`
+}
+
+// MFAAdoptionCount is the MFA adoption of a single organization's members.
+type MFAAdoptionCount struct {
+	OrganizationID   uint64  `json:"organization_id"`
+	OrganizationName string  `json:"organization_name"`
+	TotalUsers       int64   `json:"total_users"`
+	MFAEnabled       int64   `json:"mfa_enabled"`
+	Percentage       float64 `json:"percentage" gorm:"-"`
+}
+
+// UserImportResult reports the outcome of importing a single row.
+type UserImportResult struct {
+	Line              int    `json:"line"`
+	Email             string `json:"email"`
+	Username          string `json:"username"`
+	UserID            uint64 `json:"user_id,omitempty"`
+	TemporaryPassword string `json:"temporary_password,omitempty"`
+	Success           bool   `json:"success"`
+	Error             string `json:"error,omitempty"`
+}
+
+// RevokeTokenInput identifies the token to revoke, either by the token itself or by its JTI. UserID,
+// when set, must own the token.
+type RevokeTokenInput struct {
+	Token   string `json:"token,omitempty"`
+	JTI     string `json:"jti,omitempty"`
+	UserID  uint64 `json:"user_id,omitempty"`
+	ActorID uint64 `json:"-"`
+}
+
+// LogoutRequest optionally names the refresh token to revoke together with the caller's access token.
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// SwitchOrganizationRequest names the organization, and optionally the department, a new access
+// token should be issued for.
+type SwitchOrganizationRequest struct {
+	OrganizationID uint64 `json:"organization_id" validate:"required"`
+	DepartmentID   uint64 `json:"department_id,omitempty" validate:"omitempty"`
+}
+
+// SwitchOrganizationResponse carries the tokens issued for the new organization context. The refresh
+// token replaces the caller's previous one so refreshing stays in that context.
+type SwitchOrganizationResponse struct {
+	AccessToken        string        `json:"access_token"`
+	RefreshToken       string        `json:"refresh_token"`
+	ExpiresIn          int           `json:"expires_in"`
+	TokenType          string        `json:"token_type"`
+	LoggedOrganization *Organization `json:"logged_organization"`
+	LoggedDepartment   *Department   `json:"logged_department,omitempty"`
+}
+
+// LoginHistoryEntry is a single login attempt shown to the user who made it.
+type LoginHistoryEntry struct {
+	Time           time.Time `json:"time"`
+	IPAddress      string    `json:"ip_address,omitempty"`
+	UserAgent      string    `json:"user_agent,omitempty"`
+	Success        bool      `json:"success"`
+	Outcome        string    `json:"outcome"`
+	OrganizationID *uint64   `json:"organization_id,omitempty"`
 }
 
 func init() {
 	coreServer.RegisterSchemaType("login-request", LoginRequest{})
+	coreServer.RegisterSchemaType("oauth-token-request", OAuthTokenRequest{})
+	coreServer.RegisterSchemaType("register-request", RegisterRequest{})
+	coreServer.RegisterSchemaType("verify-email-request", VerifyEmailRequest{})
+	coreServer.RegisterSchemaType("organization-contact", OrganizationContact{})
+	coreServer.RegisterSchemaType("update-organization-contact-input", UpdateOrganizationContactInput{})
+	coreServer.RegisterSchemaType("organization-detail", OrganizationDetail{})
+	coreServer.RegisterSchemaType("password-validate-request", PasswordValidateRequest{})
+	coreServer.RegisterSchemaType("password-validate-response", PasswordValidateResponse{})
+	coreServer.RegisterSchemaType("password-reset-request", PasswordResetRequest{})
+	coreServer.RegisterSchemaType("password-reset-confirm-request", PasswordResetConfirmRequest{})
+	coreServer.RegisterSchemaType("change-password-request", ChangePasswordRequest{})
+	coreServer.RegisterSchemaType("update-profile-request", UpdateProfileRequest{})
 	coreServer.RegisterSchemaType("login-response", LoginResponse{})
+	coreServer.RegisterSchemaType("minimal-login-response", MinimalLoginResponse{})
+	coreServer.RegisterSchemaType("domain-verification", DomainVerification{})
+	coreServer.RegisterSchemaType("user-security-posture", UserSecurityPosture{})
+	coreServer.RegisterSchemaType("login-selection-response", LoginSelectionResponse{})
+	coreServer.RegisterSchemaType("mfa-login-challenge", MFALoginChallenge{})
+	coreServer.RegisterSchemaType("mfa-login-request", MFALoginRequest{})
+	coreServer.RegisterSchemaType("client-auth-config", ClientAuthConfig{})
+	coreServer.RegisterSchemaType("federation-discovery", FederationDiscovery{})
+	coreServer.RegisterSchemaType("jwks", JWKS{})
+	coreServer.RegisterSchemaType("signing-keys", SigningKeys{})
+	coreServer.RegisterSchemaType("mfa-challenge-request", MFAChallengeRequest{})
+	coreServer.RegisterSchemaType("token-debug-request", TokenDebugRequest{})
+	coreServer.RegisterSchemaType("token-debug-result", TokenDebugResult{})
+	coreServer.RegisterSchemaType("step-up-token-response", StepUpTokenResponse{})
+	coreServer.RegisterSchemaType("mfa-enrollment", MFAEnrollment{})
+	coreServer.RegisterSchemaType("mfa-status", MFAStatus{})
+	coreServer.RegisterSchemaType("unverified-user", UnverifiedUser{})
+	coreServer.RegisterSchemaType("verification-resend-result", VerificationResendResult{})
+	coreServer.RegisterSchemaType("mfa-adoption-stats", MFAAdoptionStats{})
+	coreServer.RegisterSchemaType("update-department-input", UpdateDepartmentInput{})
+	coreServer.RegisterSchemaType("set-departments-active-input", SetDepartmentsActiveInput{})
+	coreServer.RegisterSchemaType("set-departments-active-result", SetDepartmentsActiveResult{})
+	coreServer.RegisterSchemaType("revoke-token-request", RevokeTokenInput{})
+	coreServer.RegisterSchemaType("revoked-token", RevokedToken{})
+	coreServer.RegisterSchemaType("logout-request", LogoutRequest{})
+	coreServer.RegisterSchemaType("switch-organization-request", SwitchOrganizationRequest{})
+	coreServer.RegisterSchemaType("switch-organization-response", SwitchOrganizationResponse{})
+	coreServer.RegisterSchemaType("login-history-entry", LoginHistoryEntry{})
+	coreServer.RegisterSchemaType("api-key", APIKey{})
+	coreServer.RegisterSchemaType("create-api-key-request", CreateAPIKeyRequest{})
+	coreServer.RegisterSchemaType("create-api-key-response", CreateAPIKeyResponse{})
 }
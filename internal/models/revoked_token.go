@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	coreServer "github.com/lee-tech/core/server"
+)
+
+// RevokedToken blacklists a single token by its JTI until the token would have expired anyway.
+type RevokedToken struct {
+	ID        uint64    `gorm:"type:bigint;primaryKey;autoIncrement" json:"id"`
+	JTI       string    `gorm:"size:64;uniqueIndex;not null" json:"jti"`
+	UserID    uint64    `gorm:"type:bigint;index;not null" json:"user_id"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+	RevokedAt time.Time `gorm:"not null" json:"revoked_at"`
+	RevokedBy uint64    `gorm:"type:bigint" json:"revoked_by"`
+}
+
+func init() {
+	coreServer.RegisterMigration(func() interface{} { return &RevokedToken{} })
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// emptyAsNullSerializer stores an empty string as SQL NULL and reads NULL back as an empty string, so
+// optional values under a unique index do not collide on "".
+type emptyAsNullSerializer struct{}
+
+func (emptyAsNullSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue any) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("unsupported value %T for %s", dbValue, field.Name)
+	}
+	field.ReflectValueOf(ctx, dst).SetString(value)
+	return nil
+}
+
+func (emptyAsNullSerializer) Value(_ context.Context, _ *schema.Field, _ reflect.Value, fieldValue any) (any, error) {
+	if value, _ := fieldValue.(string); value != "" {
+		return value, nil
+	}
+	return nil, nil
+}
+
+func init() {
+	schema.RegisterSerializer("emptyasnull", emptyAsNullSerializer{})
+}
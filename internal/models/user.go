@@ -38,10 +38,26 @@ type User struct {
 	PasswordResetToken  *string    `json:"-"`
 	PasswordResetExpiry *time.Time `json:"-"`
 	VerificationToken   *string    `json:"-"`
+	VerificationExpiry  *time.Time `json:"-"`
+	MustChangePassword  bool       `gorm:"default:false" json:"must_change_password"`
+
+	// LastPasswordResetRequestedAt is only surfaced through the admin user detail.
+	LastPasswordResetRequestedAt *time.Time `json:"-"`
+	// TokensValidAfter invalidates every token issued at or before this instant.
+	TokensValidAfter *time.Time `json:"-"`
+	// AccessTokensValidAfter invalidates access tokens issued before a membership or role change so clients
+	// refresh them. Refresh tokens stay valid.
+	AccessTokensValidAfter *time.Time `json:"-"`
+	// VerificationSentAt records when a verification token was last delivered, for resend throttling.
+	VerificationSentAt *time.Time `json:"-"`
 
 	// MFA fields
 	MFAEnabled bool    `gorm:"default:false" json:"mfa_enabled"`
 	MFASecret  *string `json:"-"`
+	// MFARecoveryCodes holds bcrypt hashes of the unused one-time recovery codes.
+	MFARecoveryCodes []string `gorm:"serializer:json" json:"-"`
+	// MFAFailedAttempts counts consecutive wrong MFA codes, separately from LoginAttempts.
+	MFAFailedAttempts int `gorm:"default:0" json:"-"`
 
 	// Timestamps
 	CreatedAt time.Time      `json:"created_at"`
@@ -49,9 +65,29 @@ type User struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// User list sort columns accepted by UserListFilter.Sort.
+const (
+	UserSortCreatedAt = "created_at"
+	UserSortEmail     = "email"
+	UserSortUsername  = "username"
+)
+
+// UserListFilter narrows a user listing. Zero values are ignored; without Sort users are ordered by ID.
+type UserListFilter struct {
+	// Query matches email, username, first name or last name, case-insensitively.
+	Query          string
+	IsActive       *bool
+	IsVerified     *bool
+	OrganizationID *uint64
+	Sort           string
+	Descending     bool
+	Offset         int
+	Limit          int
+}
+
 // ToUserInfo converts User to UserInfo
 func (u *User) ToUserInfo() *UserInfo {
-	return &UserInfo{
+	info := &UserInfo{
 		ID:                    u.ID,
 		Email:                 u.Email,
 		Username:              u.Username,
@@ -61,6 +97,47 @@ func (u *User) ToUserInfo() *UserInfo {
 		PrimaryDepartmentID:   u.PrimaryDepartmentID,
 		IsSuperAdmin:          u.IsSuperAdmin,
 		MFAEnabled:            u.MFAEnabled,
+		MustChangePassword:    u.MustChangePassword,
+	}
+	if u.PrimaryOrganization != nil {
+		info.PrimaryOrganizationName = u.PrimaryOrganization.Name
+	}
+	if u.PrimaryDepartment != nil {
+		info.PrimaryDepartmentName = u.PrimaryDepartment.Name
+	}
+	return info
+}
+
+// ToAdminUserDetail converts User to AdminUserDetail for administrative views.
+// ToUnverifiedUser summarises an unverified account for the admin listing.
+func (u *User) ToUnverifiedUser() *UnverifiedUser {
+	return &UnverifiedUser{
+		ID:                  u.ID,
+		Email:               u.Email,
+		Username:            u.Username,
+		FirstName:           u.FirstName,
+		LastName:            u.LastName,
+		IsActive:            u.IsActive,
+		RegisteredAt:        u.CreatedAt,
+		VerificationPending: u.VerificationToken != nil && *u.VerificationToken != "",
+		VerificationSentAt:  u.VerificationSentAt,
+	}
+}
+
+func (u *User) ToAdminUserDetail(info *UserInfo) *AdminUserDetail {
+	if info == nil {
+		info = u.ToUserInfo()
+	}
+	return &AdminUserDetail{
+		UserInfo:                     info,
+		IsActive:                     u.IsActive,
+		IsVerified:                   u.IsVerified,
+		LastLogin:                    u.LastLogin,
+		LoginAttempts:                u.LoginAttempts,
+		LockedUntil:                  u.LockedUntil,
+		LastPasswordResetRequestedAt: u.LastPasswordResetRequestedAt,
+		CreatedAt:                    u.CreatedAt,
+		UpdatedAt:                    u.UpdatedAt,
 	}
 }
 
@@ -79,6 +156,44 @@ type RegisterRequest struct {
 	PrimaryOrganizationID *uint64 `json:"primary_organization_id,omitempty"`
 }
 
+// PasswordResetRequest asks for a reset token to be sent to the account's email
+type PasswordResetRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// PasswordResetConfirmRequest sets a new password using a reset token
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+// ChangePasswordRequest replaces the signed-in user's password. Existing sessions are revoked unless
+// revoke_sessions is false.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+	RevokeSessions  *bool  `json:"revoke_sessions,omitempty"`
+}
+
+// ShouldRevokeSessions reports whether tokens issued before the change should stop working.
+func (r *ChangePasswordRequest) ShouldRevokeSessions() bool {
+	return r.RevokeSessions == nil || *r.RevokeSessions
+}
+
+// UpdateProfileRequest changes the signed-in user's own name. Omitted fields are kept.
+type UpdateProfileRequest struct {
+	FirstName *string `json:"first_name,omitempty"`
+	LastName  *string `json:"last_name,omitempty"`
+}
+
+// ProfileEditableFields lists the request fields a user may change on their own profile.
+var ProfileEditableFields = []string{"first_name", "last_name"}
+
+// VerifyEmailRequest carries the token delivered after registration
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
 func init() {
 	coreServer.RegisterMigration(func() interface{} { return &User{} })
 }
@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Result describes the state of a caller's bucket after a request was counted.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next request would be allowed. Zero when Allowed.
+	RetryAfter time.Duration
+}
+
+// Limiter decides whether a request identified by key may proceed.
+type Limiter interface {
+	Allow(key string) Result
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// MemoryLimiter is an in-process token bucket limiter allowing limit requests per window per key.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryLimiter returns a limiter for limit requests per window, or nil when limiting is disabled.
+func NewMemoryLimiter(limit int, window time.Duration) *MemoryLimiter {
+	if limit <= 0 || window <= 0 {
+		return nil
+	}
+	return &MemoryLimiter{
+		limit:   limit,
+		window:  window,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// WithClock overrides the time source, primarily for tests.
+func (l *MemoryLimiter) WithClock(now func() time.Time) *MemoryLimiter {
+	if now != nil {
+		l.now = now
+	}
+	return l
+}
+
+// Allow consumes a token for key and reports the resulting bucket state.
+func (l *MemoryLimiter) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	limit := float64(l.limit)
+	rate := limit / l.window.Seconds()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit, updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(limit, b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return bucketResult(l.limit, l.window, b.tokens, allowed)
+}
+
+// sweep drops buckets that have refilled completely so idle keys do not accumulate.
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= l.window {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// bucketResult describes a bucket holding tokens after a request was counted.
+func bucketResult(limit int, window time.Duration, tokens float64, allowed bool) Result {
+	capacity := float64(limit)
+	rate := capacity / window.Seconds()
+
+	result := Result{Limit: limit, Allowed: allowed}
+	if !allowed {
+		result.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+	result.Remaining = int(math.Floor(tokens))
+	result.Reset = secondsToDuration((capacity - tokens) / rate)
+	return result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript applies the same token bucket as MemoryLimiter atomically in Redis. Time comes
+// from the Redis server so replicas with skewed clocks share one view of each bucket. It returns
+// whether the request was allowed and the remaining tokens as a string to keep the fraction.
+var tokenBucketScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window_ms = tonumber(ARGV[2])
+local clock = redis.call('TIME')
+local now_ms = tonumber(clock[1]) * 1000 + math.floor(tonumber(clock[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1]) or limit
+local updated = tonumber(state[2]) or now_ms
+tokens = math.min(limit, tokens + math.max(0, now_ms - updated) * limit / window_ms)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', now_ms)
+redis.call('PEXPIRE', KEYS[1], window_ms)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisLimiter is a token bucket limiter shared by every replica using the same Redis. When Redis
+// cannot be reached it falls back to an in-process bucket so limiting degrades rather than stops.
+type RedisLimiter struct {
+	client   redis.Scripter
+	prefix   string
+	limit    int
+	window   time.Duration
+	timeout  time.Duration
+	fallback *MemoryLimiter
+}
+
+// NewRedisLimiter returns a limiter for limit requests per window with buckets stored under prefix,
+// or nil when limiting is disabled.
+func NewRedisLimiter(client redis.Scripter, prefix string, limit int, window time.Duration) *RedisLimiter {
+	fallback := NewMemoryLimiter(limit, window)
+	if client == nil || fallback == nil {
+		return nil
+	}
+	return &RedisLimiter{
+		client:   client,
+		prefix:   prefix,
+		limit:    limit,
+		window:   window,
+		timeout:  time.Second,
+		fallback: fallback,
+	}
+}
+
+// Allow consumes a token for key and reports the resulting bucket state.
+func (l *RedisLimiter) Allow(key string) Result {
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+
+	values, err := tokenBucketScript.Run(ctx, l.client, []string{l.prefix + key}, l.limit, l.window.Milliseconds()).Slice()
+	if err != nil || len(values) != 2 {
+		log.Printf("rate limit store unavailable, using local bucket: %v", err)
+		return l.fallback.Allow(key)
+	}
+
+	allowed, _ := values[0].(int64)
+	raw, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		log.Printf("rate limit store returned invalid bucket %q, using local bucket", raw)
+		return l.fallback.Allow(key)
+	}
+	return bucketResult(l.limit, l.window, tokens, allowed == 1)
+}
@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeScripter runs the token bucket script against in-memory counters, without refill, and records
+// the keys it was called with. With err set every call fails.
+type fakeScripter struct {
+	tokens map[string]int
+	keys   []string
+	err    error
+}
+
+func newFakeScripter() *fakeScripter {
+	return &fakeScripter{tokens: map[string]int{}}
+}
+
+func (f *fakeScripter) run(keys []string, args []interface{}) *redis.Cmd {
+	if f.err != nil {
+		return redis.NewCmdResult(nil, f.err)
+	}
+	key := keys[0]
+	f.keys = append(f.keys, key)
+	tokens, ok := f.tokens[key]
+	if !ok {
+		tokens = args[0].(int)
+	}
+	allowed := int64(0)
+	if tokens >= 1 {
+		tokens--
+		allowed = 1
+	}
+	f.tokens[key] = tokens
+	return redis.NewCmdResult([]interface{}{allowed, strconv.Itoa(tokens)}, nil)
+}
+
+func (f *fakeScripter) Eval(_ context.Context, _ string, keys []string, args ...interface{}) *redis.Cmd {
+	return f.run(keys, args)
+}
+
+func (f *fakeScripter) EvalSha(_ context.Context, _ string, keys []string, args ...interface{}) *redis.Cmd {
+	return f.run(keys, args)
+}
+
+func (f *fakeScripter) EvalRO(_ context.Context, _ string, keys []string, args ...interface{}) *redis.Cmd {
+	return f.run(keys, args)
+}
+
+func (f *fakeScripter) EvalShaRO(_ context.Context, _ string, keys []string, args ...interface{}) *redis.Cmd {
+	return f.run(keys, args)
+}
+
+func (f *fakeScripter) ScriptExists(context.Context, ...string) *redis.BoolSliceCmd {
+	return redis.NewBoolSliceResult(nil, nil)
+}
+
+func (f *fakeScripter) ScriptLoad(context.Context, string) *redis.StringCmd {
+	return redis.NewStringResult("", nil)
+}
+
+func TestRedisLimiter(t *testing.T) {
+	store := newFakeScripter()
+	limiter := NewRedisLimiter(store, "test:", 2, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if result := limiter.Allow("login:198.51.100.1"); !result.Allowed {
+			t.Fatalf("request %d: Allowed = false, want true", i+1)
+		}
+	}
+	result := limiter.Allow("login:198.51.100.1")
+	if result.Allowed {
+		t.Fatal("request over the limit: Allowed = true, want false")
+	}
+	if result.Limit != 2 || result.Remaining != 0 || result.RetryAfter <= 0 {
+		t.Errorf("result = %+v, want limit 2, none remaining and a retry delay", result)
+	}
+	if !limiter.Allow("register:198.51.100.1").Allowed {
+		t.Error("another key shared the exhausted bucket")
+	}
+	if store.keys[0] != "test:login:198.51.100.1" {
+		t.Errorf("bucket key = %q, want it under the prefix", store.keys[0])
+	}
+}
+
+func TestRedisLimiterFallsBackWhenUnavailable(t *testing.T) {
+	store := &fakeScripter{err: errors.New("connection refused")}
+	limiter := NewRedisLimiter(store, "test:", 1, time.Minute)
+
+	if !limiter.Allow("login:198.51.100.1").Allowed {
+		t.Fatal("first request: Allowed = false, want true")
+	}
+	if limiter.Allow("login:198.51.100.1").Allowed {
+		t.Fatal("local bucket did not limit the second request")
+	}
+}
@@ -0,0 +1,105 @@
+package redirect
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrDisallowed is returned for redirect targets outside the allow-list.
+var ErrDisallowed = errors.New("redirect url is not allowed")
+
+type originPattern struct {
+	scheme string
+	// host is the exact host, or the parent domain when wildcard is set.
+	host     string
+	port     string
+	wildcard bool
+}
+
+// Policy validates redirect targets. Relative paths on the service's own origin are always allowed;
+// absolute URLs must match one of the configured origins. A nil Policy allows same-origin paths only.
+type Policy struct {
+	origins []originPattern
+}
+
+// NewPolicy compiles origin patterns such as "https://app.example.com", "http://localhost:3000" or
+// "https://*.example.com". A wildcard matches one or more subdomain labels but not the parent domain itself.
+func NewPolicy(patterns []string) (*Policy, error) {
+	policy := &Policy{}
+	for _, raw := range patterns {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		scheme, rest, found := strings.Cut(raw, "://")
+		scheme = strings.ToLower(scheme)
+		if !found || (scheme != "http" && scheme != "https") {
+			return nil, fmt.Errorf("redirect origin %q must start with http:// or https://", raw)
+		}
+		if strings.ContainsAny(strings.TrimSuffix(rest, "/"), "/?#@\\") {
+			return nil, fmt.Errorf("redirect origin %q must not contain a path, query or credentials", raw)
+		}
+
+		pattern := originPattern{scheme: scheme}
+		hostPort := strings.ToLower(strings.TrimSuffix(rest, "/"))
+		if strings.HasPrefix(hostPort, "*.") {
+			pattern.wildcard = true
+			hostPort = strings.TrimPrefix(hostPort, "*.")
+		}
+		parsed, err := url.Parse(scheme + "://" + hostPort)
+		if err != nil || parsed.Hostname() == "" || strings.Contains(parsed.Hostname(), "*") {
+			return nil, fmt.Errorf("redirect origin %q is invalid", raw)
+		}
+		// Refuse wildcards over a bare top-level domain such as "*.com".
+		if pattern.wildcard && !strings.Contains(parsed.Hostname(), ".") {
+			return nil, fmt.Errorf("redirect origin %q has a wildcard that is too broad", raw)
+		}
+		pattern.host = parsed.Hostname()
+		pattern.port = parsed.Port()
+		policy.origins = append(policy.origins, pattern)
+	}
+	return policy, nil
+}
+
+// Validate returns the redirect target when it is allowed, or ErrDisallowed.
+func (p *Policy) Validate(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.ContainsAny(raw, "\\\r\n\t") {
+		return "", ErrDisallowed
+	}
+
+	// Same-origin paths. "//host" is protocol-relative and therefore absolute.
+	if strings.HasPrefix(raw, "/") && !strings.HasPrefix(raw, "//") {
+		return raw, nil
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil || !parsed.IsAbs() || parsed.User != nil || parsed.Opaque != "" {
+		return "", ErrDisallowed
+	}
+	if p == nil {
+		return "", ErrDisallowed
+	}
+
+	scheme := strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	port := parsed.Port()
+	for _, origin := range p.origins {
+		if origin.scheme != scheme || origin.port != port {
+			continue
+		}
+		if origin.wildcard {
+			if strings.HasSuffix(host, "."+origin.host) {
+				return raw, nil
+			}
+			continue
+		}
+		if host == origin.host {
+			return raw, nil
+		}
+	}
+	return "", ErrDisallowed
+}
@@ -0,0 +1,73 @@
+package redirect
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	policy, err := NewPolicy([]string{"https://good.com", "https://*.example.com", "http://localhost:3000"})
+	if err != nil {
+		t.Fatalf("NewPolicy() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		target  string
+		allowed bool
+	}{
+		{name: "relative path", target: "/dashboard?tab=1", allowed: true},
+		{name: "exact origin", target: "https://good.com/callback", allowed: true},
+		{name: "origin host is case-insensitive", target: "https://GOOD.com/", allowed: true},
+		{name: "wildcard subdomain", target: "https://app.example.com/", allowed: true},
+		{name: "nested wildcard subdomain", target: "https://a.b.example.com/", allowed: true},
+		{name: "matching port", target: "http://localhost:3000/app", allowed: true},
+		{name: "empty", target: ""},
+		{name: "other origin", target: "https://evil.com/"},
+		{name: "allowed origin in query", target: "https://evil.com?x=good.com"},
+		{name: "allowed origin in path", target: "https://evil.com/good.com"},
+		{name: "allowed origin as suffix", target: "https://evilgood.com/"},
+		{name: "allowed origin as subdomain of attacker", target: "https://good.com.evil.com/"},
+		{name: "credentials before host", target: "https://good.com@evil.com/"},
+		{name: "protocol relative", target: "//evil.com/"},
+		{name: "backslash", target: "/\\evil.com"},
+		{name: "scheme mismatch", target: "http://good.com/"},
+		{name: "port mismatch", target: "http://localhost:4000/"},
+		{name: "wildcard parent domain", target: "https://example.com/"},
+		{name: "javascript scheme", target: "javascript:alert(1)"},
+		{name: "embedded newline", target: "/ok\r\nLocation: https://evil.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := policy.Validate(tt.target)
+			if tt.allowed {
+				if err != nil || got != tt.target {
+					t.Fatalf("Validate(%q) = %q, %v; want allowed", tt.target, got, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrDisallowed) {
+				t.Fatalf("Validate(%q) = %q, %v; want %v", tt.target, got, err, ErrDisallowed)
+			}
+		})
+	}
+}
+
+func TestNilPolicyAllowsSameOriginOnly(t *testing.T) {
+	var policy *Policy
+	if _, err := policy.Validate("/home"); err != nil {
+		t.Fatalf("Validate(/home) error = %v", err)
+	}
+	if _, err := policy.Validate("https://good.com/"); !errors.Is(err, ErrDisallowed) {
+		t.Fatalf("Validate(absolute) error = %v, want %v", err, ErrDisallowed)
+	}
+}
+
+func TestNewPolicyRejectsUnsafePatterns(t *testing.T) {
+	for _, pattern := range []string{"good.com", "ftp://good.com", "https://good.com/path", "https://*.com", "https://user@good.com"} {
+		if _, err := NewPolicy([]string{pattern}); err == nil {
+			t.Errorf("NewPolicy(%q) accepted an unsafe pattern", pattern)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/lee-tech/authentication/internal/constants"
+	"github.com/lee-tech/authentication/internal/models"
+	coreServer "github.com/lee-tech/core/server"
+	"gorm.io/gorm"
+)
+
+// AuditRepository persists audit events.
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository constructs a new repository instance.
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Record stores a single audit event.
+func (r *AuditRepository) Record(event *models.AuditEvent) error {
+	return r.db.Create(event).Error
+}
+
+// List returns the events matching the filter in chronological order, or newest first when Descending is
+// set, along with the total match count.
+func (r *AuditRepository) List(filter models.AuditEventFilter) ([]*models.AuditEvent, int64, error) {
+	query := r.db.Model(&models.AuditEvent{})
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Target != "" {
+		query = query.Where("target = ?", filter.Target)
+	}
+	if len(filter.Actions) > 0 {
+		query = query.Where("action IN ?", filter.Actions)
+	}
+	if filter.From != nil {
+		query = query.Where("timestamp >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("timestamp <= ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []*models.AuditEvent
+	if filter.Descending {
+		query = query.Order("timestamp DESC").Order("id DESC")
+	} else {
+		query = query.Order("timestamp ASC").Order("id ASC")
+	}
+	query = query.Offset(filter.Offset)
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if err := query.Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+func init() {
+	coreServer.RegisterRepository(constants.ComponentKey.AuditRepository, func(app *coreServer.HTTPApp) (interface{}, error) {
+		if app.DB == nil {
+			return nil, fmt.Errorf("database not initialised")
+		}
+		return NewAuditRepository(app.DB), nil
+	})
+}
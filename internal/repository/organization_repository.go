@@ -27,6 +27,13 @@ func (r *OrganizationRepository) CreateOrganization(org *models.Organization) er
 	return r.db.Create(org).Error
 }
 
+// ClearBlankOrganizationDomains rewrites empty domains stored before they were kept as NULL, so they no
+// longer collide on the unique domain index. It returns the number of organizations updated.
+func (r *OrganizationRepository) ClearBlankOrganizationDomains() (int64, error) {
+	result := r.db.Model(&models.Organization{}).Where("domain = ''").Update("domain", gorm.Expr("NULL"))
+	return result.RowsAffected, result.Error
+}
+
 // EnsureOrganization finds or creates an organization with the supplied identifiers.
 func (r *OrganizationRepository) EnsureOrganization(name, description, domain string) (*models.Organization, error) {
 	if strings.TrimSpace(name) == "" {
@@ -74,6 +81,10 @@ func (r *OrganizationRepository) updateOrganizationDefaults(org *models.Organiza
 	}
 	if strings.TrimSpace(domain) != "" && org.Domain != strings.TrimSpace(domain) {
 		updates["domain"] = strings.TrimSpace(domain)
+		// Ownership of the previous domain says nothing about the new one.
+		updates["domain_verified"] = false
+		updates["domain_verified_at"] = nil
+		updates["domain_verification_token"] = ""
 	}
 	if len(updates) > 0 {
 		if err := r.db.Model(org).Updates(updates).Error; err != nil {
@@ -91,6 +102,60 @@ func (r *OrganizationRepository) UpdateOrganization(org *models.Organization) er
 	return r.db.Save(org).Error
 }
 
+// OrganizationParentCreatesCycle walks the parent chain upwards from parentID and reports whether
+// making parentID the parent of orgID would form a cycle: either orgID appears in the chain (the
+// proposed parent is orgID itself or one of its descendants) or the chain already loops. Pass 0 as
+// orgID for an organization that does not exist yet.
+func (r *OrganizationRepository) OrganizationParentCreatesCycle(orgID, parentID uint64) (bool, error) {
+	seen := make(map[uint64]struct{})
+	current := &parentID
+	for current != nil {
+		if *current == orgID {
+			return true, nil
+		}
+		if _, ok := seen[*current]; ok {
+			return true, nil
+		}
+		seen[*current] = struct{}{}
+
+		var org models.Organization
+		err := r.db.Select("id", "parent_id").First(&org, "id = ?", *current).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return false, nil
+			}
+			return false, err
+		}
+		current = org.ParentID
+	}
+	return false, nil
+}
+
+// OrganizationLevel walks the parent chain upwards from orgID and returns the number of organizations
+// on it, orgID included. A loop in the chain ends the walk.
+func (r *OrganizationRepository) OrganizationLevel(orgID uint64) (int, error) {
+	seen := make(map[uint64]struct{})
+	level := 0
+	for current := &orgID; current != nil; {
+		if _, ok := seen[*current]; ok {
+			break
+		}
+		seen[*current] = struct{}{}
+
+		var org models.Organization
+		err := r.db.Select("id", "parent_id").First(&org, "id = ?", *current).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				break
+			}
+			return 0, err
+		}
+		level++
+		current = org.ParentID
+	}
+	return level, nil
+}
+
 // GetOrganizationByID fetches an organization with optional relationships.
 func (r *OrganizationRepository) GetOrganizationByID(id uint64) (*models.Organization, error) {
 	var org models.Organization
@@ -107,6 +172,60 @@ func (r *OrganizationRepository) GetOrganizationByID(id uint64) (*models.Organiz
 	return &org, nil
 }
 
+// GetOrganizationTree loads an organization with depth levels of child organizations. Departments are
+// preloaded for the organization itself when withDepartments is set. Returns nil when it does not exist.
+func (r *OrganizationRepository) GetOrganizationTree(id uint64, depth int, withDepartments bool) (*models.Organization, error) {
+	query := r.db
+	if withDepartments {
+		query = query.Preload("Departments")
+	}
+	if depth > 0 {
+		query = query.Preload(strings.TrimSuffix(strings.Repeat("Children.", depth), "."))
+	}
+
+	var org models.Organization
+	if err := query.First(&org, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &org, nil
+}
+
+// ListActiveOrganizationsByDomain returns the active organizations that verified the domain, ignoring case.
+func (r *OrganizationRepository) ListActiveOrganizationsByDomain(domain string) ([]*models.Organization, error) {
+	var orgs []*models.Organization
+	err := r.db.
+		Where("LOWER(domain) = ? AND is_active = ? AND domain_verified = ?", strings.ToLower(domain), true, true).
+		Order("name ASC").
+		Find(&orgs).Error
+	return orgs, err
+}
+
+// ListManageableOrganizations returns a page of active organizations ordered by name, with the total.
+// A non-nil adminUserID limits the result to organizations where that user is an ORG_ADMIN.
+func (r *OrganizationRepository) ListManageableOrganizations(adminUserID *uint64, offset, limit int) ([]*models.Organization, int64, error) {
+	var orgs []*models.Organization
+	var total int64
+
+	query := r.db.Model(&models.Organization{}).Where("is_active = ?", true)
+	if adminUserID != nil {
+		administered := r.db.Model(&models.UserOrganization{}).
+			Select("organization_id").
+			Where("user_id = ? AND role = ?", *adminUserID, models.OrganizationRoleOrgAdmin)
+		query = query.Where("id IN (?)", administered)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("name ASC").Offset(offset).Limit(limit).Find(&orgs).Error; err != nil {
+		return nil, 0, err
+	}
+	return orgs, total, nil
+}
+
 // ListOrganizations returns all organizations ordered by name.
 func (r *OrganizationRepository) ListOrganizations() ([]*models.Organization, error) {
 	var orgs []*models.Organization
@@ -139,6 +258,22 @@ func (r *OrganizationRepository) GetDepartmentByID(id uint64) (*models.Departmen
 	return &dept, nil
 }
 
+// GetDepartmentByCode returns the organization's department with the given code.
+func (r *OrganizationRepository) GetDepartmentByCode(orgID uint64, code models.DepartmentCode) (*models.Department, error) {
+	var dept models.Department
+	err := r.db.
+		Preload("Children").
+		Where("organization_id = ? AND code = ?", orgID, code).
+		First(&dept).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &dept, nil
+}
+
 // ListDepartmentsByOrganization returns departments for a given organization.
 func (r *OrganizationRepository) ListDepartmentsByOrganization(orgID uint64) ([]*models.Department, error) {
 	var departments []*models.Department
@@ -150,6 +285,133 @@ func (r *OrganizationRepository) ListDepartmentsByOrganization(orgID uint64) ([]
 	return departments, err
 }
 
+// ListDepartmentsByIDs returns the departments with the given IDs; unknown IDs are skipped.
+func (r *OrganizationRepository) ListDepartmentsByIDs(deptIDs []uint64) ([]*models.Department, error) {
+	var departments []*models.Department
+	if len(deptIDs) == 0 {
+		return departments, nil
+	}
+	err := r.db.
+		Where("id IN ?", deptIDs).
+		Find(&departments).Error
+	return departments, err
+}
+
+// SetDepartmentsActive sets the active flag of the given departments. An explicit change also drops
+// the organization cascade marker, so reactivating the organization leaves these departments alone.
+func (r *OrganizationRepository) SetDepartmentsActive(deptIDs []uint64, active bool) error {
+	if len(deptIDs) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.Department{}).
+		Where("id IN ?", deptIDs).
+		Updates(map[string]any{"is_active": active, "deactivated_by_organization": false}).Error
+}
+
+// MoveDepartment changes a department's parent. A nil parentID makes it top-level.
+func (r *OrganizationRepository) MoveDepartment(deptID uint64, parentID *uint64) error {
+	return r.db.Model(&models.Department{}).
+		Where("id = ?", deptID).
+		Update("parent_id", parentID).Error
+}
+
+// CascadeDeactivateDepartments deactivates the organization's active departments, marking them as
+// deactivated by the organization. It returns the IDs of the departments changed.
+func (r *OrganizationRepository) CascadeDeactivateDepartments(orgID uint64) ([]uint64, error) {
+	var ids []uint64
+	if err := r.db.Model(&models.Department{}).
+		Where("organization_id = ? AND is_active = ?", orgID, true).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	err := r.db.Model(&models.Department{}).
+		Where("id IN ?", ids).
+		Updates(map[string]any{"is_active": false, "deactivated_by_organization": true}).Error
+	return ids, err
+}
+
+// RestoreCascadedDepartments reactivates the organization's departments deactivated by
+// CascadeDeactivateDepartments. It returns the IDs of the departments changed.
+func (r *OrganizationRepository) RestoreCascadedDepartments(orgID uint64) ([]uint64, error) {
+	var ids []uint64
+	if err := r.db.Model(&models.Department{}).
+		Where("organization_id = ? AND deactivated_by_organization = ?", orgID, true).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	err := r.db.Model(&models.Department{}).
+		Where("id IN ?", ids).
+		Updates(map[string]any{"is_active": true, "deactivated_by_organization": false}).Error
+	return ids, err
+}
+
+// UpdateDepartment writes the given column values to a department.
+func (r *OrganizationRepository) UpdateDepartment(deptID uint64, updates map[string]any) error {
+	return r.db.Model(&models.Department{}).
+		Where("id = ?", deptID).
+		Updates(updates).Error
+}
+
+// SetDepartmentsOrganization reassigns the given departments to another organization.
+func (r *OrganizationRepository) SetDepartmentsOrganization(deptIDs []uint64, orgID uint64) error {
+	if len(deptIDs) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.Department{}).
+		Where("id IN ?", deptIDs).
+		Update("organization_id", orgID).Error
+}
+
+// ListDepartmentMembers returns the memberships of the given departments.
+func (r *OrganizationRepository) ListDepartmentMembers(deptIDs []uint64) ([]*models.UserDepartment, error) {
+	var memberships []*models.UserDepartment
+	if len(deptIDs) == 0 {
+		return memberships, nil
+	}
+	err := r.db.
+		Where("department_id IN ?", deptIDs).
+		Find(&memberships).Error
+	return memberships, err
+}
+
+// ListOrganizationRoles returns the role templates provisioned for an organization, highest authority first.
+func (r *OrganizationRepository) ListOrganizationRoles(orgID uint64) ([]*models.OrganizationRoleDefinition, error) {
+	var roles []*models.OrganizationRoleDefinition
+	err := r.db.
+		Where("organization_id = ?", orgID).
+		Order("level ASC, code ASC").
+		Find(&roles).Error
+	return roles, err
+}
+
+// CreateOrganizationRoles inserts role templates, skipping codes the organization already has.
+// It returns the roles that were actually created.
+func (r *OrganizationRepository) CreateOrganizationRoles(roles []*models.OrganizationRoleDefinition) ([]*models.OrganizationRoleDefinition, error) {
+	created := make([]*models.OrganizationRoleDefinition, 0, len(roles))
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, role := range roles {
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(role)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				created = append(created, role)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
 // ListUserOrganizations returns the organizations a user belongs to together with membership metadata.
 func (r *OrganizationRepository) ListUserOrganizations(userID uint64) ([]*models.UserOrganization, error) {
 	var memberships []*models.UserOrganization
@@ -161,6 +423,38 @@ func (r *OrganizationRepository) ListUserOrganizations(userID uint64) ([]*models
 	return memberships, err
 }
 
+// ListOrganizationMemberIDs returns the IDs of all users that belong to an organization.
+func (r *OrganizationRepository) ListOrganizationMemberIDs(orgID uint64) ([]uint64, error) {
+	var userIDs []uint64
+	err := r.db.
+		Model(&models.UserOrganization{}).
+		Where("organization_id = ?", orgID).
+		Order("user_id ASC").
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// ListOrganizationMembers returns the memberships of an organization together with the member users.
+func (r *OrganizationRepository) ListOrganizationMembers(orgID uint64) ([]*models.UserOrganization, error) {
+	var memberships []*models.UserOrganization
+	err := r.db.
+		Preload("User").
+		Where("organization_id = ?", orgID).
+		Order("user_id ASC").
+		Find(&memberships).Error
+	return memberships, err
+}
+
+// CountOrganizationMembersWithRole counts the members of an organization holding the given role.
+func (r *OrganizationRepository) CountOrganizationMembersWithRole(orgID uint64, role models.OrganizationRole) (int64, error) {
+	var count int64
+	err := r.db.
+		Model(&models.UserOrganization{}).
+		Where("organization_id = ? AND role = ?", orgID, role).
+		Count(&count).Error
+	return count, err
+}
+
 // ListUserDepartments returns the departments a user belongs to together with membership metadata.
 func (r *OrganizationRepository) ListUserDepartments(userID uint64) ([]*models.UserDepartment, error) {
 	var memberships []*models.UserDepartment
@@ -172,7 +466,8 @@ func (r *OrganizationRepository) ListUserDepartments(userID uint64) ([]*models.U
 	return memberships, err
 }
 
-// UpsertUserOrganization creates or updates membership between a user and organization.
+// UpsertUserOrganization creates or updates membership between a user and organization. A soft-deleted
+// membership for the same pair is restored.
 func (r *OrganizationRepository) UpsertUserOrganization(userID, orgID uint64, role models.OrganizationRole, isPrimary bool) error {
 	membership := &models.UserOrganization{
 		UserID:         userID,
@@ -183,7 +478,7 @@ func (r *OrganizationRepository) UpsertUserOrganization(userID, orgID uint64, ro
 
 	return r.db.Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "user_id"}, {Name: "organization_id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"role", "is_primary", "updated_at"}),
+		DoUpdates: clause.AssignmentColumns([]string{"role", "is_primary", "updated_at", "deleted_at"}),
 	}).Create(membership).Error
 }
 
@@ -202,7 +497,8 @@ func (r *OrganizationRepository) GetUserOrganization(userID, orgID uint64) (*mod
 	return &membership, nil
 }
 
-// UpsertUserDepartment creates or updates membership between a user and department.
+// UpsertUserDepartment creates or updates membership between a user and department. A soft-deleted
+// membership for the same pair is restored.
 func (r *OrganizationRepository) UpsertUserDepartment(userID, deptID uint64, role string, isPrimary bool) error {
 	membership := &models.UserDepartment{
 		UserID:       userID,
@@ -213,7 +509,7 @@ func (r *OrganizationRepository) UpsertUserDepartment(userID, deptID uint64, rol
 
 	return r.db.Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "user_id"}, {Name: "department_id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"role", "is_primary", "updated_at"}),
+		DoUpdates: clause.AssignmentColumns([]string{"role", "is_primary", "updated_at", "deleted_at"}),
 	}).Create(membership).Error
 }
 
@@ -260,14 +556,24 @@ func (r *OrganizationRepository) SetUserPrimaryDepartment(userID, deptID uint64)
 		Update("primary_department_id", deptID).Error
 }
 
-// RemoveUserOrganization removes a membership entry.
-func (r *OrganizationRepository) RemoveUserOrganization(userID, orgID uint64) error {
-	return r.db.Delete(&models.UserOrganization{}, "user_id = ? AND organization_id = ?", userID, orgID).Error
+// RemoveUserOrganization removes a membership entry. With soft set the row is kept with deleted_at
+// stamped; otherwise it is deleted outright.
+func (r *OrganizationRepository) RemoveUserOrganization(userID, orgID uint64, soft bool) error {
+	return r.membershipDB(soft).Delete(&models.UserOrganization{}, "user_id = ? AND organization_id = ?", userID, orgID).Error
 }
 
-// RemoveUserDepartment removes a department membership.
-func (r *OrganizationRepository) RemoveUserDepartment(userID, deptID uint64) error {
-	return r.db.Delete(&models.UserDepartment{}, "user_id = ? AND department_id = ?", userID, deptID).Error
+// RemoveUserDepartment removes a department membership. With soft set the row is kept with deleted_at
+// stamped; otherwise it is deleted outright.
+func (r *OrganizationRepository) RemoveUserDepartment(userID, deptID uint64, soft bool) error {
+	return r.membershipDB(soft).Delete(&models.UserDepartment{}, "user_id = ? AND department_id = ?", userID, deptID).Error
+}
+
+// membershipDB returns the handle membership removals run on; hard removals bypass gorm's soft delete.
+func (r *OrganizationRepository) membershipDB(soft bool) *gorm.DB {
+	if soft {
+		return r.db
+	}
+	return r.db.Unscoped()
 }
 
 func init() {
@@ -0,0 +1,31 @@
+package repository
+
+import "gorm.io/gorm"
+
+// TxRepositories groups repository instances bound to a single database transaction.
+type TxRepositories struct {
+	Users         *UserRepository
+	Organizations *OrganizationRepository
+	Audit         *AuditRepository
+}
+
+// withTx runs fn inside a transaction on db. The transaction is rolled back when fn returns an error or panics.
+func withTx(db *gorm.DB, fn func(repos *TxRepositories) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		return fn(&TxRepositories{
+			Users:         NewUserRepository(tx),
+			Organizations: NewOrganizationRepository(tx),
+			Audit:         NewAuditRepository(tx),
+		})
+	})
+}
+
+// WithTx runs fn with transaction-scoped repositories sharing this repository's connection.
+func (r *OrganizationRepository) WithTx(fn func(repos *TxRepositories) error) error {
+	return withTx(r.db, fn)
+}
+
+// WithTx runs fn with transaction-scoped repositories sharing this repository's connection.
+func (r *UserRepository) WithTx(fn func(repos *TxRepositories) error) error {
+	return withTx(r.db, fn)
+}
@@ -1,14 +1,17 @@
 package repository
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/lee-tech/authentication/internal/constants"
 	"github.com/lee-tech/authentication/internal/models"
 	coreServer "github.com/lee-tech/core/server"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserRepository handles database operations for users
@@ -73,17 +76,23 @@ func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
 	return &user, nil
 }
 
-// GetByEmailOrUsername retrieves a user by email or username
-func (r *UserRepository) GetByEmailOrUsername(identifier string) (*models.User, error) {
-	var user models.User
-	err := r.baseQuery().Where("email = ? OR username = ?", identifier, identifier).First(&user).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil
-		}
+// GetByEmailFold retrieves a user by email, ignoring case. Should legacy rows differ only in case, the
+// exact match wins, then the oldest account.
+func (r *UserRepository) GetByEmailFold(email string) (*models.User, error) {
+	var users []*models.User
+	err := r.baseQuery().
+		Where("LOWER(email) = ?", strings.ToLower(email)).
+		Order("id ASC").
+		Find(&users).Error
+	if err != nil || len(users) == 0 {
 		return nil, err
 	}
-	return &user, nil
+	for _, user := range users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return users[0], nil
 }
 
 // Update updates a user in the database
@@ -91,6 +100,24 @@ func (r *UserRepository) Update(user *models.User) error {
 	return r.db.Save(user).Error
 }
 
+// ReplaceRecoveryCodes stores remaining as the user's recovery codes if they still hold current, and
+// reports whether they did. A concurrent consumer that replaced the codes first wins.
+func (r *UserRepository) ReplaceRecoveryCodes(userID uint64, current, remaining []string) (bool, error) {
+	// Compare and store the column as the json serializer writes it
+	currentJSON, err := json.Marshal(current)
+	if err != nil {
+		return false, err
+	}
+	remainingJSON, err := json.Marshal(remaining)
+	if err != nil {
+		return false, err
+	}
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND mfa_recovery_codes = ?", userID, string(currentJSON)).
+		Update("mfa_recovery_codes", string(remainingJSON))
+	return result.RowsAffected > 0, result.Error
+}
+
 // UpdateLastLogin updates the last login timestamp for a user
 func (r *UserRepository) UpdateLastLogin(userID uint64) error {
 	now := time.Now()
@@ -102,6 +129,237 @@ func (r *UserRepository) UpdateLastLogin(userID uint64) error {
 		}).Error
 }
 
+// UpdatePasswordResetRequestedAt records when a password reset was last requested for a user
+func (r *UserRepository) UpdatePasswordResetRequestedAt(userID uint64, at time.Time) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("last_password_reset_requested_at", at).
+		Error
+}
+
+// SetPasswordResetToken stores a reset token digest with its expiry
+func (r *UserRepository) SetPasswordResetToken(userID uint64, tokenHash string, expiresAt time.Time) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"password_reset_token":  tokenHash,
+			"password_reset_expiry": expiresAt,
+		}).Error
+}
+
+// GetByPasswordResetToken retrieves the user holding the reset token digest
+func (r *UserRepository) GetByPasswordResetToken(tokenHash string) (*models.User, error) {
+	var user models.User
+	err := r.baseQuery().First(&user, "password_reset_token = ?", tokenHash).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ResetPassword stores a new password hash, clears the reset token and lockout, and invalidates the
+// user's existing tokens.
+func (r *UserRepository) ResetPassword(userID uint64, passwordHash string, at time.Time) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"password":              passwordHash,
+			"password_reset_token":  nil,
+			"password_reset_expiry": nil,
+			"must_change_password":  false,
+			"login_attempts":        0,
+			"locked_until":          nil,
+			"tokens_valid_after":    at,
+		}).Error
+}
+
+// ListPasswordHistory returns up to limit previous password hashes of the user, newest first.
+func (r *UserRepository) ListPasswordHistory(userID uint64, limit int) ([]string, error) {
+	var hashes []string
+	err := r.db.Model(&models.PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").Order("id DESC").
+		Limit(limit).
+		Pluck("password_hash", &hashes).Error
+	return hashes, err
+}
+
+// PushPasswordHistory records a replaced password hash and deletes all but the newest keep entries.
+func (r *UserRepository) PushPasswordHistory(userID uint64, passwordHash string, at time.Time, keep int) error {
+	entry := &models.PasswordHistory{UserID: userID, PasswordHash: passwordHash, CreatedAt: at}
+	if err := r.db.Create(entry).Error; err != nil {
+		return err
+	}
+
+	retained := r.db.Model(&models.PasswordHistory{}).
+		Select("id").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").Order("id DESC").
+		Limit(keep)
+	return r.db.
+		Where("user_id = ? AND id NOT IN (?)", userID, retained).
+		Delete(&models.PasswordHistory{}).Error
+}
+
+// CreateAPIKey stores a newly issued API key.
+func (r *UserRepository) CreateAPIKey(key *models.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+// ListAPIKeys returns the user's API keys, newest first.
+func (r *UserRepository) ListAPIKeys(userID uint64) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Order("id DESC").Find(&keys).Error
+	return keys, err
+}
+
+// GetAPIKeyByHash returns the API key with the given digest, or nil when there is none.
+func (r *UserRepository) GetAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// DeleteAPIKey removes one of the user's API keys and reports whether it existed.
+func (r *UserRepository) DeleteAPIKey(userID, keyID uint64) (bool, error) {
+	result := r.db.Where("id = ? AND user_id = ?", keyID, userID).Delete(&models.APIKey{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// TouchAPIKey records when an API key was last used.
+func (r *UserRepository) TouchAPIKey(keyID uint64, at time.Time) error {
+	return r.db.Model(&models.APIKey{}).Where("id = ?", keyID).Update("last_used_at", at).Error
+}
+
+// ExpireAccessTokens sets AccessTokensValidAfter for the given users.
+func (r *UserRepository) ExpireAccessTokens(userIDs []uint64, at time.Time) error {
+	return r.db.Model(&models.User{}).
+		Where("id IN ?", userIDs).
+		Update("access_tokens_valid_after", at).Error
+}
+
+// RevokeTokens sets TokensValidAfter for the given users, committing each batch in its own transaction.
+// It returns the number of users updated.
+func (r *UserRepository) RevokeTokens(userIDs []uint64, at time.Time, batchSize int) (int64, error) {
+	if batchSize <= 0 {
+		batchSize = len(userIDs)
+	}
+
+	var affected int64
+	for start := 0; start < len(userIDs); start += batchSize {
+		end := start + batchSize
+		if end > len(userIDs) {
+			end = len(userIDs)
+		}
+		batch := userIDs[start:end]
+
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&models.User{}).
+				Where("id IN ?", batch).
+				Update("tokens_valid_after", at)
+			if result.Error != nil {
+				return result.Error
+			}
+			affected += result.RowsAffected
+			return nil
+		})
+		if err != nil {
+			return affected, err
+		}
+	}
+	return affected, nil
+}
+
+// RevokeTokenJTI blacklists a single token. It reports false when the JTI was already revoked.
+func (r *UserRepository) RevokeTokenJTI(token *models.RevokedToken) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(token)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// IsTokenJTIRevoked reports whether the JTI is blacklisted and the blacklist entry has not yet expired.
+func (r *UserRepository) IsTokenJTIRevoked(jti string, at time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.RevokedToken{}).
+		Where("jti = ? AND expires_at > ?", jti, at).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// PurgeExpiredRevokedTokens deletes blacklist entries whose tokens have expired on their own.
+func (r *UserRepository) PurgeExpiredRevokedTokens(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at <= ?", before).Delete(&models.RevokedToken{})
+	return result.RowsAffected, result.Error
+}
+
+// RecordIssuedToken stores the JTI of a newly issued access or refresh token.
+func (r *UserRepository) RecordIssuedToken(token *models.IssuedToken) error {
+	return r.db.Create(token).Error
+}
+
+// GetIssuedToken returns the unexpired issued token with the JTI, or nil when there is none.
+func (r *UserRepository) GetIssuedToken(jti string, at time.Time) (*models.IssuedToken, error) {
+	var token models.IssuedToken
+	err := r.db.Where("jti = ? AND expires_at > ?", jti, at).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// PurgeExpiredIssuedTokens deletes the records of tokens that have expired.
+func (r *UserRepository) PurgeExpiredIssuedTokens(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at <= ?", before).Delete(&models.IssuedToken{})
+	return result.RowsAffected, result.Error
+}
+
+// CreateOAuthAuthorization stores a pending OAuth sign-in.
+func (r *UserRepository) CreateOAuthAuthorization(authorization *models.OAuthAuthorization) error {
+	return r.db.Create(authorization).Error
+}
+
+// TakeOAuthAuthorization removes and returns the unexpired OAuth sign-in stored under keyHash, or nil
+// when there is none. Each record can be taken once.
+func (r *UserRepository) TakeOAuthAuthorization(keyHash string, at time.Time) (*models.OAuthAuthorization, error) {
+	var authorization models.OAuthAuthorization
+	err := r.db.Where("key_hash = ? AND expires_at > ?", keyHash, at).First(&authorization).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// A concurrent taker that deleted the row first wins
+	result := r.db.Delete(&models.OAuthAuthorization{}, "id = ?", authorization.ID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &authorization, nil
+}
+
+// PurgeExpiredOAuthAuthorizations deletes OAuth sign-ins that were never completed.
+func (r *UserRepository) PurgeExpiredOAuthAuthorizations(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at <= ?", before).Delete(&models.OAuthAuthorization{})
+	return result.RowsAffected, result.Error
+}
+
 // IncrementLoginAttempts increments the login attempts counter
 func (r *UserRepository) IncrementLoginAttempts(userID uint64) error {
 	return r.db.Model(&models.User{}).
@@ -110,6 +368,22 @@ func (r *UserRepository) IncrementLoginAttempts(userID uint64) error {
 		Error
 }
 
+// IncrementMFAAttempts increments the failed MFA attempts counter
+func (r *UserRepository) IncrementMFAAttempts(userID uint64) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("mfa_failed_attempts", gorm.Expr("mfa_failed_attempts + ?", 1)).
+		Error
+}
+
+// ResetMFAAttempts clears the failed MFA attempts counter
+func (r *UserRepository) ResetMFAAttempts(userID uint64) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("mfa_failed_attempts", 0).
+		Error
+}
+
 // LockAccount locks a user account until the specified time
 func (r *UserRepository) LockAccount(userID uint64, until time.Time) error {
 	return r.db.Model(&models.User{}).
@@ -128,29 +402,197 @@ func (r *UserRepository) UnlockAccount(userID uint64) error {
 		}).Error
 }
 
+// ListInactiveUsers returns active users whose last login is older than lastLoginBefore,
+// or who never logged in and were created before createdBefore.
+func (r *UserRepository) ListInactiveUsers(lastLoginBefore, createdBefore time.Time, includeSuperAdmins bool) ([]*models.User, error) {
+	var users []*models.User
+	query := r.db.Model(&models.User{}).
+		Where("is_active = ?", true).
+		Where("(last_login IS NOT NULL AND last_login < ?) OR (last_login IS NULL AND created_at < ?)", lastLoginBefore, createdBefore)
+	if !includeSuperAdmins {
+		query = query.Where("is_super_admin = ?", false)
+	}
+	if err := query.Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// Deactivate marks a user account as inactive
+func (r *UserRepository) Deactivate(userID uint64) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("is_active", false).
+		Error
+}
+
 // Delete soft deletes a user
 func (r *UserRepository) Delete(userID uint64) error {
 	return r.db.Delete(&models.User{}, "id = ?", userID).Error
 }
 
-// List retrieves users with pagination
-func (r *UserRepository) List(offset, limit int) ([]*models.User, int64, error) {
+// List retrieves a page of users matching the filter, with the number of matching users.
+func (r *UserRepository) List(filter models.UserListFilter) ([]*models.User, int64, error) {
+	query := r.baseQuery().Model(&models.User{})
+	if q := strings.ToLower(strings.TrimSpace(filter.Query)); q != "" {
+		pattern := "%" + escapeLike(q) + "%"
+		query = query.Where(
+			"LOWER(email) LIKE ? ESCAPE '\\' OR LOWER(username) LIKE ? ESCAPE '\\' OR LOWER(first_name) LIKE ? ESCAPE '\\' OR LOWER(last_name) LIKE ? ESCAPE '\\'",
+			pattern, pattern, pattern, pattern,
+		)
+	}
+	if filter.IsActive != nil {
+		query = query.Where("is_active = ?", *filter.IsActive)
+	}
+	if filter.IsVerified != nil {
+		query = query.Where("is_verified = ?", *filter.IsVerified)
+	}
+	if filter.OrganizationID != nil {
+		members := r.db.Model(&models.UserOrganization{}).Select("user_id").Where("organization_id = ?", *filter.OrganizationID)
+		query = query.Where("id IN (?)", members)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	direction := "ASC"
+	if filter.Descending {
+		direction = "DESC"
+	}
+	switch filter.Sort {
+	case models.UserSortCreatedAt, models.UserSortEmail, models.UserSortUsername:
+		query = query.Order(filter.Sort + " " + direction).Order("id " + direction)
+	default:
+		query = query.Order("id " + direction)
+	}
+
+	var users []*models.User
+	query = query.Offset(filter.Offset)
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if err := query.Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// escapeLike escapes the LIKE wildcards in value so it is matched literally.
+func escapeLike(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
+}
+
+// ListUsersByRole retrieves users holding the organization role, optionally in a single organization,
+// ordered by ID.
+func (r *UserRepository) ListUsersByRole(role models.OrganizationRole, orgID *uint64, offset, limit int) ([]*models.User, int64, error) {
+	var users []*models.User
+	var total int64
+
+	members := r.db.Model(&models.UserOrganization{}).Select("user_id").Where("role = ?", role)
+	if orgID != nil {
+		members = members.Where("organization_id = ?", *orgID)
+	}
+
+	if err := r.db.Model(&models.User{}).Where("id IN (?)", members).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := r.baseQuery().Where("id IN (?)", members).
+		Order("id ASC").
+		Offset(offset).Limit(limit).
+		Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// ListUnverified retrieves users that have not verified their email, oldest registrations first
+func (r *UserRepository) ListUnverified(offset, limit int) ([]*models.User, int64, error) {
 	var users []*models.User
 	var total int64
 
-	// Get total count
-	if err := r.db.Model(&models.User{}).Count(&total).Error; err != nil {
+	if err := r.db.Model(&models.User{}).Where("is_verified = ?", false).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	// Get paginated results
-	if err := r.baseQuery().Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+	if err := r.db.Where("is_verified = ?", false).
+		Order("created_at ASC").Order("id ASC").
+		Offset(offset).Limit(limit).
+		Find(&users).Error; err != nil {
 		return nil, 0, err
 	}
 
 	return users, total, nil
 }
 
+// CountMFAAdoption returns the number of active users and how many of them have MFA enabled.
+func (r *UserRepository) CountMFAAdoption() (total, enabled int64, err error) {
+	var row struct {
+		Total   int64
+		Enabled int64
+	}
+	err = r.db.Model(&models.User{}).
+		Select("COUNT(*) AS total, COALESCE(SUM(CASE WHEN mfa_enabled THEN 1 ELSE 0 END), 0) AS enabled").
+		Where("is_active = ?", true).
+		Scan(&row).Error
+	return row.Total, row.Enabled, err
+}
+
+// CountMFAAdoptionByOrganization counts each organization's active members and how many of them have
+// MFA enabled. Users belonging to several organizations are counted in each.
+func (r *UserRepository) CountMFAAdoptionByOrganization() ([]*models.MFAAdoptionCount, error) {
+	var counts []*models.MFAAdoptionCount
+	err := r.db.Model(&models.UserOrganization{}).
+		Select("user_organizations.organization_id, organizations.name AS organization_name, " +
+			"COUNT(*) AS total_users, " +
+			"COALESCE(SUM(CASE WHEN users.mfa_enabled THEN 1 ELSE 0 END), 0) AS mfa_enabled").
+		Joins("JOIN users ON users.id = user_organizations.user_id AND users.deleted_at IS NULL AND users.is_active = ?", true).
+		Joins("JOIN organizations ON organizations.id = user_organizations.organization_id AND organizations.deleted_at IS NULL").
+		Group("user_organizations.organization_id, organizations.name").
+		Order("user_organizations.organization_id ASC").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// UpdateVerificationToken stores the digest of a new verification token, when it was sent and when it expires
+func (r *UserRepository) UpdateVerificationToken(userID uint64, token string, sentAt, expiresAt time.Time) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"verification_token":   token,
+			"verification_sent_at": sentAt,
+			"verification_expiry":  expiresAt,
+		}).Error
+}
+
+// GetByVerificationToken retrieves the user holding an outstanding verification token by its digest
+func (r *UserRepository) GetByVerificationToken(token string) (*models.User, error) {
+	var user models.User
+	err := r.baseQuery().First(&user, "verification_token = ?", token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// MarkVerified flags the user's email as verified and clears the verification token
+func (r *UserRepository) MarkVerified(userID uint64) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"is_verified":         true,
+			"verification_token":  nil,
+			"verification_expiry": nil,
+		}).Error
+}
+
 // ExistsByEmail checks if a user with the given email exists
 func (r *UserRepository) ExistsByEmail(email string) (bool, error) {
 	var count int64
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// apiKeyPrefix marks issued keys so they are recognisable in logs and secret scanners.
+const apiKeyPrefix = "ak_"
+
+// apiKeyDisplayLength is how much of a key is kept in clear to identify it.
+const apiKeyDisplayLength = len(apiKeyPrefix) + 8
+
+var (
+	ErrInvalidAPIKey        = errors.New("invalid API key")
+	ErrAPIKeyNotFound       = errors.New("API key not found")
+	ErrInvalidAPIKeyRequest = errors.New("invalid API key request")
+)
+
+// IssueAPIKey creates an API key for the user, valid until expiresAt when set, and returns it together
+// with the plaintext key, which is not stored and cannot be retrieved again. The key is bound to
+// organizationID, or to the user's primary organization when nil, and the user must belong to it unless
+// they are a super admin.
+func (s *AuthenticationService) IssueAPIKey(userID uint64, organizationID *uint64, name string, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	now := s.now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, "", fmt.Errorf("%w: expires_at must be in the future", ErrInvalidAPIKeyRequest)
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return nil, "", fmt.Errorf("%w: name is required and must be at most 100 characters", ErrInvalidAPIKeyRequest)
+	}
+	cleaned := make([]string, 0, len(scopes))
+	seen := make(map[string]struct{}, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			return nil, "", fmt.Errorf("%w: scopes must not be empty", ErrInvalidAPIKeyRequest)
+		}
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+		seen[scope] = struct{}{}
+		cleaned = append(cleaned, scope)
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if user == nil {
+		return nil, "", ErrUserNotFound
+	}
+	if organizationID == nil {
+		organizationID = user.PrimaryOrganizationID
+	}
+	if organizationID != nil && !user.IsSuperAdmin {
+		membership, err := s.orgRepo.GetUserOrganization(userID, *organizationID)
+		if err != nil {
+			return nil, "", err
+		}
+		if membership == nil {
+			return nil, "", ErrNotOrganizationMember
+		}
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", fmt.Errorf("generate API key: %w", err)
+	}
+	plaintext := apiKeyPrefix + hex.EncodeToString(buf)
+
+	key := &models.APIKey{
+		UserID:         userID,
+		OrganizationID: organizationID,
+		Name:           name,
+		Prefix:         plaintext[:apiKeyDisplayLength],
+		KeyHash:        hashAPIKey(plaintext),
+		Scopes:         cleaned,
+		ExpiresAt:      expiresAt,
+		CreatedAt:      now,
+	}
+	if err := s.userRepo.CreateAPIKey(key); err != nil {
+		return nil, "", fmt.Errorf("store API key: %w", err)
+	}
+	s.recordAudit(&models.AuditEvent{
+		Actor:     models.AuditUserRef(userID),
+		Action:    models.AuditActionAPIKeyIssue,
+		Target:    models.AuditUserRef(userID),
+		Success:   true,
+		Timestamp: key.CreatedAt,
+		Metadata:  map[string]any{"api_key_id": key.ID, "name": key.Name, "scopes": key.Scopes, "organization_id": key.OrganizationID},
+	})
+	return key, plaintext, nil
+}
+
+// ListAPIKeys returns the user's API keys without their secrets.
+func (s *AuthenticationService) ListAPIKeys(userID uint64) ([]*models.APIKey, error) {
+	return s.userRepo.ListAPIKeys(userID)
+}
+
+// RevokeAPIKey deletes one of the user's API keys. Keys belonging to other users are reported as not found.
+func (s *AuthenticationService) RevokeAPIKey(userID, keyID uint64) error {
+	deleted, err := s.userRepo.DeleteAPIKey(userID, keyID)
+	if err != nil {
+		return fmt.Errorf("revoke API key: %w", err)
+	}
+	if !deleted {
+		return ErrAPIKeyNotFound
+	}
+	s.recordAudit(&models.AuditEvent{
+		Actor:     models.AuditUserRef(userID),
+		Action:    models.AuditActionAPIKeyRevoke,
+		Target:    models.AuditUserRef(userID),
+		Success:   true,
+		Timestamp: s.now(),
+		Metadata:  map[string]any{"api_key_id": keyID},
+	})
+	return nil
+}
+
+// AuthenticateAPIKey resolves a plaintext key to its record and records the use. It also returns the
+// auth context an access token of the key's owner would carry, so permission and scope checks treat the
+// key like a token. Expired keys, keys of inactive or locked accounts and keys whose owner has since left
+// the key's organization are rejected like unknown keys.
+func (s *AuthenticationService) AuthenticateAPIKey(plaintext string) (*models.APIKey, *models.TokenDetails, error) {
+	plaintext = strings.TrimSpace(plaintext)
+	if !strings.HasPrefix(plaintext, apiKeyPrefix) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	key, err := s.userRepo.GetAPIKeyByHash(hashAPIKey(plaintext))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := s.now()
+	if key == nil || (key.ExpiresAt != nil && !now.Before(*key.ExpiresAt)) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	user, err := s.userRepo.GetByID(key.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil || !user.IsActive || (user.LockedUntil != nil && user.LockedUntil.After(now)) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	details, err := s.apiKeyDetails(user, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if details == nil {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	if err := s.userRepo.TouchAPIKey(key.ID, now); err != nil {
+		log.Printf("failed to record use of API key %d: %v", key.ID, err)
+	} else {
+		key.LastUsedAt = &now
+	}
+	return key, details, nil
+}
+
+// apiKeyDetails builds the auth context of a key's owner from their current memberships: the roles an
+// access token would carry, the key's organization, and the ROLE_SCOPES of the owner's role there. The
+// key's own scopes narrow it further and are checked separately. It returns nil when the owner no longer
+// belongs to the key's organization and is not a super admin.
+func (s *AuthenticationService) apiKeyDetails(user *models.User, key *models.APIKey) (*models.TokenDetails, error) {
+	memberships, err := s.orgRepo.ListUserOrganizations(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("load API key owner memberships: %w", err)
+	}
+	var organization *models.Organization
+	if key.OrganizationID != nil {
+		for _, membership := range memberships {
+			if membership != nil && membership.OrganizationID == *key.OrganizationID {
+				organization = &models.Organization{ID: membership.OrganizationID}
+				break
+			}
+		}
+		if organization == nil && !user.IsSuperAdmin {
+			return nil, nil
+		}
+	}
+	details := &models.TokenDetails{
+		UserID:         user.ID,
+		OrganizationID: key.OrganizationID,
+		IsSuperAdmin:   user.IsSuperAdmin,
+		Scopes:         s.roleScopes(user, organization, memberships),
+	}
+	roles := make([]string, 0, len(memberships))
+	for _, membership := range memberships {
+		if membership != nil && membership.Role != "" {
+			roles = append(roles, string(membership.Role))
+		}
+	}
+	if len(roles) > 0 {
+		details.Roles = uniqueStrings(roles)
+	}
+	return details, nil
+}
+
+// hashAPIKey returns the digest stored in place of an API key. Keys carry 256 bits of randomness, so an
+// unsalted SHA-256 is enough and allows lookup by digest.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestIssueAPIKeyStoresOnlyTheHash(t *testing.T) {
+	env := newTestEnv(t, nil)
+	user := env.createUser(t, "owner", nil)
+
+	key, plaintext, err := env.auth.IssueAPIKey(user.ID, nil, " billing-sync ", []string{"auth.users.read", "auth.users.read"}, nil)
+	if err != nil {
+		t.Fatalf("IssueAPIKey() error = %v", err)
+	}
+	if !strings.HasPrefix(plaintext, apiKeyPrefix) || !strings.HasPrefix(plaintext, key.Prefix) {
+		t.Fatalf("plaintext %q does not start with %q and the display prefix %q", plaintext, apiKeyPrefix, key.Prefix)
+	}
+	if key.Name != "billing-sync" || len(key.Scopes) != 1 || key.LastUsedAt != nil {
+		t.Errorf("key = %+v, want a trimmed name, deduplicated scopes and no use yet", key)
+	}
+
+	var stored models.APIKey
+	if err := env.db.First(&stored, key.ID).Error; err != nil {
+		t.Fatalf("load stored key: %v", err)
+	}
+	if stored.KeyHash != hashAPIKey(plaintext) {
+		t.Errorf("stored hash = %q, want the SHA-256 of the key", stored.KeyHash)
+	}
+	if strings.Contains(stored.KeyHash, plaintext) || strings.Contains(stored.Prefix, plaintext) {
+		t.Error("stored key contains the plaintext")
+	}
+
+	keys, err := env.auth.ListAPIKeys(user.ID)
+	if err != nil {
+		t.Fatalf("ListAPIKeys() error = %v", err)
+	}
+	if len(keys) != 1 || keys[0].ID != key.ID {
+		t.Fatalf("ListAPIKeys() = %+v, want the issued key", keys)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	for name, issue := range map[string]func() error{
+		"blank name":     func() error { _, _, err := env.auth.IssueAPIKey(user.ID, nil, " ", nil, nil); return err },
+		"blank scope":    func() error { _, _, err := env.auth.IssueAPIKey(user.ID, nil, "sync", []string{" "}, nil); return err },
+		"expiry in past": func() error { _, _, err := env.auth.IssueAPIKey(user.ID, nil, "sync", nil, &past); return err },
+	} {
+		if err := issue(); !errors.Is(err, ErrInvalidAPIKeyRequest) {
+			t.Errorf("%s: IssueAPIKey() error = %v, want %v", name, err, ErrInvalidAPIKeyRequest)
+		}
+	}
+	if _, _, err := env.auth.IssueAPIKey(9999, nil, "sync", nil, nil); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("unknown user: IssueAPIKey() error = %v, want %v", err, ErrUserNotFound)
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     func(plaintext string) string
+		expired bool
+		prepare func(t *testing.T, env *testEnv, user *models.User)
+		wantErr error
+	}{
+		{name: "valid key", key: func(plaintext string) string { return " " + plaintext + " " }},
+		{name: "unknown key", key: func(string) string { return apiKeyPrefix + strings.Repeat("0", 64) }, wantErr: ErrInvalidAPIKey},
+		{name: "missing prefix", key: func(plaintext string) string { return strings.TrimPrefix(plaintext, apiKeyPrefix) }, wantErr: ErrInvalidAPIKey},
+		{name: "expired key", key: func(plaintext string) string { return plaintext }, expired: true, wantErr: ErrInvalidAPIKey},
+		{
+			name: "inactive user",
+			key:  func(plaintext string) string { return plaintext },
+			prepare: func(t *testing.T, env *testEnv, user *models.User) {
+				if err := env.db.Model(user).Update("is_active", false).Error; err != nil {
+					t.Fatalf("deactivate user: %v", err)
+				}
+			},
+			wantErr: ErrInvalidAPIKey,
+		},
+		{
+			name: "locked user",
+			key:  func(plaintext string) string { return plaintext },
+			prepare: func(t *testing.T, env *testEnv, user *models.User) {
+				if err := env.users.LockAccount(user.ID, time.Now().Add(time.Hour)); err != nil {
+					t.Fatalf("lock user: %v", err)
+				}
+			},
+			wantErr: ErrInvalidAPIKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now().UTC().Truncate(time.Second)
+			env := newTestEnv(t, nil)
+			env.auth.WithClock(func() time.Time { return now })
+			user := env.createUser(t, "owner", nil)
+			expiresAt := now.Add(time.Hour)
+			issued, plaintext, err := env.auth.IssueAPIKey(user.ID, nil, "sync", nil, &expiresAt)
+			if err != nil {
+				t.Fatalf("IssueAPIKey() error = %v", err)
+			}
+			if tt.expired {
+				now = expiresAt
+			}
+			if tt.prepare != nil {
+				tt.prepare(t, env, user)
+			}
+
+			key, details, err := env.auth.AuthenticateAPIKey(tt.key(plaintext))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AuthenticateAPIKey() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var stored models.APIKey
+			if err := env.db.First(&stored, issued.ID).Error; err != nil {
+				t.Fatalf("load stored key: %v", err)
+			}
+			if err != nil {
+				if stored.LastUsedAt != nil {
+					t.Errorf("last_used_at = %v after a rejected key, want unset", stored.LastUsedAt)
+				}
+				return
+			}
+			if key.ID != issued.ID || key.UserID != user.ID {
+				t.Errorf("AuthenticateAPIKey() = %+v, want the issued key of user %d", key, user.ID)
+			}
+			if details == nil || details.UserID != user.ID {
+				t.Errorf("AuthenticateAPIKey() details = %+v, want the owner's auth context", details)
+			}
+			if stored.LastUsedAt == nil || !stored.LastUsedAt.Equal(now) {
+				t.Errorf("last_used_at = %v, want %v", stored.LastUsedAt, now)
+			}
+		})
+	}
+}
+
+func TestAPIKeyOrganizationBinding(t *testing.T) {
+	env := newTestEnv(t, nil)
+	home := env.createOrganization(t, "Home", nil)
+	other := env.createOrganization(t, "Other", nil)
+	user := env.createUser(t, "owner", nil)
+	env.addMember(t, user, home, "CEO")
+	if err := env.db.Model(user).Update("primary_organization_id", home.ID).Error; err != nil {
+		t.Fatalf("set primary organization: %v", err)
+	}
+
+	if _, _, err := env.auth.IssueAPIKey(user.ID, &other.ID, "sync", nil, nil); !errors.Is(err, ErrNotOrganizationMember) {
+		t.Fatalf("IssueAPIKey(other organization) error = %v, want %v", err, ErrNotOrganizationMember)
+	}
+	key, plaintext, err := env.auth.IssueAPIKey(user.ID, nil, "sync", nil, nil)
+	if err != nil {
+		t.Fatalf("IssueAPIKey() error = %v", err)
+	}
+	if key.OrganizationID == nil || *key.OrganizationID != home.ID {
+		t.Fatalf("key organization = %v, want the primary organization %d", key.OrganizationID, home.ID)
+	}
+
+	_, details, err := env.auth.AuthenticateAPIKey(plaintext)
+	if err != nil {
+		t.Fatalf("AuthenticateAPIKey() error = %v", err)
+	}
+	if details.OrganizationID == nil || *details.OrganizationID != home.ID {
+		t.Errorf("details organization = %v, want %d", details.OrganizationID, home.ID)
+	}
+
+	if err := env.orgs.RemoveUserOrganization(user.ID, home.ID, true); err != nil {
+		t.Fatalf("remove membership: %v", err)
+	}
+	if _, _, err := env.auth.AuthenticateAPIKey(plaintext); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("AuthenticateAPIKey() after leaving the organization error = %v, want %v", err, ErrInvalidAPIKey)
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// AuditLogger records security-relevant events.
+type AuditLogger interface {
+	Record(event *models.AuditEvent) error
+}
+
+// WebhookNotifier posts audit events to an external endpoint.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier returns a notifier for the given URL, or nil when the URL is blank.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return nil
+	}
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Notify delivers the event as a JSON payload.
+func (n *WebhookNotifier) Notify(event *models.AuditEvent) error {
+	if n == nil || event == nil {
+		return nil
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// recordAudit stores an audit event without failing the calling operation.
+func (s *AuthenticationService) recordAudit(event *models.AuditEvent) {
+	if s.audit == nil || event == nil {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = s.now()
+	}
+	if err := s.audit.Record(event); err != nil {
+		log.Printf("failed to record audit event %s: %v", event.Action, err)
+	}
+}
+
+// notifySecurityEvent forwards an audit event to the configured webhook, if any.
+func (s *AuthenticationService) notifySecurityEvent(event *models.AuditEvent) {
+	if s.notifier == nil || event == nil {
+		return
+	}
+	if err := s.notifier.Notify(event); err != nil {
+		log.Printf("failed to deliver security webhook for %s: %v", event.Action, err)
+	}
+}
+
+// recordAudit stores an event performed by the given user (or the system when actorID is zero)
+// without failing the calling operation.
+func (s *OrganizationService) recordAudit(actorID uint64, action, target string, orgID uint64, metadata map[string]any) {
+	if s.audit == nil {
+		return
+	}
+
+	actor := models.AuditActorSystem
+	if actorID != 0 {
+		actor = models.AuditUserRef(actorID)
+	}
+	event := &models.AuditEvent{
+		Actor:     actor,
+		Action:    action,
+		Target:    target,
+		OrgID:     &orgID,
+		Success:   true,
+		Timestamp: time.Now(),
+		Metadata:  metadata,
+	}
+	if err := s.audit.Record(event); err != nil {
+		log.Printf("failed to record audit event %s: %v", event.Action, err)
+	}
+}
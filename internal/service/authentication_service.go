@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/lee-tech/authentication/config"
 	"github.com/lee-tech/authentication/internal/constants"
 	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/redirect"
 	"github.com/lee-tech/authentication/internal/repository"
 	coreServer "github.com/lee-tech/core/server"
 	"github.com/lee-tech/core/utils"
@@ -18,18 +20,34 @@ import (
 )
 
 var (
-	ErrInvalidCredentials = errors.New("invalid username or password")
-	ErrAccountLocked      = errors.New("account is locked due to too many failed attempts")
-	ErrAccountInactive    = errors.New("account is not active")
-	ErrUserExists         = errors.New("user already exists")
-	ErrInvalidToken       = errors.New("invalid token")
+	ErrInvalidCredentials   = errors.New("invalid username or password")
+	ErrAccountLocked        = errors.New("account is locked due to too many failed attempts")
+	ErrAccountInactive      = errors.New("account is not active")
+	ErrUserExists           = errors.New("user already exists")
+	ErrEmailRegistered      = errors.New("email already registered")
+	ErrUsernameTaken        = errors.New("username already taken")
+	ErrRegistrationDisabled = errors.New("self-service registration is disabled")
+	ErrInvalidToken         = errors.New("invalid token")
+	ErrTokenRevoked         = errors.New("token has been revoked")
+	ErrInsufficientRole     = errors.New("role level is insufficient to log into this organization")
+	ErrOrganizationInactive = errors.New("organization is inactive")
+	ErrDepartmentInactive   = errors.New("department is inactive")
+	ErrRoleNotAssigned      = errors.New("role is not assigned to the user in this organization")
+	ErrEmailNotVerified     = errors.New("email address is not verified")
 )
 
 // AuthenticationService handles authentication business logic
 type AuthenticationService struct {
-	userRepo *repository.UserRepository
-	orgRepo  *repository.OrganizationRepository
-	config   *config.AuthConfig
+	userRepo     *repository.UserRepository
+	orgRepo      *repository.OrganizationRepository
+	audit        AuditLogger
+	notifier     *WebhookNotifier
+	redirects    *redirect.Policy
+	verification VerificationSender
+	resets       PasswordResetSender
+	breaches     PasswordBreachChecker
+	config       *config.AuthConfig
+	now          func() time.Time
 }
 
 // BootstrapAdminInput describes the desired bootstrap configuration for the root administrator.
@@ -46,17 +64,40 @@ type BootstrapAdminInput struct {
 }
 
 // NewAuthService creates a new auth service
-func NewAuthenticationService(userRepo *repository.UserRepository, orgRepo *repository.OrganizationRepository, config *config.AuthConfig) *AuthenticationService {
+func NewAuthenticationService(userRepo *repository.UserRepository, orgRepo *repository.OrganizationRepository, audit AuditLogger, config *config.AuthConfig) *AuthenticationService {
 	return &AuthenticationService{
 		userRepo: userRepo,
 		orgRepo:  orgRepo,
+		audit:    audit,
+		notifier: NewWebhookNotifier(config.SecurityWebhookURL),
 		config:   config,
+		now:      time.Now,
 	}
 }
 
+// WithRedirectPolicy sets the allow-list applied to OAuth and magic-link redirect targets.
+// Without one only same-origin paths are accepted.
+func (s *AuthenticationService) WithRedirectPolicy(policy *redirect.Policy) *AuthenticationService {
+	s.redirects = policy
+	return s
+}
+
+// WithClock overrides the time source used by scheduled jobs.
+func (s *AuthenticationService) WithClock(now func() time.Time) *AuthenticationService {
+	if now != nil {
+		s.now = now
+	}
+	return s
+}
+
 // BootstrapDefaultAdmin ensures the default organization and super-admin account exist.
-func (s *AuthenticationService) BootstrapDefaultAdmin() (*models.Organization, *models.User, error) {
-	input := &BootstrapAdminInput{
+func (s *AuthenticationService) BootstrapDefaultAdmin() (*models.BootstrapResult, error) {
+	return s.BootstrapAdmin(s.DefaultBootstrapInput())
+}
+
+// DefaultBootstrapInput returns the bootstrap input configured through the BOOTSTRAP_* settings.
+func (s *AuthenticationService) DefaultBootstrapInput() *BootstrapAdminInput {
+	return &BootstrapAdminInput{
 		OrganizationName:        s.config.BootstrapOrganizationName,
 		OrganizationDescription: s.config.BootstrapOrganizationDescription,
 		OrganizationDomain:      s.config.BootstrapOrganizationDomain,
@@ -66,17 +107,42 @@ func (s *AuthenticationService) BootstrapDefaultAdmin() (*models.Organization, *
 		AdminFirstName:          s.config.BootstrapAdminFirstName,
 		AdminLastName:           s.config.BootstrapAdminLastName,
 	}
-	return s.BootstrapAdmin(input)
 }
 
-// BootstrapAdmin performs bootstrap/rotation based on the provided input.
-func (s *AuthenticationService) BootstrapAdmin(input *BootstrapAdminInput) (*models.Organization, *models.User, error) {
+// ErrDefaultBootstrapEmail is returned when production would bootstrap the shipped admin email.
+var ErrDefaultBootstrapEmail = errors.New("BOOTSTRAP_ADMIN_EMAIL must be changed from the default in production")
+
+// checkBootstrapAdminEmail refuses the shipped default admin email in production, where password resets
+// and notifications need a reachable address, and warns about it elsewhere.
+func (s *AuthenticationService) checkBootstrapAdminEmail(email string) error {
+	if !strings.EqualFold(strings.TrimSpace(email), config.DefaultBootstrapAdminEmail) {
+		return nil
+	}
+	if s.config.IsProduction() && s.config.BootstrapRequireCustomEmail {
+		return ErrDefaultBootstrapEmail
+	}
+	log.Printf("WARNING: bootstrap admin uses the default email %s; set BOOTSTRAP_ADMIN_EMAIL to a real address before going to production", config.DefaultBootstrapAdminEmail)
+	return nil
+}
+
+// BootstrapAdmin performs bootstrap/rotation based on the provided input and reports whether the
+// administrator was created and whether its password was rotated.
+func (s *AuthenticationService) BootstrapAdmin(input *BootstrapAdminInput) (*models.BootstrapResult, error) {
 	if s == nil || s.userRepo == nil || s.orgRepo == nil || s.config == nil {
-		return nil, nil, fmt.Errorf("authentication service not initialised for bootstrap")
+		return nil, fmt.Errorf("authentication service not initialised for bootstrap")
 	}
 
 	if input == nil {
-		return nil, nil, fmt.Errorf("bootstrap input is required")
+		return nil, fmt.Errorf("bootstrap input is required")
+	}
+	if err := s.checkBootstrapAdminEmail(input.AdminEmail); err != nil {
+		return nil, err
+	}
+
+	if cleared, err := s.orgRepo.ClearBlankOrganizationDomains(); err != nil {
+		return nil, fmt.Errorf("clear blank organization domains: %w", err)
+	} else if cleared > 0 {
+		log.Printf("stored %d empty organization domains as NULL", cleared)
 	}
 
 	org, err := s.orgRepo.EnsureOrganization(
@@ -85,12 +151,12 @@ func (s *AuthenticationService) BootstrapAdmin(input *BootstrapAdminInput) (*mod
 		input.OrganizationDomain,
 	)
 	if err != nil {
-		return nil, nil, fmt.Errorf("ensure organization: %w", err)
+		return nil, fmt.Errorf("ensure organization: %w", err)
 	}
 
 	email := strings.TrimSpace(input.AdminEmail)
 	if email == "" {
-		return nil, nil, fmt.Errorf("bootstrap admin email is required")
+		return nil, fmt.Errorf("bootstrap admin email is required")
 	}
 
 	username := strings.TrimSpace(input.AdminUsername)
@@ -99,23 +165,21 @@ func (s *AuthenticationService) BootstrapAdmin(input *BootstrapAdminInput) (*mod
 	}
 
 	password := input.AdminPassword
-	minPasswordLength := s.config.PasswordMinLength
-	if minPasswordLength <= 0 {
-		minPasswordLength = 8
-	}
-	if len(password) < minPasswordLength {
-		return nil, nil, fmt.Errorf("bootstrap admin password must be at least %d characters", minPasswordLength)
+	if err := s.ValidatePassword(password); err != nil {
+		return nil, fmt.Errorf("bootstrap admin password: %w", err)
 	}
 
 	user, err := s.userRepo.GetByEmail(email)
 	if err != nil {
-		return nil, nil, fmt.Errorf("lookup admin user: %w", err)
+		return nil, fmt.Errorf("lookup admin user: %w", err)
 	}
 
+	created := user == nil
+	passwordRotated := false
 	if user == nil {
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), s.config.BCryptCost)
 		if err != nil {
-			return nil, nil, fmt.Errorf("hash password: %w", err)
+			return nil, fmt.Errorf("hash password: %w", err)
 		}
 
 		firstName := strings.TrimSpace(input.AdminFirstName)
@@ -139,7 +203,7 @@ func (s *AuthenticationService) BootstrapAdmin(input *BootstrapAdminInput) (*mod
 			PrimaryOrganizationID: &org.ID,
 		}
 		if err := s.userRepo.Create(user); err != nil {
-			return nil, nil, fmt.Errorf("create admin user: %w", err)
+			return nil, fmt.Errorf("create admin user: %w", err)
 		}
 	} else {
 		firstName := strings.TrimSpace(input.AdminFirstName)
@@ -169,30 +233,60 @@ func (s *AuthenticationService) BootstrapAdmin(input *BootstrapAdminInput) (*mod
 		if needPasswordUpdate {
 			hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), s.config.BCryptCost)
 			if err != nil {
-				return nil, nil, fmt.Errorf("hash password: %w", err)
+				return nil, fmt.Errorf("hash password: %w", err)
 			}
 			user.Password = string(hashedPassword)
+			passwordRotated = true
 		}
 
 		if err := s.userRepo.Update(user); err != nil {
-			return nil, nil, fmt.Errorf("update admin user: %w", err)
+			return nil, fmt.Errorf("update admin user: %w", err)
 		}
 	}
 
 	if err := s.orgRepo.UpsertUserOrganization(user.ID, org.ID, models.OrganizationRoleSystemAdmin, true); err != nil {
-		return nil, nil, fmt.Errorf("assign admin organization membership: %w", err)
+		return nil, fmt.Errorf("assign admin organization membership: %w", err)
 	}
 	if err := s.orgRepo.SetUserPrimaryOrganization(user.ID, org.ID); err != nil {
-		return nil, nil, fmt.Errorf("set admin primary organization: %w", err)
+		return nil, fmt.Errorf("set admin primary organization: %w", err)
+	}
+
+	existingRoles, err := s.orgRepo.ListOrganizationRoles(org.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list organization roles: %w", err)
+	}
+	if missing := missingDefaultRoles(org.ID, existingRoles); len(missing) > 0 {
+		if _, err := s.orgRepo.CreateOrganizationRoles(missing); err != nil {
+			return nil, fmt.Errorf("provision organization roles: %w", err)
+		}
 	}
 
-	return org, user, nil
+	orgID := org.ID
+	s.recordAudit(&models.AuditEvent{
+		Actor:     models.AuditActorSystem,
+		Action:    models.AuditActionBootstrapAdmin,
+		Target:    models.AuditUserRef(user.ID),
+		OrgID:     &orgID,
+		Success:   true,
+		Timestamp: s.now(),
+	})
+
+	return &models.BootstrapResult{
+		Organization:    models.BootstrapOrganization{ID: org.ID, Name: org.Name, Domain: org.Domain},
+		User:            models.BootstrapUser{ID: user.ID, Email: user.Email, Username: user.Username},
+		Created:         created,
+		PasswordRotated: passwordRotated,
+	}, nil
 }
 
 // Login authenticates a user and returns tokens
-func (s *AuthenticationService) Login(req *models.LoginRequest) (*models.LoginResponse, error) {
+func (s *AuthenticationService) Login(req *models.LoginRequest) (response *models.LoginResponse, err error) {
+	if req.SelectionToken != "" {
+		return s.completeLoginSelection(req)
+	}
+
 	// Find user by email or username
-	user, err := s.userRepo.GetByEmailOrUsername(req.Username)
+	user, err := s.findLoginUser(req.Username)
 	if err != nil {
 		return nil, err
 	}
@@ -200,6 +294,9 @@ func (s *AuthenticationService) Login(req *models.LoginRequest) (*models.LoginRe
 	if user == nil {
 		return nil, ErrInvalidCredentials
 	}
+	defer func() {
+		s.recordLoginAttempt(user, req, response, err)
+	}()
 
 	// Check if account is locked
 	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
@@ -220,58 +317,107 @@ func (s *AuthenticationService) Login(req *models.LoginRequest) (*models.LoginRe
 		if user.LoginAttempts+1 >= s.config.MaxLoginAttempts {
 			lockUntil := time.Now().Add(s.config.LockoutDuration)
 			s.userRepo.LockAccount(user.ID, lockUntil)
+			s.recordAudit(&models.AuditEvent{
+				Actor:     models.AuditUserRef(user.ID),
+				Action:    models.AuditActionAccountLockout,
+				Target:    models.AuditUserRef(user.ID),
+				IP:        req.IPAddress,
+				Success:   true,
+				Timestamp: s.now(),
+				Metadata: map[string]any{
+					"failed_attempts": user.LoginAttempts + 1,
+					"locked_until":    lockUntil,
+				},
+			})
 		}
 
 		return nil, ErrInvalidCredentials
 	}
 
+	if s.config.RequireEmailVerification && !user.IsVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	if err := s.requireLoginMFA(user, req); err != nil {
+		return nil, err
+	}
+
 	orgMemberships, deptMemberships, err := s.collectMemberships(&user.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	var loggedOrganization *models.Organization
-
-	for _, member := range orgMemberships {
-		if member.OrganizationID == uint64(req.OrganizationID) {
-			// Role validation
-			if role := string(member.Role); role != "" && role != string(models.OrganizationRoleSystemAdmin) {
-				return nil, fmt.Errorf("user does not have the required role in the organization")
-			}
+	organizationID := req.OrganizationID
+	if organizationID == 0 {
+		organizationID, err = s.selectLoginOrganization(user, orgMemberships, deptMemberships)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-			org, err := s.orgRepo.GetOrganizationByID(member.OrganizationID)
-			if err != nil {
-				return nil, fmt.Errorf("failed to get organization: %w", err)
-			}
+	return s.completeLogin(user, organizationID, req.DepartmentID, req.RoleID, orgMemberships, deptMemberships)
+}
 
-			loggedOrganization = org
-			break
+// findLoginUser resolves a login identifier to a single user. Email-shaped identifiers are looked up
+// as emails first, case-insensitively when LoginEmailCaseInsensitive is set; a username that merely
+// looks like an email is found when no account has that email. Other identifiers match usernames
+// exactly, after trimming surrounding whitespace.
+func (s *AuthenticationService) findLoginUser(identifier string) (*models.User, error) {
+	identifier = strings.TrimSpace(identifier)
+	if identifier == "" {
+		return nil, nil
+	}
+	if utils.IsEmail(identifier) {
+		user, err := s.findUserByEmail(identifier)
+		if err != nil || user != nil {
+			return user, err
 		}
 	}
+	return s.userRepo.GetByUsername(identifier)
+}
 
-	var loggedDepartment *models.Department
-	for _, member := range deptMemberships {
-		if member.DepartmentID == uint64(req.DepartmentID) {
-			dept, err := s.orgRepo.GetDepartmentByID(member.DepartmentID)
-			if err != nil {
-				return nil, fmt.Errorf("failed to get department: %w", err)
-			}
-			loggedDepartment = dept
-			break
-		}
+// findUserByEmail looks up an account by email, case-insensitively when LoginEmailCaseInsensitive is set.
+func (s *AuthenticationService) findUserByEmail(email string) (*models.User, error) {
+	email = strings.TrimSpace(email)
+	if s.config.LoginEmailCaseInsensitive {
+		return s.userRepo.GetByEmailFold(email)
 	}
+	return s.userRepo.GetByEmail(email)
+}
 
-	if loggedOrganization == nil {
-		return nil, fmt.Errorf("organization not found or user not a member")
+// normalizeEmail trims an email and, when emails are case-insensitive, lowercases it for storage.
+func (s *AuthenticationService) normalizeEmail(email string) string {
+	email = strings.TrimSpace(email)
+	if s.config.LoginEmailCaseInsensitive {
+		email = strings.ToLower(email)
+	}
+	return email
+}
+
+// emailRegistered reports whether an account already uses the email, honouring LoginEmailCaseInsensitive.
+func (s *AuthenticationService) emailRegistered(email string) (bool, error) {
+	if !s.config.LoginEmailCaseInsensitive {
+		return s.userRepo.ExistsByEmail(email)
+	}
+	user, err := s.findUserByEmail(email)
+	return user != nil, err
+}
+
+// completeLogin issues tokens for the organization and department the user logs into. A non-zero roleID
+// must identify the role the user holds in that organization.
+func (s *AuthenticationService) completeLogin(user *models.User, organizationID, departmentID, roleID uint64, orgMemberships []*models.UserOrganization, deptMemberships []*models.UserDepartment) (*models.LoginResponse, error) {
+	loggedOrganization, loggedDepartment, err := s.resolveLoginContext(user, organizationID, departmentID, roleID, orgMemberships, deptMemberships)
+	if err != nil {
+		return nil, err
 	}
 
 	// Generate tokens
-	accessToken, err := s.generateAccessToken(user, orgMemberships, deptMemberships)
+	accessToken, err := s.generateAccessToken(user, loggedOrganization, loggedDepartment, orgMemberships, deptMemberships)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := s.generateRefreshToken(user)
+	refreshToken, err := s.generateRefreshToken(user, loggedOrganization, loggedDepartment)
 	if err != nil {
 		return nil, err
 	}
@@ -293,15 +439,80 @@ func (s *AuthenticationService) Login(req *models.LoginRequest) (*models.LoginRe
 	}, nil
 }
 
-// Register creates a new user account
+// resolveLoginContext loads the organization and department a token is issued for and checks that the
+// user may act in them: the organization and department must be active (except for super admins), the
+// member's role must meet the organization's login policy and a requested roleID must be assigned.
+func (s *AuthenticationService) resolveLoginContext(user *models.User, organizationID, departmentID, roleID uint64, orgMemberships []*models.UserOrganization, deptMemberships []*models.UserDepartment) (*models.Organization, *models.Department, error) {
+	var loggedOrganization *models.Organization
+
+	for _, member := range orgMemberships {
+		if member.OrganizationID == organizationID {
+			if roleID != 0 {
+				if err := s.requireAssignedRole(member, roleID); err != nil {
+					return nil, nil, err
+				}
+			}
+
+			org, err := s.orgRepo.GetOrganizationByID(member.OrganizationID)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to get organization: %w", err)
+			}
+
+			if org != nil && !org.IsActive && !user.IsSuperAdmin {
+				return nil, nil, ErrOrganizationInactive
+			}
+
+			if !user.IsSuperAdmin {
+				allowed, err := s.meetsLoginRoleLevel(org, member.Role)
+				if err != nil {
+					return nil, nil, err
+				}
+				if !allowed {
+					return nil, nil, ErrInsufficientRole
+				}
+			}
+
+			loggedOrganization = org
+			break
+		}
+	}
+
+	var loggedDepartment *models.Department
+	for _, member := range deptMemberships {
+		if member.DepartmentID == departmentID {
+			dept, err := s.orgRepo.GetDepartmentByID(member.DepartmentID)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to get department: %w", err)
+			}
+			if dept != nil && !dept.IsActive && !user.IsSuperAdmin {
+				return nil, nil, ErrDepartmentInactive
+			}
+			loggedDepartment = dept
+			break
+		}
+	}
+
+	if loggedOrganization == nil {
+		return nil, nil, fmt.Errorf("organization not found or user not a member")
+	}
+	return loggedOrganization, loggedDepartment, nil
+}
+
+// Register creates a new, unverified user account and sends it an email verification token. No tokens
+// are issued; the account is verified through VerifyEmail.
 func (s *AuthenticationService) Register(req *models.RegisterRequest) (*models.User, error) {
+	if !s.config.RegistrationEnabled {
+		return nil, ErrRegistrationDisabled
+	}
+
 	// Check if email already exists
-	exists, err := s.userRepo.ExistsByEmail(req.Email)
+	req.Email = s.normalizeEmail(req.Email)
+	exists, err := s.emailRegistered(req.Email)
 	if err != nil {
 		return nil, err
 	}
 	if exists {
-		return nil, fmt.Errorf("email already registered")
+		return nil, ErrEmailRegistered
 	}
 
 	// Check if username already exists
@@ -310,7 +521,14 @@ func (s *AuthenticationService) Register(req *models.RegisterRequest) (*models.U
 		return nil, err
 	}
 	if exists {
-		return nil, fmt.Errorf("username already taken")
+		return nil, ErrUsernameTaken
+	}
+
+	if err := s.ValidatePassword(req.Password); err != nil {
+		return nil, err
+	}
+	if err := s.checkPasswordBreach(req.Password); err != nil {
+		return nil, err
 	}
 
 	// Hash password
@@ -319,6 +537,14 @@ func (s *AuthenticationService) Register(req *models.RegisterRequest) (*models.U
 		return nil, err
 	}
 
+	verificationToken, err := generateVerificationToken()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	verificationExpiry := now.Add(s.config.VerificationTokenTTL)
+	verificationDigest := hashToken(verificationToken)
+
 	// Create user
 	user := &models.User{
 		Email:                 req.Email,
@@ -329,24 +555,33 @@ func (s *AuthenticationService) Register(req *models.RegisterRequest) (*models.U
 		PrimaryOrganizationID: req.PrimaryOrganizationID,
 		IsActive:              true,
 		IsVerified:            false, // Will need email verification
+		VerificationToken:     &verificationDigest,
+		VerificationExpiry:    &verificationExpiry,
+	}
+	if s.verification != nil {
+		user.VerificationSentAt = &now
 	}
 
 	if err := s.userRepo.Create(user); err != nil {
 		return nil, err
 	}
 
+	// The account exists either way; an undelivered token can be re-sent by an administrator
+	if s.verification != nil {
+		if err := s.verification.SendVerification(user, verificationToken); err != nil {
+			log.Printf("failed to send verification to user %d: %v", user.ID, err)
+		}
+	}
+
 	return user, nil
 }
 
-// RefreshToken validates a refresh token and returns new tokens
+// RefreshToken validates a refresh token and returns new tokens for the organization and department
+// it was issued for. The login checks apply again: a locked account, an inactive organization or
+// department, or a role below the organization's login policy is refused.
 func (s *AuthenticationService) RefreshToken(refreshToken string) (*models.LoginResponse, error) {
 	// Parse and validate refresh token
-	token, err := jwt.Parse(refreshToken, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.config.Config.JWTSecret), nil
-	})
+	token, err := jwt.Parse(refreshToken, s.TokenKeyFunc, jwt.WithIssuer(s.Issuer()))
 
 	if err != nil || !token.Valid {
 		return nil, ErrInvalidToken
@@ -363,16 +598,11 @@ func (s *AuthenticationService) RefreshToken(refreshToken string) (*models.Login
 	}
 
 	// Get user ID from claims
-	userIDStr, ok := claims["user_id"].(string)
+	userID, ok := claimUserID(claims)
 	if !ok {
 		return nil, ErrInvalidToken
 	}
 
-	userID, err := utils.ParseUint64(userIDStr)
-	if err != nil {
-		return nil, ErrInvalidToken
-	}
-
 	// Get user from database
 	user, err := s.userRepo.GetByID(userID)
 	if err != nil {
@@ -383,42 +613,84 @@ func (s *AuthenticationService) RefreshToken(refreshToken string) (*models.Login
 		return nil, ErrInvalidToken
 	}
 
+	// Reject tokens issued before the user's sessions were revoked
+	if tokenRevoked(user, claims) {
+		return nil, ErrInvalidToken
+	}
+	blacklisted, err := s.tokenBlacklisted(claims)
+	if err != nil {
+		return nil, err
+	}
+	if blacklisted {
+		return nil, ErrInvalidToken
+	}
+
+	if user.LockedUntil != nil && user.LockedUntil.After(s.now()) {
+		return nil, ErrAccountLocked
+	}
+
 	orgMemberships, deptMemberships, err := s.collectMemberships(&user.ID)
 	if err != nil {
 		return nil, err
 	}
 
+	// Keep the organization and department the session was issued for. Tokens minted before refresh
+	// tokens carried org_id fall back to the primary organization.
+	organizationID := claimUint64(claims, "org_id")
+	if organizationID == 0 && user.PrimaryOrganizationID != nil {
+		organizationID = *user.PrimaryOrganizationID
+	}
+	departmentID := claimUint64(claims, "dept_id")
+
+	var loggedOrganization *models.Organization
+	var loggedDepartment *models.Department
+	if organizationID != 0 {
+		// A membership removed since login ends the session
+		if !hasOrganizationMembership(orgMemberships, organizationID) {
+			return nil, ErrInvalidToken
+		}
+		loggedOrganization, loggedDepartment, err = s.resolveLoginContext(user, organizationID, departmentID, 0, orgMemberships, deptMemberships)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Generate new tokens
-	newAccessToken, err := s.generateAccessToken(user, orgMemberships, deptMemberships)
+	newAccessToken, err := s.generateAccessToken(user, loggedOrganization, loggedDepartment, orgMemberships, deptMemberships)
 	if err != nil {
 		return nil, err
 	}
 
-	newRefreshToken, err := s.generateRefreshToken(user)
+	newRefreshToken, err := s.generateRefreshToken(user, loggedOrganization, loggedDepartment)
 	if err != nil {
 		return nil, err
 	}
 
 	return &models.LoginResponse{
-		AccessToken:  newAccessToken,
-		RefreshToken: newRefreshToken,
-		ExpiresIn:    int(s.config.TokenExpiration.Seconds()),
-		TokenType:    "Bearer",
-		User:         s.composeUserInfo(user, orgMemberships, deptMemberships),
+		AccessToken:        newAccessToken,
+		RefreshToken:       newRefreshToken,
+		ExpiresIn:          int(s.config.TokenExpiration.Seconds()),
+		TokenType:          "Bearer",
+		User:               s.composeUserInfo(user, orgMemberships, deptMemberships),
+		LoggedOrganization: loggedOrganization,
+		LoggedDepartment:   loggedDepartment,
 	}, nil
 }
 
-// generateAccessToken generates a JWT access token enriched with membership context.
-func (s *AuthenticationService) generateAccessToken(user *models.User, orgMemberships []*models.UserOrganization, deptMemberships []*models.UserDepartment) (string, error) {
+// generateAccessToken generates a JWT access token enriched with membership context. org_id names the
+// organization the token was issued for, falling back to the primary organization, and the membership
+// claims flag that organization and department as active.
+func (s *AuthenticationService) generateAccessToken(user *models.User, loggedOrganization *models.Organization, loggedDepartment *models.Department, orgMemberships []*models.UserOrganization, deptMemberships []*models.UserDepartment) (string, error) {
 	now := time.Now()
 	expiresAt := now.Add(s.config.TokenExpiration)
 
 	claims := jwt.MapClaims{
-		"iss":      s.config.Config.ServiceName,
+		"iss":      s.Issuer(),
 		"sub":      user.ID,
 		"aud":      []string{s.config.Config.ServiceName},
 		"exp":      expiresAt.Unix(),
 		"iat":      now.Unix(),
+		"iat_us":   now.UnixMicro(),
 		"nbf":      now.Unix(),
 		"jti":      uuid.NewString(),
 		"type":     "access",
@@ -427,16 +699,28 @@ func (s *AuthenticationService) generateAccessToken(user *models.User, orgMember
 		"username": user.Username,
 	}
 
-	// Add organization ID if present
-	if user.PrimaryOrganizationID != nil {
+	// Add the ID of the active organization if present
+	if loggedOrganization != nil {
+		claims["org_id"] = loggedOrganization.ID
+	} else if user.PrimaryOrganizationID != nil {
 		claims["org_id"] = user.PrimaryOrganizationID
 	}
 
+	// Add the plan of the organization the token was issued for
+	if loggedOrganization != nil {
+		claims["tenant_tier"] = s.tenantTier(loggedOrganization)
+	}
+
 	// Add super admin flag
 	if user.IsSuperAdmin {
 		claims["is_super_admin"] = true
 	}
 
+	// Restrict the token to changing the password while a temporary one is in use
+	if user.MustChangePassword {
+		claims["must_change_password"] = true
+	}
+
 	if len(orgMemberships) > 0 {
 		orgClaims := make([]map[string]any, 0, len(orgMemberships))
 		roles := make([]string, 0, len(orgMemberships))
@@ -448,6 +732,9 @@ func (s *AuthenticationService) generateAccessToken(user *models.User, orgMember
 				"id":         membership.OrganizationID,
 				"is_primary": membership.IsPrimary,
 			}
+			if loggedOrganization != nil && membership.OrganizationID == loggedOrganization.ID {
+				claim["is_active"] = true
+			}
 			if membership.Organization != nil {
 				claim["name"] = membership.Organization.Name
 			}
@@ -457,12 +744,17 @@ func (s *AuthenticationService) generateAccessToken(user *models.User, orgMember
 			}
 			orgClaims = append(orgClaims, claim)
 		}
-		claims["organizations"] = orgClaims
+		claims[s.ClaimName("organizations")] = orgClaims
 		if len(roles) > 0 {
-			claims["roles"] = uniqueStrings(roles)
+			claims[s.ClaimName("roles")] = uniqueStrings(roles)
 		}
 	}
 
+	// Add the scopes granted by the role held in the organization the token is issued for
+	if scopes := s.roleScopes(user, loggedOrganization, orgMemberships); len(scopes) > 0 {
+		claims["scopes"] = scopes
+	}
+
 	if len(deptMemberships) > 0 {
 		deptClaims := make([]map[string]any, 0, len(deptMemberships))
 		for _, membership := range deptMemberships {
@@ -473,6 +765,9 @@ func (s *AuthenticationService) generateAccessToken(user *models.User, orgMember
 				"id":         membership.DepartmentID,
 				"is_primary": membership.IsPrimary,
 			}
+			if loggedDepartment != nil && membership.DepartmentID == loggedDepartment.ID {
+				claim["is_active"] = true
+			}
 			if membership.Department != nil {
 				claim["name"] = membership.Department.Name
 			}
@@ -481,42 +776,109 @@ func (s *AuthenticationService) generateAccessToken(user *models.User, orgMember
 			}
 			deptClaims = append(deptClaims, claim)
 		}
-		claims["departments"] = deptClaims
+		claims[s.ClaimName("departments")] = deptClaims
+	}
+
+	return s.signSessionToken(claims, user.ID, now, expiresAt)
+}
+
+// ClaimName returns the name a membership claim is emitted under, honouring CLAIM_NAMES.
+func (s *AuthenticationService) ClaimName(claim string) string {
+	if name, ok := s.config.ClaimNames[claim]; ok {
+		return name
+	}
+	return claim
+}
+
+// requireAssignedRole checks that roleID is one of the organization's role definitions and that the
+// membership holds it.
+func (s *AuthenticationService) requireAssignedRole(member *models.UserOrganization, roleID uint64) error {
+	roles, err := s.orgRepo.ListOrganizationRoles(member.OrganizationID)
+	if err != nil {
+		return fmt.Errorf("failed to load organization roles: %w", err)
+	}
+	for _, role := range roles {
+		if role.ID == roleID {
+			if role.Code == member.Role {
+				return nil
+			}
+			break
+		}
+	}
+	return ErrRoleNotAssigned
+}
+
+// meetsLoginRoleLevel reports whether a member's role satisfies the organization's minimum login role level.
+// The level comes from the organization's own definition of the role, or from the default role templates
+// when the organization does not define it.
+func (s *AuthenticationService) meetsLoginRoleLevel(org *models.Organization, role models.OrganizationRole) (bool, error) {
+	if org == nil || org.MinLoginRoleLevel == nil {
+		return true, nil
+	}
+	roles, err := s.orgRepo.ListOrganizationRoles(org.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to load organization roles: %w", err)
+	}
+	for _, definition := range roles {
+		if definition.Code == role {
+			return definition.Level <= *org.MinLoginRoleLevel, nil
+		}
 	}
+	level, ok := models.RoleLevel(role)
+	return ok && level <= *org.MinLoginRoleLevel, nil
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.config.Config.JWTSecret))
+// tenantTier resolves the plan of an organization, falling back to the configured base tier.
+func (s *AuthenticationService) tenantTier(org *models.Organization) string {
+	if org != nil {
+		if tier := strings.TrimSpace(org.Tier); tier != "" {
+			return tier
+		}
+	}
+	return s.config.DefaultTenantTier
 }
 
-// generateRefreshToken generates a JWT refresh token
-func (s *AuthenticationService) generateRefreshToken(user *models.User) (string, error) {
+// generateRefreshToken generates a JWT refresh token bound to the organization and department the
+// session is logged into, so refreshing keeps that context.
+func (s *AuthenticationService) generateRefreshToken(user *models.User, loggedOrganization *models.Organization, loggedDepartment *models.Department) (string, error) {
 	now := time.Now()
 	expiresAt := now.Add(s.config.RefreshExpiration)
 
 	claims := jwt.MapClaims{
-		"iss":     s.config.Config.ServiceName,
+		"iss":     s.Issuer(),
 		"sub":     user.ID,
 		"aud":     []string{s.config.Config.ServiceName},
 		"exp":     expiresAt.Unix(),
 		"iat":     now.Unix(),
+		"iat_us":  now.UnixMicro(),
 		"nbf":     now.Unix(),
 		"jti":     uuid.NewString(),
 		"type":    "refresh",
 		"user_id": user.ID,
 	}
+	if loggedOrganization != nil {
+		claims["org_id"] = loggedOrganization.ID
+	}
+	if loggedDepartment != nil {
+		claims["dept_id"] = loggedDepartment.ID
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.config.Config.JWTSecret))
+	return s.signSessionToken(claims, user.ID, now, expiresAt)
 }
 
 // ValidateToken validates an access token and returns the user ID
 func (s *AuthenticationService) ValidateToken(tokenString string) (*uint64, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.config.Config.JWTSecret), nil
-	})
+	details, err := s.ValidateTokenDetailed(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	return &details.UserID, nil
+}
+
+// ValidateTokenDetailed validates an access token and returns its user together with the organization
+// the token is bound to. Super admin status is read from the user record rather than the claims.
+func (s *AuthenticationService) ValidateTokenDetailed(tokenString string) (*models.TokenDetails, error) {
+	token, err := jwt.Parse(tokenString, s.TokenKeyFunc, jwt.WithIssuer(s.Issuer()))
 
 	if err != nil || !token.Valid {
 		return nil, ErrInvalidToken
@@ -533,13 +895,36 @@ func (s *AuthenticationService) ValidateToken(tokenString string) (*uint64, erro
 	}
 
 	// Get user ID from claims
-	userIDStr, ok := claims["user_id"].(string)
+	userId, ok := claimUserID(claims)
 	if !ok {
 		return nil, ErrInvalidToken
 	}
 
-	userId, err := utils.ParseUint64(userIDStr)
-	return &userId, err
+	// Reject tokens issued before the user's sessions were revoked
+	user, err := s.userRepo.GetByID(userId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || tokenRevoked(user, claims) {
+		return nil, ErrInvalidToken
+	}
+	blacklisted, err := s.tokenBlacklisted(claims)
+	if err != nil {
+		return nil, err
+	}
+	if blacklisted {
+		return nil, ErrInvalidToken
+	}
+
+	details := &models.TokenDetails{
+		UserID:       userId,
+		IsSuperAdmin: user.IsSuperAdmin,
+	}
+	if orgID, ok := claimUint64Value(claims["org_id"]); ok {
+		details.OrganizationID = &orgID
+	}
+	details.Scopes = claimStrings(claims["scopes"])
+	return details, nil
 }
 
 func (s *AuthenticationService) collectMemberships(userID *uint64) ([]*models.UserOrganization, []*models.UserDepartment, error) {
@@ -655,13 +1040,104 @@ func (s *AuthenticationService) GetUserInfoByID(id uint64) (*models.UserInfo, er
 	return s.composeUserInfo(user, orgs, depts), nil
 }
 
-// ListUsers retrieves a paginated list of users with membership context.
-func (s *AuthenticationService) ListUsers(offset, limit int) ([]*models.UserInfo, int64, error) {
-	users, total, err := s.userRepo.List(offset, limit)
+// ClientConfig returns the authentication settings clients need to schedule refreshes and render login forms.
+// It must only contain non-sensitive values.
+func (s *AuthenticationService) ClientConfig() *models.ClientAuthConfig {
+	required := []string{"username", "password"}
+	optional := []string{"department_id", "role_id"}
+	if s.config.LoginSelectionEnabled {
+		optional = append([]string{"organization_id"}, optional...)
+	} else {
+		required = append(required, "organization_id")
+	}
+
+	return &models.ClientAuthConfig{
+		AccessTokenTTLSeconds:        int(s.config.TokenExpiration.Seconds()),
+		RefreshTokenTTLSeconds:       int(s.config.RefreshExpiration.Seconds()),
+		StepUpTokenTTLSeconds:        int(s.config.StepUpTokenTTL.Seconds()),
+		MFAEnabled:                   s.config.MFAEnabled,
+		RegistrationEnabled:          s.config.RegistrationEnabled,
+		OAuthEnabled:                 s.GoogleOAuthEnabled(),
+		OrganizationSelectionEnabled: s.config.LoginSelectionEnabled,
+		PasswordPolicy: models.PasswordPolicyInfo{
+			MinLength:      s.config.PasswordMinLength,
+			MinEntropyBits: s.config.PasswordMinEntropyBits,
+			RequireUpper:   s.config.PasswordRequireUpper,
+			RequireDigit:   s.config.PasswordRequireDigit,
+			RequireSymbol:  s.config.PasswordRequireSymbol,
+			RejectCommon:   s.config.PasswordRejectCommon,
+		},
+		LoginRequiredFields: required,
+		LoginOptionalFields: optional,
+	}
+}
+
+// GetAdminUserDetail returns the administrative view of a user, including account security state.
+func (s *AuthenticationService) GetAdminUserDetail(id uint64) (*models.AdminUserDetail, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, nil
+	}
+
+	orgs, depts, err := s.collectMemberships(&user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return user.ToAdminUserDetail(s.composeUserInfo(user, orgs, depts)), nil
+}
+
+// ListUsers retrieves a page of users matching the filter, with membership context.
+func (s *AuthenticationService) ListUsers(filter models.UserListFilter) ([]*models.UserInfo, int64, error) {
+	users, total, err := s.userRepo.List(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	infos, err := s.userInfos(users)
+	if err != nil {
+		return nil, 0, err
+	}
+	return infos, total, nil
+}
+
+// ListManageableOrganizations returns a page of the active organizations the user can act on: every one
+// for a super admin, otherwise those where the user is an ORG_ADMIN.
+func (s *AuthenticationService) ListManageableOrganizations(userID uint64, offset, limit int) ([]*models.Organization, int64, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if user == nil {
+		return nil, 0, ErrUserNotFound
+	}
+
+	var adminUserID *uint64
+	if !user.IsSuperAdmin {
+		adminUserID = &user.ID
+	}
+	return s.orgRepo.ListManageableOrganizations(adminUserID, offset, limit)
+}
+
+// ListUsersByRole returns a page of users holding the organization role, optionally within one organization.
+func (s *AuthenticationService) ListUsersByRole(role models.OrganizationRole, orgID *uint64, offset, limit int) ([]*models.UserInfo, int64, error) {
+	users, total, err := s.userRepo.ListUsersByRole(role, orgID, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	infos, err := s.userInfos(users)
 	if err != nil {
 		return nil, 0, err
 	}
+	return infos, total, nil
+}
 
+// userInfos composes UserInfo, with memberships, for each user.
+func (s *AuthenticationService) userInfos(users []*models.User) ([]*models.UserInfo, error) {
 	infos := make([]*models.UserInfo, 0, len(users))
 	for _, user := range users {
 		if user == nil {
@@ -669,12 +1145,11 @@ func (s *AuthenticationService) ListUsers(offset, limit int) ([]*models.UserInfo
 		}
 		orgs, depts, err := s.collectMemberships(&user.ID)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 		infos = append(infos, s.composeUserInfo(user, orgs, depts))
 	}
-
-	return infos, total, nil
+	return infos, nil
 }
 
 func init() {
@@ -699,6 +1174,16 @@ func init() {
 			return nil, fmt.Errorf("component %s has unexpected type %T", constants.ComponentKey.OrganizationRepository, orgRepoComponent)
 		}
 
+		auditComponent, ok := app.GetComponent(constants.ComponentKey.AuditRepository)
+		if !ok {
+			return nil, fmt.Errorf("component %s not found", constants.ComponentKey.AuditRepository)
+		}
+
+		auditRepo, ok := auditComponent.(*repository.AuditRepository)
+		if !ok {
+			return nil, fmt.Errorf("component %s has unexpected type %T", constants.ComponentKey.AuditRepository, auditComponent)
+		}
+
 		cfgComponent, ok := app.GetComponent(constants.ComponentKey.AuthenticationConfig)
 		if !ok {
 			return nil, fmt.Errorf("component %s not found", constants.ComponentKey.AuthenticationConfig)
@@ -709,6 +1194,18 @@ func init() {
 			return nil, fmt.Errorf("component %s has unexpected type %T", constants.ComponentKey.AuthenticationConfig, cfgComponent)
 		}
 
-		return NewAuthenticationService(userRepo, orgRepo, authCfg), nil
+		redirects, err := redirect.NewPolicy(authCfg.RedirectAllowedOrigins)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REDIRECT_ALLOWED_ORIGINS: %w", err)
+		}
+
+		authService := NewAuthenticationService(userRepo, orgRepo, auditRepo, authCfg).WithRedirectPolicy(redirects)
+		if authCfg.PasswordBreachCheckEnabled {
+			authService.WithPasswordBreachChecker(NewRangeBreachChecker(authCfg.PasswordBreachAPIURL, authCfg.PasswordBreachCacheTTL))
+		}
+		if mailer := NewMailSender(authCfg); mailer != nil {
+			authService.WithVerificationSender(mailer).WithPasswordResetSender(mailer)
+		}
+		return authService, nil
 	})
 }
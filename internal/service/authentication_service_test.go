@@ -0,0 +1,320 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestLoginRoleLevelUsesOrganizationRoles(t *testing.T) {
+	env := newTestEnv(t, nil)
+	minLevel := 3
+	org := env.createOrganization(t, "Acme", func(o *models.Organization) { o.MinLoginRoleLevel = &minLevel })
+	if _, err := env.orgs.CreateOrganizationRoles([]*models.OrganizationRoleDefinition{
+		{OrganizationID: org.ID, Code: "TEAM_LEAD", Name: "Team lead", Level: 3},
+		{OrganizationID: org.ID, Code: "INTERN", Name: "Intern", Level: 9},
+		// Redefining a template role overrides the template's level
+		{OrganizationID: org.ID, Code: "CEO", Name: "Chief executive", Level: 5},
+	}); err != nil {
+		t.Fatalf("create roles: %v", err)
+	}
+
+	tests := []struct {
+		role    models.OrganizationRole
+		wantErr error
+	}{
+		{role: "TEAM_LEAD"},
+		{role: "CHAIRMAN"},
+		{role: "INTERN", wantErr: ErrInsufficientRole},
+		{role: "CEO", wantErr: ErrInsufficientRole},
+		{role: "UNDEFINED", wantErr: ErrInsufficientRole},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			user := env.createUser(t, "user-"+string(tt.role), nil)
+			env.addMember(t, user, org, tt.role)
+
+			_, err := env.auth.Login(&models.LoginRequest{Username: user.Username, Password: testPassword})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Login() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoginRejectsInactiveOrganization(t *testing.T) {
+	env := newTestEnv(t, nil)
+	org := env.createOrganization(t, "Acme", nil)
+	member := env.createUser(t, "member", nil)
+	env.addMember(t, member, org, "CEO")
+	admin := env.createUser(t, "admin", func(u *models.User) { u.IsSuperAdmin = true })
+	env.addMember(t, admin, org, "CEO")
+
+	login := func(user *models.User) error {
+		_, err := env.auth.Login(&models.LoginRequest{Username: user.Username, Password: testPassword, OrganizationID: org.ID})
+		return err
+	}
+	if err := login(member); err != nil {
+		t.Fatalf("Login() before deactivation error = %v", err)
+	}
+
+	if _, err := env.org.SetOrganizationActive(org.ID, false, admin.ID); err != nil {
+		t.Fatalf("SetOrganizationActive() error = %v", err)
+	}
+	if err := login(member); !errors.Is(err, ErrOrganizationInactive) {
+		t.Errorf("Login() after deactivation error = %v, want %v", err, ErrOrganizationInactive)
+	}
+	if err := login(admin); err != nil {
+		t.Errorf("super admin Login() after deactivation error = %v, want nil", err)
+	}
+}
+
+func TestLoginEmailCaseInsensitive(t *testing.T) {
+	tests := []struct {
+		name            string
+		caseInsensitive bool
+		identifier      string
+		want            string
+	}{
+		{name: "exact email", identifier: "Ada@Example.com", want: "ada"},
+		{name: "mixed case when sensitive", identifier: "ADA@example.com", want: ""},
+		{name: "mixed case when insensitive", caseInsensitive: true, identifier: " ADA@EXAMPLE.COM ", want: "ada"},
+		{name: "exact match wins over the older account", caseInsensitive: true, identifier: "grace@example.com", want: "grace-lower"},
+		{name: "oldest account wins otherwise", caseInsensitive: true, identifier: "GRACE@EXAMPLE.COM", want: "grace-upper"},
+		{name: "username", caseInsensitive: true, identifier: "ada", want: "ada"},
+		{name: "username is case-sensitive", caseInsensitive: true, identifier: "ADA", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, func(cfg *config.AuthConfig) { cfg.LoginEmailCaseInsensitive = tt.caseInsensitive })
+			env.createUser(t, "ada", func(u *models.User) { u.Email = "Ada@Example.com" })
+			// Legacy rows that differ only in case
+			env.createUser(t, "grace-upper", func(u *models.User) { u.Email = "Grace@Example.com" })
+			env.createUser(t, "grace-lower", func(u *models.User) { u.Email = "grace@example.com" })
+
+			user, err := env.auth.findLoginUser(tt.identifier)
+			if err != nil {
+				t.Fatalf("findLoginUser() error = %v", err)
+			}
+			got := ""
+			if user != nil {
+				got = user.Username
+			}
+			if got != tt.want {
+				t.Fatalf("findLoginUser(%q) = %q, want %q", tt.identifier, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterNormalizesEmail(t *testing.T) {
+	tests := []struct {
+		name            string
+		caseInsensitive bool
+		email           string
+		wantEmail       string
+		wantErr         error
+	}{
+		{name: "kept as given when sensitive", email: " New.User@Example.com ", wantEmail: "New.User@Example.com"},
+		{name: "lowercased when insensitive", caseInsensitive: true, email: "New.User@Example.com", wantEmail: "new.user@example.com"},
+		{name: "case variant allowed when sensitive", email: "TAKEN@example.com", wantEmail: "TAKEN@example.com"},
+		{name: "case variant rejected when insensitive", caseInsensitive: true, email: "TAKEN@example.com", wantErr: ErrEmailRegistered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, func(cfg *config.AuthConfig) {
+				cfg.LoginEmailCaseInsensitive = tt.caseInsensitive
+				cfg.RegistrationEnabled = true
+			})
+			env.createUser(t, "taken", func(u *models.User) { u.Email = "taken@example.com" })
+
+			user, err := env.auth.Register(&models.RegisterRequest{Email: tt.email, Username: "newcomer", Password: testPassword})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Register() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && user.Email != tt.wantEmail {
+				t.Fatalf("stored email = %q, want %q", user.Email, tt.wantEmail)
+			}
+		})
+	}
+}
+
+func TestUserInfoPrimaryNames(t *testing.T) {
+	env := newTestEnv(t, nil)
+	org := env.createOrganization(t, "Acme", nil)
+	dept := &models.Department{OrganizationID: org.ID, Name: "Engineering", IsActive: true}
+	if err := env.db.Create(dept).Error; err != nil {
+		t.Fatalf("create department: %v", err)
+	}
+	created := env.createUser(t, "ada", func(u *models.User) {
+		u.PrimaryOrganizationID = &org.ID
+		u.PrimaryDepartmentID = &dept.ID
+	})
+
+	tests := []struct {
+		name     string
+		user     *models.User
+		wantOrg  string
+		wantDept string
+	}{
+		{name: "preloaded", user: env.reloadUser(t, created.ID), wantOrg: "Acme", wantDept: "Engineering"},
+		{name: "not preloaded", user: created},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := json.Marshal(tt.user.ToUserInfo())
+			if err != nil {
+				t.Fatalf("encode user info: %v", err)
+			}
+			var fields map[string]any
+			if err := json.Unmarshal(encoded, &fields); err != nil {
+				t.Fatalf("decode user info: %v", err)
+			}
+			for key, want := range map[string]string{"primary_organization_name": tt.wantOrg, "primary_department_name": tt.wantDept} {
+				got, present := fields[key]
+				if want == "" {
+					if present {
+						t.Errorf("%s = %v, want it omitted", key, got)
+					}
+					continue
+				}
+				if got != want {
+					t.Errorf("%s = %v, want %q", key, got, want)
+				}
+			}
+			if fields["primary_organization_id"] == nil || fields["primary_department_id"] == nil {
+				t.Errorf("user info = %s, want the primary ids either way", encoded)
+			}
+		})
+	}
+}
+
+func TestListManageableOrganizations(t *testing.T) {
+	env := newTestEnv(t, nil)
+	beta := env.createOrganization(t, "Beta", nil)
+	alpha := env.createOrganization(t, "Alpha", nil)
+	gamma := env.createOrganization(t, "Gamma", nil)
+	dormant := env.createOrganization(t, "Dormant", nil)
+	if err := env.db.Model(dormant).Update("is_active", false).Error; err != nil {
+		t.Fatalf("deactivate organization: %v", err)
+	}
+
+	super := env.createUser(t, "super", func(u *models.User) { u.IsSuperAdmin = true })
+	orgAdmin := env.createUser(t, "org-admin", nil)
+	env.addMember(t, orgAdmin, beta, models.OrganizationRoleOrgAdmin)
+	env.addMember(t, orgAdmin, gamma, models.OrganizationRole("CEO"))
+	env.addMember(t, orgAdmin, dormant, models.OrganizationRoleOrgAdmin)
+	member := env.createUser(t, "member", nil)
+	env.addMember(t, member, alpha, models.OrganizationRole("CEO"))
+
+	names := func(orgs []*models.Organization) []string {
+		out := make([]string, 0, len(orgs))
+		for _, org := range orgs {
+			out = append(out, org.Name)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name      string
+		userID    uint64
+		offset    int
+		limit     int
+		want      []string
+		wantTotal int64
+	}{
+		{name: "super admin sees every active organization", userID: super.ID, limit: 10, want: []string{"Alpha", "Beta", "Gamma"}, wantTotal: 3},
+		{name: "super admin page", userID: super.ID, offset: 1, limit: 1, want: []string{"Beta"}, wantTotal: 3},
+		{name: "org admin sees administered organizations", userID: orgAdmin.ID, limit: 10, want: []string{"Beta"}, wantTotal: 1},
+		{name: "member sees none", userID: member.ID, limit: 10, want: []string{}, wantTotal: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs, total, err := env.auth.ListManageableOrganizations(tt.userID, tt.offset, tt.limit)
+			if err != nil {
+				t.Fatalf("ListManageableOrganizations() error = %v", err)
+			}
+			if got := names(orgs); !slices.Equal(got, tt.want) || total != tt.wantTotal {
+				t.Fatalf("ListManageableOrganizations() = %v (total %d), want %v (total %d)", got, total, tt.want, tt.wantTotal)
+			}
+		})
+	}
+
+	if _, _, err := env.auth.ListManageableOrganizations(9999, 0, 10); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("unknown user error = %v, want %v", err, ErrUserNotFound)
+	}
+}
+
+func TestListUsersByRole(t *testing.T) {
+	env := newTestEnv(t, nil)
+	acme := env.createOrganization(t, "Acme", nil)
+	globex := env.createOrganization(t, "Globex", nil)
+	members := map[string]*models.User{}
+	for _, m := range []struct {
+		name string
+		org  *models.Organization
+		role models.OrganizationRole
+	}{
+		{"acme-ceo", acme, "CEO"},
+		{"acme-chair", acme, "CHAIRMAN"},
+		{"globex-ceo", globex, "CEO"},
+		{"both-ceo", acme, "CEO"},
+		{"both-ceo", globex, "CHAIRMAN"},
+		{"former-ceo", acme, "CEO"},
+	} {
+		user, ok := members[m.name]
+		if !ok {
+			user = env.createUser(t, m.name, nil)
+			members[m.name] = user
+		}
+		env.addMember(t, user, m.org, m.role)
+	}
+	if err := env.orgs.RemoveUserOrganization(members["former-ceo"].ID, acme.ID, true); err != nil {
+		t.Fatalf("remove membership: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		role models.OrganizationRole
+		org  *models.Organization
+		want []string
+	}{
+		{name: "role across organizations", role: "CEO", want: []string{"acme-ceo", "globex-ceo", "both-ceo"}},
+		{name: "role in one organization", role: "CEO", org: acme, want: []string{"acme-ceo", "both-ceo"}},
+		{name: "role held elsewhere", role: "CHAIRMAN", org: globex, want: []string{"both-ceo"}},
+		{name: "role nobody holds", role: "ORG_ADMIN", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var orgID *uint64
+			if tt.org != nil {
+				orgID = &tt.org.ID
+			}
+			users, total, err := env.auth.ListUsersByRole(tt.role, orgID, 0, 10)
+			if err != nil {
+				t.Fatalf("ListUsersByRole() error = %v", err)
+			}
+			var got []string
+			for _, user := range users {
+				got = append(got, user.Username)
+			}
+			if !slices.Equal(got, tt.want) || total != int64(len(tt.want)) {
+				t.Errorf("ListUsersByRole() = %v (total %d), want %v", got, total, tt.want)
+			}
+		})
+	}
+
+	page, total, err := env.auth.ListUsersByRole("CEO", nil, 1, 1)
+	if err != nil {
+		t.Fatalf("ListUsersByRole(page 2) error = %v", err)
+	}
+	if total != 3 || len(page) != 1 || page[0].Username != "globex-ceo" {
+		t.Errorf("ListUsersByRole(page 2) = %+v (total %d), want globex-ceo of 3", page, total)
+	}
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestBootstrapWithDomainlessOrganizations(t *testing.T) {
+	env := newTestEnv(t, nil)
+
+	// A domainless organization stored before empty domains were kept as NULL
+	if err := env.db.Exec("INSERT INTO organizations (name, domain, is_active) VALUES (?, '', ?)", "Legacy", true).Error; err != nil {
+		t.Fatalf("insert legacy organization: %v", err)
+	}
+	other, err := env.org.CreateOrganization(&models.CreateOrganizationInput{Name: "Partner"})
+	if err != nil {
+		t.Fatalf("CreateOrganization(domainless) error = %v", err)
+	}
+
+	input := &BootstrapAdminInput{
+		OrganizationName: "Root Organization",
+		AdminEmail:       "owner@example.com",
+		AdminPassword:    testPassword,
+	}
+	result, err := env.auth.BootstrapAdmin(input)
+	if err != nil {
+		t.Fatalf("BootstrapAdmin() error = %v", err)
+	}
+	org := result.Organization
+	if org.ID == other.ID || org.Domain != "" {
+		t.Fatalf("BootstrapAdmin() organization = %d with domain %q, want a new domainless organization", org.ID, org.Domain)
+	}
+
+	// Startup runs the bootstrap again; it must find the same organization
+	again, err := env.auth.BootstrapAdmin(input)
+	if err != nil {
+		t.Fatalf("second BootstrapAdmin() error = %v", err)
+	}
+	if again.Organization.ID != org.ID {
+		t.Fatalf("second BootstrapAdmin() organization = %d, want %d", again.Organization.ID, org.ID)
+	}
+
+	var blank, null int64
+	if err := env.db.Model(&models.Organization{}).Where("domain = ''").Count(&blank).Error; err != nil {
+		t.Fatalf("count blank domains: %v", err)
+	}
+	if err := env.db.Model(&models.Organization{}).Where("domain IS NULL").Count(&null).Error; err != nil {
+		t.Fatalf("count NULL domains: %v", err)
+	}
+	if blank != 0 || null != 3 {
+		t.Fatalf("stored domains: %d empty and %d NULL, want 0 and 3", blank, null)
+	}
+
+	orgs, err := env.org.ListOrganizations()
+	if err != nil {
+		t.Fatalf("ListOrganizations() error = %v", err)
+	}
+	for _, listed := range orgs {
+		if listed.Domain != "" {
+			t.Errorf("organization %s read back with domain %q, want empty", listed.Name, listed.Domain)
+		}
+	}
+}
+
+func TestBootstrapDefaultAdminEmailGuard(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		email       string
+		allow       bool
+		wantErr     error
+		wantWarning bool
+	}{
+		{name: "default email in production", environment: "production", email: config.DefaultBootstrapAdminEmail, wantErr: ErrDefaultBootstrapEmail},
+		{name: "default email in any case", environment: "prod", email: " ADMIN@root.local ", wantErr: ErrDefaultBootstrapEmail},
+		{name: "custom email in production", environment: "production", email: "owner@example.com"},
+		{name: "default email in development", environment: "development", email: config.DefaultBootstrapAdminEmail, wantWarning: true},
+		{name: "guard disabled", environment: "production", email: config.DefaultBootstrapAdminEmail, allow: true, wantWarning: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, func(cfg *config.AuthConfig) {
+				cfg.Environment = tt.environment
+				cfg.BootstrapRequireCustomEmail = !tt.allow
+				cfg.BootstrapOrganizationName = "Root Organization"
+				cfg.BootstrapAdminEmail = tt.email
+				cfg.BootstrapAdminUsername = "root"
+				cfg.BootstrapAdminPassword = "Corr3ct-Horse-Battery!"
+			})
+			var logged bytes.Buffer
+			previous := log.Writer()
+			log.SetOutput(&logged)
+			t.Cleanup(func() { log.SetOutput(previous) })
+
+			result, err := env.auth.BootstrapDefaultAdmin()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("BootstrapDefaultAdmin() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				var count int64
+				if err := env.db.Model(&models.User{}).Count(&count).Error; err != nil {
+					t.Fatalf("count users: %v", err)
+				}
+				if count != 0 {
+					t.Fatalf("%d users created, want none", count)
+				}
+			} else if result == nil {
+				t.Fatal("BootstrapDefaultAdmin() returned no admin")
+			}
+			if warned := strings.Contains(logged.String(), "default email"); warned != tt.wantWarning {
+				t.Errorf("warning logged = %v, want %v: %q", warned, tt.wantWarning, logged.String())
+			}
+		})
+	}
+}
+
+func TestBootstrapAdminResult(t *testing.T) {
+	env := newTestEnv(t, nil)
+	input := &BootstrapAdminInput{
+		OrganizationName:   "Root Organization",
+		OrganizationDomain: "root.example",
+		AdminEmail:         "owner@example.com",
+		AdminUsername:      "owner",
+		AdminPassword:      testPassword,
+	}
+
+	tests := []struct {
+		name        string
+		password    string
+		force       bool
+		wantCreated bool
+		wantRotated bool
+	}{
+		{name: "first run", password: testPassword, wantCreated: true},
+		{name: "unchanged password", password: testPassword},
+		{name: "new password", password: "another-horse-battery", wantRotated: true},
+		{name: "forced password", password: "another-horse-battery", force: true, wantRotated: true},
+	}
+	var adminID uint64
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input.AdminPassword = tt.password
+			input.ForcePasswordReset = tt.force
+			result, err := env.auth.BootstrapAdmin(input)
+			if err != nil {
+				t.Fatalf("BootstrapAdmin() error = %v", err)
+			}
+			if adminID == 0 {
+				adminID = result.User.ID
+			}
+
+			encoded, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("encode result: %v", err)
+			}
+			var got map[string]any
+			if err := json.Unmarshal(encoded, &got); err != nil {
+				t.Fatalf("decode result: %v", err)
+			}
+			want := map[string]any{
+				"organization":     map[string]any{"id": float64(result.Organization.ID), "name": "Root Organization", "domain": "root.example"},
+				"user":             map[string]any{"id": float64(adminID), "email": "owner@example.com", "username": "owner"},
+				"created":          tt.wantCreated,
+				"password_rotated": tt.wantRotated,
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("result = %s, want %v", encoded, want)
+			}
+		})
+	}
+}
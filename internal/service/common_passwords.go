@@ -0,0 +1,26 @@
+package service
+
+import "strings"
+
+// commonPasswords holds frequently used passwords from public breach corpora, compared
+// case-insensitively. It is a short local list; the breach check covers the long tail.
+var commonPasswords = map[string]struct{}{
+	"123456": {}, "123456789": {}, "12345678": {}, "1234567890": {}, "12345": {}, "1234567": {},
+	"111111": {}, "000000": {}, "123123": {}, "654321": {}, "666666": {}, "121212": {},
+	"password": {}, "password1": {}, "password12": {}, "password123": {}, "passw0rd": {}, "p@ssw0rd": {},
+	"p@ssword": {}, "p@ssword1": {}, "password!": {}, "password1!": {}, "changeme": {}, "changeme1": {},
+	"changeme123": {}, "changeme123!": {}, "welcome": {}, "welcome1": {}, "welcome123": {}, "welcome1!": {},
+	"qwerty": {}, "qwerty123": {}, "qwertyuiop": {}, "qwerty1!": {}, "1q2w3e4r": {}, "1qaz2wsx": {},
+	"zaq12wsx": {}, "asdfghjkl": {}, "iloveyou": {}, "iloveyou1": {}, "abc123": {}, "abcd1234": {},
+	"admin": {}, "admin123": {}, "admin@123": {}, "administrator": {}, "letmein": {}, "letmein1": {},
+	"monkey": {}, "dragon": {}, "football": {}, "baseball": {}, "sunshine": {}, "princess": {},
+	"superman": {}, "trustno1": {}, "master": {}, "shadow": {}, "michael": {}, "login": {},
+	"secret": {}, "secret123": {}, "starwars": {}, "whatever": {}, "summer2024": {}, "winter2024": {},
+	"spring2025": {}, "summer2025": {}, "autumn2025": {}, "winter2025": {}, "company123": {}, "test1234": {},
+}
+
+// isCommonPassword reports whether the password is on the common password list.
+func isCommonPassword(password string) bool {
+	_, found := commonPasswords[strings.ToLower(strings.TrimSpace(password))]
+	return found
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestConcurrentLoginAlert(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    bool
+		ips        []string
+		wantAlerts int
+	}{
+		{name: "distant addresses", enabled: true, ips: []string{"203.0.113.7", "198.51.100.9"}, wantAlerts: 1},
+		{name: "alerted once per address", enabled: true, ips: []string{"203.0.113.7", "198.51.100.9", "198.51.100.9"}, wantAlerts: 1},
+		{name: "same network", enabled: true, ips: []string{"203.0.113.7", "203.0.200.1"}},
+		{name: "disabled", ips: []string{"203.0.113.7", "198.51.100.9"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				webhooks []string
+			)
+			webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var event models.AuditEvent
+				if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+					t.Errorf("decode webhook: %v", err)
+				}
+				mu.Lock()
+				webhooks = append(webhooks, event.Action)
+				mu.Unlock()
+			}))
+			defer webhook.Close()
+
+			env := newTestEnv(t, func(cfg *config.AuthConfig) {
+				cfg.ConcurrentLoginDetection = tt.enabled
+				cfg.ConcurrentLoginWindow = time.Hour
+				cfg.ConcurrentLoginIPv4Prefix = 16
+				cfg.ConcurrentLoginIPv6Prefix = 48
+				cfg.SecurityWebhookURL = webhook.URL
+			})
+			org := env.createOrganization(t, "Acme", nil)
+			user := env.createUser(t, "traveller", nil)
+			env.addMember(t, user, org, models.OrganizationRole("CEO"))
+
+			for _, ip := range tt.ips {
+				if _, err := env.auth.Login(&models.LoginRequest{Username: user.Username, Password: testPassword, OrganizationID: org.ID, IPAddress: ip}); err != nil {
+					t.Fatalf("Login(%s) error = %v", ip, err)
+				}
+			}
+
+			var alerts int64
+			if err := env.db.Model(&models.AuditEvent{}).Where("action = ?", models.AuditActionConcurrentLogin).Count(&alerts).Error; err != nil {
+				t.Fatalf("count alerts: %v", err)
+			}
+			if alerts != int64(tt.wantAlerts) {
+				t.Fatalf("recorded %d alerts, want %d", alerts, tt.wantAlerts)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			notified := 0
+			for _, action := range webhooks {
+				if action == models.AuditActionConcurrentLogin {
+					notified++
+				}
+			}
+			if notified != tt.wantAlerts {
+				t.Fatalf("webhook received %d alerts, want %d", notified, tt.wantAlerts)
+			}
+		})
+	}
+}
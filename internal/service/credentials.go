@@ -0,0 +1,79 @@
+package service
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+)
+
+const (
+	temporaryPasswordLength = 16
+
+	passwordLowerChars  = "abcdefghijkmnopqrstuvwxyz"
+	passwordUpperChars  = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	passwordDigitChars  = "23456789"
+	passwordSymbolChars = "!@#$%^&*-_=+?"
+)
+
+// reservedUsernames cannot be claimed by regular accounts.
+var reservedUsernames = map[string]struct{}{
+	"admin":         {},
+	"administrator": {},
+	"root":          {},
+	"root-admin":    {},
+	"system":        {},
+	"support":       {},
+	"security":      {},
+	"superuser":     {},
+}
+
+// isReservedUsername reports whether the username is reserved for platform use.
+func isReservedUsername(username string) bool {
+	_, ok := reservedUsernames[strings.ToLower(strings.TrimSpace(username))]
+	return ok
+}
+
+// generateTemporaryPassword returns a random password containing every character class.
+func generateTemporaryPassword(length int) (string, error) {
+	classes := []string{passwordLowerChars, passwordUpperChars, passwordDigitChars, passwordSymbolChars}
+	if length < len(classes) {
+		length = len(classes)
+	}
+	all := strings.Join(classes, "")
+
+	password := make([]byte, 0, length)
+	for _, class := range classes {
+		c, err := randomChar(class)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	for len(password) < length {
+		c, err := randomChar(all)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+
+	// Shuffle so the guaranteed characters are not always at the front.
+	for i := len(password) - 1; i > 0; i-- {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		j := int(n.Int64())
+		password[i], password[j] = password[j], password[i]
+	}
+
+	return string(password), nil
+}
+
+func randomChar(charset string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+	if err != nil {
+		return 0, err
+	}
+	return charset[n.Int64()], nil
+}
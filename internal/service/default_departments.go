@@ -0,0 +1,85 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/repository"
+)
+
+// validateDefaultDepartments checks the flattened blueprint before anything is written: codes must
+// be unique, parents must be defined and every kind must fit under its parent.
+func (s *OrganizationService) validateDefaultDepartments(defs []models.DepartmentDefinition) error {
+	byCode := make(map[models.DepartmentCode]models.DepartmentDefinition, len(defs))
+	for _, def := range defs {
+		if _, exists := byCode[def.Code]; exists {
+			return fmt.Errorf("default department structure defines %s more than once", def.Code)
+		}
+		byCode[def.Code] = def
+	}
+
+	for _, def := range defs {
+		var parent *models.Department
+		if def.Parent != nil {
+			parentDef, ok := byCode[*def.Parent]
+			if !ok {
+				return fmt.Errorf("default department %s references unknown parent %s", def.Code, *def.Parent)
+			}
+			parent = &models.Department{Kind: parentDef.Kind}
+		}
+		if err := s.validateDepartmentKind(def.Kind, parent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedDefaultDepartments creates the default department structure for orgID using repo. Children
+// reference their parent by code, so departments are created first and linked in a second pass.
+func (s *OrganizationService) seedDefaultDepartments(repo *repository.OrganizationRepository, orgID uint64) ([]models.Department, error) {
+	defs := models.FlattenDepartmentStructure(models.DefaultDepartmentStructure)
+	if err := s.validateDefaultDepartments(defs); err != nil {
+		return nil, err
+	}
+
+	created := make([]*models.Department, 0, len(defs))
+	idsByCode := make(map[models.DepartmentCode]uint64, len(defs))
+	for _, def := range defs {
+		code := def.Code
+		dept := &models.Department{
+			OrganizationID: orgID,
+			Code:           &code,
+			Name:           strings.TrimSpace(def.Name),
+			Kind:           def.Kind,
+			Description:    strings.TrimSpace(def.Description),
+			Function:       strings.TrimSpace(def.Function),
+			IsActive:       true,
+		}
+		if def.IsActive != nil {
+			dept.IsActive = *def.IsActive
+		}
+		if err := repo.CreateDepartment(dept); err != nil {
+			return nil, fmt.Errorf("create default department %s: %w", def.Code, err)
+		}
+		created = append(created, dept)
+		idsByCode[def.Code] = dept.ID
+	}
+
+	for i, def := range defs {
+		if def.Parent == nil {
+			continue
+		}
+		parentID := idsByCode[*def.Parent]
+		if err := repo.MoveDepartment(created[i].ID, &parentID); err != nil {
+			return nil, fmt.Errorf("link default department %s to %s: %w", def.Code, *def.Parent, err)
+		}
+		created[i].ParentID = &parentID
+	}
+
+	departments := make([]models.Department, 0, len(created))
+	for _, dept := range created {
+		departments = append(departments, *dept)
+	}
+	return departments, nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/repository"
+)
+
+// ErrDepartmentOrganizationMismatch is returned when a bulk department update names departments that
+// do not exist or belong to another organization.
+var ErrDepartmentOrganizationMismatch = errors.New("departments do not belong to the organization")
+
+// SetDepartmentsActive activates or deactivates the listed departments of an organization in one
+// transaction. Every department must belong to the organization; otherwise nothing is changed and the
+// result reports which IDs were rejected alongside ErrDepartmentOrganizationMismatch.
+func (s *OrganizationService) SetDepartmentsActive(orgID uint64, input *models.SetDepartmentsActiveInput) (*models.SetDepartmentsActiveResult, error) {
+	if input == nil || input.IsActive == nil {
+		return nil, fmt.Errorf("is_active is required")
+	}
+	deptIDs := uniqueIDs(input.DepartmentIDs)
+	if len(deptIDs) == 0 {
+		return nil, fmt.Errorf("department_ids is required")
+	}
+
+	org, err := s.orgRepo.GetOrganizationByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	active := *input.IsActive
+	result := &models.SetDepartmentsActiveResult{
+		OrganizationID: orgID,
+		IsActive:       active,
+		Results:        make([]*models.DepartmentActivationResult, 0, len(deptIDs)),
+	}
+
+	var changed []uint64
+	err = s.orgRepo.WithTx(func(repos *repository.TxRepositories) error {
+		depts, err := repos.Organizations.ListDepartmentsByIDs(deptIDs)
+		if err != nil {
+			return err
+		}
+		byID := make(map[uint64]*models.Department, len(depts))
+		for _, dept := range depts {
+			byID[dept.ID] = dept
+		}
+
+		valid := true
+		for _, id := range deptIDs {
+			entry := &models.DepartmentActivationResult{DepartmentID: id}
+			dept := byID[id]
+			switch {
+			case dept == nil:
+				entry.Error = ErrDepartmentNotFound.Error()
+				valid = false
+			case dept.OrganizationID != orgID:
+				entry.Error = "department belongs to another organization"
+				valid = false
+			default:
+				entry.Success = true
+				entry.Changed = dept.IsActive != active
+				if entry.Changed {
+					changed = append(changed, id)
+				}
+			}
+			result.Results = append(result.Results, entry)
+		}
+		if !valid {
+			return ErrDepartmentOrganizationMismatch
+		}
+		return repos.Organizations.SetDepartmentsActive(changed, active)
+	})
+	if err != nil {
+		if errors.Is(err, ErrDepartmentOrganizationMismatch) {
+			for _, entry := range result.Results {
+				entry.Success, entry.Changed = false, false
+			}
+			return result, err
+		}
+		return nil, err
+	}
+	result.Updated = true
+
+	action := models.AuditActionDepartmentDeactivate
+	if active {
+		action = models.AuditActionDepartmentReactivate
+	}
+	for _, id := range changed {
+		s.recordAudit(input.ActorID, action, models.AuditDepartmentRef(id), orgID, nil)
+	}
+	return result, nil
+}
+
+// uniqueIDs returns ids without zero values and duplicates, keeping their order.
+func uniqueIDs(ids []uint64) []uint64 {
+	seen := make(map[uint64]struct{}, len(ids))
+	unique := make([]uint64, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok || id == 0 {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+	return unique
+}
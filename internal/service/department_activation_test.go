@@ -0,0 +1,51 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestSetDepartmentsActiveRejectsForeignDepartments(t *testing.T) {
+	env := newTestEnv(t, nil)
+	home := env.createOrganization(t, "Home", nil)
+	away := env.createOrganization(t, "Away", nil)
+	own := env.departmentChain(t, home, "Sales")[0]
+	foreign := env.departmentChain(t, away, "Support")[0]
+	inactive := false
+
+	result, err := env.org.SetDepartmentsActive(home.ID, &models.SetDepartmentsActiveInput{
+		DepartmentIDs: []uint64{own.ID, foreign.ID, 9999},
+		IsActive:      &inactive,
+	})
+	if !errors.Is(err, ErrDepartmentOrganizationMismatch) {
+		t.Fatalf("SetDepartmentsActive() error = %v, want %v", err, ErrDepartmentOrganizationMismatch)
+	}
+	if result == nil || result.Updated || len(result.Results) != 3 {
+		t.Fatalf("result = %+v, want one entry per department and no update", result)
+	}
+	for _, entry := range result.Results {
+		if entry.Success || entry.Changed {
+			t.Errorf("department %d: success = %v, changed = %v, want neither", entry.DepartmentID, entry.Success, entry.Changed)
+		}
+		if wantError := entry.DepartmentID != own.ID; wantError != (entry.Error != "") {
+			t.Errorf("department %d: error = %q, want an error: %v", entry.DepartmentID, entry.Error, wantError)
+		}
+	}
+
+	for _, dept := range []*models.Department{own, foreign} {
+		var stored models.Department
+		if err := env.db.First(&stored, dept.ID).Error; err != nil {
+			t.Fatalf("reload %s: %v", dept.Name, err)
+		}
+		if !stored.IsActive {
+			t.Errorf("%s was deactivated by a rejected request", dept.Name)
+		}
+	}
+
+	result, err = env.org.SetDepartmentsActive(home.ID, &models.SetDepartmentsActiveInput{DepartmentIDs: []uint64{own.ID}, IsActive: &inactive})
+	if err != nil || !result.Updated || !result.Results[0].Changed {
+		t.Fatalf("SetDepartmentsActive(own department) = %+v, %v, want it deactivated", result, err)
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// departmentKindRoot is the rule key listing the kinds allowed at the top of the hierarchy.
+const departmentKindRoot = "ROOT"
+
+var ErrInvalidDepartmentKind = errors.New("invalid department kind")
+
+// departmentKindRules returns the configured parent-to-child kind rules.
+func (s *OrganizationService) departmentKindRules() map[string][]string {
+	if s.config == nil {
+		return nil
+	}
+	return s.config.DepartmentKindRules
+}
+
+// departmentKindAllowed reports whether child may sit directly under parent ("ROOT" for top-level).
+func (s *OrganizationService) departmentKindAllowed(parent string, child models.DepartmentKind) bool {
+	for _, allowed := range s.departmentKindRules()[parent] {
+		if allowed == string(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// knownDepartmentKind reports whether the kind is referenced by the ruleset.
+func (s *OrganizationService) knownDepartmentKind(kind models.DepartmentKind) bool {
+	for parent, children := range s.departmentKindRules() {
+		if parent == string(kind) {
+			return true
+		}
+		for _, child := range children {
+			if child == string(kind) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateDepartmentKind checks that a department of the given kind may be placed under parent.
+func (s *OrganizationService) validateDepartmentKind(kind models.DepartmentKind, parent *models.Department) error {
+	if s.config == nil || !s.config.DepartmentKindValidation {
+		return nil
+	}
+	if !s.knownDepartmentKind(kind) {
+		return fmt.Errorf("%w: unknown kind %s", ErrInvalidDepartmentKind, kind)
+	}
+
+	if parent == nil {
+		if !s.departmentKindAllowed(departmentKindRoot, kind) {
+			return fmt.Errorf("%w: %s cannot be a top-level unit", ErrInvalidDepartmentKind, kind)
+		}
+		return nil
+	}
+	if !s.departmentKindAllowed(string(parent.Kind), kind) {
+		return fmt.Errorf("%w: %s cannot be nested under %s", ErrInvalidDepartmentKind, kind, parent.Kind)
+	}
+	return nil
+}
+
+// validateDepartmentKindChange checks that changing dept to kind keeps it valid under its parent
+// and does not orphan children whose kind is not allowed beneath the new kind.
+func (s *OrganizationService) validateDepartmentKindChange(dept *models.Department, parent *models.Department, kind models.DepartmentKind) error {
+	if s.config == nil || !s.config.DepartmentKindValidation || dept.Kind == kind {
+		return nil
+	}
+	if err := s.validateDepartmentKind(kind, parent); err != nil {
+		return err
+	}
+	for _, child := range dept.Children {
+		if !s.departmentKindAllowed(string(kind), child.Kind) {
+			return fmt.Errorf("%w: changing %s to %s would leave child %s (%s) under an incompatible parent", ErrInvalidDepartmentKind, dept.Kind, kind, child.Name, child.Kind)
+		}
+	}
+	return nil
+}
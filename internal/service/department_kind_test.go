@@ -0,0 +1,118 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// defaultKindRules mirrors the DEPARTMENT_KIND_RULES default: DEPARTMENT > DIVISION > TEAM, with teams
+// also allowed directly under a department.
+func defaultKindRules(cfg *config.AuthConfig) {
+	cfg.DepartmentKindValidation = true
+	cfg.DepartmentKindRules = map[string][]string{
+		departmentKindRoot: {"DEPARTMENT"},
+		"DEPARTMENT":       {"DIVISION", "TEAM"},
+		"DIVISION":         {"TEAM"},
+		"TEAM":             nil,
+	}
+}
+
+func TestValidateDepartmentKind(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    models.DepartmentKind
+		parent  models.DepartmentKind
+		wantErr bool
+	}{
+		{name: "department at the top", kind: models.DepartmentKindDepartment},
+		{name: "division at the top", kind: models.DepartmentKindDivision, wantErr: true},
+		{name: "team at the top", kind: models.DepartmentKindTeam, wantErr: true},
+		{name: "division under department", kind: models.DepartmentKindDivision, parent: models.DepartmentKindDepartment},
+		{name: "team under department", kind: models.DepartmentKindTeam, parent: models.DepartmentKindDepartment},
+		{name: "team under division", kind: models.DepartmentKindTeam, parent: models.DepartmentKindDivision},
+		{name: "department under division", kind: models.DepartmentKindDepartment, parent: models.DepartmentKindDivision, wantErr: true},
+		{name: "division under division", kind: models.DepartmentKindDivision, parent: models.DepartmentKindDivision, wantErr: true},
+		{name: "division under team", kind: models.DepartmentKindDivision, parent: models.DepartmentKindTeam, wantErr: true},
+		{name: "team under team", kind: models.DepartmentKindTeam, parent: models.DepartmentKindTeam, wantErr: true},
+		{name: "unknown kind", kind: "SQUAD", parent: models.DepartmentKindDepartment, wantErr: true},
+	}
+	env := newTestEnv(t, defaultKindRules)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var parent *models.Department
+			if tt.parent != "" {
+				parent = &models.Department{Name: "Parent", Kind: tt.parent}
+			}
+			err := env.org.validateDepartmentKind(tt.kind, parent)
+			if (tt.wantErr && !errors.Is(err, ErrInvalidDepartmentKind)) || (!tt.wantErr && err != nil) {
+				t.Fatalf("validateDepartmentKind(%s under %q) error = %v, want error: %v", tt.kind, tt.parent, err, tt.wantErr)
+			}
+		})
+	}
+
+	disabled := newTestEnv(t, nil)
+	if err := disabled.org.validateDepartmentKind(models.DepartmentKindTeam, nil); err != nil {
+		t.Errorf("validateDepartmentKind() with validation disabled error = %v, want nil", err)
+	}
+}
+
+func TestValidateDepartmentKindChange(t *testing.T) {
+	// Sales (DEPARTMENT) > North (DIVISION) > Alpha (TEAM), and Support (DEPARTMENT) > Desk (DIVISION)
+	// without children.
+	tests := []struct {
+		name    string
+		dept    string
+		kind    models.DepartmentKind
+		wantErr bool
+	}{
+		{name: "division with a team to team", dept: "North", kind: models.DepartmentKindTeam, wantErr: true},
+		{name: "department with a division to division", dept: "Sales", kind: models.DepartmentKindDivision, wantErr: true},
+		{name: "childless division to team", dept: "Desk", kind: models.DepartmentKindTeam},
+		{name: "team to division under a division", dept: "Alpha", kind: models.DepartmentKindDivision, wantErr: true},
+		{name: "unchanged kind", dept: "North", kind: models.DepartmentKindDivision},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, defaultKindRules)
+			org := env.createOrganization(t, "Acme", nil)
+			depts := map[string]*models.Department{}
+			for _, d := range []struct {
+				name, parent string
+				kind         models.DepartmentKind
+			}{
+				{"Sales", "", models.DepartmentKindDepartment},
+				{"North", "Sales", models.DepartmentKindDivision},
+				{"Alpha", "North", models.DepartmentKindTeam},
+				{"Support", "", models.DepartmentKindDepartment},
+				{"Desk", "Support", models.DepartmentKindDivision},
+			} {
+				dept := &models.Department{OrganizationID: org.ID, Name: d.name, Kind: d.kind, IsActive: true}
+				if d.parent != "" {
+					dept.ParentID = &depts[d.parent].ID
+				}
+				if err := env.db.Create(dept).Error; err != nil {
+					t.Fatalf("create department %s: %v", d.name, err)
+				}
+				depts[d.name] = dept
+			}
+
+			kind := tt.kind
+			_, err := env.org.UpdateDepartment(depts[tt.dept].ID, &models.UpdateDepartmentInput{Kind: &kind})
+			if (tt.wantErr && !errors.Is(err, ErrInvalidDepartmentKind)) || (!tt.wantErr && err != nil) {
+				t.Fatalf("UpdateDepartment(%s to %s) error = %v, want error: %v", tt.dept, tt.kind, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				var stored models.Department
+				if err := env.db.First(&stored, depts[tt.dept].ID).Error; err != nil {
+					t.Fatalf("reload department: %v", err)
+				}
+				if stored.Kind != depts[tt.dept].Kind {
+					t.Errorf("kind = %s after a rejected change, want %s", stored.Kind, depts[tt.dept].Kind)
+				}
+			}
+		})
+	}
+}
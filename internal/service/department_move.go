@@ -0,0 +1,270 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/repository"
+)
+
+var (
+	ErrDepartmentMembershipConflict = errors.New("department members do not belong to the target organization")
+	ErrPrimaryDepartmentOrphaned    = errors.New("move would leave users without a primary department")
+)
+
+// primaryFallback replaces a user's primary department that is being cleared by a move.
+type primaryFallback struct {
+	userID     uint64
+	membership *models.UserDepartment
+}
+
+// MoveDepartment re-parents a department together with its descendants, optionally into another
+// organization. Cross-organization moves require every member of the subtree to belong to the target
+// organization unless ClearMemberships is set, in which case their memberships are removed. A cleared
+// primary department falls back to another of the user's departments; the move is refused when there is none.
+func (s *OrganizationService) MoveDepartment(deptID uint64, input *models.MoveDepartmentInput) (*models.MoveDepartmentResult, error) {
+	if input == nil {
+		return nil, fmt.Errorf("%w: input required", ErrInvalidDepartment)
+	}
+
+	dept, err := s.orgRepo.GetDepartmentByID(deptID)
+	if err != nil {
+		return nil, err
+	}
+	if dept == nil {
+		return nil, ErrDepartmentNotFound
+	}
+
+	targetOrgID := dept.OrganizationID
+	if input.OrganizationID != nil && *input.OrganizationID != 0 {
+		targetOrgID = *input.OrganizationID
+	}
+	org, err := s.orgRepo.GetOrganizationByID(targetOrgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	subtree, err := s.departmentSubtree(dept)
+	if err != nil {
+		return nil, err
+	}
+
+	var parent *models.Department
+	if input.ParentID != nil {
+		parent, err = s.orgRepo.GetDepartmentByID(*input.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			return nil, ErrDepartmentNotFound
+		}
+		if parent.OrganizationID != targetOrgID {
+			return nil, fmt.Errorf("%w: parent department belongs to another organization", ErrInvalidDepartment)
+		}
+		if _, ok := subtree[parent.ID]; ok {
+			return nil, fmt.Errorf("%w: a department cannot be moved under itself or its descendants", ErrInvalidDepartment)
+		}
+		height, err := s.departmentHeight(dept)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.validateDepartmentDepth(parent, height); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.validateDepartmentKind(dept.Kind, parent); err != nil {
+		return nil, err
+	}
+
+	subtreeIDs := make([]uint64, 0, len(subtree))
+	for id := range subtree {
+		subtreeIDs = append(subtreeIDs, id)
+	}
+
+	crossOrg := targetOrgID != dept.OrganizationID
+	var stale []*models.UserDepartment
+	var fallbacks []primaryFallback
+	if crossOrg {
+		stale, err = s.foreignDepartmentMembers(subtreeIDs, targetOrgID)
+		if err != nil {
+			return nil, err
+		}
+		if len(stale) > 0 && !input.ClearMemberships {
+			return nil, fmt.Errorf("%w: %d membership(s) would be affected; set clear_memberships to remove them", ErrDepartmentMembershipConflict, len(stale))
+		}
+		fallbacks, err = s.primaryDepartmentFallbacks(stale, subtree)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	soft := s.softDeleteMemberships()
+	err = s.orgRepo.WithTx(func(repos *repository.TxRepositories) error {
+		if err := repos.Organizations.MoveDepartment(dept.ID, input.ParentID); err != nil {
+			return err
+		}
+		if crossOrg {
+			if err := repos.Organizations.SetDepartmentsOrganization(subtreeIDs, targetOrgID); err != nil {
+				return err
+			}
+		}
+		for _, membership := range stale {
+			if err := repos.Organizations.RemoveUserDepartment(membership.UserID, membership.DepartmentID, soft); err != nil {
+				return err
+			}
+		}
+		for _, fallback := range fallbacks {
+			if err := repos.Organizations.ClearPrimaryDepartment(fallback.userID); err != nil {
+				return err
+			}
+			if err := repos.Organizations.UpsertUserDepartment(fallback.userID, fallback.membership.DepartmentID, fallback.membership.Role, true); err != nil {
+				return err
+			}
+			if err := repos.Organizations.SetUserPrimaryDepartment(fallback.userID, fallback.membership.DepartmentID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordAudit(input.ActorID, models.AuditActionDepartmentMove, models.AuditDepartmentRef(dept.ID), targetOrgID, map[string]any{
+		"from_organization_id": dept.OrganizationID,
+		"to_organization_id":   targetOrgID,
+		"from_parent_id":       dept.ParentID,
+		"to_parent_id":         input.ParentID,
+		"moved_departments":    len(subtreeIDs),
+		"cleared_memberships":  len(stale),
+	})
+	for _, membership := range stale {
+		s.recordAudit(input.ActorID, models.AuditActionMembershipDepartmentRevoke, models.AuditUserRef(membership.UserID), dept.OrganizationID, map[string]any{
+			"department_id": membership.DepartmentID,
+			"reason":        "department_moved",
+		})
+	}
+	affected := make([]uint64, 0, len(stale)+len(fallbacks))
+	for _, fallback := range fallbacks {
+		s.recordAudit(input.ActorID, models.AuditActionMembershipPrimaryChange, models.AuditUserRef(fallback.userID), dept.OrganizationID, map[string]any{
+			"department_id": fallback.membership.DepartmentID,
+			"reason":        "department_moved",
+		})
+		affected = append(affected, fallback.userID)
+	}
+	for _, membership := range stale {
+		affected = append(affected, membership.UserID)
+	}
+	s.expireAccessTokens(affected...)
+
+	moved, err := s.orgRepo.GetDepartmentByID(dept.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.MoveDepartmentResult{
+		Department:         moved,
+		MovedDepartments:   len(subtreeIDs),
+		ClearedMemberships: len(stale),
+	}, nil
+}
+
+// departmentSubtree returns the IDs of dept and all of its descendants.
+func (s *OrganizationService) departmentSubtree(dept *models.Department) (map[uint64]struct{}, error) {
+	departments, err := s.orgRepo.ListDepartmentsByOrganization(dept.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[uint64][]uint64)
+	for _, candidate := range departments {
+		if candidate.ParentID != nil {
+			children[*candidate.ParentID] = append(children[*candidate.ParentID], candidate.ID)
+		}
+	}
+
+	subtree := map[uint64]struct{}{dept.ID: {}}
+	queue := []uint64{dept.ID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range children[current] {
+			if _, seen := subtree[child]; seen {
+				continue
+			}
+			subtree[child] = struct{}{}
+			queue = append(queue, child)
+		}
+	}
+	return subtree, nil
+}
+
+// foreignDepartmentMembers returns the memberships of the departments held by users outside the organization.
+func (s *OrganizationService) foreignDepartmentMembers(deptIDs []uint64, orgID uint64) ([]*models.UserDepartment, error) {
+	memberships, err := s.orgRepo.ListDepartmentMembers(deptIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	belongs := make(map[uint64]bool)
+	foreign := make([]*models.UserDepartment, 0)
+	for _, membership := range memberships {
+		member, checked := belongs[membership.UserID]
+		if !checked {
+			orgMembership, err := s.orgRepo.GetUserOrganization(membership.UserID, orgID)
+			if err != nil {
+				return nil, err
+			}
+			member = orgMembership != nil
+			belongs[membership.UserID] = member
+		}
+		if !member {
+			foreign = append(foreign, membership)
+		}
+	}
+	return foreign, nil
+}
+
+// primaryDepartmentFallbacks picks a replacement primary department for users whose primary department
+// membership is about to be cleared. It fails when a user has no other department to fall back to.
+func (s *OrganizationService) primaryDepartmentFallbacks(cleared []*models.UserDepartment, subtree map[uint64]struct{}) ([]primaryFallback, error) {
+	fallbacks := make([]primaryFallback, 0)
+	seen := make(map[uint64]struct{})
+	for _, membership := range cleared {
+		if _, ok := seen[membership.UserID]; ok {
+			continue
+		}
+		seen[membership.UserID] = struct{}{}
+
+		user, err := s.userRepo.GetByID(membership.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil || user.PrimaryDepartmentID == nil {
+			continue
+		}
+		if _, affected := subtree[*user.PrimaryDepartmentID]; !affected {
+			continue
+		}
+
+		others, err := s.orgRepo.ListUserDepartments(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		var replacement *models.UserDepartment
+		for _, other := range others {
+			if _, affected := subtree[other.DepartmentID]; !affected {
+				replacement = other
+				break
+			}
+		}
+		if replacement == nil {
+			return nil, fmt.Errorf("%w: user %d has no other department", ErrPrimaryDepartmentOrphaned, user.ID)
+		}
+		fallbacks = append(fallbacks, primaryFallback{userID: user.ID, membership: replacement})
+	}
+	return fallbacks, nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestMoveDepartmentAcrossOrganizations(t *testing.T) {
+	// The subtree Sales > North > Retail > Stores sits in Home; Hub is the new parent in Away. A member of
+	// Retail belongs to Home only.
+	tests := []struct {
+		name      string
+		targetOrg string
+		withUser  bool
+		clear     bool
+		wantErr   error
+	}{
+		{name: "parent in another organization", withUser: true, wantErr: ErrInvalidDepartment},
+		{name: "parent outside the named organization", targetOrg: "home", withUser: true, wantErr: ErrInvalidDepartment},
+		{name: "members outside the target organization", targetOrg: "away", withUser: true, wantErr: ErrDepartmentMembershipConflict},
+		{name: "primary department would be orphaned", targetOrg: "away", withUser: true, clear: true, wantErr: ErrPrimaryDepartmentOrphaned},
+		{name: "subtree without foreign members", targetOrg: "away"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, nil)
+			home := env.createOrganization(t, "Home", nil)
+			away := env.createOrganization(t, "Away", nil)
+			subtree := env.departmentChain(t, home, "Sales", "North", "Retail", "Stores")
+			hub := env.departmentChain(t, away, "Hub")[0]
+			if tt.withUser {
+				user := env.createUser(t, "clerk", nil)
+				env.addMember(t, user, home, "CEO")
+				if err := env.db.Create(&models.UserDepartment{UserID: user.ID, DepartmentID: subtree[2].ID, Role: "MEMBER", IsPrimary: true}).Error; err != nil {
+					t.Fatalf("add department member: %v", err)
+				}
+				if err := env.db.Model(user).Update("primary_department_id", subtree[2].ID).Error; err != nil {
+					t.Fatalf("set primary department: %v", err)
+				}
+			}
+
+			input := &models.MoveDepartmentInput{ParentID: &hub.ID, ClearMemberships: tt.clear}
+			switch tt.targetOrg {
+			case "home":
+				input.OrganizationID = &home.ID
+			case "away":
+				input.OrganizationID = &away.ID
+			}
+			result, err := env.org.MoveDepartment(subtree[0].ID, input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MoveDepartment() error = %v, want %v", err, tt.wantErr)
+			}
+
+			wantOrg, wantParent := home.ID, (*uint64)(nil)
+			if tt.wantErr == nil {
+				if result.MovedDepartments != len(subtree) {
+					t.Errorf("moved departments = %d, want %d", result.MovedDepartments, len(subtree))
+				}
+				wantOrg, wantParent = away.ID, &hub.ID
+			}
+			for i, dept := range subtree {
+				var stored models.Department
+				if err := env.db.First(&stored, dept.ID).Error; err != nil {
+					t.Fatalf("reload %s: %v", dept.Name, err)
+				}
+				if stored.OrganizationID != wantOrg {
+					t.Errorf("%s organization = %d, want %d", dept.Name, stored.OrganizationID, wantOrg)
+				}
+				if i == 0 && (stored.ParentID == nil) != (wantParent == nil) {
+					t.Errorf("%s parent = %v, want %v", dept.Name, stored.ParentID, wantParent)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+var (
+	ErrDepartmentCycle        = errors.New("a department cannot be moved under itself or its descendants")
+	ErrDepartmentCodeConflict = errors.New("department code already in use")
+)
+
+// UpdateDepartment changes the supplied fields of a department. A ParentID of 0 makes the department
+// top-level; any other parent must belong to the same organization and must not be the department
+// itself or one of its descendants. Moving across organizations is left to MoveDepartment.
+func (s *OrganizationService) UpdateDepartment(id uint64, input *models.UpdateDepartmentInput) (*models.Department, error) {
+	if input == nil {
+		return nil, fmt.Errorf("input required")
+	}
+
+	dept, err := s.orgRepo.GetDepartmentByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if dept == nil {
+		return nil, ErrDepartmentNotFound
+	}
+
+	changed := []string{}
+	updates := map[string]any{}
+
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" {
+			return nil, fmt.Errorf("department name is required")
+		}
+		updates["name"] = name
+		changed = append(changed, "name")
+	}
+	if input.Description != nil {
+		updates["description"] = strings.TrimSpace(*input.Description)
+		changed = append(changed, "description")
+	}
+	if input.Function != nil {
+		updates["function"] = strings.TrimSpace(*input.Function)
+		changed = append(changed, "function")
+	}
+	if input.IsActive != nil {
+		updates["is_active"] = *input.IsActive
+		updates["deactivated_by_organization"] = false
+		changed = append(changed, "is_active")
+	}
+	if input.Code != nil {
+		var code *models.DepartmentCode
+		if trimmed := strings.TrimSpace(string(*input.Code)); trimmed != "" {
+			c := models.DepartmentCode(trimmed)
+			existing, err := s.orgRepo.GetDepartmentByCode(dept.OrganizationID, c)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil && existing.ID != dept.ID {
+				return nil, fmt.Errorf("%w: %s", ErrDepartmentCodeConflict, c)
+			}
+			code = &c
+		}
+		updates["code"] = code
+		changed = append(changed, "code")
+	}
+
+	parentID := dept.ParentID
+	if input.ParentID != nil {
+		parentID = nil
+		if *input.ParentID != 0 {
+			parentID = input.ParentID
+		}
+		updates["parent_id"] = parentID
+		changed = append(changed, "parent_id")
+	}
+
+	var parent *models.Department
+	if parentID != nil {
+		parent, err = s.orgRepo.GetDepartmentByID(*parentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			return nil, ErrDepartmentNotFound
+		}
+		if input.ParentID != nil {
+			if parent.OrganizationID != dept.OrganizationID {
+				return nil, fmt.Errorf("parent department belongs to another organization")
+			}
+			subtree, err := s.departmentSubtree(dept)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := subtree[parent.ID]; ok {
+				return nil, ErrDepartmentCycle
+			}
+			height, err := s.departmentHeight(dept)
+			if err != nil {
+				return nil, err
+			}
+			if err := s.validateDepartmentDepth(parent, height); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	kind := dept.Kind
+	if input.Kind != nil {
+		kind = models.DepartmentKind(strings.ToUpper(strings.TrimSpace(string(*input.Kind))))
+		if kind == "" {
+			kind = models.DepartmentKindDepartment
+		}
+		if err := s.validateDepartmentKindChange(dept, parent, kind); err != nil {
+			return nil, err
+		}
+		updates["kind"] = kind
+		changed = append(changed, "kind")
+	}
+	if input.ParentID != nil && kind == dept.Kind {
+		if err := s.validateDepartmentKind(kind, parent); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(changed) == 0 {
+		return dept, nil
+	}
+	if err := s.orgRepo.UpdateDepartment(dept.ID, updates); err != nil {
+		return nil, err
+	}
+
+	s.recordAudit(input.ActorID, models.AuditActionDepartmentUpdate, models.AuditDepartmentRef(dept.ID), dept.OrganizationID, map[string]any{
+		"fields": changed,
+	})
+
+	updated, err := s.orgRepo.GetDepartmentByID(dept.ID)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, ErrDepartmentNotFound
+	}
+	updated.Parent = parent
+	return updated, nil
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// Login methods reported by federation discovery.
+const (
+	LoginMethodPassword    = "password"
+	LoginMethodOAuthGoogle = "oauth:google"
+)
+
+// DiscoverFederation returns the active organizations whose domain matches the email's domain and the
+// login methods available to them. Malformed emails and unknown domains yield an empty result.
+func (s *AuthenticationService) DiscoverFederation(email string) (*models.FederationDiscovery, error) {
+	result := &models.FederationDiscovery{Organizations: make([]models.FederatedOrganization, 0)}
+
+	_, domain, found := strings.Cut(strings.TrimSpace(email), "@")
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if !found || domain == "" || strings.Contains(domain, "@") {
+		return result, nil
+	}
+	result.Domain = domain
+
+	orgs, err := s.orgRepo.ListActiveOrganizationsByDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	methods := s.loginMethods()
+	for _, org := range orgs {
+		result.Organizations = append(result.Organizations, models.FederatedOrganization{
+			ID:           org.ID,
+			Name:         org.Name,
+			LoginMethods: methods,
+		})
+	}
+	return result, nil
+}
+
+// loginMethods lists the login methods the service accepts. They are configured service-wide; there
+// are no per-organization identity providers yet.
+func (s *AuthenticationService) loginMethods() []string {
+	methods := []string{LoginMethodPassword}
+	if s.GoogleOAuthEnabled() {
+		methods = append(methods, LoginMethodOAuthGoogle)
+	}
+	return methods
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/repository"
+	"github.com/lee-tech/authentication/internal/testdb"
+	coreConfig "github.com/lee-tech/core/config"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const testPassword = "correct-horse-battery"
+
+// testEnv bundles a service wired to an in-memory database.
+type testEnv struct {
+	db    *gorm.DB
+	cfg   *config.AuthConfig
+	users *repository.UserRepository
+	orgs  *repository.OrganizationRepository
+	audit *repository.AuditRepository
+	auth  *AuthenticationService
+	org   *OrganizationService
+}
+
+func testConfig() *config.AuthConfig {
+	return &config.AuthConfig{
+		Config:                 &coreConfig.Config{ServiceName: "authentication", JWTSecret: "test-secret"},
+		JWTAlgorithm:           "HS256",
+		JWTIssuer:              "authentication",
+		TokenExpiration:        15 * time.Minute,
+		RefreshExpiration:      24 * time.Hour,
+		MaxLoginAttempts:       5,
+		LockoutDuration:        15 * time.Minute,
+		BCryptCost:             bcrypt.MinCost,
+		PasswordMinLength:      8,
+		VerificationTokenTTL:   24 * time.Hour,
+		PasswordResetTTL:       time.Hour,
+		LoginSelectionEnabled:  true,
+		LoginSelectionTokenTTL: 5 * time.Minute,
+		MaxHierarchyDepth:      10,
+		DefaultTenantTier:      "basic",
+		MembershipRemovalMode:  config.MembershipRemovalHard,
+		MFAEnabled:             true,
+		MFAEncryptionKey:       "test-mfa-key",
+		MFAChallengeTokenTTL:   5 * time.Minute,
+		MaxMFAAttempts:         5,
+		StepUpTokenTTL:         5 * time.Minute,
+	}
+}
+
+// newTestEnv builds the services over a fresh database; configure adjusts the config first.
+func newTestEnv(t *testing.T, configure func(cfg *config.AuthConfig)) *testEnv {
+	t.Helper()
+
+	cfg := testConfig()
+	if configure != nil {
+		configure(cfg)
+	}
+	db := testdb.Open(t)
+	env := &testEnv{
+		db:    db,
+		cfg:   cfg,
+		users: repository.NewUserRepository(db),
+		orgs:  repository.NewOrganizationRepository(db),
+		audit: repository.NewAuditRepository(db),
+	}
+	env.auth = NewAuthenticationService(env.users, env.orgs, env.audit, cfg)
+	env.org = NewOrganizationService(env.orgs, env.users, env.audit, cfg)
+	return env
+}
+
+// createUser stores an active, verified user with testPassword; adjust tweaks it before insert.
+func (e *testEnv) createUser(t *testing.T, username string, adjust func(u *models.User)) *models.User {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	user := &models.User{
+		Email:      username + "@example.com",
+		Username:   username,
+		Password:   string(hash),
+		IsActive:   true,
+		IsVerified: true,
+	}
+	if adjust != nil {
+		adjust(user)
+	}
+	if err := e.users.Create(user); err != nil {
+		t.Fatalf("create user %s: %v", username, err)
+	}
+	return user
+}
+
+// createOrganization stores an active organization; adjust tweaks it before insert.
+func (e *testEnv) createOrganization(t *testing.T, name string, adjust func(o *models.Organization)) *models.Organization {
+	t.Helper()
+
+	org := &models.Organization{Name: name, IsActive: true}
+	if adjust != nil {
+		adjust(org)
+	}
+	if err := e.db.Create(org).Error; err != nil {
+		t.Fatalf("create organization %s: %v", name, err)
+	}
+	return org
+}
+
+// addMember makes the user a member of the organization with the given role.
+func (e *testEnv) addMember(t *testing.T, user *models.User, org *models.Organization, role models.OrganizationRole) {
+	t.Helper()
+
+	membership := &models.UserOrganization{UserID: user.ID, OrganizationID: org.ID, Role: role, IsPrimary: true}
+	if err := e.db.Create(membership).Error; err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+}
+
+// reloadUser reads the user back from the database.
+func (e *testEnv) reloadUser(t *testing.T, id uint64) *models.User {
+	t.Helper()
+
+	user, err := e.users.GetByID(id)
+	if err != nil || user == nil {
+		t.Fatalf("reload user %d: %v", id, err)
+	}
+	return user
+}
+
+// recordingSender captures delivered verification and reset tokens.
+type recordingSender struct {
+	verifications map[uint64]string
+	resets        map[uint64]string
+}
+
+func newRecordingSender() *recordingSender {
+	return &recordingSender{verifications: map[uint64]string{}, resets: map[uint64]string{}}
+}
+
+func (s *recordingSender) SendVerification(user *models.User, token string) error {
+	s.verifications[user.ID] = token
+	return nil
+}
+
+func (s *recordingSender) SendPasswordReset(user *models.User, token string) error {
+	s.resets[user.ID] = token
+	return nil
+}
+
+// enableMFA enrolls the user in TOTP MFA and returns the secret and recovery codes.
+func (e *testEnv) enableMFA(t *testing.T, user *models.User) (string, []string) {
+	t.Helper()
+
+	enrollment, err := e.auth.EnrollMFA(user.ID)
+	if err != nil {
+		t.Fatalf("enroll mfa: %v", err)
+	}
+	if err := e.auth.VerifyMFA(user.ID, e.totp(t, enrollment.Secret)); err != nil {
+		t.Fatalf("verify mfa: %v", err)
+	}
+	return enrollment.Secret, enrollment.RecoveryCodes
+}
+
+// totp returns the current code for the secret on the service clock.
+func (e *testEnv) totp(t *testing.T, secret string) string {
+	t.Helper()
+
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		t.Fatalf("decode totp secret: %v", err)
+	}
+	return totpCode(key, uint64(e.auth.now().Unix()/int64(totpPeriod/time.Second)))
+}
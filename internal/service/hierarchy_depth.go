@@ -0,0 +1,98 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// ErrHierarchyTooDeep is returned when assigning a parent would make an organization or department
+// tree deeper than MaxHierarchyDepth.
+var ErrHierarchyTooDeep = errors.New("hierarchy exceeds the maximum depth")
+
+// validateOrganizationDepth rejects a new child of parentID when it would sit below the depth cap.
+func (s *OrganizationService) validateOrganizationDepth(parentID uint64) error {
+	level, err := s.orgRepo.OrganizationLevel(parentID)
+	if err != nil {
+		return err
+	}
+	if maxDepth := s.maxHierarchyDepth(); level+1 > maxDepth {
+		return fmt.Errorf("%w: the organization would be at level %d, above the maximum of %d", ErrHierarchyTooDeep, level+1, maxDepth)
+	}
+	return nil
+}
+
+// validateDepartmentDepth rejects placing a subtree height levels tall under parent when the deepest
+// department would end up below the depth cap. A nil parent places the subtree at the top level.
+func (s *OrganizationService) validateDepartmentDepth(parent *models.Department, height int) error {
+	depth := height
+	if parent != nil {
+		level, err := s.departmentLevel(parent)
+		if err != nil {
+			return err
+		}
+		depth += level
+	}
+	if maxDepth := s.maxHierarchyDepth(); depth > maxDepth {
+		return fmt.Errorf("%w: the department tree would be %d levels deep, above the maximum of %d", ErrHierarchyTooDeep, depth, maxDepth)
+	}
+	return nil
+}
+
+// departmentLevel returns the number of departments on the path from the top level down to dept,
+// dept included.
+func (s *OrganizationService) departmentLevel(dept *models.Department) (int, error) {
+	departments, err := s.orgRepo.ListDepartmentsByOrganization(dept.OrganizationID)
+	if err != nil {
+		return 0, err
+	}
+
+	parents := make(map[uint64]*uint64, len(departments))
+	for _, candidate := range departments {
+		parents[candidate.ID] = candidate.ParentID
+	}
+
+	level := 1
+	seen := map[uint64]struct{}{dept.ID: {}}
+	for current := dept.ParentID; current != nil; current = parents[*current] {
+		if _, ok := seen[*current]; ok {
+			break
+		}
+		seen[*current] = struct{}{}
+		level++
+	}
+	return level, nil
+}
+
+// departmentHeight returns the number of levels in the subtree rooted at dept, dept included.
+func (s *OrganizationService) departmentHeight(dept *models.Department) (int, error) {
+	departments, err := s.orgRepo.ListDepartmentsByOrganization(dept.OrganizationID)
+	if err != nil {
+		return 0, err
+	}
+
+	children := make(map[uint64][]uint64)
+	for _, candidate := range departments {
+		if candidate.ParentID != nil {
+			children[*candidate.ParentID] = append(children[*candidate.ParentID], candidate.ID)
+		}
+	}
+
+	height := 0
+	seen := map[uint64]struct{}{dept.ID: {}}
+	for level := []uint64{dept.ID}; len(level) > 0; height++ {
+		var next []uint64
+		for _, id := range level {
+			for _, child := range children[id] {
+				if _, ok := seen[child]; ok {
+					continue
+				}
+				seen[child] = struct{}{}
+				next = append(next, child)
+			}
+		}
+		level = next
+	}
+	return height, nil
+}
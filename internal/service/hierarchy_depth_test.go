@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// departmentChain creates a chain of departments, each under the previous one, and returns them top down.
+func (e *testEnv) departmentChain(t *testing.T, org *models.Organization, names ...string) []*models.Department {
+	t.Helper()
+
+	chain := make([]*models.Department, 0, len(names))
+	for _, name := range names {
+		dept := &models.Department{OrganizationID: org.ID, Name: name, Kind: models.DepartmentKindDepartment, IsActive: true}
+		if len(chain) > 0 {
+			dept.ParentID = &chain[len(chain)-1].ID
+		}
+		if err := e.db.Create(dept).Error; err != nil {
+			t.Fatalf("create department %s: %v", name, err)
+		}
+		chain = append(chain, dept)
+	}
+	return chain
+}
+
+func TestDepartmentParentRespectsDepthCap(t *testing.T) {
+	// With a cap of three levels, the deep chain A > B > C is full and the shallow chain X > Y leaves
+	// room for one more level.
+	tests := []struct {
+		name    string
+		assign  func(env *testEnv, org *models.Organization, deep, shallow []*models.Department) error
+		wantErr error
+	}{
+		{
+			name: "create under the deepest level",
+			assign: func(env *testEnv, org *models.Organization, deep, _ []*models.Department) error {
+				_, err := env.org.CreateDepartment(&models.CreateDepartmentInput{OrganizationID: org.ID, Name: "D", ParentID: &deep[2].ID})
+				return err
+			},
+			wantErr: ErrHierarchyTooDeep,
+		},
+		{
+			name: "create at the last allowed level",
+			assign: func(env *testEnv, org *models.Organization, _, shallow []*models.Department) error {
+				_, err := env.org.CreateDepartment(&models.CreateDepartmentInput{OrganizationID: org.ID, Name: "Z", ParentID: &shallow[1].ID})
+				return err
+			},
+		},
+		{
+			name: "update parent so a subtree ends too deep",
+			assign: func(env *testEnv, _ *models.Organization, deep, shallow []*models.Department) error {
+				_, err := env.org.UpdateDepartment(shallow[0].ID, &models.UpdateDepartmentInput{ParentID: &deep[1].ID})
+				return err
+			},
+			wantErr: ErrHierarchyTooDeep,
+		},
+		{
+			name: "update parent within the cap",
+			assign: func(env *testEnv, _ *models.Organization, deep, shallow []*models.Department) error {
+				_, err := env.org.UpdateDepartment(shallow[0].ID, &models.UpdateDepartmentInput{ParentID: &deep[0].ID})
+				return err
+			},
+		},
+		{
+			name: "move a subtree too deep",
+			assign: func(env *testEnv, _ *models.Organization, deep, shallow []*models.Department) error {
+				_, err := env.org.MoveDepartment(shallow[0].ID, &models.MoveDepartmentInput{ParentID: &deep[2].ID})
+				return err
+			},
+			wantErr: ErrHierarchyTooDeep,
+		},
+		{
+			name: "move a leaf to the last allowed level",
+			assign: func(env *testEnv, _ *models.Organization, deep, shallow []*models.Department) error {
+				_, err := env.org.MoveDepartment(shallow[1].ID, &models.MoveDepartmentInput{ParentID: &deep[1].ID})
+				return err
+			},
+		},
+		{
+			name: "move a subtree to the top level",
+			assign: func(env *testEnv, _ *models.Organization, deep, _ []*models.Department) error {
+				_, err := env.org.MoveDepartment(deep[1].ID, &models.MoveDepartmentInput{})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, func(cfg *config.AuthConfig) { cfg.MaxHierarchyDepth = 3 })
+			org := env.createOrganization(t, "Acme", nil)
+			deep := env.departmentChain(t, org, "A", "B", "C")
+			shallow := env.departmentChain(t, org, "X", "Y")
+
+			err := tt.assign(env, org, deep, shallow)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateOrganizationRespectsDepthCap(t *testing.T) {
+	env := newTestEnv(t, func(cfg *config.AuthConfig) { cfg.MaxHierarchyDepth = 2 })
+	root := env.createOrganization(t, "Root", nil)
+
+	child, err := env.org.CreateOrganization(&models.CreateOrganizationInput{Name: "Child", ParentID: &root.ID})
+	if err != nil {
+		t.Fatalf("CreateOrganization(child) error = %v", err)
+	}
+	if _, err := env.org.CreateOrganization(&models.CreateOrganizationInput{Name: "Grandchild", ParentID: &child.ID}); !errors.Is(err, ErrHierarchyTooDeep) {
+		t.Fatalf("CreateOrganization(grandchild) error = %v, want %v", err, ErrHierarchyTooDeep)
+	}
+}
+
+func TestExportStructureTruncatesLegacyTrees(t *testing.T) {
+	env := newTestEnv(t, func(cfg *config.AuthConfig) { cfg.MaxHierarchyDepth = 2 })
+	org := env.createOrganization(t, "Legacy", nil)
+	// Stored directly, as data written before the cap was enforced
+	env.departmentChain(t, org, "A", "B", "C")
+	env.departmentChain(t, org, "X")
+
+	exported, err := env.org.ExportStructure(org.ID)
+	if err != nil {
+		t.Fatalf("ExportStructure() error = %v", err)
+	}
+	if !exported.Truncated {
+		t.Fatal("ExportStructure() did not flag the tree as truncated")
+	}
+	if len(exported.Departments) != 2 {
+		t.Fatalf("exported %d top-level departments, want 2", len(exported.Departments))
+	}
+	for _, top := range exported.Departments {
+		for _, child := range top.Children {
+			if len(child.Children) != 0 {
+				t.Fatalf("%s > %s exported %d children below the cap", top.Name, child.Name, len(child.Children))
+			}
+		}
+	}
+
+	shallow := env.createOrganization(t, "Shallow", nil)
+	env.departmentChain(t, shallow, "A", "B")
+	exported, err = env.org.ExportStructure(shallow.ID)
+	if err != nil {
+		t.Fatalf("ExportStructure(shallow) error = %v", err)
+	}
+	if exported.Truncated {
+		t.Fatal("ExportStructure() flagged a tree within the cap as truncated")
+	}
+}
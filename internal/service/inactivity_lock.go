@@ -0,0 +1,93 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// LockInactiveUsers deactivates accounts that have not logged in within the configured window.
+// Users that never logged in are only considered once their account is older than the grace period.
+func (s *AuthenticationService) LockInactiveUsers() (int, error) {
+	if s.config.InactivityLockDays <= 0 {
+		return 0, nil
+	}
+
+	now := s.now()
+	lastLoginBefore := now.AddDate(0, 0, -s.config.InactivityLockDays)
+	graceDays := s.config.InactivityLockGraceDays
+	if graceDays <= 0 {
+		graceDays = s.config.InactivityLockDays
+	}
+	createdBefore := now.AddDate(0, 0, -graceDays)
+
+	users, err := s.userRepo.ListInactiveUsers(lastLoginBefore, createdBefore, s.config.InactivityLockSuperAdmins)
+	if err != nil {
+		return 0, err
+	}
+
+	locked := 0
+	for _, user := range users {
+		if user == nil {
+			continue
+		}
+		if err := s.userRepo.Deactivate(user.ID); err != nil {
+			return locked, err
+		}
+		locked++
+
+		metadata := map[string]any{
+			"inactivity_days": s.config.InactivityLockDays,
+		}
+		if user.LastLogin != nil {
+			metadata["last_login"] = user.LastLogin.UTC()
+		}
+		event := &models.AuditEvent{
+			Actor:     models.AuditActorSystem,
+			Action:    models.AuditActionInactivityLock,
+			Target:    models.AuditUserRef(user.ID),
+			OrgID:     user.PrimaryOrganizationID,
+			Success:   true,
+			Timestamp: now,
+			Metadata:  metadata,
+		}
+		s.recordAudit(event)
+		s.notifySecurityEvent(event)
+	}
+
+	return locked, nil
+}
+
+// StartInactivityLock runs LockInactiveUsers on the configured interval until the returned stop function is called.
+func (s *AuthenticationService) StartInactivityLock() func() {
+	if s.config.InactivityLockDays <= 0 {
+		return func() {}
+	}
+
+	interval := s.config.InactivityLockInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if count, err := s.LockInactiveUsers(); err != nil {
+				log.Printf("inactivity lock sweep failed: %v", err)
+			} else if count > 0 {
+				log.Printf("inactivity lock sweep deactivated %d user(s)", count)
+			}
+
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestLockInactiveUsers(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) *time.Time {
+		at := now.AddDate(0, 0, -days)
+		return &at
+	}
+
+	// Accounts are created 100 days ago unless createdDaysAgo says otherwise.
+	accounts := []struct {
+		username       string
+		lastLogin      *time.Time
+		createdDaysAgo int
+		superAdmin     bool
+		inactive       bool
+	}{
+		{username: "stale", lastLogin: daysAgo(40)},
+		{username: "recent", lastLogin: daysAgo(10)},
+		{username: "never-old", createdDaysAgo: 10},
+		{username: "never-new", createdDaysAgo: 3},
+		{username: "stale-admin", lastLogin: daysAgo(40), superAdmin: true},
+		{username: "already-inactive", lastLogin: daysAgo(40), inactive: true},
+	}
+
+	tests := []struct {
+		name        string
+		lockDays    int
+		superAdmins bool
+		want        []string
+	}{
+		{name: "disabled", lockDays: 0},
+		{name: "super admins excluded", lockDays: 30, want: []string{"never-old", "stale"}},
+		{name: "super admins included", lockDays: 30, superAdmins: true, want: []string{"never-old", "stale", "stale-admin"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var notified []string
+			webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var event models.AuditEvent
+				if err := json.NewDecoder(r.Body).Decode(&event); err == nil {
+					mu.Lock()
+					notified = append(notified, event.Target)
+					mu.Unlock()
+				}
+			}))
+			defer webhook.Close()
+
+			env := newTestEnv(t, func(cfg *config.AuthConfig) {
+				cfg.InactivityLockDays = tt.lockDays
+				cfg.InactivityLockGraceDays = 7
+				cfg.InactivityLockSuperAdmins = tt.superAdmins
+				cfg.SecurityWebhookURL = webhook.URL
+			})
+			env.auth.WithClock(func() time.Time { return now })
+
+			ids := map[uint64]string{}
+			for _, account := range accounts {
+				user := env.createUser(t, account.username, func(u *models.User) { u.IsSuperAdmin = account.superAdmin })
+				created := 100
+				if account.createdDaysAgo > 0 {
+					created = account.createdDaysAgo
+				}
+				updates := map[string]any{"created_at": *daysAgo(created), "last_login": account.lastLogin}
+				if account.inactive {
+					updates["is_active"] = false
+				}
+				if err := env.db.Model(user).Updates(updates).Error; err != nil {
+					t.Fatalf("age user %s: %v", account.username, err)
+				}
+				ids[user.ID] = account.username
+			}
+
+			locked, err := env.auth.LockInactiveUsers()
+			if err != nil {
+				t.Fatalf("LockInactiveUsers() error = %v", err)
+			}
+			if locked != len(tt.want) {
+				t.Errorf("LockInactiveUsers() = %d, want %d", locked, len(tt.want))
+			}
+
+			var deactivated []string
+			for id, username := range ids {
+				if username != "already-inactive" && !env.reloadUser(t, id).IsActive {
+					deactivated = append(deactivated, username)
+				}
+			}
+			sort.Strings(deactivated)
+			if !equalStrings(deactivated, tt.want) {
+				t.Errorf("deactivated %v, want %v", deactivated, tt.want)
+			}
+
+			events, _, err := env.audit.List(models.AuditEventFilter{Actions: []string{models.AuditActionInactivityLock}})
+			if err != nil {
+				t.Fatalf("list audit events: %v", err)
+			}
+			if len(events) != len(tt.want) {
+				t.Errorf("recorded %d inactivity lock events, want %d", len(events), len(tt.want))
+			}
+			for _, event := range events {
+				if event.Actor != models.AuditActorSystem {
+					t.Errorf("event actor = %q, want %q", event.Actor, models.AuditActorSystem)
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if len(notified) != len(tt.want) {
+				t.Errorf("webhook received %d events, want %d", len(notified), len(tt.want))
+			}
+		})
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
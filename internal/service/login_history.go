@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// Login history outcomes.
+const (
+	LoginOutcomeSuccess             = "success"
+	LoginOutcomeMFAPending          = "mfa_pending"
+	LoginOutcomeInvalidCredentials  = "invalid_credentials"
+	LoginOutcomeAccountLocked       = "account_locked"
+	LoginOutcomeAccountInactive     = "account_inactive"
+	LoginOutcomeEmailUnverified     = "email_unverified"
+	LoginOutcomeOrganizationBlocked = "organization_denied"
+	LoginOutcomeFailed              = "failed"
+)
+
+// recordLoginAttempt audits the outcome of a login for a known user. A password accepted pending the
+// MFA code is recorded as pending rather than failed. Logins paused for organization selection are not
+// recorded; the attempt that completes them is.
+func (s *AuthenticationService) recordLoginAttempt(user *models.User, req *models.LoginRequest, response *models.LoginResponse, err error) {
+	if user == nil || req == nil {
+		return
+	}
+	var selectionErr *OrganizationSelectionError
+	if errors.As(err, &selectionErr) {
+		return
+	}
+
+	event := &models.AuditEvent{
+		Actor:     models.AuditUserRef(user.ID),
+		Action:    models.AuditActionLoginSuccess,
+		Target:    models.AuditUserRef(user.ID),
+		IP:        req.IPAddress,
+		Success:   err == nil,
+		Timestamp: s.now(),
+		Metadata: map[string]any{
+			"outcome":    loginOutcome(err),
+			"user_agent": req.UserAgent,
+		},
+	}
+	var mfaErr *MFARequiredError
+	switch {
+	case errors.As(err, &mfaErr):
+		event.Action = models.AuditActionLoginPending
+	case err != nil:
+		event.Action = models.AuditActionLoginFailure
+	}
+	if response != nil && response.LoggedOrganization != nil {
+		orgID := response.LoggedOrganization.ID
+		event.OrgID = &orgID
+	} else if req.OrganizationID != 0 {
+		orgID := req.OrganizationID
+		event.OrgID = &orgID
+	}
+	if err == nil {
+		s.detectConcurrentLogin(user, event)
+	}
+	s.recordAudit(event)
+}
+
+// loginOutcome maps a login error to the outcome shown in the login history.
+func loginOutcome(err error) string {
+	switch {
+	case err == nil:
+		return LoginOutcomeSuccess
+	case errors.As(err, new(*MFARequiredError)):
+		return LoginOutcomeMFAPending
+	case errors.Is(err, ErrInvalidCredentials), errors.Is(err, ErrInvalidToken), errors.Is(err, ErrInvalidMFACode):
+		return LoginOutcomeInvalidCredentials
+	case errors.Is(err, ErrAccountLocked):
+		return LoginOutcomeAccountLocked
+	case errors.Is(err, ErrAccountInactive):
+		return LoginOutcomeAccountInactive
+	case errors.Is(err, ErrEmailNotVerified):
+		return LoginOutcomeEmailUnverified
+	case errors.Is(err, ErrInsufficientRole), errors.Is(err, ErrOrganizationInactive), errors.Is(err, ErrDepartmentInactive), errors.Is(err, ErrRoleNotAssigned):
+		return LoginOutcomeOrganizationBlocked
+	default:
+		return LoginOutcomeFailed
+	}
+}
+
+// ListLoginHistory returns the user's own login attempts within LoginHistoryWindow, newest first.
+func (s *AuthenticationService) ListLoginHistory(userID uint64, offset, limit int) ([]*models.LoginHistoryEntry, int64, error) {
+	reader, ok := s.audit.(AuditReader)
+	if !ok {
+		return nil, 0, ErrAuditUnavailable
+	}
+
+	var from *time.Time
+	if s.config.LoginHistoryWindow > 0 {
+		cutoff := s.now().Add(-s.config.LoginHistoryWindow)
+		from = &cutoff
+	}
+
+	events, total, err := reader.List(models.AuditEventFilter{
+		Target:     models.AuditUserRef(userID),
+		Actions:    models.LoginAuditActions,
+		From:       from,
+		Offset:     offset,
+		Limit:      limit,
+		Descending: true,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries := make([]*models.LoginHistoryEntry, 0, len(events))
+	for _, event := range events {
+		entry := &models.LoginHistoryEntry{
+			Time:           event.Timestamp,
+			IPAddress:      event.IP,
+			Success:        event.Success,
+			Outcome:        LoginOutcomeFailed,
+			OrganizationID: event.OrgID,
+		}
+		if outcome, ok := event.Metadata["outcome"].(string); ok && outcome != "" {
+			entry.Outcome = outcome
+		}
+		if userAgent, ok := event.Metadata["user_agent"].(string); ok {
+			entry.UserAgent = userAgent
+		}
+		entries = append(entries, entry)
+	}
+	return entries, total, nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestListLoginHistoryOutcomes(t *testing.T) {
+	tests := []struct {
+		name        string
+		mfaEnabled  bool
+		password    string
+		wantErr     func(err error) bool
+		wantOutcome string
+		wantSuccess bool
+		wantAction  string
+	}{
+		{
+			name:        "success",
+			password:    testPassword,
+			wantErr:     func(err error) bool { return err == nil },
+			wantOutcome: LoginOutcomeSuccess,
+			wantSuccess: true,
+			wantAction:  models.AuditActionLoginSuccess,
+		},
+		{
+			name:        "wrong password",
+			password:    "wrong-password",
+			wantErr:     func(err error) bool { return errors.Is(err, ErrInvalidCredentials) },
+			wantOutcome: LoginOutcomeInvalidCredentials,
+			wantAction:  models.AuditActionLoginFailure,
+		},
+		{
+			name:        "mfa challenge is pending, not failed",
+			mfaEnabled:  true,
+			password:    testPassword,
+			wantErr:     func(err error) bool { return errors.As(err, new(*MFARequiredError)) },
+			wantOutcome: LoginOutcomeMFAPending,
+			wantAction:  models.AuditActionLoginPending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, func(cfg *config.AuthConfig) {
+				cfg.LoginHistoryWindow = 24 * time.Hour
+				cfg.MFAChallengeTokenTTL = 5 * time.Minute
+			})
+			org := env.createOrganization(t, "Acme", nil)
+			user := env.createUser(t, "ada", func(u *models.User) { u.MFAEnabled = tt.mfaEnabled })
+			env.addMember(t, user, org, models.OrganizationRoleOrgAdmin)
+
+			_, err := env.auth.Login(&models.LoginRequest{
+				Username:       user.Username,
+				Password:       tt.password,
+				OrganizationID: org.ID,
+				IPAddress:      "203.0.113.7",
+				UserAgent:      "test-agent",
+			})
+			if !tt.wantErr(err) {
+				t.Fatalf("Login() unexpected error = %v", err)
+			}
+
+			entries, total, err := env.auth.ListLoginHistory(user.ID, 0, 10)
+			if err != nil {
+				t.Fatalf("ListLoginHistory() error = %v", err)
+			}
+			if total != 1 || len(entries) != 1 {
+				t.Fatalf("ListLoginHistory() = %d entries (total %d), want 1", len(entries), total)
+			}
+			entry := entries[0]
+			if entry.Outcome != tt.wantOutcome || entry.Success != tt.wantSuccess {
+				t.Fatalf("entry outcome = %q success = %v, want %q %v", entry.Outcome, entry.Success, tt.wantOutcome, tt.wantSuccess)
+			}
+			if entry.IPAddress != "203.0.113.7" || entry.UserAgent != "test-agent" {
+				t.Fatalf("entry client = %q %q", entry.IPAddress, entry.UserAgent)
+			}
+
+			var actions []string
+			env.db.Model(&models.AuditEvent{}).Pluck("action", &actions)
+			if len(actions) != 1 || actions[0] != tt.wantAction {
+				t.Fatalf("audit actions = %v, want [%s]", actions, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestListLoginHistoryIsScopedToCaller(t *testing.T) {
+	env := newTestEnv(t, func(cfg *config.AuthConfig) { cfg.LoginHistoryWindow = 24 * time.Hour })
+	org := env.createOrganization(t, "Acme", nil)
+	ada := env.createUser(t, "ada", nil)
+	grace := env.createUser(t, "grace", nil)
+	env.addMember(t, ada, org, models.OrganizationRoleOrgAdmin)
+	env.addMember(t, grace, org, models.OrganizationRoleOrgAdmin)
+
+	for _, username := range []string{"ada", "grace", "grace"} {
+		if _, err := env.auth.Login(&models.LoginRequest{Username: username, Password: testPassword, OrganizationID: org.ID}); err != nil {
+			t.Fatalf("Login(%s) error = %v", username, err)
+		}
+	}
+
+	entries, total, err := env.auth.ListLoginHistory(ada.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("ListLoginHistory() error = %v", err)
+	}
+	if total != 1 || len(entries) != 1 {
+		t.Fatalf("ListLoginHistory(ada) = %d entries (total %d), want only ada's login", len(entries), total)
+	}
+}
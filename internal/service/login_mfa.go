@@ -0,0 +1,138 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+const mfaPendingTokenType = "mfa_pending"
+
+// ErrMFARequired is returned by Login when the user has MFA enabled and no code was supplied.
+var ErrMFARequired = errors.New("mfa code required")
+
+// MFARequiredError carries the challenge token that completes a login paused for MFA.
+type MFARequiredError struct {
+	Challenge *models.MFALoginChallenge
+}
+
+func (e *MFARequiredError) Error() string {
+	return ErrMFARequired.Error()
+}
+
+func (e *MFARequiredError) Unwrap() error {
+	return ErrMFARequired
+}
+
+// requireLoginMFA enforces MFA after the password was verified. A code in the request is checked
+// directly; without one the login is paused and a challenge token is returned.
+func (s *AuthenticationService) requireLoginMFA(user *models.User, req *models.LoginRequest) error {
+	if !user.MFAEnabled {
+		return nil
+	}
+	if req.MFACode != "" {
+		return s.verifyMFACode(user, req.MFACode)
+	}
+
+	token, err := s.generateMFAPendingToken(user, req)
+	if err != nil {
+		return fmt.Errorf("failed to generate mfa challenge token: %w", err)
+	}
+	return &MFARequiredError{
+		Challenge: &models.MFALoginChallenge{
+			MFARequired:    true,
+			ChallengeToken: token,
+			ExpiresIn:      int(s.config.MFAChallengeTokenTTL.Seconds()),
+		},
+	}
+}
+
+// CompleteMFALogin finishes a login paused by ErrMFARequired using the challenge token and an MFA code.
+func (s *AuthenticationService) CompleteMFALogin(req *models.MFALoginRequest) (response *models.LoginResponse, err error) {
+	claims, err := s.parseTypedToken(req.ChallengeToken, mfaPendingTokenType)
+	if err != nil {
+		return nil, err
+	}
+	userID, ok := claimUserID(claims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidToken
+	}
+
+	loginReq := &models.LoginRequest{
+		OrganizationID: claimUint64(claims, "organization_id"),
+		DepartmentID:   claimUint64(claims, "department_id"),
+		RoleID:         claimUint64(claims, "role_id"),
+		IPAddress:      req.IPAddress,
+		UserAgent:      req.UserAgent,
+	}
+	defer func() {
+		s.recordLoginAttempt(user, loginReq, response, err)
+	}()
+
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		return nil, ErrAccountLocked
+	}
+	if !user.IsActive {
+		return nil, ErrAccountInactive
+	}
+	if err := s.verifyMFACode(user, req.Code); err != nil {
+		return nil, err
+	}
+
+	orgMemberships, deptMemberships, err := s.collectMemberships(&user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	organizationID := loginReq.OrganizationID
+	if organizationID == 0 {
+		organizationID, err = s.selectLoginOrganization(user, orgMemberships, deptMemberships)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.completeLogin(user, organizationID, loginReq.DepartmentID, loginReq.RoleID, orgMemberships, deptMemberships)
+}
+
+// generateMFAPendingToken issues a short-lived token proving the password was verified, remembering the
+// organization and department requested so the MFA step can complete the same login.
+func (s *AuthenticationService) generateMFAPendingToken(user *models.User, req *models.LoginRequest) (string, error) {
+	now := s.now()
+	expiresAt := now.Add(s.config.MFAChallengeTokenTTL)
+
+	claims := jwt.MapClaims{
+		"iss":     s.Issuer(),
+		"sub":     user.ID,
+		"aud":     []string{s.config.Config.ServiceName},
+		"exp":     expiresAt.Unix(),
+		"iat":     now.Unix(),
+		"nbf":     now.Unix(),
+		"jti":     uuid.NewString(),
+		"type":    mfaPendingTokenType,
+		"user_id": user.ID,
+	}
+	if req.OrganizationID != 0 {
+		claims["organization_id"] = req.OrganizationID
+	}
+	if req.DepartmentID != 0 {
+		claims["department_id"] = req.DepartmentID
+	}
+	if req.RoleID != 0 {
+		claims["role_id"] = req.RoleID
+	}
+
+	return s.signToken(claims)
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+const loginSelectionTokenType = "login_selection"
+
+var (
+	ErrOrganizationRequired          = errors.New("organization is required")
+	ErrOrganizationSelectionRequired = errors.New("organization selection required")
+)
+
+// OrganizationSelectionError is returned by Login when the user belongs to several organizations and
+// did not pick one. It carries the options and the selection token used to complete the login.
+type OrganizationSelectionError struct {
+	Selection *models.LoginSelectionResponse
+}
+
+func (e *OrganizationSelectionError) Error() string {
+	return ErrOrganizationSelectionRequired.Error()
+}
+
+func (e *OrganizationSelectionError) Unwrap() error {
+	return ErrOrganizationSelectionRequired
+}
+
+// selectLoginOrganization picks the organization for a login that did not specify one. A single membership
+// or a primary organization is used directly; otherwise the user is asked to choose.
+func (s *AuthenticationService) selectLoginOrganization(user *models.User, orgMemberships []*models.UserOrganization, deptMemberships []*models.UserDepartment) (uint64, error) {
+	if len(orgMemberships) == 1 {
+		return orgMemberships[0].OrganizationID, nil
+	}
+	if user.PrimaryOrganizationID != nil {
+		return *user.PrimaryOrganizationID, nil
+	}
+	if len(orgMemberships) == 0 || !s.config.LoginSelectionEnabled {
+		return 0, ErrOrganizationRequired
+	}
+
+	token, err := s.generateLoginSelectionToken(user)
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate selection token: %w", err)
+	}
+
+	info := s.composeUserInfo(user, orgMemberships, deptMemberships)
+	return 0, &OrganizationSelectionError{
+		Selection: &models.LoginSelectionResponse{
+			SelectionRequired: true,
+			SelectionToken:    token,
+			ExpiresIn:         int(s.config.LoginSelectionTokenTTL.Seconds()),
+			Organizations:     info.Organizations,
+			Departments:       info.Departments,
+		},
+	}
+}
+
+// completeLoginSelection finishes a login using a selection token and the organization the user chose.
+func (s *AuthenticationService) completeLoginSelection(req *models.LoginRequest) (response *models.LoginResponse, err error) {
+	claims, err := s.parseTypedToken(req.SelectionToken, loginSelectionTokenType)
+	if err != nil {
+		return nil, err
+	}
+	userID, ok := claimUserID(claims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	if req.OrganizationID == 0 {
+		return nil, ErrOrganizationRequired
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidToken
+	}
+	defer func() {
+		s.recordLoginAttempt(user, req, response, err)
+	}()
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		return nil, ErrAccountLocked
+	}
+	if !user.IsActive {
+		return nil, ErrAccountInactive
+	}
+
+	orgMemberships, deptMemberships, err := s.collectMemberships(&user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.completeLogin(user, req.OrganizationID, req.DepartmentID, req.RoleID, orgMemberships, deptMemberships)
+}
+
+// generateLoginSelectionToken issues a short-lived token proving the user's credentials were verified.
+func (s *AuthenticationService) generateLoginSelectionToken(user *models.User) (string, error) {
+	now := s.now()
+	expiresAt := now.Add(s.config.LoginSelectionTokenTTL)
+
+	claims := jwt.MapClaims{
+		"iss":     s.Issuer(),
+		"sub":     user.ID,
+		"aud":     []string{s.config.Config.ServiceName},
+		"exp":     expiresAt.Unix(),
+		"iat":     now.Unix(),
+		"nbf":     now.Unix(),
+		"jti":     uuid.NewString(),
+		"type":    loginSelectionTokenType,
+		"user_id": user.ID,
+	}
+
+	return s.signToken(claims)
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestLoginOrganizationSelection(t *testing.T) {
+	env := newTestEnv(t, nil)
+	first := env.createOrganization(t, "First", nil)
+	second := env.createOrganization(t, "Second", nil)
+	other := env.createOrganization(t, "Other", nil)
+
+	single := env.createUser(t, "single", nil)
+	env.addMember(t, single, second, "CEO")
+	primary := env.createUser(t, "primary", func(u *models.User) { u.PrimaryOrganizationID = &second.ID })
+	env.addMember(t, primary, first, "CEO")
+	env.addMember(t, primary, second, "CEO")
+	multi := env.createUser(t, "multi", nil)
+	env.addMember(t, multi, first, "CEO")
+	env.addMember(t, multi, second, "CEO")
+
+	login := func(user *models.User) (*models.LoginResponse, error) {
+		return env.auth.Login(&models.LoginRequest{Username: user.Username, Password: testPassword})
+	}
+
+	for _, tt := range []struct {
+		name string
+		user *models.User
+	}{
+		{name: "single membership", user: single},
+		{name: "primary organization", user: primary},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := login(tt.user)
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+			if resp.LoggedOrganization == nil || resp.LoggedOrganization.ID != second.ID {
+				t.Fatalf("logged organization = %+v, want %d", resp.LoggedOrganization, second.ID)
+			}
+		})
+	}
+
+	// selectionToken logs multi in without an organization and returns the selection token offered.
+	selectionToken := func(t *testing.T) string {
+		t.Helper()
+		_, err := login(multi)
+		var selection *OrganizationSelectionError
+		if !errors.As(err, &selection) {
+			t.Fatalf("Login() error = %v, want an organization selection", err)
+		}
+		if !selection.Selection.SelectionRequired || selection.Selection.SelectionToken == "" || len(selection.Selection.Organizations) != 2 {
+			t.Fatalf("selection = %+v, want a token and both organizations", selection.Selection)
+		}
+		return selection.Selection.SelectionToken
+	}
+
+	t.Run("multiple memberships", func(t *testing.T) {
+		resp, err := env.auth.Login(&models.LoginRequest{SelectionToken: selectionToken(t), OrganizationID: first.ID})
+		if err != nil {
+			t.Fatalf("Login(selection) error = %v", err)
+		}
+		if resp.LoggedOrganization == nil || resp.LoggedOrganization.ID != first.ID {
+			t.Fatalf("logged organization = %+v, want %d", resp.LoggedOrganization, first.ID)
+		}
+	})
+
+	t.Run("expired selection token", func(t *testing.T) {
+		token := selectionToken(t)
+		env.auth.WithClock(func() time.Time { return time.Now().Add(env.cfg.LoginSelectionTokenTTL + time.Minute) })
+		defer env.auth.WithClock(time.Now)
+
+		if _, err := env.auth.Login(&models.LoginRequest{SelectionToken: token, OrganizationID: first.ID}); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("Login(selection) error = %v, want %v", err, ErrInvalidToken)
+		}
+	})
+
+	t.Run("organization the user is not a member of", func(t *testing.T) {
+		resp, err := env.auth.Login(&models.LoginRequest{SelectionToken: selectionToken(t), OrganizationID: other.ID})
+		if err == nil {
+			t.Fatalf("Login(selection) = %+v, want an error", resp)
+		}
+	})
+}
+
+func TestLoginSelectionDisabled(t *testing.T) {
+	env := newTestEnv(t, func(cfg *config.AuthConfig) { cfg.LoginSelectionEnabled = false })
+	user := env.createUser(t, "multi", nil)
+	env.addMember(t, user, env.createOrganization(t, "First", nil), "CEO")
+	env.addMember(t, user, env.createOrganization(t, "Second", nil), "CEO")
+
+	_, err := env.auth.Login(&models.LoginRequest{Username: user.Username, Password: testPassword})
+	if !errors.Is(err, ErrOrganizationRequired) {
+		t.Fatalf("Login() error = %v, want %v", err, ErrOrganizationRequired)
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// Logout blacklists the caller's access token, and the refresh token when one is supplied, until they
+// expire. Both must be valid tokens of userID; revoking a token that is already blacklisted is not an error.
+func (s *AuthenticationService) Logout(userID uint64, accessToken, refreshToken string) error {
+	now := s.now()
+	claims, err := s.parseTypedToken(accessToken, "access")
+	if err != nil {
+		return ErrInvalidToken
+	}
+	revoked := []jwt.MapClaims{claims}
+
+	if refreshToken = strings.TrimSpace(refreshToken); refreshToken != "" {
+		refreshClaims, err := s.parseTypedToken(refreshToken, "refresh")
+		if err != nil {
+			return ErrInvalidToken
+		}
+		revoked = append(revoked, refreshClaims)
+	}
+
+	jtis := make([]string, 0, len(revoked))
+	for _, tokenClaims := range revoked {
+		entry, err := revokedTokenEntry(tokenClaims, userID, now)
+		if err != nil {
+			return err
+		}
+		if _, err := s.userRepo.RevokeTokenJTI(entry); err != nil {
+			return fmt.Errorf("revoke token: %w", err)
+		}
+		jtis = append(jtis, entry.JTI)
+	}
+
+	s.recordAudit(&models.AuditEvent{
+		Actor:     models.AuditUserRef(userID),
+		Action:    models.AuditActionLogout,
+		Target:    models.AuditUserRef(userID),
+		Success:   true,
+		Timestamp: now,
+		Metadata: map[string]any{
+			"jtis": jtis,
+		},
+	})
+	return nil
+}
+
+// revokedTokenEntry builds the blacklist entry for a verified token owned by userID.
+func revokedTokenEntry(claims jwt.MapClaims, userID uint64, now time.Time) (*models.RevokedToken, error) {
+	jti, _ := claims["jti"].(string)
+	owner, ok := claimUserID(claims)
+	expiresAt, err := claims.GetExpirationTime()
+	if jti == "" || !ok || owner != userID || err != nil || expiresAt == nil {
+		return nil, ErrInvalidToken
+	}
+	return &models.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt.Time,
+		RevokedAt: now,
+		RevokedBy: userID,
+	}, nil
+}
+
+// StartRevokedTokenCleanup periodically purges blacklist entries and issued token records for tokens that
+// have expired, along with abandoned OAuth sign-ins. The returned function stops the cleanup.
+func (s *AuthenticationService) StartRevokedTokenCleanup() func() {
+	interval := s.config.RevokedTokenCleanupInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if count, err := s.userRepo.PurgeExpiredRevokedTokens(s.now()); err != nil {
+				log.Printf("revoked token cleanup failed: %v", err)
+			} else if count > 0 {
+				log.Printf("revoked token cleanup removed %d expired blacklist entries", count)
+			}
+			if count, err := s.userRepo.PurgeExpiredIssuedTokens(s.now()); err != nil {
+				log.Printf("issued token cleanup failed: %v", err)
+			} else if count > 0 {
+				log.Printf("issued token cleanup removed %d expired token records", count)
+			}
+			if count, err := s.userRepo.PurgeExpiredOAuthAuthorizations(s.now()); err != nil {
+				log.Printf("oauth state cleanup failed: %v", err)
+			} else if count > 0 {
+				log.Printf("oauth state cleanup removed %d abandoned sign-ins", count)
+			}
+
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// MailSender delivers both verification and password reset tokens.
+type MailSender interface {
+	VerificationSender
+	PasswordResetSender
+}
+
+// NewMailSender returns the sender selected by MAIL_DELIVERY, or nil when delivery is disabled.
+func NewMailSender(cfg *config.AuthConfig) MailSender {
+	switch cfg.MailDelivery {
+	case config.MailDeliverySMTP:
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+	case config.MailDeliveryLog:
+		return LogMailer{}
+	default:
+		return nil
+	}
+}
+
+// LogMailer writes tokens to the service log instead of sending them. It is meant for development,
+// where no mail server is available.
+type LogMailer struct{}
+
+// SendVerification logs the verification token.
+func (LogMailer) SendVerification(user *models.User, token string) error {
+	log.Printf("verification token for user %d <%s>: %s", user.ID, user.Email, token)
+	return nil
+}
+
+// SendPasswordReset logs the password reset token.
+func (LogMailer) SendPasswordReset(user *models.User, token string) error {
+	log.Printf("password reset token for user %d <%s>: %s", user.ID, user.Email, token)
+	return nil
+}
+
+// SMTPMailer emails tokens through an SMTP relay. PLAIN authentication is used when a username is set.
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+	send func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPMailer returns a mailer for the relay at host:port sending as from.
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	mailer := &SMTPMailer{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: from,
+		send: smtp.SendMail,
+	}
+	if username != "" {
+		mailer.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return mailer
+}
+
+// SendVerification emails the verification token to the user.
+func (m *SMTPMailer) SendVerification(user *models.User, token string) error {
+	return m.deliver(user.Email, "Verify your email address",
+		"Use this token to verify your email address:\r\n\r\n"+token+"\r\n")
+}
+
+// SendPasswordReset emails the password reset token to the user.
+func (m *SMTPMailer) SendPasswordReset(user *models.User, token string) error {
+	return m.deliver(user.Email, "Reset your password",
+		"Use this token to reset your password. If you did not ask for a reset, ignore this email.\r\n\r\n"+token+"\r\n")
+}
+
+func (m *SMTPMailer) deliver(to, subject, body string) error {
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("invalid recipient address %q", to)
+	}
+
+	var msg strings.Builder
+	msg.WriteString("From: " + m.from + "\r\n")
+	msg.WriteString("To: " + to + "\r\n")
+	msg.WriteString("Subject: " + subject + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(body)
+
+	if err := m.send(m.addr, m.auth, m.from, []string{to}, []byte(msg.String())); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+	return nil
+}
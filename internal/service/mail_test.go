@@ -0,0 +1,83 @@
+package service
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestNewMailSender(t *testing.T) {
+	tests := []struct {
+		delivery string
+		want     string
+	}{
+		{delivery: config.MailDeliverySMTP, want: "*service.SMTPMailer"},
+		{delivery: config.MailDeliveryLog, want: "service.LogMailer"},
+		{delivery: config.MailDeliveryNone, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.delivery, func(t *testing.T) {
+			cfg := &config.AuthConfig{MailDelivery: tt.delivery, SMTPHost: "mail.example.com", SMTPPort: 587, SMTPFrom: "auth@example.com"}
+			sender := NewMailSender(cfg)
+			got := ""
+			if sender != nil {
+				got = fmt.Sprintf("%T", sender)
+			}
+			if got != tt.want {
+				t.Fatalf("NewMailSender(%q) = %s, want %s", tt.delivery, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	user := &models.User{ID: 7, Email: "ada@example.com"}
+
+	tests := []struct {
+		name    string
+		send    func(m MailSender) error
+		subject string
+	}{
+		{
+			name:    "verification",
+			send:    func(m MailSender) error { return m.SendVerification(user, "verify-token") },
+			subject: "Subject: Verify your email address",
+		},
+		{
+			name:    "password reset",
+			send:    func(m MailSender) error { return m.SendPasswordReset(user, "reset-token") },
+			subject: "Subject: Reset your password",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mailer := NewSMTPMailer("mail.example.com", 2525, "", "", "auth@example.com")
+			var gotAddr string
+			var gotTo []string
+			var gotMsg string
+			mailer.send = func(addr string, _ smtp.Auth, _ string, to []string, msg []byte) error {
+				gotAddr, gotTo, gotMsg = addr, to, string(msg)
+				return nil
+			}
+
+			if err := tt.send(mailer); err != nil {
+				t.Fatalf("send: %v", err)
+			}
+			if gotAddr != "mail.example.com:2525" {
+				t.Errorf("addr = %q", gotAddr)
+			}
+			if len(gotTo) != 1 || gotTo[0] != user.Email {
+				t.Errorf("to = %v", gotTo)
+			}
+			if !strings.Contains(gotMsg, tt.subject) || !strings.Contains(gotMsg, "-token") {
+				t.Errorf("message missing subject or token:\n%s", gotMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"log"
+	"time"
+)
+
+// expireAccessTokens makes the users' current access tokens stale after a membership or role change, so
+// clients refresh them and receive claims built from the updated memberships. Refresh tokens stay valid.
+// The change itself is already committed, so a failure only leaves the old claims in place until expiry.
+func (s *OrganizationService) expireAccessTokens(userIDs ...uint64) {
+	if len(userIDs) == 0 {
+		return
+	}
+	if err := s.userRepo.ExpireAccessTokens(userIDs, time.Now()); err != nil {
+		log.Printf("failed to expire access tokens after membership change for users %v: %v", userIDs, err)
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// ErrAuditUnavailable is returned when the configured audit store cannot be queried.
+var ErrAuditUnavailable = errors.New("audit log is not available")
+
+// AuditReader queries recorded audit events.
+type AuditReader interface {
+	List(filter models.AuditEventFilter) ([]*models.AuditEvent, int64, error)
+}
+
+// ListMembershipHistory returns the user's organization and department membership changes in
+// chronological order, optionally bounded by a time range.
+func (s *AuthenticationService) ListMembershipHistory(userID uint64, from, to *time.Time, offset, limit int) ([]*models.AuditEvent, int64, error) {
+	reader, ok := s.audit.(AuditReader)
+	if !ok {
+		return nil, 0, ErrAuditUnavailable
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if user == nil {
+		return nil, 0, ErrUserNotFound
+	}
+
+	return reader.List(models.AuditEventFilter{
+		Target:  models.AuditUserRef(userID),
+		Actions: models.MembershipAuditActions,
+		From:    from,
+		To:      to,
+		Offset:  offset,
+		Limit:   limit,
+	})
+}
+
+// ListAuditEvents returns recorded audit events, newest first, optionally narrowed to an actor, an
+// action and a time range.
+func (s *AuthenticationService) ListAuditEvents(actor, action string, from, to *time.Time, offset, limit int) ([]*models.AuditEvent, int64, error) {
+	reader, ok := s.audit.(AuditReader)
+	if !ok {
+		return nil, 0, ErrAuditUnavailable
+	}
+
+	filter := models.AuditEventFilter{
+		Actor:      actor,
+		From:       from,
+		To:         to,
+		Offset:     offset,
+		Limit:      limit,
+		Descending: true,
+	}
+	if action != "" {
+		filter.Actions = []string{action}
+	}
+	return reader.List(filter)
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// mfaSecretPrefix marks MFA secrets encrypted at rest; values without it are legacy plaintext secrets.
+const mfaSecretPrefix = "enc:v1:"
+
+// totpSecretBytes is the size of generated TOTP secrets (160 bits, as recommended by RFC 4226).
+const totpSecretBytes = 20
+
+// recoveryCodeCount is the number of one-time recovery codes issued at enrollment.
+const recoveryCodeCount = 10
+
+// totpQRCodeSize is the width and height in pixels of the enrollment QR code.
+const totpQRCodeSize = 256
+
+var (
+	ErrMFADisabled       = errors.New("mfa is disabled")
+	ErrMFAAlreadyEnabled = errors.New("mfa is already enabled for this account")
+	ErrMFANotEnrolled    = errors.New("mfa enrollment has not been started")
+	ErrMFAKeyMissing     = errors.New("MFA_ENCRYPTION_KEY is not configured")
+)
+
+// EnrollMFA generates a new TOTP secret for the user and stores it encrypted, together with bcrypt hashes
+// of freshly generated recovery codes. MFA stays disabled until the user proves possession of the secret
+// through VerifyMFA; enrolling again replaces a pending secret and its recovery codes.
+func (s *AuthenticationService) EnrollMFA(userID uint64) (*models.MFAEnrollment, error) {
+	if !s.config.MFAEnabled {
+		return nil, ErrMFADisabled
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidToken
+	}
+	if !user.IsActive {
+		return nil, ErrAccountInactive
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	raw := make([]byte, totpSecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("generate totp secret: %w", err)
+	}
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)
+
+	encrypted, err := s.encryptMFASecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	codes, hashes, err := s.generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	user.MFASecret = &encrypted
+	user.MFARecoveryCodes = hashes
+	user.MFAEnabled = false
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("store mfa secret: %w", err)
+	}
+
+	otpURL := totpURL(s.config.TOTPIssuer, user.Email, secret)
+	png, err := qrcode.Encode(otpURL, qrcode.Medium, totpQRCodeSize)
+	if err != nil {
+		return nil, fmt.Errorf("render totp qr code: %w", err)
+	}
+
+	return &models.MFAEnrollment{
+		Secret:        secret,
+		OTPAuthURL:    otpURL,
+		QRCode:        "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+		RecoveryCodes: codes,
+	}, nil
+}
+
+// generateRecoveryCodes returns recoveryCodeCount one-time codes such as "3f9c2-a41be" together with the
+// bcrypt hashes that are stored in their place.
+func (s *AuthenticationService) generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, fmt.Errorf("generate recovery code: %w", err)
+		}
+		encoded := hex.EncodeToString(raw)
+		code := encoded[:5] + "-" + encoded[5:]
+		hashed, err := bcrypt.GenerateFromPassword([]byte(code), s.config.BCryptCost)
+		if err != nil {
+			return nil, nil, fmt.Errorf("hash recovery code: %w", err)
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, string(hashed))
+	}
+	return codes, hashes, nil
+}
+
+// VerifyMFA completes enrollment by checking a code generated from the pending secret.
+func (s *AuthenticationService) VerifyMFA(userID uint64, code string) error {
+	if !s.config.MFAEnabled {
+		return ErrMFADisabled
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrInvalidToken
+	}
+	if !user.IsActive {
+		return ErrAccountInactive
+	}
+	if user.LockedUntil != nil && user.LockedUntil.After(s.now()) {
+		return ErrAccountLocked
+	}
+	if user.MFAEnabled {
+		return ErrMFAAlreadyEnabled
+	}
+	if user.MFASecret == nil {
+		return ErrMFANotEnrolled
+	}
+
+	secret, err := s.decryptMFASecret(*user.MFASecret)
+	if err != nil {
+		return err
+	}
+	if !validateTOTP(secret, code, s.now()) {
+		s.recordMFAFailure(user)
+		return ErrInvalidMFACode
+	}
+
+	user.MFAEnabled = true
+	user.MFAFailedAttempts = 0
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("enable mfa: %w", err)
+	}
+	return nil
+}
+
+// totpURL builds the otpauth:// key URI understood by authenticator apps.
+func totpURL(issuer, account, secret string) string {
+	label := account
+	if issuer != "" {
+		label = issuer + ":" + account
+	}
+	query := url.Values{}
+	query.Set("secret", secret)
+	if issuer != "" {
+		query.Set("issuer", issuer)
+	}
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+	return "otpauth://totp/" + url.PathEscape(label) + "?" + query.Encode()
+}
+
+// mfaCipher returns the AES-GCM cipher protecting MFA secrets, keyed with a digest of MFAEncryptionKey.
+func (s *AuthenticationService) mfaCipher() (cipher.AEAD, error) {
+	if s.config.MFAEncryptionKey == "" {
+		return nil, ErrMFAKeyMissing
+	}
+	return mfaCipherFor(s.config.MFAEncryptionKey)
+}
+
+// legacyMFACipher returns the cipher of secrets enrolled before MFA_ENCRYPTION_KEY was required, when they
+// were keyed with the JWT secret, or nil when no JWT secret is configured.
+func (s *AuthenticationService) legacyMFACipher() (cipher.AEAD, error) {
+	if s.config.Config.JWTSecret == "" || s.config.Config.JWTSecret == s.config.MFAEncryptionKey {
+		return nil, nil
+	}
+	return mfaCipherFor(s.config.Config.JWTSecret)
+}
+
+func mfaCipherFor(material string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(material))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *AuthenticationService) encryptMFASecret(secret string) (string, error) {
+	aead, err := s.mfaCipher()
+	if err != nil {
+		return "", fmt.Errorf("encrypt mfa secret: %w", err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("encrypt mfa secret: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(secret), nil)
+	return mfaSecretPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptMFASecret returns the base32 TOTP secret. Secrets stored before encryption was introduced
+// are returned unchanged, and secrets keyed with the JWT secret are still read; see upgradeMFASecret.
+func (s *AuthenticationService) decryptMFASecret(stored string) (string, error) {
+	secret, _, err := s.openMFASecret(stored)
+	return secret, err
+}
+
+// openMFASecret decrypts a stored secret and reports whether it was stored plaintext or with the legacy key.
+func (s *AuthenticationService) openMFASecret(stored string) (string, bool, error) {
+	if !strings.HasPrefix(stored, mfaSecretPrefix) {
+		return stored, true, nil
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(stored, mfaSecretPrefix))
+	if err != nil {
+		return "", false, fmt.Errorf("decrypt mfa secret: %w", err)
+	}
+	aead, err := s.mfaCipher()
+	if err != nil {
+		return "", false, fmt.Errorf("decrypt mfa secret: %w", err)
+	}
+	plain, err := openSealed(aead, sealed)
+	if err == nil {
+		return plain, false, nil
+	}
+	legacy, legacyErr := s.legacyMFACipher()
+	if legacyErr != nil || legacy == nil {
+		return "", false, fmt.Errorf("decrypt mfa secret: %w", err)
+	}
+	plain, err = openSealed(legacy, sealed)
+	if err != nil {
+		return "", false, fmt.Errorf("decrypt mfa secret: %w", err)
+	}
+	return plain, true, nil
+}
+
+func openSealed(aead cipher.AEAD, sealed []byte) (string, error) {
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// upgradeMFASecret re-encrypts a secret stored plaintext or with the legacy key under MFA_ENCRYPTION_KEY.
+// Failures are logged; the secret stays readable the old way.
+func (s *AuthenticationService) upgradeMFASecret(user *models.User) {
+	if user.MFASecret == nil {
+		return
+	}
+	secret, outdated, err := s.openMFASecret(*user.MFASecret)
+	if err != nil || !outdated {
+		return
+	}
+	encrypted, err := s.encryptMFASecret(secret)
+	if err != nil {
+		log.Printf("failed to re-encrypt mfa secret of user %d: %v", user.ID, err)
+		return
+	}
+	user.MFASecret = &encrypted
+	if err := s.userRepo.Update(user); err != nil {
+		log.Printf("failed to store re-encrypted mfa secret of user %d: %v", user.ID, err)
+	}
+}
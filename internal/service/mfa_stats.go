@@ -0,0 +1,42 @@
+package service
+
+import (
+	"math"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// MFAAdoptionStats reports how many active users have MFA enabled, overall and broken down by
+// organization. Deactivated accounts are left out. Users without an organization only appear in the
+// overall figures.
+func (s *AuthenticationService) MFAAdoptionStats() (*models.MFAAdoptionStats, error) {
+	total, enabled, err := s.userRepo.CountMFAAdoption()
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.userRepo.CountMFAAdoptionByOrganization()
+	if err != nil {
+		return nil, err
+	}
+	if counts == nil {
+		counts = make([]*models.MFAAdoptionCount, 0)
+	}
+	for _, count := range counts {
+		count.Percentage = adoptionPercentage(count.MFAEnabled, count.TotalUsers)
+	}
+
+	return &models.MFAAdoptionStats{
+		TotalUsers:    total,
+		MFAEnabled:    enabled,
+		Percentage:    adoptionPercentage(enabled, total),
+		Organizations: counts,
+	}, nil
+}
+
+// adoptionPercentage returns part as a percentage of total, rounded to two decimals.
+func adoptionPercentage(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*10000/float64(total)) / 100
+}
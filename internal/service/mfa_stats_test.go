@@ -0,0 +1,72 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestMFAAdoptionStats(t *testing.T) {
+	env := newTestEnv(t, nil)
+	acme := env.createOrganization(t, "Acme", nil)
+	globex := env.createOrganization(t, "Globex", nil)
+
+	// Acme: two enrolled and one unenrolled member, plus an enrolled and an unenrolled inactive member.
+	// Globex: one unenrolled member, and one of Acme's enrolled members. One enrolled user has no
+	// organization.
+	seed := []struct {
+		name     string
+		enrolled bool
+		inactive bool
+		orgs     []*models.Organization
+	}{
+		{name: "acme-mfa-1", enrolled: true, orgs: []*models.Organization{acme, globex}},
+		{name: "acme-mfa-2", enrolled: true, orgs: []*models.Organization{acme}},
+		{name: "acme-plain", orgs: []*models.Organization{acme}},
+		{name: "acme-inactive-mfa", enrolled: true, inactive: true, orgs: []*models.Organization{acme}},
+		{name: "acme-inactive", inactive: true, orgs: []*models.Organization{acme}},
+		{name: "globex-plain", orgs: []*models.Organization{globex}},
+		{name: "loner-mfa", enrolled: true},
+	}
+	for _, s := range seed {
+		user := env.createUser(t, s.name, nil)
+		for _, org := range s.orgs {
+			env.addMember(t, user, org, "CEO")
+		}
+		if s.enrolled {
+			env.enableMFA(t, user)
+		}
+		if s.inactive {
+			if err := env.db.Model(user).Update("is_active", false).Error; err != nil {
+				t.Fatalf("deactivate %s: %v", s.name, err)
+			}
+		}
+	}
+
+	stats, err := env.auth.MFAAdoptionStats()
+	if err != nil {
+		t.Fatalf("MFAAdoptionStats() error = %v", err)
+	}
+	if stats.TotalUsers != 5 || stats.MFAEnabled != 3 || stats.Percentage != 60 {
+		t.Errorf("overall = %d of %d (%v%%), want 3 of 5 (60%%)", stats.MFAEnabled, stats.TotalUsers, stats.Percentage)
+	}
+
+	want := map[uint64]models.MFAAdoptionCount{
+		acme.ID:   {OrganizationName: "Acme", TotalUsers: 3, MFAEnabled: 2, Percentage: 66.67},
+		globex.ID: {OrganizationName: "Globex", TotalUsers: 2, MFAEnabled: 1, Percentage: 50},
+	}
+	if len(stats.Organizations) != len(want) {
+		t.Fatalf("organizations = %d, want %d", len(stats.Organizations), len(want))
+	}
+	for _, got := range stats.Organizations {
+		expected, ok := want[got.OrganizationID]
+		if !ok {
+			t.Errorf("unexpected organization %d", got.OrganizationID)
+			continue
+		}
+		expected.OrganizationID = got.OrganizationID
+		if *got != expected {
+			t.Errorf("organization %d = %+v, want %+v", got.OrganizationID, *got, expected)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package service
+
+import "github.com/lee-tech/authentication/internal/models"
+
+// ListMyDepartments returns the caller's department memberships, optionally limited to one
+// organization. With DepartmentListingRequiresOrgMembership, departments of organizations the user no
+// longer belongs to are left out, and filtering by such an organization returns ErrNotOrganizationMember.
+func (s *AuthenticationService) ListMyDepartments(userID uint64, orgID *uint64) ([]*models.UserDepartment, error) {
+	memberships, err := s.orgRepo.ListUserDepartments(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var memberOf map[uint64]struct{}
+	if s.config.DepartmentListingRequiresOrgMembership {
+		orgMemberships, err := s.orgRepo.ListUserOrganizations(userID)
+		if err != nil {
+			return nil, err
+		}
+		memberOf = make(map[uint64]struct{}, len(orgMemberships))
+		for _, membership := range orgMemberships {
+			memberOf[membership.OrganizationID] = struct{}{}
+		}
+		if orgID != nil {
+			if _, ok := memberOf[*orgID]; !ok {
+				return nil, ErrNotOrganizationMember
+			}
+		}
+	}
+
+	result := make([]*models.UserDepartment, 0, len(memberships))
+	for _, membership := range memberships {
+		if membership.Department == nil {
+			continue
+		}
+		deptOrgID := membership.Department.OrganizationID
+		if orgID != nil && deptOrgID != *orgID {
+			continue
+		}
+		if memberOf != nil {
+			if _, ok := memberOf[deptOrgID]; !ok {
+				continue
+			}
+		}
+		result = append(result, membership)
+	}
+	return result, nil
+}
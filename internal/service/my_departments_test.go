@@ -0,0 +1,78 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestListMyDepartments(t *testing.T) {
+	// Ada belongs to Sales in Home and to Ops in Away, an organization Ada no longer belongs to. Grace belongs
+	// to Support in Home.
+	tests := []struct {
+		name              string
+		requireMembership bool
+		org               string
+		want              []string
+		wantErr           error
+	}{
+		{name: "all departments", requireMembership: true, want: []string{"Sales"}},
+		{name: "filtered by organization", requireMembership: true, org: "home", want: []string{"Sales"}},
+		{name: "organization the caller left", requireMembership: true, org: "away", wantErr: ErrNotOrganizationMember},
+		{name: "without the membership requirement", want: []string{"Sales", "Ops"}},
+		{name: "filtered without the membership requirement", org: "away", want: []string{"Ops"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, func(cfg *config.AuthConfig) { cfg.DepartmentListingRequiresOrgMembership = tt.requireMembership })
+			home := env.createOrganization(t, "Home", nil)
+			away := env.createOrganization(t, "Away", nil)
+			sales := env.departmentChain(t, home, "Sales")[0]
+			support := env.departmentChain(t, home, "Support")[0]
+			ops := env.departmentChain(t, away, "Ops")[0]
+
+			ada := env.createUser(t, "ada", nil)
+			env.addMember(t, ada, home, "CEO")
+			grace := env.createUser(t, "grace", nil)
+			env.addMember(t, grace, home, "CEO")
+			for _, membership := range []*models.UserDepartment{
+				{UserID: ada.ID, DepartmentID: sales.ID, Role: "MEMBER"},
+				{UserID: ada.ID, DepartmentID: ops.ID, Role: "MEMBER"},
+				{UserID: grace.ID, DepartmentID: support.ID, Role: "LEAD"},
+			} {
+				if err := env.db.Create(membership).Error; err != nil {
+					t.Fatalf("add department member: %v", err)
+				}
+			}
+
+			var orgID *uint64
+			switch tt.org {
+			case "home":
+				orgID = &home.ID
+			case "away":
+				orgID = &away.ID
+			}
+			departments, err := env.auth.ListMyDepartments(ada.ID, orgID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ListMyDepartments() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var got []string
+			for _, membership := range departments {
+				if membership.UserID != ada.ID {
+					t.Errorf("membership of user %d returned to user %d", membership.UserID, ada.ID)
+				}
+				got = append(got, membership.Department.Name)
+			}
+			slices.Sort(got)
+			want := slices.Clone(tt.want)
+			slices.Sort(want)
+			if !slices.Equal(got, want) {
+				t.Errorf("departments = %v, want %v", got, want)
+			}
+		})
+	}
+}
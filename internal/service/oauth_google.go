@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lee-tech/authentication/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const googleRequestTimeout = 10 * time.Second
+
+// Google OAuth 2.0 endpoints used by the login flow. Tests point them at a fake server.
+var (
+	googleAuthURL     = "https://accounts.google.com/o/oauth2/v2/auth"
+	googleTokenURL    = "https://oauth2.googleapis.com/token"
+	googleUserInfoURL = "https://openidconnect.googleapis.com/v1/userinfo"
+)
+
+var (
+	ErrOAuthDisabled        = errors.New("google oauth login is not enabled")
+	ErrOAuthDenied          = errors.New("google sign-in was not completed")
+	ErrOAuthExchangeFailed  = errors.New("google oauth code exchange failed")
+	ErrOAuthEmailUnverified = errors.New("google account email is not verified")
+	ErrOAuthNoOrganization  = errors.New("no organization available for oauth user")
+)
+
+// googleProfile is the subset of the OpenID Connect userinfo response the login flow uses.
+type googleProfile struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+}
+
+// GoogleOAuthEnabled reports whether the Google login flow is configured.
+func (s *AuthenticationService) GoogleOAuthEnabled() bool {
+	return s.config.OAuthEnabled && s.config.GoogleClientID != "" && s.config.GoogleClientSecret != "" && s.config.GoogleRedirectURL != ""
+}
+
+// OAuthCallbackResult tells the callback where to send the browser and, after a successful sign-in,
+// the login code the client redeems for tokens.
+type OAuthCallbackResult struct {
+	ReturnURL string
+	LoginCode string
+}
+
+// StartGoogleOAuth stores the authorization request server-side and returns Google's consent screen URL
+// carrying its state and a PKCE challenge.
+func (s *AuthenticationService) StartGoogleOAuth(input OAuthStateInput) (string, error) {
+	if !s.GoogleOAuthEnabled() {
+		return "", ErrOAuthDisabled
+	}
+	state, challenge, err := s.issueOAuthState(input)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("client_id", s.config.GoogleClientID)
+	query.Set("redirect_uri", s.config.GoogleRedirectURL)
+	query.Set("response_type", "code")
+	query.Set("scope", "openid email profile")
+	query.Set("state", state)
+	query.Set("code_challenge", challenge)
+	query.Set("code_challenge_method", PKCEMethodS256)
+	query.Set("prompt", "select_account")
+	return googleAuthURL + "?" + query.Encode(), nil
+}
+
+// CompleteGoogleOAuth handles Google's callback. It consumes the state, exchanges the authorization code
+// with the stored PKCE verifier and finds or creates the user owning the verified Google email. An empty
+// code means the user did not grant access. Once the state is accepted the result carries the return URL
+// even when the sign-in fails, so the browser can be sent back with an error.
+func (s *AuthenticationService) CompleteGoogleOAuth(ctx context.Context, state, code string) (*OAuthCallbackResult, error) {
+	if !s.GoogleOAuthEnabled() {
+		return nil, ErrOAuthDisabled
+	}
+	authorization, err := s.takeOAuthState(state)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &OAuthCallbackResult{ReturnURL: authorization.ReturnURL}
+	if strings.TrimSpace(code) == "" {
+		return result, ErrOAuthDenied
+	}
+	accessToken, err := s.exchangeGoogleCode(ctx, code, authorization.ProviderVerifier)
+	if err != nil {
+		return result, err
+	}
+	profile, err := s.fetchGoogleProfile(ctx, accessToken)
+	if err != nil {
+		return result, err
+	}
+	if !profile.EmailVerified || strings.TrimSpace(profile.Email) == "" {
+		return result, ErrOAuthEmailUnverified
+	}
+
+	user, err := s.findOrCreateOAuthUser(profile)
+	if err != nil {
+		return result, err
+	}
+	result.LoginCode, err = s.issueOAuthLoginCode(authorization, user.ID)
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// RedeemGoogleLoginCode exchanges the login code from the callback, together with the client's PKCE
+// verifier, for a login like a password login, including the MFA gate and organization selection. Users
+// without any membership are linked to the bootstrap organization.
+func (s *AuthenticationService) RedeemGoogleLoginCode(code, codeVerifier string, req *models.LoginRequest) (response *models.LoginResponse, err error) {
+	if !s.GoogleOAuthEnabled() {
+		return nil, ErrOAuthDisabled
+	}
+	if req == nil {
+		req = &models.LoginRequest{}
+	}
+
+	authorization, err := s.takeOAuthLoginCode(code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(*authorization.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidOAuthLoginCode
+	}
+	defer func() {
+		s.recordLoginAttempt(user, req, response, err)
+	}()
+
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		return nil, ErrAccountLocked
+	}
+	if !user.IsActive {
+		return nil, ErrAccountInactive
+	}
+	if err := s.requireLoginMFA(user, req); err != nil {
+		return nil, err
+	}
+
+	orgMemberships, deptMemberships, err := s.collectMemberships(&user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(orgMemberships) == 0 {
+		if err := s.linkBootstrapOrganization(user); err != nil {
+			return nil, err
+		}
+		if orgMemberships, deptMemberships, err = s.collectMemberships(&user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	organizationID, err := s.selectLoginOrganization(user, orgMemberships, deptMemberships)
+	if err != nil {
+		return nil, err
+	}
+	return s.completeLogin(user, organizationID, 0, 0, orgMemberships, deptMemberships)
+}
+
+// exchangeGoogleCode trades an authorization code and its PKCE verifier for a Google access token.
+func (s *AuthenticationService) exchangeGoogleCode(ctx context.Context, code, codeVerifier string) (string, error) {
+	form := url.Values{}
+	form.Set("code", code)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", s.config.GoogleClientID)
+	form.Set("client_secret", s.config.GoogleClientSecret)
+	form.Set("redirect_uri", s.config.GoogleRedirectURL)
+	form.Set("grant_type", "authorization_code")
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, googleTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := s.googleRequest(request, &token); err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", ErrOAuthExchangeFailed
+	}
+	return token.AccessToken, nil
+}
+
+// fetchGoogleProfile reads the signed-in Google account's profile.
+func (s *AuthenticationService) fetchGoogleProfile(ctx context.Context, accessToken string) (*googleProfile, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, googleUserInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+	request.Header.Set("Accept", "application/json")
+
+	var profile googleProfile
+	if err := s.googleRequest(request, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// googleRequest sends a request to Google and decodes a successful JSON response into out.
+func (s *AuthenticationService) googleRequest(request *http.Request, out any) error {
+	client := &http.Client{Timeout: googleRequestTimeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrOAuthExchangeFailed, err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrOAuthExchangeFailed, err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s returned %d", ErrOAuthExchangeFailed, request.URL.Host, response.StatusCode)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%w: %v", ErrOAuthExchangeFailed, err)
+	}
+	return nil
+}
+
+// findOrCreateOAuthUser returns the account owning the Google email, creating a verified account with
+// an unusable random password when there is none.
+func (s *AuthenticationService) findOrCreateOAuthUser(profile *googleProfile) (*models.User, error) {
+	email := s.normalizeEmail(profile.Email)
+	user, err := s.findUserByEmail(email)
+	if err != nil || user != nil {
+		return user, err
+	}
+
+	username, err := s.oauthUsername(email)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := generateVerificationToken()
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(secret), s.config.BCryptCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user = &models.User{
+		Email:      email,
+		Username:   username,
+		Password:   string(hashedPassword),
+		FirstName:  strings.TrimSpace(profile.GivenName),
+		LastName:   strings.TrimSpace(profile.FamilyName),
+		IsActive:   true,
+		IsVerified: true,
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, fmt.Errorf("create oauth user: %w", err)
+	}
+	return user, nil
+}
+
+// oauthUsername picks a free username for a new OAuth account, starting from the email.
+func (s *AuthenticationService) oauthUsername(email string) (string, error) {
+	candidate := email
+	for attempt := 0; attempt < 5; attempt++ {
+		taken, err := s.userRepo.ExistsByUsername(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+		suffix, err := generateVerificationToken()
+		if err != nil {
+			return "", err
+		}
+		candidate = email + "-" + suffix[:6]
+	}
+	return "", fmt.Errorf("could not find a free username for %s", email)
+}
+
+// linkBootstrapOrganization makes the bootstrap organization the primary organization of a user that
+// belongs to none.
+func (s *AuthenticationService) linkBootstrapOrganization(user *models.User) error {
+	if strings.TrimSpace(s.config.BootstrapOrganizationName) == "" {
+		return ErrOAuthNoOrganization
+	}
+	org, err := s.orgRepo.EnsureOrganization(
+		s.config.BootstrapOrganizationName,
+		s.config.BootstrapOrganizationDescription,
+		s.config.BootstrapOrganizationDomain,
+	)
+	if err != nil {
+		return fmt.Errorf("ensure bootstrap organization: %w", err)
+	}
+	if err := s.orgRepo.UpsertUserOrganization(user.ID, org.ID, "", true); err != nil {
+		return fmt.Errorf("assign bootstrap organization membership: %w", err)
+	}
+	if err := s.orgRepo.SetUserPrimaryOrganization(user.ID, org.ID); err != nil {
+		return fmt.Errorf("set primary organization: %w", err)
+	}
+	user.PrimaryOrganizationID = &org.ID
+	return nil
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/redirect"
+)
+
+// fakeGoogle serves the token and userinfo endpoints, accepting "good-code" only with the verifier whose
+// challenge was sent to the consent screen.
+type fakeGoogle struct {
+	challenge string
+	email     string
+	verified  bool
+}
+
+func (g *fakeGoogle) serve(t *testing.T) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if err := r.ParseForm(); err != nil || r.PostForm.Get("code") != "good-code" || pkceChallenge(r.PostForm.Get("code_verifier")) != g.challenge {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"access_token": "google-access"})
+		case "/userinfo":
+			json.NewEncoder(w).Encode(map[string]any{"sub": "1", "email": g.email, "email_verified": g.verified})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	previousToken, previousUserInfo := googleTokenURL, googleUserInfoURL
+	googleTokenURL, googleUserInfoURL = server.URL+"/token", server.URL+"/userinfo"
+	t.Cleanup(func() { googleTokenURL, googleUserInfoURL = previousToken, previousUserInfo })
+}
+
+func newOAuthEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	env := newTestEnv(t, func(cfg *config.AuthConfig) {
+		cfg.OAuthEnabled = true
+		cfg.GoogleClientID = "client"
+		cfg.GoogleClientSecret = "secret"
+		cfg.GoogleRedirectURL = "https://auth.example.com/v1/oauth/google/callback"
+		cfg.OAuthStateTTL = 10 * time.Minute
+		cfg.BootstrapOrganizationName = "Root"
+	})
+	policy, err := redirect.NewPolicy([]string{"https://app.example.com"})
+	if err != nil {
+		t.Fatalf("redirect policy: %v", err)
+	}
+	env.auth.WithRedirectPolicy(policy)
+	return env
+}
+
+// startGoogle runs the start route and returns the state and challenge sent to Google.
+func startGoogle(t *testing.T, env *testEnv, input OAuthStateInput) (string, string) {
+	t.Helper()
+
+	consentURL, err := env.auth.StartGoogleOAuth(input)
+	if err != nil {
+		t.Fatalf("StartGoogleOAuth() error = %v", err)
+	}
+	parsed, err := url.Parse(consentURL)
+	if err != nil {
+		t.Fatalf("parse consent url: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != PKCEMethodS256 {
+		t.Fatalf("consent url code_challenge_method = %q", query.Get("code_challenge_method"))
+	}
+	return query.Get("state"), query.Get("code_challenge")
+}
+
+func TestStartGoogleOAuth(t *testing.T) {
+	clientVerifier := "client-verifier-0123456789-0123456789-0123456789"
+
+	tests := []struct {
+		name    string
+		input   OAuthStateInput
+		wantErr error
+	}{
+		{name: "default return url", input: OAuthStateInput{}},
+		{name: "allowed origin", input: OAuthStateInput{ReturnURL: "https://app.example.com/done"}},
+		{name: "disallowed origin", input: OAuthStateInput{ReturnURL: "https://evil.example.net/"}, wantErr: ErrInvalidReturnURL},
+		{name: "allowed origin only in query", input: OAuthStateInput{ReturnURL: "https://evil.example.net/?x=app.example.com"}, wantErr: ErrInvalidReturnURL},
+		{name: "protocol relative", input: OAuthStateInput{ReturnURL: "//evil.example.net/"}, wantErr: ErrInvalidReturnURL},
+		{name: "public client without challenge", input: OAuthStateInput{ClientType: OAuthClientPublic}, wantErr: ErrPKCERequired},
+		{name: "public client with challenge", input: OAuthStateInput{ClientType: OAuthClientPublic, CodeChallenge: pkceChallenge(clientVerifier)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newOAuthEnv(t)
+			consentURL, err := env.auth.StartGoogleOAuth(tt.input)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("StartGoogleOAuth() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("StartGoogleOAuth() error = %v", err)
+			}
+
+			parsed, _ := url.Parse(consentURL)
+			query := parsed.Query()
+			state, challenge := query.Get("state"), query.Get("code_challenge")
+			if state == "" || challenge == "" || query.Get("code_challenge_method") != PKCEMethodS256 {
+				t.Fatalf("consent url lacks state or PKCE challenge: %s", consentURL)
+			}
+
+			var stored models.OAuthAuthorization
+			if err := env.db.First(&stored).Error; err != nil {
+				t.Fatalf("load stored state: %v", err)
+			}
+			if stored.KeyHash != hashToken(state) {
+				t.Fatal("state is not stored as a digest")
+			}
+			if pkceChallenge(stored.ProviderVerifier) != challenge {
+				t.Fatal("stored verifier does not match the challenge sent to Google")
+			}
+		})
+	}
+}
+
+func TestCompleteGoogleOAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		state      func(valid string) string
+		code       string
+		verified   bool
+		wantErr    error
+		wantResult bool
+	}{
+		{name: "success", state: func(s string) string { return s }, code: "good-code", verified: true, wantResult: true},
+		{name: "unknown state", state: func(string) string { return "forged" }, code: "good-code", verified: true, wantErr: ErrInvalidOAuthState},
+		{name: "denied by user", state: func(s string) string { return s }, code: "", verified: true, wantErr: ErrOAuthDenied, wantResult: true},
+		{name: "exchange rejected", state: func(s string) string { return s }, code: "bad-code", verified: true, wantErr: ErrOAuthExchangeFailed, wantResult: true},
+		{name: "unverified google email", state: func(s string) string { return s }, code: "good-code", verified: false, wantErr: ErrOAuthEmailUnverified, wantResult: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newOAuthEnv(t)
+			state, challenge := startGoogle(t, env, OAuthStateInput{ReturnURL: "https://app.example.com/done"})
+			(&fakeGoogle{challenge: challenge, email: "ada@example.com", verified: tt.verified}).serve(t)
+
+			result, err := env.auth.CompleteGoogleOAuth(context.Background(), tt.state(state), tt.code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CompleteGoogleOAuth() error = %v, want %v", err, tt.wantErr)
+			}
+			if (result != nil) != tt.wantResult {
+				t.Fatalf("CompleteGoogleOAuth() result = %+v, want result %v", result, tt.wantResult)
+			}
+			if result != nil && result.ReturnURL != "https://app.example.com/done" {
+				t.Fatalf("ReturnURL = %q", result.ReturnURL)
+			}
+			if tt.wantErr == nil && result.LoginCode == "" {
+				t.Fatal("no login code issued")
+			}
+		})
+	}
+}
+
+func TestCompleteGoogleOAuthStateIsSingleUse(t *testing.T) {
+	env := newOAuthEnv(t)
+	state, challenge := startGoogle(t, env, OAuthStateInput{})
+	(&fakeGoogle{challenge: challenge, email: "ada@example.com", verified: true}).serve(t)
+
+	if _, err := env.auth.CompleteGoogleOAuth(context.Background(), state, "good-code"); err != nil {
+		t.Fatalf("first callback error = %v", err)
+	}
+	if _, err := env.auth.CompleteGoogleOAuth(context.Background(), state, "good-code"); !errors.Is(err, ErrInvalidOAuthState) {
+		t.Fatalf("replayed callback error = %v, want %v", err, ErrInvalidOAuthState)
+	}
+}
+
+func TestCompleteGoogleOAuthRevalidatesReturnURL(t *testing.T) {
+	env := newOAuthEnv(t)
+	state, challenge := startGoogle(t, env, OAuthStateInput{ReturnURL: "https://app.example.com/done"})
+	(&fakeGoogle{challenge: challenge, email: "ada@example.com", verified: true}).serve(t)
+
+	// The origin is removed from the allow-list while the user is at Google.
+	policy, err := redirect.NewPolicy([]string{"https://other.example.com"})
+	if err != nil {
+		t.Fatalf("redirect policy: %v", err)
+	}
+	env.auth.WithRedirectPolicy(policy)
+
+	result, err := env.auth.CompleteGoogleOAuth(context.Background(), state, "good-code")
+	if !errors.Is(err, ErrInvalidReturnURL) || result != nil {
+		t.Fatalf("CompleteGoogleOAuth() = %+v, %v; want %v", result, err, ErrInvalidReturnURL)
+	}
+}
+
+func TestRedeemGoogleLoginCode(t *testing.T) {
+	clientVerifier := "client-verifier-0123456789-0123456789-0123456789"
+
+	tests := []struct {
+		name     string
+		input    OAuthStateInput
+		code     func(valid string) string
+		verifier string
+		wantErr  error
+	}{
+		{name: "confidential client", code: func(c string) string { return c }},
+		{name: "public client with verifier", input: OAuthStateInput{ClientType: OAuthClientPublic, CodeChallenge: pkceChallenge(clientVerifier)}, code: func(c string) string { return c }, verifier: clientVerifier},
+		{name: "mismatched verifier", input: OAuthStateInput{ClientType: OAuthClientPublic, CodeChallenge: pkceChallenge(clientVerifier)}, code: func(c string) string { return c }, verifier: clientVerifier + "x", wantErr: ErrInvalidPKCEVerifier},
+		{name: "unknown code", code: func(string) string { return "forged" }, wantErr: ErrInvalidOAuthLoginCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newOAuthEnv(t)
+			state, challenge := startGoogle(t, env, tt.input)
+			(&fakeGoogle{challenge: challenge, email: "ada@example.com", verified: true}).serve(t)
+			result, err := env.auth.CompleteGoogleOAuth(context.Background(), state, "good-code")
+			if err != nil {
+				t.Fatalf("CompleteGoogleOAuth() error = %v", err)
+			}
+
+			response, err := env.auth.RedeemGoogleLoginCode(tt.code(result.LoginCode), tt.verifier, nil)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("RedeemGoogleLoginCode() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RedeemGoogleLoginCode() error = %v", err)
+			}
+			if response.AccessToken == "" || response.RefreshToken == "" {
+				t.Fatal("RedeemGoogleLoginCode() issued no tokens")
+			}
+			if _, err := env.auth.RedeemGoogleLoginCode(result.LoginCode, tt.verifier, nil); !errors.Is(err, ErrInvalidOAuthLoginCode) {
+				t.Fatalf("second redemption error = %v, want %v", err, ErrInvalidOAuthLoginCode)
+			}
+		})
+	}
+}
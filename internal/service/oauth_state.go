@@ -0,0 +1,223 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/redirect"
+)
+
+// oauthLoginCodeTTL bounds how long a client has to redeem the login code returned by the callback.
+const oauthLoginCodeTTL = time.Minute
+
+// OAuth client types. Public clients cannot keep a secret and must use PKCE.
+const (
+	OAuthClientPublic       = "public"
+	OAuthClientConfidential = "confidential"
+)
+
+// PKCE code challenge methods.
+const (
+	PKCEMethodS256  = "S256"
+	PKCEMethodPlain = "plain"
+)
+
+var (
+	ErrInvalidOAuthState     = errors.New("invalid or expired oauth state")
+	ErrInvalidOAuthLoginCode = errors.New("invalid or expired oauth login code")
+	ErrInvalidReturnURL      = redirect.ErrDisallowed
+	ErrPKCERequired          = errors.New("pkce code challenge is required")
+	ErrInvalidPKCEVerifier   = errors.New("pkce verification failed")
+)
+
+// pkceValuePattern matches the RFC 7636 character set and length for verifiers and challenges.
+var pkceValuePattern = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// OAuthStateInput describes the client's authorization request: where to send the browser afterwards
+// and the PKCE challenge the client will prove when redeeming the login code.
+type OAuthStateInput struct {
+	ReturnURL           string
+	ClientType          string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// issueOAuthState validates the authorization request and stores it under a fresh random state. It
+// returns the state and the PKCE challenge for Google, whose verifier never leaves the service.
+func (s *AuthenticationService) issueOAuthState(input OAuthStateInput) (string, string, error) {
+	returnURL := strings.TrimSpace(input.ReturnURL)
+	if returnURL == "" {
+		returnURL = "/"
+	}
+	returnURL, err := s.ValidateRedirect(returnURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	clientType := strings.ToLower(strings.TrimSpace(input.ClientType))
+	if clientType == "" {
+		clientType = OAuthClientConfidential
+	}
+	if clientType != OAuthClientPublic && clientType != OAuthClientConfidential {
+		return "", "", fmt.Errorf("unsupported client type %q", input.ClientType)
+	}
+
+	challenge := strings.TrimSpace(input.CodeChallenge)
+	method := strings.TrimSpace(input.CodeChallengeMethod)
+	if challenge == "" {
+		if s.pkceRequired(clientType) {
+			return "", "", ErrPKCERequired
+		}
+		method = ""
+	} else {
+		if method == "" {
+			method = PKCEMethodS256
+		}
+		// Plain challenges are only accepted from confidential clients, which also hold a client secret.
+		if method != PKCEMethodS256 && !(method == PKCEMethodPlain && clientType == OAuthClientConfidential) {
+			return "", "", fmt.Errorf("unsupported code challenge method %q", method)
+		}
+		if !pkceValuePattern.MatchString(challenge) {
+			return "", "", errors.New("code challenge is malformed")
+		}
+	}
+
+	state, err := generateVerificationToken()
+	if err != nil {
+		return "", "", err
+	}
+	providerVerifier, err := generatePKCEVerifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	err = s.userRepo.CreateOAuthAuthorization(&models.OAuthAuthorization{
+		KeyHash:             hashToken(state),
+		ReturnURL:           returnURL,
+		ClientType:          clientType,
+		CodeChallenge:       challenge,
+		CodeChallengeMethod: method,
+		ProviderVerifier:    providerVerifier,
+		ExpiresAt:           s.now().Add(s.config.OAuthStateTTL),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("store oauth state: %w", err)
+	}
+	return state, pkceChallenge(providerVerifier), nil
+}
+
+// takeOAuthState consumes a pending authorization by the state Google returned. The return URL is
+// checked again because the allow-list may have changed since the state was issued; a target that is no
+// longer allowed fails with ErrInvalidReturnURL.
+func (s *AuthenticationService) takeOAuthState(state string) (*models.OAuthAuthorization, error) {
+	state = strings.TrimSpace(state)
+	if state == "" {
+		return nil, ErrInvalidOAuthState
+	}
+	authorization, err := s.userRepo.TakeOAuthAuthorization(hashToken(state), s.now())
+	if err != nil {
+		return nil, err
+	}
+	if authorization == nil || authorization.UserID != nil {
+		return nil, ErrInvalidOAuthState
+	}
+	if _, err := s.ValidateRedirect(authorization.ReturnURL); err != nil {
+		return nil, ErrInvalidReturnURL
+	}
+	return authorization, nil
+}
+
+// issueOAuthLoginCode replaces a completed authorization with a short-lived login code for the user.
+func (s *AuthenticationService) issueOAuthLoginCode(authorization *models.OAuthAuthorization, userID uint64) (string, error) {
+	code, err := generateVerificationToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.userRepo.CreateOAuthAuthorization(&models.OAuthAuthorization{
+		KeyHash:             hashToken(code),
+		UserID:              &userID,
+		ReturnURL:           authorization.ReturnURL,
+		ClientType:          authorization.ClientType,
+		CodeChallenge:       authorization.CodeChallenge,
+		CodeChallengeMethod: authorization.CodeChallengeMethod,
+		ExpiresAt:           s.now().Add(oauthLoginCodeTTL),
+	})
+	if err != nil {
+		return "", fmt.Errorf("store oauth login code: %w", err)
+	}
+	return code, nil
+}
+
+// takeOAuthLoginCode consumes a login code and checks the client's PKCE verifier against the challenge
+// sent to the start route.
+func (s *AuthenticationService) takeOAuthLoginCode(code, codeVerifier string) (*models.OAuthAuthorization, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, ErrInvalidOAuthLoginCode
+	}
+	authorization, err := s.userRepo.TakeOAuthAuthorization(hashToken(code), s.now())
+	if err != nil {
+		return nil, err
+	}
+	if authorization == nil || authorization.UserID == nil {
+		return nil, ErrInvalidOAuthLoginCode
+	}
+
+	if authorization.CodeChallenge == "" {
+		if s.pkceRequired(authorization.ClientType) {
+			return nil, ErrPKCERequired
+		}
+		return authorization, nil
+	}
+	if !verifyPKCE(authorization.CodeChallenge, authorization.CodeChallengeMethod, codeVerifier) {
+		return nil, ErrInvalidPKCEVerifier
+	}
+	return authorization, nil
+}
+
+// pkceRequired reports whether the client type must present a PKCE challenge.
+func (s *AuthenticationService) pkceRequired(clientType string) bool {
+	return clientType != OAuthClientConfidential || s.config.OAuthRequirePKCE
+}
+
+// generatePKCEVerifier returns a random RFC 7636 code verifier.
+func generatePKCEVerifier() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate pkce verifier: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// pkceChallenge derives the S256 code challenge of a verifier.
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// verifyPKCE checks a code verifier against the challenge sent with the authorization request (RFC 7636).
+func verifyPKCE(challenge, method, verifier string) bool {
+	if !pkceValuePattern.MatchString(verifier) {
+		return false
+	}
+
+	expected := verifier
+	if method != PKCEMethodPlain {
+		expected = pkceChallenge(verifier)
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// ValidateRedirect checks a redirect target against the allow-list so the OAuth start and callback routes
+// cannot be used as open redirects. It returns ErrInvalidReturnURL for disallowed targets.
+func (s *AuthenticationService) ValidateRedirect(target string) (string, error) {
+	return s.redirects.Validate(target)
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/repository"
+)
+
+// ErrLastOrganizationAdmin is returned when the only admin of an organization tries to give up the role.
+var ErrLastOrganizationAdmin = errors.New("the last organization admin cannot demote themselves")
+
+// AssignOrganizationAdmin grants ORG_ADMIN in the organization to a user, creating the membership when
+// needed. The organization becomes the user's primary one when they have none.
+func (s *OrganizationService) AssignOrganizationAdmin(orgID, userID, actorID uint64) (*models.UserOrganization, error) {
+	if userID == 0 {
+		return nil, fmt.Errorf("user_id is required")
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	org, err := s.orgRepo.GetOrganizationByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	previous, err := s.orgRepo.GetUserOrganization(userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	makePrimary := user.PrimaryOrganizationID == nil || (previous != nil && previous.IsPrimary)
+
+	err = s.orgRepo.WithTx(func(repos *repository.TxRepositories) error {
+		if err := repos.Organizations.UpsertUserOrganization(userID, orgID, models.OrganizationRoleOrgAdmin, makePrimary); err != nil {
+			return err
+		}
+		if user.PrimaryOrganizationID == nil {
+			return repos.Organizations.SetUserPrimaryOrganization(userID, orgID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	membership, err := s.orgRepo.GetUserOrganization(userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]any{
+		"organization_id": orgID,
+		"is_primary":      makePrimary,
+	}
+	if previous != nil {
+		metadata["previous_role"] = previous.Role
+	}
+	s.recordAudit(actorID, models.AuditActionOrganizationAdminAssign, models.AuditUserRef(userID), orgID, metadata)
+	s.expireAccessTokens(userID)
+	return membership, nil
+}
+
+// guardLastOrganizationAdmin refuses to let the actor drop their own ORG_ADMIN membership when nobody
+// else administers the organization.
+func (s *OrganizationService) guardLastOrganizationAdmin(actorID uint64, membership *models.UserOrganization) error {
+	if membership.Role != models.OrganizationRoleOrgAdmin || membership.UserID != actorID {
+		return nil
+	}
+	admins, err := s.orgRepo.CountOrganizationMembersWithRole(membership.OrganizationID, models.OrganizationRoleOrgAdmin)
+	if err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return ErrLastOrganizationAdmin
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestAssignOrganizationAdmin(t *testing.T) {
+	env := newTestEnv(t, nil)
+	org := env.createOrganization(t, "Acme", nil)
+	actor := env.createUser(t, "root", func(u *models.User) { u.IsSuperAdmin = true })
+	user := env.createUser(t, "promoted", nil)
+
+	membership, err := env.org.AssignOrganizationAdmin(org.ID, user.ID, actor.ID)
+	if err != nil {
+		t.Fatalf("AssignOrganizationAdmin() error = %v", err)
+	}
+	if membership.Role != models.OrganizationRoleOrgAdmin || !membership.IsPrimary || membership.OrganizationID != org.ID {
+		t.Errorf("membership = %+v, want a primary ORG_ADMIN membership of organization %d", membership, org.ID)
+	}
+	if reloaded := env.reloadUser(t, user.ID); reloaded.PrimaryOrganizationID == nil || *reloaded.PrimaryOrganizationID != org.ID {
+		t.Errorf("primary organization = %v, want %d", reloaded.PrimaryOrganizationID, org.ID)
+	}
+
+	if _, err := env.org.AssignOrganizationAdmin(org.ID, 9999, actor.ID); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("unknown user: error = %v, want %v", err, ErrUserNotFound)
+	}
+	if _, err := env.org.AssignOrganizationAdmin(9999, user.ID, actor.ID); !errors.Is(err, ErrOrganizationNotFound) {
+		t.Errorf("unknown organization: error = %v, want %v", err, ErrOrganizationNotFound)
+	}
+}
+
+func TestLastOrganizationAdminCannotStepDown(t *testing.T) {
+	tests := []struct {
+		name       string
+		otherAdmin bool
+		stepDown   func(env *testEnv, admin *models.User, org *models.Organization) error
+		wantErr    error
+	}{
+		{
+			name: "demote themselves",
+			stepDown: func(env *testEnv, admin *models.User, org *models.Organization) error {
+				_, err := env.org.AssignUserToOrganization(&models.AssignUserOrganizationInput{UserID: admin.ID, OrganizationID: org.ID, Role: "CEO", ActorID: admin.ID})
+				return err
+			},
+			wantErr: ErrLastOrganizationAdmin,
+		},
+		{
+			name: "remove themselves",
+			stepDown: func(env *testEnv, admin *models.User, org *models.Organization) error {
+				return env.org.RemoveUserOrganization(&admin.ID, &org.ID, admin.ID)
+			},
+			wantErr: ErrLastOrganizationAdmin,
+		},
+		{
+			name:       "demote themselves with another admin",
+			otherAdmin: true,
+			stepDown: func(env *testEnv, admin *models.User, org *models.Organization) error {
+				_, err := env.org.AssignUserToOrganization(&models.AssignUserOrganizationInput{UserID: admin.ID, OrganizationID: org.ID, Role: "CEO", ActorID: admin.ID})
+				return err
+			},
+		},
+		{
+			name:       "remove themselves with another admin",
+			otherAdmin: true,
+			stepDown: func(env *testEnv, admin *models.User, org *models.Organization) error {
+				return env.org.RemoveUserOrganization(&admin.ID, &org.ID, admin.ID)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, nil)
+			org := env.createOrganization(t, "Acme", nil)
+			admin := env.createUser(t, "admin", nil)
+			if _, err := env.org.AssignOrganizationAdmin(org.ID, admin.ID, admin.ID); err != nil {
+				t.Fatalf("AssignOrganizationAdmin() error = %v", err)
+			}
+			if tt.otherAdmin {
+				other := env.createUser(t, "other", nil)
+				if _, err := env.org.AssignOrganizationAdmin(org.ID, other.ID, admin.ID); err != nil {
+					t.Fatalf("AssignOrganizationAdmin(other) error = %v", err)
+				}
+			}
+
+			err := tt.stepDown(env, admin, org)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			membership, err := env.orgs.GetUserOrganization(admin.ID, org.ID)
+			if err != nil {
+				t.Fatalf("GetUserOrganization() error = %v", err)
+			}
+			stillAdmin := membership != nil && membership.Role == models.OrganizationRoleOrgAdmin
+			if stillAdmin != (tt.wantErr != nil) {
+				t.Errorf("membership = %+v, want the ORG_ADMIN role kept: %v", membership, tt.wantErr != nil)
+			}
+		})
+	}
+}
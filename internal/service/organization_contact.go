@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/core/utils"
+)
+
+// Contact field limits, matching the column sizes.
+const (
+	maxContactEmailLength = 255
+	maxContactPhoneLength = 32
+	maxAddressLength      = 1024
+)
+
+// ErrInvalidOrganizationContact is wrapped by every contact validation failure.
+var ErrInvalidOrganizationContact = errors.New("invalid organization contact")
+
+// GetOrganizationContact returns the organization's contact details.
+func (s *OrganizationService) GetOrganizationContact(orgID uint64) (*models.OrganizationContact, error) {
+	org, err := s.orgRepo.GetOrganizationByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return org.Contact(), nil
+}
+
+// UpdateOrganizationContact validates and stores the supplied contact fields.
+func (s *OrganizationService) UpdateOrganizationContact(orgID uint64, input *models.UpdateOrganizationContactInput) (*models.OrganizationContact, error) {
+	if input == nil {
+		return nil, fmt.Errorf("input required")
+	}
+
+	org, err := s.orgRepo.GetOrganizationByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	changed := []string{}
+	if input.ContactEmail != nil {
+		email := strings.TrimSpace(*input.ContactEmail)
+		if len(email) > maxContactEmailLength || (email != "" && !utils.IsEmail(email)) {
+			return nil, fmt.Errorf("%w: contact_email must be a valid email address", ErrInvalidOrganizationContact)
+		}
+		org.ContactEmail = email
+		changed = append(changed, "contact_email")
+	}
+	if input.ContactPhone != nil {
+		phone := strings.TrimSpace(*input.ContactPhone)
+		if len(phone) > maxContactPhoneLength || (phone != "" && !validContactPhone(phone)) {
+			return nil, fmt.Errorf("%w: contact_phone may only contain digits, spaces and + - ( ) . and must include a digit", ErrInvalidOrganizationContact)
+		}
+		org.ContactPhone = phone
+		changed = append(changed, "contact_phone")
+	}
+	if input.Address != nil {
+		address := strings.TrimSpace(*input.Address)
+		if len(address) > maxAddressLength {
+			return nil, fmt.Errorf("%w: address must be at most %d characters", ErrInvalidOrganizationContact, maxAddressLength)
+		}
+		org.Address = address
+		changed = append(changed, "address")
+	}
+	if len(changed) == 0 {
+		return org.Contact(), nil
+	}
+
+	if err := s.orgRepo.UpdateOrganization(org); err != nil {
+		return nil, err
+	}
+	s.recordAudit(input.ActorID, models.AuditActionOrganizationContactUpdate, models.AuditOrganizationRef(orgID), orgID, map[string]any{
+		"fields": changed,
+	})
+	return org.Contact(), nil
+}
+
+// validContactPhone accepts common phone number punctuation around at least one digit.
+func validContactPhone(phone string) bool {
+	hasDigit := false
+	for _, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			hasDigit = true
+		case strings.ContainsRune(" +-().", r):
+		default:
+			return false
+		}
+	}
+	return hasDigit
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// ErrInvalidHierarchyDepth is returned when a requested child depth is negative or above the configured cap.
+var ErrInvalidHierarchyDepth = errors.New("invalid hierarchy depth")
+
+// GetOrganizationDetail returns the organization with depth levels of child organizations expanded.
+// Departments and memberships are only loaded when requested.
+func (s *OrganizationService) GetOrganizationDetail(orgID uint64, depth int, includeDepartments, includeMembers bool) (*models.OrganizationDetail, error) {
+	if maxDepth := s.maxHierarchyDepth(); depth < 0 || depth > maxDepth {
+		return nil, fmt.Errorf("%w: depth must be between 0 and %d", ErrInvalidHierarchyDepth, maxDepth)
+	}
+
+	org, err := s.orgRepo.GetOrganizationTree(orgID, depth, includeDepartments)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	detail := &models.OrganizationDetail{Organization: org}
+	if includeMembers {
+		members, err := s.orgRepo.ListOrganizationMembers(orgID)
+		if err != nil {
+			return nil, err
+		}
+		detail.Members = members
+	}
+	return detail, nil
+}
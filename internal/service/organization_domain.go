@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// DNS TXT record an organization publishes to prove it controls its domain. The record lives at
+// domainVerificationRecordPrefix + domain and holds domainVerificationValuePrefix + token.
+const (
+	domainVerificationRecordPrefix = "_authentication-verification."
+	domainVerificationValuePrefix  = "authentication-verification="
+	domainVerificationTimeout      = 5 * time.Second
+)
+
+var (
+	ErrOrganizationDomainMissing       = errors.New("organization has no domain")
+	ErrDomainAlreadyVerified           = errors.New("organization domain is already verified")
+	ErrDomainVerificationNotStarted    = errors.New("domain verification has not been started")
+	ErrDomainVerificationRecordMissing = errors.New("domain verification record not found")
+)
+
+// TXTResolver looks up DNS TXT records. *net.Resolver satisfies it.
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// WithTXTResolver replaces the resolver used to check domain verification records.
+func (s *OrganizationService) WithTXTResolver(resolver TXTResolver) *OrganizationService {
+	if resolver != nil {
+		s.resolver = resolver
+	}
+	return s
+}
+
+// StartDomainVerification returns the TXT record the organization must publish to verify its domain.
+// Repeated calls return the same record until the domain is verified.
+func (s *OrganizationService) StartDomainVerification(orgID, actorID uint64) (*models.DomainVerification, error) {
+	org, err := s.domainVerificationOrganization(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org.DomainVerified {
+		return nil, ErrDomainAlreadyVerified
+	}
+
+	if org.DomainVerificationToken == "" {
+		token, err := generateVerificationToken()
+		if err != nil {
+			return nil, err
+		}
+		org.DomainVerificationToken = token
+		if err := s.orgRepo.UpdateOrganization(org); err != nil {
+			return nil, err
+		}
+		s.recordAudit(actorID, models.AuditActionOrganizationDomainVerify, models.AuditOrganizationRef(orgID), orgID, map[string]any{
+			"domain": org.Domain,
+			"stage":  "start",
+		})
+	}
+	return domainVerificationStatus(org), nil
+}
+
+// CheckDomainVerification looks up the organization's TXT record and marks the domain verified when
+// it holds the issued token.
+func (s *OrganizationService) CheckDomainVerification(orgID, actorID uint64) (*models.DomainVerification, error) {
+	org, err := s.domainVerificationOrganization(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org.DomainVerified {
+		return domainVerificationStatus(org), nil
+	}
+	if org.DomainVerificationToken == "" {
+		return nil, ErrDomainVerificationNotStarted
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), domainVerificationTimeout)
+	defer cancel()
+
+	expected := domainVerificationValuePrefix + org.DomainVerificationToken
+	records, lookupErr := s.resolver.LookupTXT(ctx, domainVerificationRecordPrefix+org.Domain)
+	found := false
+	for _, record := range records {
+		if strings.TrimSpace(record) == expected {
+			found = true
+			break
+		}
+	}
+	if !found {
+		s.recordAudit(actorID, models.AuditActionOrganizationDomainVerify, models.AuditOrganizationRef(orgID), orgID, map[string]any{
+			"domain": org.Domain,
+			"stage":  "check",
+			"result": "not_found",
+		})
+		if lookupErr != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDomainVerificationRecordMissing, lookupErr)
+		}
+		return nil, ErrDomainVerificationRecordMissing
+	}
+
+	now := time.Now().UTC()
+	org.DomainVerified = true
+	org.DomainVerifiedAt = &now
+	org.DomainVerificationToken = ""
+	if err := s.orgRepo.UpdateOrganization(org); err != nil {
+		return nil, err
+	}
+	s.recordAudit(actorID, models.AuditActionOrganizationDomainVerify, models.AuditOrganizationRef(orgID), orgID, map[string]any{
+		"domain": org.Domain,
+		"stage":  "check",
+		"result": "verified",
+	})
+	return domainVerificationStatus(org), nil
+}
+
+// domainVerificationOrganization loads an organization that has a domain to verify.
+func (s *OrganizationService) domainVerificationOrganization(orgID uint64) (*models.Organization, error) {
+	org, err := s.orgRepo.GetOrganizationByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+	if strings.TrimSpace(org.Domain) == "" {
+		return nil, ErrOrganizationDomainMissing
+	}
+	return org, nil
+}
+
+// domainVerificationStatus describes the organization's verification state, including the record to
+// publish while it is pending.
+func domainVerificationStatus(org *models.Organization) *models.DomainVerification {
+	status := &models.DomainVerification{
+		OrganizationID: org.ID,
+		Domain:         org.Domain,
+		Verified:       org.DomainVerified,
+		VerifiedAt:     org.DomainVerifiedAt,
+	}
+	if !org.DomainVerified && org.DomainVerificationToken != "" {
+		status.RecordType = "TXT"
+		status.RecordName = domainVerificationRecordPrefix + org.Domain
+		status.RecordValue = domainVerificationValuePrefix + org.DomainVerificationToken
+	}
+	return status
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// stubResolver answers TXT lookups from a fixed table, or fails every lookup with err.
+type stubResolver struct {
+	records map[string][]string
+	err     error
+}
+
+func (r *stubResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.records[name], nil
+}
+
+func TestDomainVerification(t *testing.T) {
+	tests := []struct {
+		name     string
+		records  func(status *models.DomainVerification) map[string][]string
+		err      error
+		wantErr  error
+		verified bool
+	}{
+		{
+			name: "matching record",
+			records: func(status *models.DomainVerification) map[string][]string {
+				return map[string][]string{status.RecordName: {"v=spf1 -all", " " + status.RecordValue + " "}}
+			},
+			verified: true,
+		},
+		{
+			name:    "no record",
+			records: func(*models.DomainVerification) map[string][]string { return nil },
+			wantErr: ErrDomainVerificationRecordMissing,
+		},
+		{
+			name: "stale token",
+			records: func(status *models.DomainVerification) map[string][]string {
+				return map[string][]string{status.RecordName: {domainVerificationValuePrefix + "stale"}}
+			},
+			wantErr: ErrDomainVerificationRecordMissing,
+		},
+		{
+			name: "record on another name",
+			records: func(status *models.DomainVerification) map[string][]string {
+				return map[string][]string{"acme.example": {status.RecordValue}}
+			},
+			wantErr: ErrDomainVerificationRecordMissing,
+		},
+		{
+			name:    "lookup failure",
+			records: func(*models.DomainVerification) map[string][]string { return nil },
+			err:     errors.New("no such host"),
+			wantErr: ErrDomainVerificationRecordMissing,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, nil)
+			org := env.createOrganization(t, "Acme", func(o *models.Organization) { o.Domain = "acme.example" })
+			resolver := &stubResolver{err: tt.err}
+			env.org.WithTXTResolver(resolver)
+
+			if _, err := env.org.CheckDomainVerification(org.ID, 0); !errors.Is(err, ErrDomainVerificationNotStarted) {
+				t.Fatalf("CheckDomainVerification() before start error = %v, want %v", err, ErrDomainVerificationNotStarted)
+			}
+			status, err := env.org.StartDomainVerification(org.ID, 0)
+			if err != nil {
+				t.Fatalf("StartDomainVerification() error = %v", err)
+			}
+			if status.RecordType != "TXT" || status.RecordName != "_authentication-verification.acme.example" || status.RecordValue == "" {
+				t.Fatalf("StartDomainVerification() = %+v, want a TXT record for the domain", status)
+			}
+			again, err := env.org.StartDomainVerification(org.ID, 0)
+			if err != nil || again.RecordValue != status.RecordValue {
+				t.Fatalf("repeated StartDomainVerification() = %+v, %v, want the same record", again, err)
+			}
+			resolver.records = tt.records(status)
+
+			checked, err := env.org.CheckDomainVerification(org.ID, 0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CheckDomainVerification() error = %v, want %v", err, tt.wantErr)
+			}
+			stored, err := env.orgs.GetOrganizationByID(org.ID)
+			if err != nil {
+				t.Fatalf("reload organization: %v", err)
+			}
+			if stored.DomainVerified != tt.verified {
+				t.Fatalf("domain_verified = %v, want %v", stored.DomainVerified, tt.verified)
+			}
+			if !tt.verified {
+				if stored.DomainVerificationToken == "" {
+					t.Error("failed check discarded the pending token")
+				}
+				return
+			}
+			if !checked.Verified || checked.VerifiedAt == nil || checked.RecordValue != "" {
+				t.Errorf("CheckDomainVerification() = %+v, want verified without a pending record", checked)
+			}
+			if _, err := env.org.StartDomainVerification(org.ID, 0); !errors.Is(err, ErrDomainAlreadyVerified) {
+				t.Errorf("StartDomainVerification() after verification error = %v, want %v", err, ErrDomainAlreadyVerified)
+			}
+		})
+	}
+}
+
+func TestDomainVerificationRequiresDomain(t *testing.T) {
+	env := newTestEnv(t, nil)
+	org := env.createOrganization(t, "Acme", nil)
+
+	if _, err := env.org.StartDomainVerification(org.ID, 0); !errors.Is(err, ErrOrganizationDomainMissing) {
+		t.Errorf("StartDomainVerification() error = %v, want %v", err, ErrOrganizationDomainMissing)
+	}
+	if _, err := env.org.CheckDomainVerification(9999, 0); !errors.Is(err, ErrOrganizationNotFound) {
+		t.Errorf("CheckDomainVerification() for unknown organization error = %v, want %v", err, ErrOrganizationNotFound)
+	}
+}
@@ -3,31 +3,48 @@ package service
 import (
 	"errors"
 	"fmt"
+	"log"
+	"net"
 	"strings"
 
+	"github.com/lee-tech/authentication/config"
 	"github.com/lee-tech/authentication/internal/constants"
 	"github.com/lee-tech/authentication/internal/models"
 	"github.com/lee-tech/authentication/internal/repository"
 	coreServer "github.com/lee-tech/core/server"
 )
 
+// defaultMaxHierarchyDepth applies when MAX_HIERARCHY_DEPTH is not configured.
+const defaultMaxHierarchyDepth = 10
+
 var (
 	ErrOrganizationNotFound = errors.New("organization not found")
 	ErrDepartmentNotFound   = errors.New("department not found")
 	ErrUserNotFound         = errors.New("user not found")
+	ErrMembershipNotFound   = errors.New("membership not found")
+	ErrLastSystemAdmin      = errors.New("the last SYSTEM_ADMIN of the bootstrap organization cannot be removed")
+	ErrOrganizationCycle    = errors.New("an organization cannot be its own ancestor")
+	// ErrInvalidDepartment is wrapped by department validation failures in create, move and import.
+	ErrInvalidDepartment = errors.New("invalid department")
 )
 
 // OrganizationService coordinates tenant hierarchy and membership management.
 type OrganizationService struct {
 	orgRepo  *repository.OrganizationRepository
 	userRepo *repository.UserRepository
+	audit    AuditLogger
+	config   *config.AuthConfig
+	resolver TXTResolver
 }
 
 // NewOrganizationService constructs the service.
-func NewOrganizationService(orgRepo *repository.OrganizationRepository, userRepo *repository.UserRepository) *OrganizationService {
+func NewOrganizationService(orgRepo *repository.OrganizationRepository, userRepo *repository.UserRepository, audit AuditLogger, config *config.AuthConfig) *OrganizationService {
 	return &OrganizationService{
 		orgRepo:  orgRepo,
 		userRepo: userRepo,
+		audit:    audit,
+		config:   config,
+		resolver: net.DefaultResolver,
 	}
 }
 
@@ -51,20 +68,45 @@ func (s *OrganizationService) CreateOrganization(input *models.CreateOrganizatio
 		if parent == nil {
 			return nil, ErrOrganizationNotFound
 		}
+		if err := s.validateOrganizationParent(0, parent.ID); err != nil {
+			return nil, err
+		}
+		if err := s.validateOrganizationDepth(parent.ID); err != nil {
+			return nil, err
+		}
 	}
 
 	org := &models.Organization{
 		Name:        name,
 		Description: strings.TrimSpace(input.Description),
 		Domain:      strings.TrimSpace(strings.ToLower(input.Domain)),
+		Tier:        strings.TrimSpace(strings.ToLower(input.Tier)),
 		ParentID:    input.ParentID,
 		IsActive:    true,
 	}
+	if org.Tier == "" && s.config != nil {
+		org.Tier = s.config.DefaultTenantTier
+	}
 	if input.IsActive != nil {
 		org.IsActive = *input.IsActive
 	}
 
-	if err := s.orgRepo.CreateOrganization(org); err != nil {
+	if input.SeedDefaultDepartments {
+		err = s.orgRepo.WithTx(func(repos *repository.TxRepositories) error {
+			if err := repos.Organizations.CreateOrganization(org); err != nil {
+				return err
+			}
+			departments, err := s.seedDefaultDepartments(repos.Organizations, org.ID)
+			if err != nil {
+				return err
+			}
+			org.Departments = departments
+			return nil
+		})
+	} else {
+		err = s.orgRepo.CreateOrganization(org)
+	}
+	if err != nil {
 		return nil, err
 	}
 
@@ -72,9 +114,201 @@ func (s *OrganizationService) CreateOrganization(input *models.CreateOrganizatio
 		org.Parent = parent
 	}
 
+	// The organization exists at this point; missing roles can still be provisioned later
+	if _, err := s.ProvisionOrganizationRoles(org.ID, 0); err != nil {
+		log.Printf("failed to provision roles for organization %d: %v", org.ID, err)
+	}
+
+	return org, nil
+}
+
+// validateOrganizationParent rejects parentID as the parent of orgID (0 for a new organization) when
+// it is orgID itself, one of its descendants, or part of an existing loop.
+func (s *OrganizationService) validateOrganizationParent(orgID, parentID uint64) error {
+	cycle, err := s.orgRepo.OrganizationParentCreatesCycle(orgID, parentID)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return ErrOrganizationCycle
+	}
+	return nil
+}
+
+// UpdateOrganizationTier changes the plan of an organization.
+func (s *OrganizationService) UpdateOrganizationTier(orgID uint64, input *models.UpdateOrganizationTierInput) (*models.Organization, error) {
+	if input == nil {
+		return nil, fmt.Errorf("input required")
+	}
+	tier := strings.TrimSpace(strings.ToLower(input.Tier))
+	if tier == "" {
+		return nil, fmt.Errorf("tier is required")
+	}
+
+	org, err := s.orgRepo.GetOrganizationByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	org.Tier = tier
+	if err := s.orgRepo.UpdateOrganization(org); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// UpdateOrganizationLoginPolicy sets the minimum role level required to log into an organization.
+// A nil level removes the restriction.
+func (s *OrganizationService) UpdateOrganizationLoginPolicy(orgID uint64, input *models.UpdateOrganizationLoginPolicyInput) (*models.Organization, error) {
+	if input == nil {
+		return nil, fmt.Errorf("input required")
+	}
+	if input.MinLoginRoleLevel != nil && *input.MinLoginRoleLevel < 0 {
+		return nil, fmt.Errorf("min_login_role_level must not be negative")
+	}
+
+	org, err := s.orgRepo.GetOrganizationByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	org.MinLoginRoleLevel = input.MinLoginRoleLevel
+	if err := s.orgRepo.UpdateOrganization(org); err != nil {
+		return nil, err
+	}
 	return org, nil
 }
 
+// SetOrganizationActive deactivates or reactivates an organization. Members of an inactive
+// organization cannot log into it. With OrganizationDeactivationCascade, deactivation also switches off
+// the organization's active departments and reactivation restores the ones it switched off.
+func (s *OrganizationService) SetOrganizationActive(orgID uint64, active bool, actorID uint64) (*models.Organization, error) {
+	org, err := s.orgRepo.GetOrganizationByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+	if org.IsActive == active {
+		return org, nil
+	}
+
+	org.IsActive = active
+	cascade := s.config != nil && s.config.OrganizationDeactivationCascade
+	var cascaded []uint64
+	if cascade {
+		err = s.orgRepo.WithTx(func(repos *repository.TxRepositories) error {
+			if err := repos.Organizations.UpdateOrganization(org); err != nil {
+				return err
+			}
+			var err error
+			if active {
+				cascaded, err = repos.Organizations.RestoreCascadedDepartments(orgID)
+			} else {
+				cascaded, err = repos.Organizations.CascadeDeactivateDepartments(orgID)
+			}
+			return err
+		})
+	} else {
+		err = s.orgRepo.UpdateOrganization(org)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	changed := make(map[uint64]struct{}, len(cascaded))
+	for _, id := range cascaded {
+		changed[id] = struct{}{}
+	}
+	for i := range org.Departments {
+		if _, ok := changed[org.Departments[i].ID]; ok {
+			org.Departments[i].IsActive = active
+			org.Departments[i].DeactivatedByOrganization = !active
+		}
+	}
+
+	action := models.AuditActionOrganizationDeactivate
+	if active {
+		action = models.AuditActionOrganizationReactivate
+	}
+	var metadata map[string]any
+	if cascade {
+		metadata = map[string]any{"cascaded_departments": cascaded}
+	}
+	s.recordAudit(actorID, action, models.AuditOrganizationRef(orgID), orgID, metadata)
+	return org, nil
+}
+
+// ProvisionOrganizationRoles seeds the organization's role templates from DefaultOrganizationRoles.
+// Codes the organization already has are left untouched, so repeated calls create nothing new.
+// It returns the templates created by this call.
+func (s *OrganizationService) ProvisionOrganizationRoles(orgID, actorID uint64) ([]*models.OrganizationRoleDefinition, error) {
+	org, err := s.orgRepo.GetOrganizationByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	existing, err := s.orgRepo.ListOrganizationRoles(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := missingDefaultRoles(orgID, existing)
+	if len(missing) == 0 {
+		return []*models.OrganizationRoleDefinition{}, nil
+	}
+
+	created, err := s.orgRepo.CreateOrganizationRoles(missing)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(created) > 0 {
+		codes := make([]string, 0, len(created))
+		for _, role := range created {
+			codes = append(codes, string(role.Code))
+		}
+		s.recordAudit(actorID, models.AuditActionOrganizationRolesProvision, models.AuditOrganizationRef(orgID), orgID, map[string]any{
+			"codes": codes,
+		})
+	}
+	return created, nil
+}
+
+// missingDefaultRoles returns role definitions for the DefaultOrganizationRoles codes the organization
+// does not have yet.
+func missingDefaultRoles(orgID uint64, existing []*models.OrganizationRoleDefinition) []*models.OrganizationRoleDefinition {
+	known := make(map[models.OrganizationRole]struct{}, len(existing))
+	for _, role := range existing {
+		known[role.Code] = struct{}{}
+	}
+
+	missing := make([]*models.OrganizationRoleDefinition, 0, len(models.DefaultOrganizationRoles))
+	for _, template := range models.DefaultOrganizationRoles {
+		if _, ok := known[template.Code]; ok {
+			continue
+		}
+		missing = append(missing, &models.OrganizationRoleDefinition{
+			OrganizationID: orgID,
+			Code:           template.Code,
+			Name:           template.Name,
+			Description:    template.Description,
+			Level:          template.Level,
+		})
+	}
+	return missing
+}
+
 // ListOrganizations returns all organizations.
 func (s *OrganizationService) ListOrganizations() ([]*models.Organization, error) {
 	return s.orgRepo.ListOrganizations()
@@ -83,14 +317,14 @@ func (s *OrganizationService) ListOrganizations() ([]*models.Organization, error
 // CreateDepartment provisions a new department under an organization.
 func (s *OrganizationService) CreateDepartment(input *models.CreateDepartmentInput) (*models.Department, error) {
 	if input == nil {
-		return nil, fmt.Errorf("input required")
+		return nil, fmt.Errorf("%w: input required", ErrInvalidDepartment)
 	}
 	if input.OrganizationID == 0 {
-		return nil, fmt.Errorf("organization_id is required")
+		return nil, fmt.Errorf("%w: organization_id is required", ErrInvalidDepartment)
 	}
 	name := strings.TrimSpace(input.Name)
 	if name == "" {
-		return nil, fmt.Errorf("department name is required")
+		return nil, fmt.Errorf("%w: department name is required", ErrInvalidDepartment)
 	}
 
 	org, err := s.orgRepo.GetOrganizationByID(input.OrganizationID)
@@ -111,14 +345,20 @@ func (s *OrganizationService) CreateDepartment(input *models.CreateDepartmentInp
 			return nil, ErrDepartmentNotFound
 		}
 		if parentDept.OrganizationID != input.OrganizationID {
-			return nil, fmt.Errorf("parent department belongs to another organization")
+			return nil, fmt.Errorf("%w: parent department belongs to another organization", ErrInvalidDepartment)
+		}
+		if err := s.validateDepartmentDepth(parentDept, 1); err != nil {
+			return nil, err
 		}
 	}
 
-	kind := input.Kind
+	kind := models.DepartmentKind(strings.ToUpper(strings.TrimSpace(string(input.Kind))))
 	if kind == "" {
 		kind = models.DepartmentKindDepartment
 	}
+	if err := s.validateDepartmentKind(kind, parentDept); err != nil {
+		return nil, err
+	}
 
 	dept := &models.Department{
 		OrganizationID: input.OrganizationID,
@@ -159,6 +399,191 @@ func (s *OrganizationService) ListDepartments(orgID *uint64) ([]*models.Departme
 	return s.orgRepo.ListDepartmentsByOrganization(*orgID)
 }
 
+// GetDepartmentByCode looks up a department by its stable code within an organization.
+func (s *OrganizationService) GetDepartmentByCode(orgID uint64, code string) (*models.Department, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, fmt.Errorf("department code is required")
+	}
+
+	dept, err := s.orgRepo.GetDepartmentByCode(orgID, models.DepartmentCode(code))
+	if err != nil {
+		return nil, err
+	}
+	if dept == nil {
+		return nil, ErrDepartmentNotFound
+	}
+	return dept, nil
+}
+
+// ExportStructure builds a portable snapshot of an organization's department tree and role templates.
+func (s *OrganizationService) ExportStructure(orgID uint64) (*models.OrganizationStructureExport, error) {
+	org, err := s.orgRepo.GetOrganizationByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	departments, err := s.orgRepo.ListDepartmentsByOrganization(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[uint64]struct{}, len(departments))
+	for _, dept := range departments {
+		known[dept.ID] = struct{}{}
+	}
+
+	children := make(map[uint64][]*models.Department)
+	var roots []*models.Department
+	for _, dept := range departments {
+		if dept.ParentID != nil {
+			if _, ok := known[*dept.ParentID]; ok {
+				children[*dept.ParentID] = append(children[*dept.ParentID], dept)
+				continue
+			}
+		}
+		roots = append(roots, dept)
+	}
+
+	// Export the organization's provisioned role templates, or the platform defaults if it has none.
+	provisioned, err := s.orgRepo.ListOrganizationRoles(org.ID)
+	if err != nil {
+		return nil, err
+	}
+	roles := make([]models.OrganizationRoleTemplate, 0, len(provisioned))
+	for _, role := range provisioned {
+		roles = append(roles, role.Template())
+	}
+	if len(roles) == 0 {
+		roles = append(roles, models.DefaultOrganizationRoles...)
+	}
+
+	truncated := false
+	return &models.OrganizationStructureExport{
+		Organization: models.OrganizationStructureInfo{
+			ID:          org.ID,
+			Name:        org.Name,
+			Description: org.Description,
+			Domain:      org.Domain,
+		},
+		Departments: buildDepartmentDefinitions(roots, nil, children, s.maxHierarchyDepth(), &truncated),
+		Roles:       roles,
+		Truncated:   truncated,
+	}, nil
+}
+
+// maxHierarchyDepth returns the configured traversal depth cap.
+func (s *OrganizationService) maxHierarchyDepth() int {
+	if s.config == nil || s.config.MaxHierarchyDepth <= 0 {
+		return defaultMaxHierarchyDepth
+	}
+	return s.config.MaxHierarchyDepth
+}
+
+// buildDepartmentDefinitions converts departments into nested definitions, descending at most depth levels.
+// truncated is set when children had to be dropped because the cap was reached.
+func buildDepartmentDefinitions(depts []*models.Department, parent *models.DepartmentCode, children map[uint64][]*models.Department, depth int, truncated *bool) []models.DepartmentDefinition {
+	if len(depts) == 0 {
+		return nil
+	}
+	if depth <= 0 {
+		*truncated = true
+		return nil
+	}
+	defs := make([]models.DepartmentDefinition, 0, len(depts))
+	for _, dept := range depts {
+		isActive := dept.IsActive
+		def := models.DepartmentDefinition{
+			Name:        dept.Name,
+			Kind:        dept.Kind,
+			Description: dept.Description,
+			Function:    dept.Function,
+			IsActive:    &isActive,
+			Parent:      parent,
+		}
+		if dept.Code != nil {
+			def.Code = *dept.Code
+		}
+		var childParent *models.DepartmentCode
+		if def.Code != "" {
+			code := def.Code
+			childParent = &code
+		}
+		def.Children = buildDepartmentDefinitions(children[dept.ID], childParent, children, depth-1, truncated)
+		defs = append(defs, def)
+	}
+	return defs
+}
+
+// ImportStructure recreates an exported department tree under the target organization. The tree is
+// created in one transaction, so a failed import leaves no departments behind.
+func (s *OrganizationService) ImportStructure(orgID uint64, structure *models.OrganizationStructureExport) ([]*models.Department, error) {
+	if structure == nil {
+		return nil, fmt.Errorf("%w: input required", ErrInvalidDepartment)
+	}
+
+	org, err := s.orgRepo.GetOrganizationByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	// A failure part way through the tree rolls back the departments already created
+	var created []*models.Department
+	err = s.orgRepo.WithTx(func(repos *repository.TxRepositories) error {
+		return s.withRepositories(repos).importDepartments(orgID, nil, structure.Departments, s.maxHierarchyDepth(), &created)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// withRepositories returns a copy of the service that reads and writes through the transaction's repositories.
+func (s *OrganizationService) withRepositories(repos *repository.TxRepositories) *OrganizationService {
+	scoped := *s
+	scoped.orgRepo = repos.Organizations
+	scoped.userRepo = repos.Users
+	return &scoped
+}
+
+func (s *OrganizationService) importDepartments(orgID uint64, parentID *uint64, defs []models.DepartmentDefinition, depth int, created *[]*models.Department) error {
+	if len(defs) > 0 && depth <= 0 {
+		return fmt.Errorf("%w: department structure exceeds the maximum depth of %d", ErrInvalidDepartment, s.maxHierarchyDepth())
+	}
+	for _, def := range defs {
+		input := &models.CreateDepartmentInput{
+			OrganizationID: orgID,
+			ParentID:       parentID,
+			Name:           def.Name,
+			Kind:           def.Kind,
+			Description:    def.Description,
+			Function:       def.Function,
+			IsActive:       def.IsActive,
+		}
+		if def.Code != "" {
+			code := def.Code
+			input.Code = &code
+		}
+
+		dept, err := s.CreateDepartment(input)
+		if err != nil {
+			return fmt.Errorf("import department %q: %w", def.Name, err)
+		}
+		*created = append(*created, dept)
+
+		if err := s.importDepartments(orgID, &dept.ID, def.Children, depth-1, created); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // AssignUserToOrganization associates a user with an organization and optionally marks it as primary.
 func (s *OrganizationService) AssignUserToOrganization(input *models.AssignUserOrganizationInput) (*models.UserOrganization, error) {
 	if input == nil {
@@ -187,26 +612,64 @@ func (s *OrganizationService) AssignUserToOrganization(input *models.AssignUserO
 		return nil, ErrOrganizationNotFound
 	}
 
-	if input.IsPrimary {
-		if err := s.orgRepo.ClearPrimaryOrganization(input.UserID); err != nil {
+	previous, err := s.orgRepo.GetUserOrganization(input.UserID, input.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil && input.Role != models.OrganizationRoleOrgAdmin {
+		if err := s.guardLastOrganizationAdmin(input.ActorID, previous); err != nil {
 			return nil, err
 		}
 	}
 
-	if err := s.orgRepo.UpsertUserOrganization(input.UserID, input.OrganizationID, input.Role, input.IsPrimary); err != nil {
-		return nil, err
-	}
+	// Switching the primary organization must not leave the user without one if a step fails
+	err = s.orgRepo.WithTx(func(repos *repository.TxRepositories) error {
+		if input.IsPrimary {
+			if err := repos.Organizations.ClearPrimaryOrganization(input.UserID); err != nil {
+				return err
+			}
+		}
 
-	if input.IsPrimary {
-		if err := s.orgRepo.SetUserPrimaryOrganization(input.UserID, input.OrganizationID); err != nil {
-			return nil, err
+		if err := repos.Organizations.UpsertUserOrganization(input.UserID, input.OrganizationID, input.Role, input.IsPrimary); err != nil {
+			return err
+		}
+
+		if input.IsPrimary {
+			if err := repos.Organizations.SetUserPrimaryOrganization(input.UserID, input.OrganizationID); err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	membership, err := s.orgRepo.GetUserOrganization(input.UserID, input.OrganizationID)
 	if err != nil {
 		return nil, err
 	}
+
+	switch {
+	case previous == nil:
+		s.recordAudit(input.ActorID, models.AuditActionMembershipOrganizationGrant, models.AuditUserRef(input.UserID), input.OrganizationID, map[string]any{
+			"organization_id": input.OrganizationID,
+			"role":            input.Role,
+		})
+	case previous.Role != input.Role:
+		s.recordAudit(input.ActorID, models.AuditActionMembershipRoleChange, models.AuditUserRef(input.UserID), input.OrganizationID, map[string]any{
+			"organization_id": input.OrganizationID,
+			"previous_role":   previous.Role,
+			"role":            input.Role,
+		})
+	}
+	if input.IsPrimary && (user.PrimaryOrganizationID == nil || *user.PrimaryOrganizationID != input.OrganizationID) {
+		s.recordAudit(input.ActorID, models.AuditActionMembershipPrimaryChange, models.AuditUserRef(input.UserID), input.OrganizationID, map[string]any{
+			"organization_id":          input.OrganizationID,
+			"previous_organization_id": user.PrimaryOrganizationID,
+		})
+	}
+	s.expireAccessTokens(input.UserID)
 	return membership, nil
 }
 
@@ -238,6 +701,11 @@ func (s *OrganizationService) AssignUserToDepartment(input *models.AssignUserDep
 		return nil, ErrDepartmentNotFound
 	}
 
+	previous, err := s.orgRepo.GetUserDepartment(*input.UserID, *input.DepartmentID)
+	if err != nil {
+		return nil, err
+	}
+
 	if input.IsPrimary {
 		if err := s.orgRepo.ClearPrimaryDepartment(*input.UserID); err != nil {
 			return nil, err
@@ -258,6 +726,27 @@ func (s *OrganizationService) AssignUserToDepartment(input *models.AssignUserDep
 	if err != nil {
 		return nil, err
 	}
+
+	switch {
+	case previous == nil:
+		s.recordAudit(input.ActorID, models.AuditActionMembershipDepartmentGrant, models.AuditUserRef(*input.UserID), dept.OrganizationID, map[string]any{
+			"department_id": dept.ID,
+			"role":          input.Role,
+		})
+	case previous.Role != input.Role:
+		s.recordAudit(input.ActorID, models.AuditActionMembershipRoleChange, models.AuditUserRef(*input.UserID), dept.OrganizationID, map[string]any{
+			"department_id": dept.ID,
+			"previous_role": previous.Role,
+			"role":          input.Role,
+		})
+	}
+	if input.IsPrimary && (user.PrimaryDepartmentID == nil || *user.PrimaryDepartmentID != dept.ID) {
+		s.recordAudit(input.ActorID, models.AuditActionMembershipPrimaryChange, models.AuditUserRef(*input.UserID), dept.OrganizationID, map[string]any{
+			"department_id":          dept.ID,
+			"previous_department_id": user.PrimaryDepartmentID,
+		})
+	}
+	s.expireAccessTokens(*input.UserID)
 	return membership, nil
 }
 
@@ -277,23 +766,151 @@ func (s *OrganizationService) ListUserDepartments(userID *uint64) ([]*models.Use
 	return s.orgRepo.ListUserDepartments(*userID)
 }
 
-// RemoveUserOrganization removes a user's membership from an organization.
-func (s *OrganizationService) RemoveUserOrganization(userID, orgID *uint64) error {
+// ListAssignableDepartments returns the active departments across the user's organizations
+// that the user is not already a member of.
+func (s *OrganizationService) ListAssignableDepartments(userID *uint64) ([]*models.Department, error) {
+	if userID == nil {
+		return nil, fmt.Errorf("user_id is required")
+	}
+
+	user, err := s.userRepo.GetByID(*userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	orgMemberships, err := s.ListUserOrganizations(userID)
+	if err != nil {
+		return nil, err
+	}
+	deptMemberships, err := s.ListUserDepartments(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[uint64]struct{}, len(deptMemberships))
+	for _, membership := range deptMemberships {
+		current[membership.DepartmentID] = struct{}{}
+	}
+
+	assignable := make([]*models.Department, 0)
+	for _, membership := range orgMemberships {
+		departments, err := s.orgRepo.ListDepartmentsByOrganization(membership.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		for _, dept := range departments {
+			if !dept.IsActive {
+				continue
+			}
+			if _, ok := current[dept.ID]; ok {
+				continue
+			}
+			assignable = append(assignable, dept)
+		}
+	}
+
+	return assignable, nil
+}
+
+// softDeleteMemberships reports whether membership removals keep a soft-deleted row.
+func (s *OrganizationService) softDeleteMemberships() bool {
+	return s.config != nil && s.config.SoftDeleteMemberships()
+}
+
+// RemoveUserOrganization removes a user's membership from an organization. The last SYSTEM_ADMIN of the
+// bootstrap organization cannot be removed, so the platform always keeps an administrator.
+func (s *OrganizationService) RemoveUserOrganization(userID, orgID *uint64, actorID uint64) error {
 	if userID == nil || orgID == nil {
 		return fmt.Errorf("user_id and organization_id are required")
 	}
-	if err := s.orgRepo.RemoveUserOrganization(*userID, *orgID); err != nil {
+	previous, err := s.orgRepo.GetUserOrganization(*userID, *orgID)
+	if err != nil {
 		return err
 	}
+	if previous == nil {
+		return ErrMembershipNotFound
+	}
+	if err := s.guardLastOrganizationAdmin(actorID, previous); err != nil {
+		return err
+	}
+	if err := s.guardLastSystemAdmin(previous); err != nil {
+		return err
+	}
+	if err := s.orgRepo.RemoveUserOrganization(*userID, *orgID, s.softDeleteMemberships()); err != nil {
+		return err
+	}
+	s.recordAudit(actorID, models.AuditActionMembershipOrganizationRevoke, models.AuditUserRef(*userID), *orgID, map[string]any{
+		"organization_id": *orgID,
+	})
+	s.expireAccessTokens(*userID)
 	return nil
 }
 
 // RemoveUserDepartment removes a user's membership from a department.
-func (s *OrganizationService) RemoveUserDepartment(userID, deptID *uint64) error {
+func (s *OrganizationService) RemoveUserDepartment(userID, deptID *uint64, actorID uint64) error {
 	if userID == nil || deptID == nil {
 		return fmt.Errorf("user_id and department_id are required")
 	}
-	return s.orgRepo.RemoveUserDepartment(*userID, *deptID)
+	dept, err := s.orgRepo.GetDepartmentByID(*deptID)
+	if err != nil {
+		return err
+	}
+	if dept == nil {
+		return ErrDepartmentNotFound
+	}
+	membership, err := s.orgRepo.GetUserDepartment(*userID, *deptID)
+	if err != nil {
+		return err
+	}
+	if membership == nil {
+		return ErrMembershipNotFound
+	}
+	if err := s.orgRepo.RemoveUserDepartment(*userID, *deptID, s.softDeleteMemberships()); err != nil {
+		return err
+	}
+	s.recordAudit(actorID, models.AuditActionMembershipDepartmentRevoke, models.AuditUserRef(*userID), dept.OrganizationID, map[string]any{
+		"department_id": *deptID,
+	})
+	s.expireAccessTokens(*userID)
+	return nil
+}
+
+// guardLastSystemAdmin refuses to remove the only SYSTEM_ADMIN membership of the bootstrap organization.
+func (s *OrganizationService) guardLastSystemAdmin(membership *models.UserOrganization) error {
+	if membership.Role != models.OrganizationRoleSystemAdmin {
+		return nil
+	}
+	org, err := s.orgRepo.GetOrganizationByID(membership.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if !s.isBootstrapOrganization(org) {
+		return nil
+	}
+	admins, err := s.orgRepo.CountOrganizationMembersWithRole(membership.OrganizationID, models.OrganizationRoleSystemAdmin)
+	if err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return ErrLastSystemAdmin
+	}
+	return nil
+}
+
+// isBootstrapOrganization reports whether org is the one created by the bootstrap, matched the same way
+// EnsureOrganization finds it: by domain when configured, otherwise by name.
+func (s *OrganizationService) isBootstrapOrganization(org *models.Organization) bool {
+	if org == nil || s.config == nil {
+		return false
+	}
+	if domain := strings.TrimSpace(s.config.BootstrapOrganizationDomain); domain != "" && org.Domain == domain {
+		return true
+	}
+	name := strings.TrimSpace(s.config.BootstrapOrganizationName)
+	return name != "" && org.Name == name
 }
 
 func init() {
@@ -316,6 +933,24 @@ func init() {
 			return nil, fmt.Errorf("component %s has unexpected type %T", constants.ComponentKey.AuthenticationUserRepo, userRepoComponent)
 		}
 
-		return NewOrganizationService(orgRepo, userRepo), nil
+		cfgComponent, ok := app.GetComponent(constants.ComponentKey.AuthenticationConfig)
+		if !ok {
+			return nil, fmt.Errorf("component %s not found", constants.ComponentKey.AuthenticationConfig)
+		}
+		authCfg, ok := cfgComponent.(*config.AuthConfig)
+		if !ok {
+			return nil, fmt.Errorf("component %s has unexpected type %T", constants.ComponentKey.AuthenticationConfig, cfgComponent)
+		}
+
+		auditComponent, ok := app.GetComponent(constants.ComponentKey.AuditRepository)
+		if !ok {
+			return nil, fmt.Errorf("component %s not found", constants.ComponentKey.AuditRepository)
+		}
+		auditRepo, ok := auditComponent.(*repository.AuditRepository)
+		if !ok {
+			return nil, fmt.Errorf("component %s has unexpected type %T", constants.ComponentKey.AuditRepository, auditComponent)
+		}
+
+		return NewOrganizationService(orgRepo, userRepo, auditRepo, authCfg), nil
 	})
 }
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+	"gorm.io/gorm"
+)
+
+func TestAssignUserToOrganizationRollsBackOnFailure(t *testing.T) {
+	env := newTestEnv(t, nil)
+	user := env.createUser(t, "mover", nil)
+	current := env.createOrganization(t, "Current", nil)
+	next := env.createOrganization(t, "Next", nil)
+	env.addMember(t, user, current, models.OrganizationRole("CEO"))
+
+	// Fail the write of the user's primary organization, after the membership row has been upserted
+	errWrite := errors.New("write failed")
+	err := env.db.Callback().Update().Before("gorm:update").Register("test:fail_user_update", func(tx *gorm.DB) {
+		if tx.Statement.Table == "users" {
+			tx.AddError(errWrite)
+		}
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	_, err = env.org.AssignUserToOrganization(&models.AssignUserOrganizationInput{
+		UserID:         user.ID,
+		OrganizationID: next.ID,
+		Role:           models.OrganizationRole("CEO"),
+		IsPrimary:      true,
+	})
+	if !errors.Is(err, errWrite) {
+		t.Fatalf("AssignUserToOrganization() error = %v, want %v", err, errWrite)
+	}
+
+	var count int64
+	if err := env.db.Unscoped().Model(&models.UserOrganization{}).
+		Where("user_id = ? AND organization_id = ?", user.ID, next.ID).Count(&count).Error; err != nil {
+		t.Fatalf("count memberships: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("membership rows for the new organization = %d, want 0", count)
+	}
+	kept, err := env.orgs.GetUserOrganization(user.ID, current.ID)
+	if err != nil || kept == nil {
+		t.Fatalf("GetUserOrganization(current) = %v, %v", kept, err)
+	}
+	if !kept.IsPrimary {
+		t.Fatal("existing membership lost its primary flag")
+	}
+}
+
+func TestRemoveAndReaddMembership(t *testing.T) {
+	tests := []struct {
+		mode     string
+		keptRows int64
+	}{
+		{mode: config.MembershipRemovalHard, keptRows: 0},
+		{mode: config.MembershipRemovalSoft, keptRows: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			env := newTestEnv(t, func(cfg *config.AuthConfig) { cfg.MembershipRemovalMode = tt.mode })
+			user := env.createUser(t, "member", nil)
+			org := env.createOrganization(t, "Acme", nil)
+			env.addMember(t, user, org, models.OrganizationRole("CEO"))
+			dept := &models.Department{OrganizationID: org.ID, Name: "Engineering", IsActive: true}
+			if err := env.db.Create(dept).Error; err != nil {
+				t.Fatalf("create department: %v", err)
+			}
+			assignDept := func() {
+				t.Helper()
+				if _, err := env.org.AssignUserToDepartment(&models.AssignUserDepartmentInput{UserID: &user.ID, DepartmentID: &dept.ID, Role: "MEMBER"}); err != nil {
+					t.Fatalf("AssignUserToDepartment() error = %v", err)
+				}
+			}
+			assignDept()
+
+			if err := env.org.RemoveUserDepartment(&user.ID, &dept.ID, 0); err != nil {
+				t.Fatalf("RemoveUserDepartment() error = %v", err)
+			}
+			if err := env.org.RemoveUserOrganization(&user.ID, &org.ID, 0); err != nil {
+				t.Fatalf("RemoveUserOrganization() error = %v", err)
+			}
+			var orgRows, deptRows int64
+			if err := env.db.Unscoped().Model(&models.UserOrganization{}).Where("user_id = ?", user.ID).Count(&orgRows).Error; err != nil {
+				t.Fatalf("count organization memberships: %v", err)
+			}
+			if err := env.db.Unscoped().Model(&models.UserDepartment{}).Where("user_id = ?", user.ID).Count(&deptRows).Error; err != nil {
+				t.Fatalf("count department memberships: %v", err)
+			}
+			if orgRows != tt.keptRows || deptRows != tt.keptRows {
+				t.Fatalf("rows kept after removal = %d organization, %d department, want %d", orgRows, deptRows, tt.keptRows)
+			}
+			if membership, err := env.orgs.GetUserOrganization(user.ID, org.ID); err != nil || membership != nil {
+				t.Fatalf("GetUserOrganization() after removal = %v, %v, want none", membership, err)
+			}
+
+			if _, err := env.org.AssignUserToOrganization(&models.AssignUserOrganizationInput{UserID: user.ID, OrganizationID: org.ID, Role: models.OrganizationRole("CEO")}); err != nil {
+				t.Fatalf("AssignUserToOrganization() error = %v", err)
+			}
+			assignDept()
+			if membership, err := env.orgs.GetUserOrganization(user.ID, org.ID); err != nil || membership == nil {
+				t.Fatalf("GetUserOrganization() after re-add = %v, %v", membership, err)
+			}
+			if membership, err := env.orgs.GetUserDepartment(user.ID, dept.ID); err != nil || membership == nil {
+				t.Fatalf("GetUserDepartment() after re-add = %v, %v", membership, err)
+			}
+		})
+	}
+}
+
+func TestProvisionOrganizationRolesIsIdempotent(t *testing.T) {
+	env := newTestEnv(t, nil)
+	org := env.createOrganization(t, "Acme", nil)
+	// A default code the organization already customised is left alone
+	custom := models.DefaultOrganizationRoles[0]
+	if _, err := env.orgs.CreateOrganizationRoles([]*models.OrganizationRoleDefinition{
+		{OrganizationID: org.ID, Code: custom.Code, Name: "Board Chair", Level: 7},
+	}); err != nil {
+		t.Fatalf("create custom role: %v", err)
+	}
+
+	created, err := env.org.ProvisionOrganizationRoles(org.ID, 0)
+	if err != nil {
+		t.Fatalf("ProvisionOrganizationRoles() error = %v", err)
+	}
+	if len(created) != len(models.DefaultOrganizationRoles)-1 {
+		t.Fatalf("created %d roles, want %d", len(created), len(models.DefaultOrganizationRoles)-1)
+	}
+	for _, role := range created {
+		if role.Code == custom.Code {
+			t.Fatalf("provisioned %s again", custom.Code)
+		}
+	}
+
+	again, err := env.org.ProvisionOrganizationRoles(org.ID, 0)
+	if err != nil {
+		t.Fatalf("second ProvisionOrganizationRoles() error = %v", err)
+	}
+	if len(again) != 0 {
+		t.Fatalf("second call created %d roles, want none", len(again))
+	}
+
+	roles, err := env.orgs.ListOrganizationRoles(org.ID)
+	if err != nil {
+		t.Fatalf("list roles: %v", err)
+	}
+	if len(roles) != len(models.DefaultOrganizationRoles) {
+		t.Fatalf("organization has %d roles, want %d", len(roles), len(models.DefaultOrganizationRoles))
+	}
+	for _, role := range roles {
+		if role.Code == custom.Code && (role.Name != "Board Chair" || role.Level != 7) {
+			t.Errorf("custom role = %+v, want it unchanged", role)
+		}
+	}
+
+	if _, err := env.org.ProvisionOrganizationRoles(9999, 0); !errors.Is(err, ErrOrganizationNotFound) {
+		t.Errorf("unknown organization error = %v, want %v", err, ErrOrganizationNotFound)
+	}
+}
+
+func TestSetOrganizationActiveCascade(t *testing.T) {
+	// Acme has Sales > Retail, both active, and Archive, already switched off. Globex's Ops must never
+	// be touched.
+	type state struct {
+		active         bool
+		byOrganization bool
+	}
+	tests := []struct {
+		name            string
+		cascade         bool
+		afterDeactivate map[string]state
+	}{
+		{
+			name:    "cascade enabled",
+			cascade: true,
+			afterDeactivate: map[string]state{
+				"Sales":   {active: false, byOrganization: true},
+				"Retail":  {active: false, byOrganization: true},
+				"Archive": {active: false},
+				"Ops":     {active: true},
+			},
+		},
+		{
+			name: "cascade disabled",
+			afterDeactivate: map[string]state{
+				"Sales":   {active: true},
+				"Retail":  {active: true},
+				"Archive": {active: false},
+				"Ops":     {active: true},
+			},
+		},
+	}
+	restored := map[string]state{
+		"Sales":   {active: true},
+		"Retail":  {active: true},
+		"Archive": {active: false},
+		"Ops":     {active: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, func(cfg *config.AuthConfig) { cfg.OrganizationDeactivationCascade = tt.cascade })
+			acme := env.createOrganization(t, "Acme", nil)
+			globex := env.createOrganization(t, "Globex", nil)
+			depts := map[string]*models.Department{}
+			for _, dept := range append(env.departmentChain(t, acme, "Sales", "Retail"), env.departmentChain(t, acme, "Archive")[0], env.departmentChain(t, globex, "Ops")[0]) {
+				depts[dept.Name] = dept
+			}
+			if err := env.db.Model(depts["Archive"]).Update("is_active", false).Error; err != nil {
+				t.Fatalf("deactivate Archive: %v", err)
+			}
+
+			check := func(step string, want map[string]state) {
+				t.Helper()
+				for name, dept := range depts {
+					var stored models.Department
+					if err := env.db.First(&stored, dept.ID).Error; err != nil {
+						t.Fatalf("reload %s: %v", name, err)
+					}
+					if got := (state{stored.IsActive, stored.DeactivatedByOrganization}); got != want[name] {
+						t.Errorf("%s: %s = %+v, want %+v", step, name, got, want[name])
+					}
+				}
+			}
+
+			if _, err := env.org.SetOrganizationActive(acme.ID, false, 0); err != nil {
+				t.Fatalf("SetOrganizationActive(false) error = %v", err)
+			}
+			check("after deactivation", tt.afterDeactivate)
+
+			if _, err := env.org.SetOrganizationActive(acme.ID, true, 0); err != nil {
+				t.Fatalf("SetOrganizationActive(true) error = %v", err)
+			}
+			check("after reactivation", restored)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// exportTree builds the nested structure export used as import input.
+func exportTree() *models.OrganizationStructureExport {
+	return &models.OrganizationStructureExport{
+		Departments: []models.DepartmentDefinition{
+			{Code: "ENG", Name: "Engineering", Kind: models.DepartmentKindDepartment, Children: []models.DepartmentDefinition{
+				{Code: "PLAT", Name: "Platform", Kind: models.DepartmentKindDepartment},
+				{Name: "Mobile", Kind: models.DepartmentKindDepartment},
+			}},
+			{Code: "FIN", Name: "Finance", Kind: models.DepartmentKindDepartment},
+		},
+	}
+}
+
+func TestStructureExportImportRoundTrip(t *testing.T) {
+	env := newTestEnv(t, nil)
+	source := env.createOrganization(t, "Source", nil)
+	if _, err := env.org.ImportStructure(source.ID, exportTree()); err != nil {
+		t.Fatalf("seed ImportStructure() error = %v", err)
+	}
+
+	exported, err := env.org.ExportStructure(source.ID)
+	if err != nil {
+		t.Fatalf("ExportStructure() error = %v", err)
+	}
+	target := env.createOrganization(t, "Target", nil)
+	created, err := env.org.ImportStructure(target.ID, exported)
+	if err != nil {
+		t.Fatalf("ImportStructure() error = %v", err)
+	}
+	if len(created) != 4 {
+		t.Fatalf("ImportStructure() created %d departments, want 4", len(created))
+	}
+
+	reexported, err := env.org.ExportStructure(target.ID)
+	if err != nil {
+		t.Fatalf("ExportStructure(target) error = %v", err)
+	}
+	if !reflect.DeepEqual(reexported.Departments, exported.Departments) {
+		t.Fatalf("round-tripped departments = %+v, want %+v", reexported.Departments, exported.Departments)
+	}
+}
+
+func TestImportStructureRollsBackOnFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		mutate   func(structure *models.OrganizationStructureExport)
+		maxDepth int
+	}{
+		{
+			name: "nameless department after others were created",
+			mutate: func(structure *models.OrganizationStructureExport) {
+				structure.Departments[1].Name = " "
+			},
+		},
+		{
+			name: "tree deeper than the cap",
+			mutate: func(structure *models.OrganizationStructureExport) {
+				structure.Departments[0].Children[0].Children = []models.DepartmentDefinition{{Name: "Too deep"}}
+			},
+			maxDepth: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, nil)
+			if tt.maxDepth > 0 {
+				env.cfg.MaxHierarchyDepth = tt.maxDepth
+			}
+			org := env.createOrganization(t, "Acme", nil)
+			structure := exportTree()
+			tt.mutate(structure)
+
+			created, err := env.org.ImportStructure(org.ID, structure)
+			if !errors.Is(err, ErrInvalidDepartment) {
+				t.Fatalf("ImportStructure() error = %v, want %v", err, ErrInvalidDepartment)
+			}
+			if created != nil {
+				t.Errorf("ImportStructure() returned %d departments for a failed import", len(created))
+			}
+			var count int64
+			if err := env.db.Model(&models.Department{}).Where("organization_id = ?", org.ID).Count(&count).Error; err != nil {
+				t.Fatalf("count departments: %v", err)
+			}
+			if count != 0 {
+				t.Fatalf("failed import left %d departments behind", count)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+var (
+	ErrNotOrganizationMember = errors.New("user is not a member of the organization")
+	ErrNotDepartmentMember   = errors.New("user is not a member of the department in this organization")
+)
+
+// SwitchOrganization issues new tokens for another organization the user belongs to, and optionally
+// one of their departments in it. The same checks as at login apply, and the new refresh token keeps
+// the switched context.
+func (s *AuthenticationService) SwitchOrganization(userID uint64, req *models.SwitchOrganizationRequest) (*models.SwitchOrganizationResponse, error) {
+	if req == nil || req.OrganizationID == 0 {
+		return nil, ErrOrganizationRequired
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		return nil, ErrInvalidToken
+	}
+
+	orgMemberships, deptMemberships, err := s.collectMemberships(&user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasOrganizationMembership(orgMemberships, req.OrganizationID) {
+		return nil, ErrNotOrganizationMember
+	}
+	if req.DepartmentID != 0 && !hasDepartmentMembership(deptMemberships, req.DepartmentID, req.OrganizationID) {
+		return nil, ErrNotDepartmentMember
+	}
+
+	org, dept, err := s.resolveLoginContext(user, req.OrganizationID, req.DepartmentID, 0, orgMemberships, deptMemberships)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.generateAccessToken(user, org, dept, orgMemberships, deptMemberships)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := s.generateRefreshToken(user, org, dept)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata map[string]any
+	if dept != nil {
+		metadata = map[string]any{"department_id": dept.ID}
+	}
+	orgID := org.ID
+	s.recordAudit(&models.AuditEvent{
+		Actor:    models.AuditUserRef(user.ID),
+		Action:   models.AuditActionOrganizationSwitch,
+		Target:   models.AuditUserRef(user.ID),
+		OrgID:    &orgID,
+		Success:  true,
+		Metadata: metadata,
+	})
+
+	return &models.SwitchOrganizationResponse{
+		AccessToken:        accessToken,
+		RefreshToken:       refreshToken,
+		ExpiresIn:          int(s.config.TokenExpiration.Seconds()),
+		TokenType:          "Bearer",
+		LoggedOrganization: org,
+		LoggedDepartment:   dept,
+	}, nil
+}
+
+// hasOrganizationMembership reports whether memberships include orgID.
+func hasOrganizationMembership(memberships []*models.UserOrganization, orgID uint64) bool {
+	for _, membership := range memberships {
+		if membership != nil && membership.OrganizationID == orgID {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDepartmentMembership reports whether memberships include deptID within orgID.
+func hasDepartmentMembership(memberships []*models.UserDepartment, deptID, orgID uint64) bool {
+	for _, membership := range memberships {
+		if membership == nil || membership.DepartmentID != deptID {
+			continue
+		}
+		return membership.Department != nil && membership.Department.OrganizationID == orgID
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// breachCacheMaxPrefixes bounds the number of cached range responses.
+const breachCacheMaxPrefixes = 4096
+
+var (
+	ErrPasswordBreached       = errors.New("password has appeared in a known data breach; choose a different password")
+	ErrBreachCheckUnavailable = errors.New("password breach check is unavailable")
+)
+
+// PasswordBreachChecker reports how many times a password appears in known breaches.
+type PasswordBreachChecker interface {
+	BreachCount(password string) (int, error)
+}
+
+// RangeBreachChecker queries a k-anonymity range API such as Pwned Passwords. Only the first five
+// hex characters of the password's SHA-1 hash leave the service; the matching suffixes are compared
+// locally. Range responses are cached per prefix.
+type RangeBreachChecker struct {
+	url      string
+	client   *http.Client
+	cacheTTL time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]breachRange
+}
+
+type breachRange struct {
+	counts    map[string]int
+	fetchedAt time.Time
+}
+
+// NewRangeBreachChecker returns a checker for the range API at baseURL, e.g. "https://api.pwnedpasswords.com/range".
+func NewRangeBreachChecker(baseURL string, cacheTTL time.Duration) *RangeBreachChecker {
+	return &RangeBreachChecker{
+		url:      strings.TrimRight(strings.TrimSpace(baseURL), "/"),
+		client:   &http.Client{Timeout: 5 * time.Second},
+		cacheTTL: cacheTTL,
+		now:      time.Now,
+		cache:    make(map[string]breachRange),
+	}
+}
+
+// BreachCount returns the breach count of the password, or zero when it is not listed.
+func (c *RangeBreachChecker) BreachCount(password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	counts, err := c.lookup(prefix)
+	if err != nil {
+		return 0, err
+	}
+	return counts[suffix], nil
+}
+
+func (c *RangeBreachChecker) lookup(prefix string) (map[string]int, error) {
+	c.mu.Lock()
+	cached, ok := c.cache[prefix]
+	c.mu.Unlock()
+	if ok && c.now().Sub(cached.fetchedAt) < c.cacheTTL {
+		return cached.counts, nil
+	}
+
+	counts, err := c.fetch(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if len(c.cache) >= breachCacheMaxPrefixes {
+		c.cache = make(map[string]breachRange)
+	}
+	c.cache[prefix] = breachRange{counts: counts, fetchedAt: c.now()}
+	c.mu.Unlock()
+	return counts, nil
+}
+
+func (c *RangeBreachChecker) fetch(prefix string) (map[string]int, error) {
+	req, err := http.NewRequest(http.MethodGet, c.url+"/"+prefix, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Padding hides the real number of suffixes in the response
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("breach range API responded with status %d", resp.StatusCode)
+	}
+
+	counts := make(map[string]int)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		suffix, count, found := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !found {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil || n <= 0 {
+			continue
+		}
+		counts[strings.ToUpper(suffix)] = n
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read breach range response: %w", err)
+	}
+	return counts, nil
+}
+
+// WithPasswordBreachChecker enables rejecting passwords found in known breaches.
+func (s *AuthenticationService) WithPasswordBreachChecker(checker PasswordBreachChecker) *AuthenticationService {
+	s.breaches = checker
+	return s
+}
+
+// checkPasswordBreach rejects breached passwords. When the lookup fails the password is allowed
+// unless PasswordBreachFailClosed is set.
+func (s *AuthenticationService) checkPasswordBreach(password string) error {
+	if s.breaches == nil {
+		return nil
+	}
+
+	count, err := s.breaches.BreachCount(password)
+	if err != nil {
+		if s.config.PasswordBreachFailClosed {
+			return fmt.Errorf("%w: %v", ErrBreachCheckUnavailable, err)
+		}
+		log.Printf("password breach check failed, allowing password: %v", err)
+		return nil
+	}
+	if count > 0 {
+		return ErrPasswordBreached
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lee-tech/authentication/config"
+)
+
+const breachedPassword = "password123"
+
+// rangeServer stubs the range API. It lists breachedPassword under its prefix, or answers every request
+// with status when it is not 200, and counts the requests it receives.
+type rangeServer struct {
+	*httptest.Server
+	requests atomic.Int32
+	paths    chan string
+}
+
+func newRangeServer(t *testing.T, status int) *rangeServer {
+	t.Helper()
+
+	sum := sha1.Sum([]byte(breachedPassword))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	srv := &rangeServer{paths: make(chan string, 16)}
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		srv.requests.Add(1)
+		srv.paths <- r.URL.Path
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		if strings.TrimPrefix(r.URL.Path, "/range/") == hash[:5] {
+			fmt.Fprintf(w, "0018A45C4D1DEF81644B54AB7F969B88D65:0\r\n%s:3861493\r\n", hash[5:])
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRangeBreachChecker(t *testing.T) {
+	srv := newRangeServer(t, http.StatusOK)
+	env := newTestEnv(t, nil)
+	env.auth.WithPasswordBreachChecker(NewRangeBreachChecker(srv.URL+"/range", time.Hour))
+
+	if err := env.auth.checkPasswordBreach(breachedPassword); !errors.Is(err, ErrPasswordBreached) {
+		t.Fatalf("checkPasswordBreach(listed) error = %v, want %v", err, ErrPasswordBreached)
+	}
+	sum := sha1.Sum([]byte(breachedPassword))
+	prefix := strings.ToUpper(hex.EncodeToString(sum[:]))[:5]
+	if path := <-srv.paths; path != "/range/"+prefix {
+		t.Fatalf("request path = %q, want only the hash prefix %q", path, "/range/"+prefix)
+	}
+
+	if err := env.auth.checkPasswordBreach("an unlisted passphrase"); err != nil {
+		t.Fatalf("checkPasswordBreach(unlisted) error = %v, want nil", err)
+	}
+	<-srv.paths
+
+	before := srv.requests.Load()
+	if err := env.auth.checkPasswordBreach(breachedPassword); !errors.Is(err, ErrPasswordBreached) {
+		t.Fatalf("checkPasswordBreach(cached) error = %v, want %v", err, ErrPasswordBreached)
+	}
+	if got := srv.requests.Load(); got != before {
+		t.Fatalf("cached prefix made %d more requests, want 0", got-before)
+	}
+}
+
+func TestRangeBreachCheckerUnavailable(t *testing.T) {
+	tests := []struct {
+		name       string
+		failClosed bool
+		wantErr    error
+	}{
+		{name: "fails open by default"},
+		{name: "fails closed when configured", failClosed: true, wantErr: ErrBreachCheckUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newRangeServer(t, http.StatusServiceUnavailable)
+			env := newTestEnv(t, func(cfg *config.AuthConfig) { cfg.PasswordBreachFailClosed = tt.failClosed })
+			env.auth.WithPasswordBreachChecker(NewRangeBreachChecker(srv.URL+"/range", time.Hour))
+
+			err := env.auth.checkPasswordBreach(breachedPassword)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("checkPasswordBreach() error = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("checkPasswordBreach() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrCurrentPasswordIncorrect = errors.New("current password is incorrect")
+	ErrPasswordUnchanged        = errors.New("new password must differ from the current password")
+)
+
+// ChangePassword replaces the password of a signed-in user after re-verifying the current one. When
+// revokeSessions is set, every token issued to the user so far stops working, including the caller's.
+func (s *AuthenticationService) ChangePassword(userID uint64, input *models.ChangePasswordRequest) error {
+	if err := s.ValidatePassword(input.NewPassword); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.CurrentPassword)) != nil {
+		return ErrCurrentPasswordIncorrect
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.NewPassword)) == nil {
+		return ErrPasswordUnchanged
+	}
+	if err := s.checkPasswordHistory(user, input.NewPassword); err != nil {
+		return err
+	}
+	if err := s.checkPasswordBreach(input.NewPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), s.config.BCryptCost)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	previousHash := user.Password
+	user.Password = string(hashedPassword)
+	user.MustChangePassword = false
+	if input.ShouldRevokeSessions() {
+		user.TokensValidAfter = &now
+	}
+	if err := s.replacePassword(user.ID, previousHash, now, func(users *repository.UserRepository) error {
+		return users.Update(user)
+	}); err != nil {
+		return fmt.Errorf("change password: %w", err)
+	}
+
+	event := &models.AuditEvent{
+		Actor:     models.AuditUserRef(user.ID),
+		Action:    models.AuditActionPasswordChange,
+		Target:    models.AuditUserRef(user.ID),
+		Success:   true,
+		Timestamp: now,
+		Metadata:  map[string]any{"sessions_revoked": input.ShouldRevokeSessions()},
+	}
+	s.recordAudit(event)
+	s.notifySecurityEvent(event)
+	return nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrPasswordReused is returned when a new password matches the current or a recently used password.
+var ErrPasswordReused = errors.New("password was used recently")
+
+// checkPasswordHistory refuses a new password that matches the user's current password or one of the
+// last PasswordHistorySize passwords. It is a no-op when the history is disabled.
+func (s *AuthenticationService) checkPasswordHistory(user *models.User, newPassword string) error {
+	size := s.config.PasswordHistorySize
+	if size <= 0 {
+		return nil
+	}
+
+	hashes, err := s.userRepo.ListPasswordHistory(user.ID, size)
+	if err != nil {
+		return fmt.Errorf("load password history: %w", err)
+	}
+	for _, hash := range append([]string{user.Password}, hashes...) {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(newPassword)) == nil {
+			return fmt.Errorf("%w: choose a password different from your current and last %d passwords", ErrPasswordReused, size)
+		}
+	}
+	return nil
+}
+
+// replacePassword runs update and, when the history is enabled, records the replaced hash and trims the
+// history in the same transaction.
+func (s *AuthenticationService) replacePassword(userID uint64, previousHash string, at time.Time, update func(users *repository.UserRepository) error) error {
+	size := s.config.PasswordHistorySize
+	if size <= 0 || previousHash == "" {
+		return update(s.userRepo)
+	}
+	return s.userRepo.WithTx(func(repos *repository.TxRepositories) error {
+		if err := update(repos.Users); err != nil {
+			return err
+		}
+		if err := repos.Users.PushPasswordHistory(userID, previousHash, at, size); err != nil {
+			return fmt.Errorf("record password history: %w", err)
+		}
+		return nil
+	})
+}
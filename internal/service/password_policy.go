@@ -0,0 +1,100 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ErrPasswordTooWeak is returned when a password is long enough but too predictable.
+	ErrPasswordTooWeak = errors.New("password is too predictable")
+	// ErrPasswordComplexity is returned when a password lacks a required character class.
+	ErrPasswordComplexity = errors.New("password does not meet the complexity policy")
+	// ErrPasswordCommon is returned when a password is on the common password list.
+	ErrPasswordCommon = errors.New("password is too common")
+)
+
+// ValidatePassword applies the local password policy: PasswordMinLength, the required character
+// classes, the common password list when PasswordRejectCommon is set and, when PasswordMinEntropyBits
+// is set, a minimum Shannon entropy. The breach check is separate because it may call an external
+// service.
+func (s *AuthenticationService) ValidatePassword(password string) error {
+	minLength := s.config.PasswordMinLength
+	if minLength <= 0 {
+		minLength = 8
+	}
+	if len(password) < minLength {
+		return fmt.Errorf("%w: must be at least %d characters", ErrPasswordTooShort, minLength)
+	}
+
+	if missing := s.missingPasswordClasses(password); len(missing) > 0 {
+		return fmt.Errorf("%w: must contain %s", ErrPasswordComplexity, joinPasswordRules(missing))
+	}
+	if s.config.PasswordRejectCommon && isCommonPassword(password) {
+		return fmt.Errorf("%w: choose a password that is not on lists of frequently used passwords", ErrPasswordCommon)
+	}
+
+	if minBits := s.config.PasswordMinEntropyBits; minBits > 0 && passwordEntropyBits(password) < float64(minBits) {
+		return fmt.Errorf("%w: avoid repeated characters and use a longer mix of words, digits and symbols", ErrPasswordTooWeak)
+	}
+	return nil
+}
+
+// missingPasswordClasses names the required character classes the password lacks.
+func (s *AuthenticationService) missingPasswordClasses(password string) []string {
+	var hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	var missing []string
+	if s.config.PasswordRequireUpper && !hasUpper {
+		missing = append(missing, "an uppercase letter")
+	}
+	if s.config.PasswordRequireDigit && !hasDigit {
+		missing = append(missing, "a digit")
+	}
+	if s.config.PasswordRequireSymbol && !hasSymbol {
+		missing = append(missing, "a symbol")
+	}
+	return missing
+}
+
+// joinPasswordRules lists rules as "a", "a and b" or "a, b and c".
+func joinPasswordRules(rules []string) string {
+	if len(rules) == 1 {
+		return rules[0]
+	}
+	return strings.Join(rules[:len(rules)-1], ", ") + " and " + rules[len(rules)-1]
+}
+
+// passwordEntropyBits estimates a password's entropy as its Shannon entropy per character times its
+// length, so repeated characters add little: "Aaaaaaa1!" scores about 13 bits.
+func passwordEntropyBits(password string) float64 {
+	counts := make(map[rune]int)
+	length := 0
+	for _, r := range password {
+		counts[r]++
+		length++
+	}
+	if length == 0 {
+		return 0
+	}
+
+	perChar := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(length)
+		perChar -= p * math.Log2(p)
+	}
+	return perChar * float64(length)
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/lee-tech/authentication/config"
+)
+
+func TestPasswordEntropyBits(t *testing.T) {
+	tests := []struct {
+		password string
+		want     float64
+	}{
+		{"", 0},
+		{"aaaaaaaa", 0},
+		{"aabbccdd", 16},
+		{"abcdefga", 22},
+		{"abcdefgh", 24},
+		{"abcdefghijklmnop", 64},
+	}
+	for _, tt := range tests {
+		if got := passwordEntropyBits(tt.password); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("passwordEntropyBits(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePasswordEntropyThreshold(t *testing.T) {
+	tests := []struct {
+		name     string
+		minBits  int
+		password string
+		wantErr  error
+	}{
+		{name: "below the threshold", minBits: 24, password: "abcdefga", wantErr: ErrPasswordTooWeak},
+		{name: "at the threshold", minBits: 24, password: "abcdefgh"},
+		{name: "above the threshold", minBits: 24, password: "abcdefghi"},
+		{name: "one bit short", minBits: 25, password: "abcdefgh", wantErr: ErrPasswordTooWeak},
+		{name: "repeated characters", minBits: 24, password: "aaaabbbbcccc", wantErr: ErrPasswordTooWeak},
+		{name: "rule disabled", password: "aaaaaaaa"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, func(cfg *config.AuthConfig) {
+				cfg.PasswordMinLength = 8
+				cfg.PasswordRequireUpper = false
+				cfg.PasswordRequireDigit = false
+				cfg.PasswordRequireSymbol = false
+				cfg.PasswordRejectCommon = false
+				cfg.PasswordMinEntropyBits = tt.minBits
+			})
+			if err := env.auth.ValidatePassword(tt.password); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidatePassword(%q) error = %v, want %v", tt.password, err, tt.wantErr)
+			}
+		})
+	}
+}
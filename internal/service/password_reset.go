@@ -0,0 +1,129 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+	ErrPasswordTooShort  = errors.New("password is too short")
+)
+
+// PasswordResetSender delivers a password reset token to a user, typically by email.
+type PasswordResetSender interface {
+	SendPasswordReset(user *models.User, token string) error
+}
+
+// WithPasswordResetSender sets the channel used to deliver password reset tokens.
+func (s *AuthenticationService) WithPasswordResetSender(sender PasswordResetSender) *AuthenticationService {
+	s.resets = sender
+	return s
+}
+
+// RequestPasswordReset issues a reset token valid for PasswordResetTTL to the active account owning the
+// email. Every request for an existing account stamps LastPasswordResetRequestedAt. Unknown or inactive
+// accounts get no token so callers respond identically either way.
+func (s *AuthenticationService) RequestPasswordReset(email string) error {
+	user, err := s.findUserByEmail(email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+	now := s.now()
+	if err := s.userRepo.UpdatePasswordResetRequestedAt(user.ID, now); err != nil {
+		return fmt.Errorf("record password reset request: %w", err)
+	}
+	if !user.IsActive {
+		return nil
+	}
+
+	token, err := generateVerificationToken()
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.SetPasswordResetToken(user.ID, hashToken(token), now.Add(s.config.PasswordResetTTL)); err != nil {
+		return fmt.Errorf("store password reset token: %w", err)
+	}
+	s.recordAudit(&models.AuditEvent{
+		Actor:     models.AuditUserRef(user.ID),
+		Action:    models.AuditActionPasswordResetRequest,
+		Target:    models.AuditUserRef(user.ID),
+		Success:   true,
+		Timestamp: now,
+	})
+
+	if s.resets == nil {
+		log.Printf("password reset requested for user %d but no delivery channel is configured", user.ID)
+		return nil
+	}
+	if err := s.resets.SendPasswordReset(user, token); err != nil {
+		log.Printf("failed to send password reset to user %d: %v", user.ID, err)
+	}
+	return nil
+}
+
+// ConfirmPasswordReset sets a new password for the holder of an unexpired reset token. The token is
+// single use, and tokens issued to the user before the reset stop working.
+func (s *AuthenticationService) ConfirmPasswordReset(token, newPassword string) error {
+	if err := s.ValidatePassword(newPassword); err != nil {
+		return err
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return ErrInvalidResetToken
+	}
+	user, err := s.userRepo.GetByPasswordResetToken(hashToken(token))
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	if user == nil || user.PasswordResetExpiry == nil || !now.Before(*user.PasswordResetExpiry) {
+		return ErrInvalidResetToken
+	}
+
+	if err := s.checkPasswordHistory(user, newPassword); err != nil {
+		return err
+	}
+	if err := s.checkPasswordBreach(newPassword); err != nil {
+		return err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), s.config.BCryptCost)
+	if err != nil {
+		return err
+	}
+	if err := s.replacePassword(user.ID, user.Password, now, func(users *repository.UserRepository) error {
+		return users.ResetPassword(user.ID, string(hashedPassword), now)
+	}); err != nil {
+		return fmt.Errorf("reset password: %w", err)
+	}
+
+	event := &models.AuditEvent{
+		Actor:     models.AuditUserRef(user.ID),
+		Action:    models.AuditActionPasswordReset,
+		Target:    models.AuditUserRef(user.ID),
+		Success:   true,
+		Timestamp: now,
+	}
+	s.recordAudit(event)
+	s.notifySecurityEvent(event)
+	return nil
+}
+
+// hashToken returns the digest stored in place of a password reset or email verification token, so a
+// database leak does not expose usable tokens.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
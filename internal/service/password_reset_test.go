@@ -0,0 +1,54 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRequestPasswordResetRecordsRequestTime(t *testing.T) {
+	tests := []struct {
+		name      string
+		email     string
+		active    bool
+		wantToken bool
+	}{
+		{name: "active account", email: "reset@example.com", active: true, wantToken: true},
+		{name: "inactive account", email: "reset@example.com", active: false},
+		{name: "unknown email", email: "nobody@example.com", active: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, nil)
+			sender := newRecordingSender()
+			clock := time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)
+			env.auth.WithPasswordResetSender(sender).WithClock(func() time.Time { return clock })
+			user := env.createUser(t, "reset", nil)
+			if !tt.active {
+				// Updated after insert, since the column default replaces a false IsActive on create
+				if err := env.db.Model(user).Update("is_active", false).Error; err != nil {
+					t.Fatalf("deactivate user: %v", err)
+				}
+			}
+			known := tt.email == user.Email
+
+			for i := 0; i < 2; i++ {
+				if err := env.auth.RequestPasswordReset(tt.email); err != nil {
+					t.Fatalf("RequestPasswordReset() error = %v", err)
+				}
+				requested := env.reloadUser(t, user.ID).LastPasswordResetRequestedAt
+				switch {
+				case !known && requested != nil:
+					t.Fatalf("request for an unknown email stamped %v on another account", requested)
+				case known && (requested == nil || !requested.Equal(clock)):
+					t.Fatalf("request %d: LastPasswordResetRequestedAt = %v, want %v", i+1, requested, clock)
+				}
+				clock = clock.Add(time.Hour)
+			}
+
+			if _, sent := sender.resets[user.ID]; sent != tt.wantToken {
+				t.Errorf("reset token delivered = %v, want %v", sent, tt.wantToken)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// maxProfileNameLength bounds first and last names.
+const maxProfileNameLength = 100
+
+// ErrInvalidProfile is wrapped by every profile validation failure.
+var ErrInvalidProfile = errors.New("invalid profile")
+
+// UpdateProfile applies the supplied name fields to the user's own profile and returns the refreshed
+// user info. Account, role and membership fields are not editable here.
+func (s *AuthenticationService) UpdateProfile(userID uint64, input *models.UpdateProfileRequest) (*models.UserInfo, error) {
+	if input == nil {
+		return nil, fmt.Errorf("input required")
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	changed := false
+	if input.FirstName != nil {
+		name, err := profileName("first_name", *input.FirstName)
+		if err != nil {
+			return nil, err
+		}
+		user.FirstName = name
+		changed = true
+	}
+	if input.LastName != nil {
+		name, err := profileName("last_name", *input.LastName)
+		if err != nil {
+			return nil, err
+		}
+		user.LastName = name
+		changed = true
+	}
+	if changed {
+		if err := s.userRepo.Update(user); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetUserInfoByID(userID)
+}
+
+// profileName trims and validates a name field.
+func profileName(field, value string) (string, error) {
+	name := strings.TrimSpace(value)
+	if name == "" {
+		return "", fmt.Errorf("%w: %s must not be empty", ErrInvalidProfile, field)
+	}
+	if len(name) > maxProfileNameLength {
+		return "", fmt.Errorf("%w: %s must be at most %d characters", ErrInvalidProfile, field, maxProfileNameLength)
+	}
+	return name, nil
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// refreshClaims decodes a refresh token issued by the service.
+func refreshClaims(t *testing.T, env *testEnv, token string) jwt.MapClaims {
+	t.Helper()
+
+	parsed, err := jwt.Parse(token, env.auth.TokenKeyFunc)
+	if err != nil {
+		t.Fatalf("parse refresh token: %v", err)
+	}
+	return parsed.Claims.(jwt.MapClaims)
+}
+
+// refreshFixture is a user who belongs to a primary and a secondary organization and to a department
+// of the secondary one.
+type refreshFixture struct {
+	user      *models.User
+	primary   *models.Organization
+	secondary *models.Organization
+	dept      *models.Department
+}
+
+func newRefreshFixture(t *testing.T, env *testEnv) *refreshFixture {
+	t.Helper()
+
+	primary := env.createOrganization(t, "Primary", nil)
+	secondary := env.createOrganization(t, "Secondary", nil)
+	user := env.createUser(t, "ada", func(u *models.User) { u.PrimaryOrganizationID = &primary.ID })
+	env.addMember(t, user, primary, "CHAIRMAN")
+	env.addMember(t, user, secondary, "CEO")
+
+	dept := &models.Department{OrganizationID: secondary.ID, Name: "Engineering", IsActive: true}
+	if err := env.db.Create(dept).Error; err != nil {
+		t.Fatalf("create department: %v", err)
+	}
+	if err := env.db.Create(&models.UserDepartment{UserID: user.ID, DepartmentID: dept.ID}).Error; err != nil {
+		t.Fatalf("add department member: %v", err)
+	}
+	return &refreshFixture{user: user, primary: primary, secondary: secondary, dept: dept}
+}
+
+// loginSecondary logs the fixture user into the secondary organization and its department.
+func (f *refreshFixture) loginSecondary(t *testing.T, env *testEnv) *models.LoginResponse {
+	t.Helper()
+
+	response, err := env.auth.Login(&models.LoginRequest{
+		Username:       f.user.Username,
+		Password:       testPassword,
+		OrganizationID: f.secondary.ID,
+		DepartmentID:   f.dept.ID,
+	})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	return response
+}
+
+func TestRefreshTokenKeepsLoginContext(t *testing.T) {
+	env := newTestEnv(t, nil)
+	fixture := newRefreshFixture(t, env)
+	login := fixture.loginSecondary(t, env)
+
+	claims := refreshClaims(t, env, login.RefreshToken)
+	if claimUint64(claims, "org_id") != fixture.secondary.ID || claimUint64(claims, "dept_id") != fixture.dept.ID {
+		t.Fatalf("refresh token claims org_id=%v dept_id=%v", claims["org_id"], claims["dept_id"])
+	}
+
+	refreshed, err := env.auth.RefreshToken(login.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	if refreshed.LoggedOrganization == nil || refreshed.LoggedOrganization.ID != fixture.secondary.ID {
+		t.Fatalf("LoggedOrganization = %+v, want %d", refreshed.LoggedOrganization, fixture.secondary.ID)
+	}
+	if refreshed.LoggedDepartment == nil || refreshed.LoggedDepartment.ID != fixture.dept.ID {
+		t.Fatalf("LoggedDepartment = %+v, want %d", refreshed.LoggedDepartment, fixture.dept.ID)
+	}
+	access := refreshClaims(t, env, refreshed.AccessToken)
+	if claimUint64(access, "org_id") != fixture.secondary.ID {
+		t.Fatalf("access token org_id = %v, want %d", access["org_id"], fixture.secondary.ID)
+	}
+	again := refreshClaims(t, env, refreshed.RefreshToken)
+	if claimUint64(again, "org_id") != fixture.secondary.ID || claimUint64(again, "dept_id") != fixture.dept.ID {
+		t.Fatalf("reissued refresh token lost the context: %v", again)
+	}
+}
+
+func TestRefreshTokenAfterSwitchOrganization(t *testing.T) {
+	env := newTestEnv(t, nil)
+	fixture := newRefreshFixture(t, env)
+
+	login, err := env.auth.Login(&models.LoginRequest{Username: fixture.user.Username, Password: testPassword, OrganizationID: fixture.primary.ID})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	switched, err := env.auth.SwitchOrganization(fixture.user.ID, &models.SwitchOrganizationRequest{OrganizationID: fixture.secondary.ID})
+	if err != nil {
+		t.Fatalf("SwitchOrganization() error = %v", err)
+	}
+	if switched.RefreshToken == "" {
+		t.Fatal("SwitchOrganization() issued no refresh token")
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  uint64
+	}{
+		{name: "refresh token from login", token: login.RefreshToken, want: fixture.primary.ID},
+		{name: "refresh token from switch", token: switched.RefreshToken, want: fixture.secondary.ID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refreshed, err := env.auth.RefreshToken(tt.token)
+			if err != nil {
+				t.Fatalf("RefreshToken() error = %v", err)
+			}
+			if refreshed.LoggedOrganization == nil || refreshed.LoggedOrganization.ID != tt.want {
+				t.Fatalf("LoggedOrganization = %+v, want %d", refreshed.LoggedOrganization, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenAppliesLoginChecks(t *testing.T) {
+	minLevel := 1
+
+	tests := []struct {
+		name    string
+		change  func(t *testing.T, env *testEnv, f *refreshFixture)
+		wantErr error
+	}{
+		{
+			name: "organization deactivated",
+			change: func(t *testing.T, env *testEnv, f *refreshFixture) {
+				env.db.Model(f.secondary).Update("is_active", false)
+			},
+			wantErr: ErrOrganizationInactive,
+		},
+		{
+			name: "department deactivated",
+			change: func(t *testing.T, env *testEnv, f *refreshFixture) {
+				env.db.Model(f.dept).Update("is_active", false)
+			},
+			wantErr: ErrDepartmentInactive,
+		},
+		{
+			name: "login policy raised above the member's role",
+			change: func(t *testing.T, env *testEnv, f *refreshFixture) {
+				env.db.Model(f.secondary).Update("min_login_role_level", minLevel)
+			},
+			wantErr: ErrInsufficientRole,
+		},
+		{
+			name: "account locked",
+			change: func(t *testing.T, env *testEnv, f *refreshFixture) {
+				if err := env.users.LockAccount(f.user.ID, time.Now().Add(time.Hour)); err != nil {
+					t.Fatalf("lock account: %v", err)
+				}
+			},
+			wantErr: ErrAccountLocked,
+		},
+		{
+			name: "membership removed",
+			change: func(t *testing.T, env *testEnv, f *refreshFixture) {
+				env.db.Unscoped().Where("user_id = ? AND organization_id = ?", f.user.ID, f.secondary.ID).Delete(&models.UserOrganization{})
+			},
+			wantErr: ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, nil)
+			fixture := newRefreshFixture(t, env)
+			login := fixture.loginSecondary(t, env)
+
+			tt.change(t, env, fixture)
+			if _, err := env.auth.RefreshToken(login.RefreshToken); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RefreshToken() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenAfterRoleChange(t *testing.T) {
+	env := newTestEnv(t, nil)
+	fixture := newRefreshFixture(t, env)
+	login := fixture.loginSecondary(t, env)
+
+	// The change lands in the same second as the login, which must not let the old token through
+	if _, err := env.org.AssignUserToOrganization(&models.AssignUserOrganizationInput{
+		UserID:         fixture.user.ID,
+		OrganizationID: fixture.secondary.ID,
+		Role:           models.OrganizationRole("CHAIRMAN"),
+	}); err != nil {
+		t.Fatalf("AssignUserToOrganization() error = %v", err)
+	}
+	if _, err := env.auth.ValidateTokenDetailed(login.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("ValidateTokenDetailed(stale access token) error = %v, want %v", err, ErrInvalidToken)
+	}
+
+	refreshed, err := env.auth.RefreshToken(login.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshToken() error = %v", err)
+	}
+	if _, err := env.auth.ValidateTokenDetailed(refreshed.AccessToken); err != nil {
+		t.Fatalf("ValidateTokenDetailed(refreshed access token) error = %v", err)
+	}
+	organizations, _ := accessClaims(t, env, refreshed.AccessToken)["organizations"].([]any)
+	var role any
+	for _, item := range organizations {
+		if claim, _ := item.(map[string]any); claimUint64(claim, "id") == fixture.secondary.ID {
+			role = claim["role"]
+		}
+	}
+	if role != "CHAIRMAN" {
+		t.Errorf("refreshed token role in the secondary organization = %v, want CHAIRMAN", role)
+	}
+}
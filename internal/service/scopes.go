@@ -0,0 +1,42 @@
+package service
+
+import (
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// roleScopes returns the ROLE_SCOPES entry for the role the user holds in the organization the token is
+// issued for: the organization logged into, otherwise the primary one. Tokens without an organization or
+// for a role without configured scopes carry none.
+func (s *AuthenticationService) roleScopes(user *models.User, loggedOrganization *models.Organization, memberships []*models.UserOrganization) []string {
+	if len(s.config.RoleScopes) == 0 {
+		return nil
+	}
+	orgID := user.PrimaryOrganizationID
+	if loggedOrganization != nil {
+		orgID = &loggedOrganization.ID
+	}
+	if orgID == nil {
+		return nil
+	}
+	for _, membership := range memberships {
+		if membership != nil && membership.OrganizationID == *orgID {
+			return s.config.RoleScopes[string(membership.Role)]
+		}
+	}
+	return nil
+}
+
+// claimStrings reads a list-of-strings claim, returning nil when it is absent or empty.
+func claimStrings(claim any) []string {
+	items, ok := claim.([]any)
+	if !ok {
+		return nil
+	}
+	var values []string
+	for _, item := range items {
+		if value, ok := item.(string); ok && value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
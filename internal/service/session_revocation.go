@@ -0,0 +1,186 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// sessionRevocationBatchSize bounds how many users are updated per transaction.
+const sessionRevocationBatchSize = 500
+
+var (
+	ErrTokenNotRecognized  = errors.New("token is not recognized among active sessions")
+	ErrTokenAlreadyRevoked = errors.New("token is already revoked")
+)
+
+// RevokeOrganizationSessions invalidates every token issued to members of the organization by bumping
+// their TokensValidAfter. It returns the number of affected users and records a single audit event.
+func (s *AuthenticationService) RevokeOrganizationSessions(orgID, actorID uint64) (int, error) {
+	org, err := s.orgRepo.GetOrganizationByID(orgID)
+	if err != nil {
+		return 0, err
+	}
+	if org == nil {
+		return 0, ErrOrganizationNotFound
+	}
+
+	memberIDs, err := s.orgRepo.ListOrganizationMemberIDs(orgID)
+	if err != nil {
+		return 0, fmt.Errorf("list organization members: %w", err)
+	}
+
+	now := s.now()
+	affected, err := s.userRepo.RevokeTokens(memberIDs, now, sessionRevocationBatchSize)
+
+	event := &models.AuditEvent{
+		Actor:     models.AuditUserRef(actorID),
+		Action:    models.AuditActionOrganizationSessionsRevoke,
+		Target:    models.AuditOrganizationRef(orgID),
+		OrgID:     &orgID,
+		Success:   err == nil,
+		Timestamp: now,
+		Metadata: map[string]any{
+			"members":       len(memberIDs),
+			"revoked_users": affected,
+		},
+	}
+	s.recordAudit(event)
+	s.notifySecurityEvent(event)
+
+	if err != nil {
+		return int(affected), fmt.Errorf("revoke member sessions: %w", err)
+	}
+	return int(affected), nil
+}
+
+// RevokeToken blacklists a single token by JTI until it expires. When the token itself is supplied
+// it must be a valid, unexpired access or refresh token of an existing user. A bare JTI must name an
+// unexpired token recorded at issue, owned by user_id when one is given; anything else fails with
+// ErrTokenNotRecognized.
+func (s *AuthenticationService) RevokeToken(input *models.RevokeTokenInput) (*models.RevokedToken, error) {
+	if input == nil {
+		return nil, fmt.Errorf("input required")
+	}
+
+	now := s.now()
+	revoked := &models.RevokedToken{
+		JTI:       strings.TrimSpace(input.JTI),
+		UserID:    input.UserID,
+		RevokedAt: now,
+		RevokedBy: input.ActorID,
+	}
+
+	if token := strings.TrimSpace(input.Token); token != "" {
+		claims, err := s.parseTypedToken(token, "access")
+		if err != nil {
+			claims, err = s.parseTypedToken(token, "refresh")
+		}
+		if err != nil {
+			return nil, ErrTokenNotRecognized
+		}
+
+		jti, _ := claims["jti"].(string)
+		userID, ok := claimUserID(claims)
+		expiresAt, err := claims.GetExpirationTime()
+		if jti == "" || !ok || err != nil || expiresAt == nil {
+			return nil, ErrTokenNotRecognized
+		}
+		if (revoked.JTI != "" && revoked.JTI != jti) || (revoked.UserID != 0 && revoked.UserID != userID) {
+			return nil, ErrTokenNotRecognized
+		}
+		revoked.JTI = jti
+		revoked.UserID = userID
+		revoked.ExpiresAt = expiresAt.Time
+
+		user, err := s.userRepo.GetByID(userID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil || tokenRevoked(user, claims) {
+			return nil, ErrTokenNotRecognized
+		}
+	} else {
+		if revoked.JTI == "" {
+			return nil, fmt.Errorf("token or jti is required")
+		}
+		issued, err := s.userRepo.GetIssuedToken(revoked.JTI, now)
+		if err != nil {
+			return nil, err
+		}
+		if issued == nil || (revoked.UserID != 0 && revoked.UserID != issued.UserID) {
+			return nil, ErrTokenNotRecognized
+		}
+		revoked.UserID = issued.UserID
+		revoked.ExpiresAt = issued.ExpiresAt
+
+		// Tokens cut off by a session revocation are no longer active sessions
+		user, err := s.userRepo.GetByID(issued.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil || tokenRevoked(user, issuedTokenClaims(issued)) {
+			return nil, ErrTokenNotRecognized
+		}
+	}
+
+	created, err := s.userRepo.RevokeTokenJTI(revoked)
+	if err != nil {
+		return nil, fmt.Errorf("revoke token: %w", err)
+	}
+	if !created {
+		return nil, ErrTokenAlreadyRevoked
+	}
+
+	event := &models.AuditEvent{
+		Actor:     models.AuditUserRef(input.ActorID),
+		Action:    models.AuditActionTokenRevoke,
+		Target:    models.AuditUserRef(revoked.UserID),
+		Success:   true,
+		Timestamp: now,
+		Metadata: map[string]any{
+			"jti":        revoked.JTI,
+			"expires_at": revoked.ExpiresAt,
+		},
+	}
+	s.recordAudit(event)
+	s.notifySecurityEvent(event)
+
+	return revoked, nil
+}
+
+// signSessionToken signs an access or refresh token and records its JTI so the token can later be
+// revoked by JTI alone.
+func (s *AuthenticationService) signSessionToken(claims jwt.MapClaims, userID uint64, issuedAt, expiresAt time.Time) (string, error) {
+	signed, err := s.signToken(claims)
+	if err != nil {
+		return "", err
+	}
+
+	jti, _ := claims["jti"].(string)
+	tokenType, _ := claims["type"].(string)
+	err = s.userRepo.RecordIssuedToken(&models.IssuedToken{
+		JTI:       jti,
+		UserID:    userID,
+		Type:      tokenType,
+		IssuedAt:  issuedAt,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", fmt.Errorf("record issued token: %w", err)
+	}
+	return signed, nil
+}
+
+// issuedTokenClaims rebuilds the claims tokenRevoked reads from an issued token record.
+func issuedTokenClaims(issued *models.IssuedToken) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iat":    issued.IssuedAt.Unix(),
+		"iat_us": issued.IssuedAt.UnixMicro(),
+		"type":   issued.Type,
+	}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lee-tech/authentication/internal/models"
+	"gorm.io/gorm"
+)
+
+// tokenJTI reads the jti claim of a token issued by the service.
+func tokenJTI(t *testing.T, env *testEnv, token string) string {
+	t.Helper()
+
+	parsed, err := jwt.Parse(token, env.auth.TokenKeyFunc)
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	jti, _ := parsed.Claims.(jwt.MapClaims)["jti"].(string)
+	return jti
+}
+
+func TestRevokeTokenByJTI(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   func(env *testEnv, owner, other *models.User, jti string) *models.RevokeTokenInput
+		wantErr error
+	}{
+		{
+			name: "bare jti",
+			input: func(_ *testEnv, _, _ *models.User, jti string) *models.RevokeTokenInput {
+				return &models.RevokeTokenInput{JTI: jti}
+			},
+		},
+		{
+			name: "jti with owner",
+			input: func(_ *testEnv, owner, _ *models.User, jti string) *models.RevokeTokenInput {
+				return &models.RevokeTokenInput{JTI: jti, UserID: owner.ID}
+			},
+		},
+		{
+			name: "unknown jti",
+			input: func(_ *testEnv, owner, _ *models.User, _ string) *models.RevokeTokenInput {
+				return &models.RevokeTokenInput{JTI: "2f1c7e1a-0000-4000-8000-000000000000", UserID: owner.ID}
+			},
+			wantErr: ErrTokenNotRecognized,
+		},
+		{
+			name: "jti of another user",
+			input: func(_ *testEnv, _, other *models.User, jti string) *models.RevokeTokenInput {
+				return &models.RevokeTokenInput{JTI: jti, UserID: other.ID}
+			},
+			wantErr: ErrTokenNotRecognized,
+		},
+		{
+			name: "jti of a session revoked token",
+			input: func(env *testEnv, owner, _ *models.User, jti string) *models.RevokeTokenInput {
+				env.db.Model(owner).Update("tokens_valid_after", time.Now().Add(time.Second))
+				return &models.RevokeTokenInput{JTI: jti}
+			},
+			wantErr: ErrTokenNotRecognized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, nil)
+			org := env.createOrganization(t, "Acme", nil)
+			owner := env.createUser(t, "ada", nil)
+			other := env.createUser(t, "grace", nil)
+			env.addMember(t, owner, org, models.OrganizationRoleOrgAdmin)
+
+			leaked, err := env.auth.Login(&models.LoginRequest{Username: owner.Username, Password: testPassword, OrganizationID: org.ID})
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+			kept, err := env.auth.Login(&models.LoginRequest{Username: owner.Username, Password: testPassword, OrganizationID: org.ID})
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+
+			_, err = env.auth.RevokeToken(tt.input(env, owner, other, tokenJTI(t, env, leaked.AccessToken)))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("RevokeToken() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RevokeToken() error = %v", err)
+			}
+
+			if _, err := env.auth.ValidateToken(leaked.AccessToken); !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("revoked token validation error = %v, want %v", err, ErrInvalidToken)
+			}
+			if _, err := env.auth.ValidateToken(kept.AccessToken); err != nil {
+				t.Fatalf("other token of the user was rejected: %v", err)
+			}
+			if _, err := env.auth.RefreshToken(leaked.RefreshToken); err != nil {
+				t.Fatalf("refresh token of the revoked session was rejected: %v", err)
+			}
+		})
+	}
+}
+
+func TestRevokeTokenTwice(t *testing.T) {
+	env := newTestEnv(t, nil)
+	org := env.createOrganization(t, "Acme", nil)
+	user := env.createUser(t, "ada", nil)
+	env.addMember(t, user, org, models.OrganizationRoleOrgAdmin)
+
+	login, err := env.auth.Login(&models.LoginRequest{Username: user.Username, Password: testPassword, OrganizationID: org.ID})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if _, err := env.auth.RevokeToken(&models.RevokeTokenInput{Token: login.RefreshToken}); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+	if _, err := env.auth.RevokeToken(&models.RevokeTokenInput{JTI: tokenJTI(t, env, login.RefreshToken)}); !errors.Is(err, ErrTokenAlreadyRevoked) {
+		t.Fatalf("second RevokeToken() error = %v, want %v", err, ErrTokenAlreadyRevoked)
+	}
+	if _, err := env.auth.RefreshToken(login.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("revoked refresh token error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestRevokeOrganizationSessionsInBatches(t *testing.T) {
+	env := newTestEnv(t, nil)
+	org := env.createOrganization(t, "Acme", nil)
+	outsider := env.createUser(t, "outsider", nil)
+
+	const members = sessionRevocationBatchSize + 1
+	users := make([]*models.User, members)
+	for i := range users {
+		name := fmt.Sprintf("member-%d", i)
+		users[i] = &models.User{Email: name + "@example.com", Username: name, Password: "x", IsActive: true}
+	}
+	if err := env.db.CreateInBatches(users, 100).Error; err != nil {
+		t.Fatalf("create users: %v", err)
+	}
+	memberships := make([]*models.UserOrganization, members)
+	for i, user := range users {
+		memberships[i] = &models.UserOrganization{UserID: user.ID, OrganizationID: org.ID, Role: "CEO"}
+	}
+	if err := env.db.CreateInBatches(memberships, 100).Error; err != nil {
+		t.Fatalf("create memberships: %v", err)
+	}
+
+	var userUpdates int
+	err := env.db.Callback().Update().Before("gorm:update").Register("test:count_user_updates", func(tx *gorm.DB) {
+		if tx.Statement.Table == "users" {
+			userUpdates++
+		}
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	revoked, err := env.auth.RevokeOrganizationSessions(org.ID, outsider.ID)
+	if err != nil {
+		t.Fatalf("RevokeOrganizationSessions() error = %v", err)
+	}
+	if revoked != members {
+		t.Errorf("revoked = %d, want %d", revoked, members)
+	}
+	if userUpdates != 2 {
+		t.Errorf("user updates = %d, want 2 batches", userUpdates)
+	}
+
+	var stamped int64
+	if err := env.db.Model(&models.User{}).Where("tokens_valid_after IS NOT NULL").Count(&stamped).Error; err != nil {
+		t.Fatalf("count revoked users: %v", err)
+	}
+	if stamped != members {
+		t.Errorf("users with revoked sessions = %d, want %d members and not the outsider", stamped, members)
+	}
+
+	var events int64
+	if err := env.db.Model(&models.AuditEvent{}).Where("action = ?", models.AuditActionOrganizationSessionsRevoke).Count(&events).Error; err != nil {
+		t.Fatalf("count audit events: %v", err)
+	}
+	if events != 1 {
+		t.Errorf("audit events = %d, want 1", events)
+	}
+}
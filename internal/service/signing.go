@@ -0,0 +1,226 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// signingMethod returns the configured token signing algorithm.
+func (s *AuthenticationService) signingMethod() jwt.SigningMethod {
+	if s.config.JWTAlgorithm == jwt.SigningMethodRS256.Alg() {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodHS256
+}
+
+// TokenOrganizationBindingEnabled reports whether access tokens may only act on the organization they
+// were issued for.
+func (s *AuthenticationService) TokenOrganizationBindingEnabled() bool {
+	return s.config.TokenOrganizationBinding
+}
+
+// UsesAsymmetricSigning reports whether tokens are signed with the RS256 private key rather than
+// the shared JWT secret.
+func (s *AuthenticationService) UsesAsymmetricSigning() bool {
+	return s.signingMethod() == jwt.SigningMethodRS256
+}
+
+// Issuer returns the iss claim of issued tokens: JWT_ISSUER when configured, otherwise the service name.
+func (s *AuthenticationService) Issuer() string {
+	if s.config.JWTIssuer != "" {
+		return s.config.JWTIssuer
+	}
+	return s.config.Config.ServiceName
+}
+
+// signToken signs claims with the configured algorithm and key.
+func (s *AuthenticationService) signToken(claims jwt.MapClaims) (string, error) {
+	method := s.signingMethod()
+	token := jwt.NewWithClaims(method, claims)
+	var key any = []byte(s.config.Config.JWTSecret)
+	if method == jwt.SigningMethodRS256 {
+		key = s.config.JWTPrivateKey
+	}
+	token.Header["kid"] = s.activeKeyID()
+	return token.SignedString(key)
+}
+
+// activeKeyID returns the kid of the key currently signing tokens.
+func (s *AuthenticationService) activeKeyID() string {
+	if s.UsesAsymmetricSigning() {
+		return rsaKeyID(s.config.JWTPublicKey)
+	}
+	if s.config.JWTSecretKID != "" && len(s.config.JWTSecrets) > 0 {
+		return s.config.JWTSecretKID
+	}
+	return hmacKeyID(s.config.Config.JWTSecret)
+}
+
+// hmacSecret returns the HS256 secret verifying a token with the given kid. Tokens without a kid use the
+// current secret. A kid matches a JWT_SECRETS entry by name or, for tokens signed before the secret was
+// listed there, by the kid derived from its value.
+func (s *AuthenticationService) hmacSecret(kid string) (string, bool) {
+	if kid == "" || len(s.config.JWTSecrets) == 0 {
+		return s.config.Config.JWTSecret, true
+	}
+	if secret, ok := s.config.JWTSecrets[kid]; ok {
+		return secret, true
+	}
+	for _, secret := range s.config.JWTSecrets {
+		if hmac.Equal([]byte(hmacKeyID(secret)), []byte(kid)) {
+			return secret, true
+		}
+	}
+	return "", false
+}
+
+// SignedWithActiveKey reports whether a token names the current signing key, or no key at all, in its kid
+// header. The signature is not verified. Only tokens signed with a retired JWT_SECRETS entry report false.
+func (s *AuthenticationService) SignedWithActiveKey(tokenString string) bool {
+	if s.UsesAsymmetricSigning() || len(s.config.JWTSecrets) == 0 {
+		return true
+	}
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+	if err != nil {
+		return true
+	}
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" || kid == s.activeKeyID() {
+		return true
+	}
+	secret, ok := s.hmacSecret(kid)
+	return !ok || secret == s.config.Config.JWTSecret
+}
+
+// SigningKeys lists the kid and algorithm of the active signing key, together with the JWKS for
+// asymmetric keys. No HMAC secret material is included.
+func (s *AuthenticationService) SigningKeys() *models.SigningKeys {
+	keys := &models.SigningKeys{
+		Keys: make([]models.SigningKeyInfo, 0, 1),
+		JWKS: s.JWKS(),
+	}
+	if kid := s.activeKeyID(); kid != "" {
+		keys.Keys = append(keys.Keys, models.SigningKeyInfo{
+			Kid: kid,
+			Alg: s.signingMethod().Alg(),
+			Use: "sig",
+		})
+	}
+	return keys
+}
+
+// JWKS returns the public keys that verify issued tokens. The set is empty with HS256, whose shared
+// secret is never published.
+func (s *AuthenticationService) JWKS() *models.JWKS {
+	set := &models.JWKS{Keys: make([]models.JWK, 0, 1)}
+	if !s.UsesAsymmetricSigning() || s.config.JWTPublicKey == nil {
+		return set
+	}
+	pub := s.config.JWTPublicKey
+	set.Keys = append(set.Keys, models.JWK{
+		Kid: rsaKeyID(pub),
+		Kty: "RSA",
+		Alg: jwt.SigningMethodRS256.Alg(),
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	})
+	return set
+}
+
+// rsaKeyID derives the kid for an RSA public key as its RFC 7638 JWK thumbprint, so the value is
+// stable across restarts and changes whenever the key pair is rotated.
+func rsaKeyID(pub *rsa.PublicKey) string {
+	if pub == nil {
+		return ""
+	}
+	e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	n := base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+	sum := sha256.Sum256([]byte(`{"e":"` + e + `","kty":"RSA","n":"` + n + `"}`))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// hmacKeyID derives the kid for an HMAC secret as an HMAC of a fixed label keyed with the secret. Like
+// the signature of any issued token, it reveals nothing about the secret beyond allowing a guess to be
+// checked, and it changes whenever the secret is rotated.
+func hmacKeyID(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("authentication-signing-key-id"))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// TokenKeyFunc returns the key that verifies tokens issued by the service, chosen by the kid header when
+// several HS256 secrets are configured. Tokens signed with any other algorithm than the configured one
+// are rejected.
+func (s *AuthenticationService) TokenKeyFunc(token *jwt.Token) (any, error) {
+	method := s.signingMethod()
+	if token.Method == nil || token.Method.Alg() != method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	if method == jwt.SigningMethodRS256 {
+		return s.config.JWTPublicKey, nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	secret, ok := s.hmacSecret(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return []byte(secret), nil
+}
+
+// VerifyAccessToken verifies an access token issued by the service and returns the identity it carries:
+// its user, organization, roles, permissions, scopes and super admin flag, read from the claims. It is
+// the single check the auth middleware chain runs per request, so it also loads the user once to reject
+// tokens that were blacklisted or issued before a session revocation with ErrTokenRevoked, to drop the
+// super admin flag of users who lost it, and to report whether a temporary password still has to be
+// replaced.
+func (s *AuthenticationService) VerifyAccessToken(accessToken string) (*models.TokenDetails, error) {
+	claims, err := s.parseTypedToken(accessToken, "access")
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	userID, ok := claimUserID(claims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || tokenRevoked(user, claims) {
+		return nil, ErrTokenRevoked
+	}
+	blacklisted, err := s.tokenBlacklisted(claims)
+	if err != nil {
+		return nil, err
+	}
+	if blacklisted {
+		return nil, ErrTokenRevoked
+	}
+
+	details := &models.TokenDetails{
+		UserID:      userID,
+		Roles:       claimStrings(claims[s.ClaimName("roles")]),
+		Permissions: claimStrings(claims["permissions"]),
+		Scopes:      claimStrings(claims["scopes"]),
+	}
+	details.IsSuperAdmin, _ = claims["is_super_admin"].(bool)
+	details.IsSuperAdmin = details.IsSuperAdmin && user.IsSuperAdmin
+	if orgID, ok := claimUint64Value(claims["org_id"]); ok {
+		details.OrganizationID = &orgID
+	}
+	// Only tokens issued under a temporary password are restricted; the claim lapses once it is replaced
+	mustChange, _ := claims["must_change_password"].(bool)
+	details.MustChangePassword = mustChange && user.MustChangePassword
+	return details, nil
+}
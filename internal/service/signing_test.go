@@ -0,0 +1,259 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestTokenKeyFuncSelectsSecretByKid(t *testing.T) {
+	env := newTestEnv(t, func(cfg *config.AuthConfig) {
+		cfg.JWTSecrets = map[string]string{"2024-10": "old-secret", "2025-04": "new-secret"}
+		cfg.JWTSecretKID = "2025-04"
+		cfg.Config.JWTSecret = "new-secret"
+	})
+	user := env.createUser(t, "keyed", nil)
+
+	issued, err := env.auth.generateAccessToken(user, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("generate access token: %v", err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(issued, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("parse issued token: %v", err)
+	}
+	if kid := parsed.Header["kid"]; kid != "2025-04" {
+		t.Fatalf("issued kid = %v, want 2025-04", kid)
+	}
+
+	sign := func(method jwt.SigningMethod, kid, secret string) string {
+		token := jwt.NewWithClaims(method, jwt.MapClaims{
+			"user_id": user.ID,
+			"type":    "access",
+			"iss":     env.auth.Issuer(),
+			"exp":     time.Now().Add(time.Hour).Unix(),
+		})
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString([]byte(secret))
+		if err != nil {
+			t.Fatalf("sign token: %v", err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"issued token", issued, true},
+		{"retired kid", sign(jwt.SigningMethodHS256, "2024-10", "old-secret"), true},
+		{"derived kid of a listed secret", sign(jwt.SigningMethodHS256, hmacKeyID("old-secret"), "old-secret"), true},
+		{"no kid uses the current secret", sign(jwt.SigningMethodHS256, "", "new-secret"), true},
+		{"no kid with a retired secret", sign(jwt.SigningMethodHS256, "", "old-secret"), false},
+		{"unknown kid", sign(jwt.SigningMethodHS256, "2023-01", "old-secret"), false},
+		{"other algorithm", sign(jwt.SigningMethodHS512, "2025-04", "new-secret"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.auth.ValidateToken(tt.token)
+			if (err == nil) != tt.valid {
+				t.Errorf("ValidateToken err = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+
+	if env.auth.SignedWithActiveKey(sign(jwt.SigningMethodHS256, "2024-10", "old-secret")) {
+		t.Errorf("token with a retired kid reported as signed with the active key")
+	}
+	if !env.auth.SignedWithActiveKey(issued) {
+		t.Errorf("issued token reported as signed with a retired key")
+	}
+}
+
+func TestJWTIssuerOverride(t *testing.T) {
+	tests := []struct {
+		name       string
+		issuer     string
+		wantIssuer string
+	}{
+		{name: "override", issuer: "https://auth.example.com", wantIssuer: "https://auth.example.com"},
+		{name: "service name fallback", wantIssuer: "authentication"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, func(cfg *config.AuthConfig) { cfg.JWTIssuer = tt.issuer })
+			user := env.createUser(t, "issued", nil)
+			if got := env.auth.Issuer(); got != tt.wantIssuer {
+				t.Fatalf("Issuer() = %q, want %q", got, tt.wantIssuer)
+			}
+
+			issued, err := env.auth.generateAccessToken(user, nil, nil, nil, nil)
+			if err != nil {
+				t.Fatalf("generate access token: %v", err)
+			}
+			parsed, _, err := jwt.NewParser().ParseUnverified(issued, jwt.MapClaims{})
+			if err != nil {
+				t.Fatalf("parse issued token: %v", err)
+			}
+			if iss := parsed.Claims.(jwt.MapClaims)["iss"]; iss != tt.wantIssuer {
+				t.Fatalf("iss = %v, want %q", iss, tt.wantIssuer)
+			}
+			if _, err := env.auth.ValidateToken(issued); err != nil {
+				t.Fatalf("ValidateToken(issued) error = %v", err)
+			}
+
+			foreign := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+				"user_id": user.ID,
+				"type":    "access",
+				"iss":     "someone-else",
+				"exp":     time.Now().Add(time.Hour).Unix(),
+			})
+			signed, err := foreign.SignedString([]byte(env.cfg.JWTSecret))
+			if err != nil {
+				t.Fatalf("sign token: %v", err)
+			}
+			if _, err := env.auth.ValidateToken(signed); err == nil {
+				t.Fatal("ValidateToken() accepted a token from another issuer")
+			}
+		})
+	}
+}
+
+func TestSigningKeysOmitSecrets(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(cfg *config.AuthConfig)
+		secrets   []string
+		kid       string
+	}{
+		{
+			name:      "single secret",
+			configure: func(cfg *config.AuthConfig) { cfg.Config.JWTSecret = "single-signing-secret" },
+			secrets:   []string{"single-signing-secret"},
+			kid:       hmacKeyID("single-signing-secret"),
+		},
+		{
+			name: "secrets by kid",
+			configure: func(cfg *config.AuthConfig) {
+				cfg.JWTSecrets = map[string]string{"2024-10": "retired-signing-secret", "2025-04": "current-signing-secret"}
+				cfg.JWTSecretKID = "2025-04"
+				cfg.Config.JWTSecret = "current-signing-secret"
+			},
+			secrets: []string{"retired-signing-secret", "current-signing-secret"},
+			kid:     "2025-04",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, tt.configure)
+			keys := env.auth.SigningKeys()
+			if len(keys.Keys) != 1 || keys.Keys[0].Kid != tt.kid || keys.Keys[0].Alg != "HS256" {
+				t.Errorf("keys = %+v, want the HS256 key %q", keys.Keys, tt.kid)
+			}
+			if len(keys.JWKS.Keys) != 0 {
+				t.Errorf("JWKS = %+v, want no keys for HS256", keys.JWKS.Keys)
+			}
+
+			body, err := json.Marshal(keys)
+			if err != nil {
+				t.Fatalf("marshal signing keys: %v", err)
+			}
+			for _, secret := range tt.secrets {
+				for _, encoded := range []string{secret, base64.StdEncoding.EncodeToString([]byte(secret)), base64.RawURLEncoding.EncodeToString([]byte(secret))} {
+					if strings.Contains(string(body), encoded) {
+						t.Errorf("signing keys %s contain the secret %q", body, secret)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyAccessToken(t *testing.T) {
+	tests := []struct {
+		name           string
+		mustChange     bool
+		superAdmin     bool
+		after          func(t *testing.T, env *testEnv, user *models.User, token string)
+		wantErr        error
+		wantMustChange bool
+		wantSuperAdmin bool
+	}{
+		{name: "active token"},
+		{name: "temporary password", mustChange: true, wantMustChange: true},
+		{
+			name:       "temporary password replaced",
+			mustChange: true,
+			after: func(t *testing.T, env *testEnv, user *models.User, _ string) {
+				env.db.Model(user).Update("must_change_password", false)
+			},
+		},
+		{name: "super admin", superAdmin: true, wantSuperAdmin: true},
+		{
+			name:       "super admin demoted",
+			superAdmin: true,
+			after: func(t *testing.T, env *testEnv, user *models.User, _ string) {
+				env.db.Model(user).Update("is_super_admin", false)
+			},
+		},
+		{
+			name: "logged out",
+			after: func(t *testing.T, env *testEnv, user *models.User, token string) {
+				if err := env.auth.Logout(user.ID, token, ""); err != nil {
+					t.Fatalf("Logout() error = %v", err)
+				}
+			},
+			wantErr: ErrTokenRevoked,
+		},
+		{
+			name: "user deleted",
+			after: func(t *testing.T, env *testEnv, user *models.User, _ string) {
+				env.db.Unscoped().Delete(user)
+			},
+			wantErr: ErrTokenRevoked,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, nil)
+			user := env.createUser(t, "verified", func(u *models.User) {
+				u.MustChangePassword = tt.mustChange
+				u.IsSuperAdmin = tt.superAdmin
+			})
+			env.addMember(t, user, env.createOrganization(t, "Acme", nil), "CEO")
+			response, err := env.auth.Login(&models.LoginRequest{Username: user.Username, Password: testPassword})
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+			if tt.after != nil {
+				tt.after(t, env, user, response.AccessToken)
+			}
+
+			details, err := env.auth.VerifyAccessToken(response.AccessToken)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyAccessToken() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if details.UserID != user.ID || details.MustChangePassword != tt.wantMustChange || details.IsSuperAdmin != tt.wantSuperAdmin {
+				t.Errorf("details = %+v, want user %d, must change password %v, super admin %v", details, user.ID, tt.wantMustChange, tt.wantSuperAdmin)
+			}
+		})
+	}
+
+	env := newTestEnv(t, nil)
+	if _, err := env.auth.VerifyAccessToken("not-a-token"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("malformed token: error = %v, want %v", err, ErrInvalidToken)
+	}
+}
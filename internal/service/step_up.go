@@ -0,0 +1,223 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/lee-tech/authentication/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const stepUpTokenType = "step_up"
+
+var (
+	ErrMFANotEnabled  = errors.New("mfa is not enabled for this account")
+	ErrInvalidMFACode = errors.New("invalid mfa code")
+)
+
+// ChallengeMFA verifies a TOTP or recovery code for an MFA-enabled user and issues a short-lived step-up token.
+func (s *AuthenticationService) ChallengeMFA(userID uint64, code string) (*models.StepUpTokenResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidToken
+	}
+	if !user.IsActive {
+		return nil, ErrAccountInactive
+	}
+	if user.LockedUntil != nil && user.LockedUntil.After(s.now()) {
+		return nil, ErrAccountLocked
+	}
+	if err := s.verifyMFACode(user, code); err != nil {
+		return nil, err
+	}
+
+	token, err := s.generateStepUpToken(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate step-up token: %w", err)
+	}
+
+	return &models.StepUpTokenResponse{
+		StepUpToken: token,
+		ExpiresIn:   int(s.config.StepUpTokenTTL.Seconds()),
+		TokenType:   "Bearer",
+	}, nil
+}
+
+// verifyMFACode checks a TOTP or recovery code for an MFA-enabled user, counting failures towards the
+// MFA lockout and clearing them on success.
+func (s *AuthenticationService) verifyMFACode(user *models.User, code string) error {
+	if !user.MFAEnabled || user.MFASecret == nil {
+		return ErrMFANotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ErrInvalidMFACode
+	}
+	secret, err := s.decryptMFASecret(*user.MFASecret)
+	if err != nil {
+		return err
+	}
+	if !validateTOTP(secret, code, s.now()) {
+		used, err := s.consumeRecoveryCode(user, code)
+		if err != nil {
+			return err
+		}
+		if !used {
+			s.recordMFAFailure(user)
+			return ErrInvalidMFACode
+		}
+	}
+	s.resetMFAFailures(user)
+	s.upgradeMFASecret(user)
+	return nil
+}
+
+// StepUpRequired reports whether sensitive endpoints need a step-up token from the user, which is the
+// case once they have MFA enabled.
+func (s *AuthenticationService) StepUpRequired(userID uint64) (bool, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return false, err
+	}
+	return user != nil && user.MFAEnabled, nil
+}
+
+// ValidateStepUpToken checks that the step-up token is valid and was issued to the given user.
+func (s *AuthenticationService) ValidateStepUpToken(tokenString string, userID uint64) error {
+	_, err := s.stepUpExpiry(tokenString, userID)
+	return err
+}
+
+// stepUpExpiry validates a step-up token issued to the user and returns when it expires.
+func (s *AuthenticationService) stepUpExpiry(tokenString string, userID uint64) (time.Time, error) {
+	claims, err := s.parseTypedToken(tokenString, stepUpTokenType)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !claimContains(claims["amr"], "mfa") {
+		return time.Time{}, ErrInvalidToken
+	}
+	if subject, ok := claimUserID(claims); !ok || subject != userID {
+		return time.Time{}, ErrInvalidToken
+	}
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return time.Time{}, ErrInvalidToken
+	}
+	return expiresAt.Time, nil
+}
+
+// MFAStatus reports the user's MFA posture. stepUpToken is the caller's step-up token, if any; an
+// invalid or expired one simply reports no active step-up grant.
+func (s *AuthenticationService) MFAStatus(userID uint64, stepUpToken string) (*models.MFAStatus, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidToken
+	}
+
+	status := &models.MFAStatus{
+		MFAEnabled: user.MFAEnabled,
+	}
+	if user.MFAEnabled {
+		status.RecoveryCodesRemaining = len(user.MFARecoveryCodes)
+	}
+	if stepUpToken = strings.TrimSpace(stepUpToken); stepUpToken != "" {
+		if expiresAt, err := s.stepUpExpiry(stepUpToken, userID); err == nil {
+			status.StepUpActive = true
+			status.StepUpExpiresAt = &expiresAt
+		}
+	}
+	return status, nil
+}
+
+// generateStepUpToken issues a token proving the user recently completed an MFA challenge.
+func (s *AuthenticationService) generateStepUpToken(user *models.User) (string, error) {
+	now := s.now()
+	expiresAt := now.Add(s.config.StepUpTokenTTL)
+
+	claims := jwt.MapClaims{
+		"iss":     s.Issuer(),
+		"sub":     user.ID,
+		"aud":     []string{s.config.Config.ServiceName},
+		"exp":     expiresAt.Unix(),
+		"iat":     now.Unix(),
+		"nbf":     now.Unix(),
+		"jti":     uuid.NewString(),
+		"type":    stepUpTokenType,
+		"amr":     []string{"mfa"},
+		"user_id": user.ID,
+	}
+
+	return s.signToken(claims)
+}
+
+// consumeRecoveryCode removes a matching recovery code so it cannot be used again. Only the code list is
+// written, and only if no concurrent request consumed a code first.
+func (s *AuthenticationService) consumeRecoveryCode(user *models.User, code string) (bool, error) {
+	for i, hashed := range user.MFARecoveryCodes {
+		if bcrypt.CompareHashAndPassword([]byte(hashed), []byte(code)) != nil {
+			continue
+		}
+		remaining := make([]string, 0, len(user.MFARecoveryCodes)-1)
+		remaining = append(remaining, user.MFARecoveryCodes[:i]...)
+		remaining = append(remaining, user.MFARecoveryCodes[i+1:]...)
+		replaced, err := s.userRepo.ReplaceRecoveryCodes(user.ID, user.MFARecoveryCodes, remaining)
+		if err != nil {
+			return false, fmt.Errorf("failed to consume recovery code: %w", err)
+		}
+		if !replaced {
+			return false, nil
+		}
+		user.MFARecoveryCodes = remaining
+		return true, nil
+	}
+	return false, nil
+}
+
+// recordMFAFailure counts a wrong MFA code and locks the account once MaxMFAAttempts is reached.
+// Password failures are counted separately.
+func (s *AuthenticationService) recordMFAFailure(user *models.User) {
+	if err := s.userRepo.IncrementMFAAttempts(user.ID); err != nil {
+		log.Printf("failed to record mfa failure for user %d: %v", user.ID, err)
+		return
+	}
+	user.MFAFailedAttempts++
+	if s.config.MaxMFAAttempts <= 0 || user.MFAFailedAttempts < s.config.MaxMFAAttempts {
+		return
+	}
+
+	lockUntil := s.now().Add(s.config.LockoutDuration)
+	if err := s.userRepo.LockAccount(user.ID, lockUntil); err != nil {
+		log.Printf("failed to lock user %d after mfa failures: %v", user.ID, err)
+		return
+	}
+	// Start a fresh count once the lockout expires
+	if err := s.userRepo.ResetMFAAttempts(user.ID); err != nil {
+		log.Printf("failed to reset mfa failures for user %d: %v", user.ID, err)
+	}
+	user.MFAFailedAttempts = 0
+}
+
+// resetMFAFailures clears the failed MFA counter after a correct code.
+func (s *AuthenticationService) resetMFAFailures(user *models.User) {
+	if user.MFAFailedAttempts == 0 {
+		return
+	}
+	if err := s.userRepo.ResetMFAAttempts(user.ID); err != nil {
+		log.Printf("failed to reset mfa failures for user %d: %v", user.ID, err)
+		return
+	}
+	user.MFAFailedAttempts = 0
+}
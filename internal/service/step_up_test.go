@@ -0,0 +1,212 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestChallengeMFA(t *testing.T) {
+	tests := []struct {
+		name    string
+		mfa     bool
+		code    func(env *testEnv, secret string, recovery []string) string
+		prepare func(t *testing.T, env *testEnv, user *models.User)
+		wantErr error
+	}{
+		{
+			name: "totp code",
+			mfa:  true,
+			code: func(env *testEnv, secret string, _ []string) string { return env.totp(t, secret) },
+		},
+		{
+			name: "recovery code",
+			mfa:  true,
+			code: func(_ *testEnv, _ string, recovery []string) string { return recovery[0] },
+		},
+		{
+			name:    "wrong code",
+			mfa:     true,
+			code:    func(*testEnv, string, []string) string { return "000000" },
+			wantErr: ErrInvalidMFACode,
+		},
+		{
+			name:    "blank code",
+			mfa:     true,
+			code:    func(*testEnv, string, []string) string { return " " },
+			wantErr: ErrInvalidMFACode,
+		},
+		{
+			name:    "mfa not enabled",
+			code:    func(*testEnv, string, []string) string { return "123456" },
+			wantErr: ErrMFANotEnabled,
+		},
+		{
+			name: "inactive account",
+			mfa:  true,
+			code: func(env *testEnv, secret string, _ []string) string { return env.totp(t, secret) },
+			prepare: func(t *testing.T, env *testEnv, user *models.User) {
+				if err := env.db.Model(user).Update("is_active", false).Error; err != nil {
+					t.Fatalf("deactivate user: %v", err)
+				}
+			},
+			wantErr: ErrAccountInactive,
+		},
+		{
+			name: "locked account",
+			mfa:  true,
+			code: func(env *testEnv, secret string, _ []string) string { return env.totp(t, secret) },
+			prepare: func(t *testing.T, env *testEnv, user *models.User) {
+				if err := env.users.LockAccount(user.ID, time.Now().Add(time.Hour)); err != nil {
+					t.Fatalf("lock user: %v", err)
+				}
+			},
+			wantErr: ErrAccountLocked,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, nil)
+			user := env.createUser(t, "stepper", nil)
+			var secret string
+			var recovery []string
+			if tt.mfa {
+				secret, recovery = env.enableMFA(t, user)
+			}
+			if tt.prepare != nil {
+				tt.prepare(t, env, user)
+			}
+
+			response, err := env.auth.ChallengeMFA(user.ID, tt.code(env, secret, recovery))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ChallengeMFA() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if response.ExpiresIn != int(env.cfg.StepUpTokenTTL.Seconds()) {
+				t.Errorf("expires_in = %d, want %d", response.ExpiresIn, int(env.cfg.StepUpTokenTTL.Seconds()))
+			}
+			claims := accessClaims(t, env, response.StepUpToken)
+			if amr, _ := claims["amr"].([]any); len(amr) != 1 || amr[0] != "mfa" {
+				t.Errorf("amr = %v, want [mfa]", claims["amr"])
+			}
+			if err := env.auth.ValidateStepUpToken(response.StepUpToken, user.ID); err != nil {
+				t.Errorf("ValidateStepUpToken() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestChallengeMFARecoveryCodeIsSingleUse(t *testing.T) {
+	env := newTestEnv(t, nil)
+	user := env.createUser(t, "stepper", nil)
+	_, recovery := env.enableMFA(t, user)
+
+	if _, err := env.auth.ChallengeMFA(user.ID, recovery[0]); err != nil {
+		t.Fatalf("first ChallengeMFA() error = %v", err)
+	}
+	if _, err := env.auth.ChallengeMFA(user.ID, recovery[0]); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("reused recovery code error = %v, want %v", err, ErrInvalidMFACode)
+	}
+	if remaining := len(env.reloadUser(t, user.ID).MFARecoveryCodes); remaining != len(recovery)-1 {
+		t.Errorf("%d recovery codes remain, want %d", remaining, len(recovery)-1)
+	}
+}
+
+func TestConsumeRecoveryCodeConcurrently(t *testing.T) {
+	env := newTestEnv(t, nil)
+	user := env.createUser(t, "stepper", nil)
+	_, recovery := env.enableMFA(t, user)
+
+	// Two requests loaded the user before either consumed the code
+	first := env.reloadUser(t, user.ID)
+	second := env.reloadUser(t, user.ID)
+	if err := env.users.IncrementLoginAttempts(user.ID); err != nil {
+		t.Fatalf("increment login attempts: %v", err)
+	}
+
+	if used, err := env.auth.consumeRecoveryCode(first, recovery[0]); err != nil || !used {
+		t.Fatalf("first consumeRecoveryCode() = %v, %v, want true", used, err)
+	}
+	if used, err := env.auth.consumeRecoveryCode(second, recovery[0]); err != nil || used {
+		t.Fatalf("second consumeRecoveryCode() = %v, %v, want false", used, err)
+	}
+
+	stored := env.reloadUser(t, user.ID)
+	if remaining := len(stored.MFARecoveryCodes); remaining != len(recovery)-1 {
+		t.Errorf("%d recovery codes remain, want %d", remaining, len(recovery)-1)
+	}
+	if stored.LoginAttempts != 1 {
+		t.Errorf("login attempts = %d, want the concurrent failure kept", stored.LoginAttempts)
+	}
+}
+
+func TestChallengeMFALocksAfterRepeatedFailures(t *testing.T) {
+	env := newTestEnv(t, nil)
+	user := env.createUser(t, "stepper", nil)
+	secret, _ := env.enableMFA(t, user)
+
+	for i := 0; i < env.cfg.MaxMFAAttempts; i++ {
+		if _, err := env.auth.ChallengeMFA(user.ID, "000000"); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("attempt %d error = %v, want %v", i+1, err, ErrInvalidMFACode)
+		}
+	}
+	if _, err := env.auth.ChallengeMFA(user.ID, env.totp(t, secret)); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("ChallengeMFA() after lockout error = %v, want %v", err, ErrAccountLocked)
+	}
+}
+
+func TestValidateStepUpTokenLifecycle(t *testing.T) {
+	now := time.Now()
+	env := newTestEnv(t, nil)
+	env.auth.WithClock(func() time.Time { return now })
+	user := env.createUser(t, "stepper", nil)
+	other := env.createUser(t, "other", nil)
+	org := env.createOrganization(t, "Acme", nil)
+	env.addMember(t, other, org, "CEO")
+	secret, _ := env.enableMFA(t, user)
+
+	response, err := env.auth.ChallengeMFA(user.ID, env.totp(t, secret))
+	if err != nil {
+		t.Fatalf("ChallengeMFA() error = %v", err)
+	}
+	login, err := env.auth.Login(&models.LoginRequest{Username: other.Username, Password: testPassword, OrganizationID: org.ID})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		userID  uint64
+		elapsed time.Duration
+		valid   bool
+	}{
+		{name: "fresh token", token: response.StepUpToken, userID: user.ID, valid: true},
+		{name: "just before expiry", token: response.StepUpToken, userID: user.ID, elapsed: env.cfg.StepUpTokenTTL - time.Second, valid: true},
+		{name: "expired", token: response.StepUpToken, userID: user.ID, elapsed: env.cfg.StepUpTokenTTL + time.Second},
+		{name: "issued to another user", token: response.StepUpToken, userID: other.ID},
+		{name: "access token", token: login.AccessToken, userID: other.ID},
+		{name: "garbage", token: "not-a-token", userID: user.ID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env.auth.WithClock(func() time.Time { return now.Add(tt.elapsed) })
+			err := env.auth.ValidateStepUpToken(tt.token, tt.userID)
+			if (err == nil) != tt.valid {
+				t.Fatalf("ValidateStepUpToken() error = %v, want valid %v", err, tt.valid)
+			}
+
+			status, err := env.auth.MFAStatus(tt.userID, tt.token)
+			if err != nil {
+				t.Fatalf("MFAStatus() error = %v", err)
+			}
+			if status.StepUpActive != tt.valid {
+				t.Errorf("step_up_active = %v, want %v", status.StepUpActive, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// accessClaims decodes an access token issued by the service.
+func accessClaims(t *testing.T, env *testEnv, token string) jwt.MapClaims {
+	t.Helper()
+
+	parsed, err := jwt.Parse(token, env.auth.TokenKeyFunc)
+	if err != nil {
+		t.Fatalf("parse access token: %v", err)
+	}
+	return parsed.Claims.(jwt.MapClaims)
+}
+
+func TestTenantTierClaimFollowsLoggedOrganization(t *testing.T) {
+	env := newTestEnv(t, func(cfg *config.AuthConfig) { cfg.DefaultTenantTier = "starter" })
+	enterprise := env.createOrganization(t, "Enterprise", func(o *models.Organization) { o.Tier = "enterprise" })
+	untiered := env.createOrganization(t, "Untiered", nil)
+	user := env.createUser(t, "tiered", func(u *models.User) { u.PrimaryOrganizationID = &enterprise.ID })
+	env.addMember(t, user, enterprise, "CEO")
+	env.addMember(t, user, untiered, "CEO")
+
+	tests := []struct {
+		name string
+		org  *models.Organization
+		want string
+	}{
+		{name: "organization tier", org: enterprise, want: "enterprise"},
+		{name: "default tier", org: untiered, want: "starter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := env.auth.Login(&models.LoginRequest{
+				Username:       user.Username,
+				Password:       testPassword,
+				OrganizationID: tt.org.ID,
+			})
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+			if got := accessClaims(t, env, response.AccessToken)["tenant_tier"]; got != tt.want {
+				t.Errorf("login tenant_tier = %v, want %q", got, tt.want)
+			}
+
+			refreshed, err := env.auth.RefreshToken(response.RefreshToken)
+			if err != nil {
+				t.Fatalf("RefreshToken() error = %v", err)
+			}
+			if got := accessClaims(t, env, refreshed.AccessToken)["tenant_tier"]; got != tt.want {
+				t.Errorf("refreshed tenant_tier = %v, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateOrganizationDefaultsTier(t *testing.T) {
+	tests := []struct {
+		name string
+		tier string
+		want string
+	}{
+		{name: "explicit tier is normalised", tier: " Pro ", want: "pro"},
+		{name: "missing tier uses the default", want: "basic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, nil)
+			org, err := env.org.CreateOrganization(&models.CreateOrganizationInput{Name: "Acme", Tier: tt.tier})
+			if err != nil {
+				t.Fatalf("CreateOrganization() error = %v", err)
+			}
+			if org.Tier != tt.want {
+				t.Errorf("tier = %q, want %q", org.Tier, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateOrganizationTier(t *testing.T) {
+	env := newTestEnv(t, nil)
+	org := env.createOrganization(t, "Acme", nil)
+
+	tests := []struct {
+		name     string
+		orgID    uint64
+		tier     string
+		want     string
+		notFound bool
+		wantErr  bool
+	}{
+		{name: "upgrade", orgID: org.ID, tier: " Enterprise", want: "enterprise"},
+		{name: "blank tier", orgID: org.ID, tier: "  ", wantErr: true},
+		{name: "unknown organization", orgID: org.ID + 100, tier: "pro", notFound: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := env.org.UpdateOrganizationTier(tt.orgID, &models.UpdateOrganizationTierInput{Tier: tt.tier})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdateOrganizationTier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrOrganizationNotFound) != tt.notFound {
+				t.Fatalf("UpdateOrganizationTier() error = %v, want not found %v", err, tt.notFound)
+			}
+			if err != nil {
+				return
+			}
+			if updated.Tier != tt.want {
+				t.Errorf("tier = %q, want %q", updated.Tier, tt.want)
+			}
+			stored, err := env.orgs.GetOrganizationByID(org.ID)
+			if err != nil {
+				t.Fatalf("reload organization: %v", err)
+			}
+			if stored.Tier != tt.want {
+				t.Errorf("stored tier = %q, want %q", stored.Tier, tt.want)
+			}
+		})
+	}
+}
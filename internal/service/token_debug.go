@@ -0,0 +1,56 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+var ErrMalformedToken = errors.New("token is malformed")
+
+// DebugToken decodes a token and reports its signature and expiry status without requiring it to be active.
+// It is meant for integration debugging; callers must never log the token.
+func (s *AuthenticationService) DebugToken(tokenString string) (*models.TokenDebugResult, error) {
+	unverified, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	claims, ok := unverified.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrMalformedToken
+	}
+
+	result := &models.TokenDebugResult{
+		Algorithm: unverified.Method.Alg(),
+		Header:    unverified.Header,
+		Claims:    claims,
+	}
+	if tokenType, ok := claims["type"].(string); ok {
+		result.TokenType = tokenType
+	}
+	if issuedAt, err := claims.GetIssuedAt(); err == nil && issuedAt != nil {
+		t := issuedAt.Time
+		result.IssuedAt = &t
+	}
+	if expiresAt, err := claims.GetExpirationTime(); err == nil && expiresAt != nil {
+		t := expiresAt.Time
+		result.ExpiresAt = &t
+		result.Expired = !s.now().Before(t)
+	}
+
+	// Verify the signature separately so expired tokens still report a valid signature
+	_, err = jwt.Parse(tokenString, s.TokenKeyFunc, jwt.WithoutClaimsValidation())
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.SignatureValid = true
+	}
+
+	notYetValid := false
+	if notBefore, err := claims.GetNotBefore(); err == nil && notBefore != nil {
+		notYetValid = s.now().Before(notBefore.Time)
+	}
+	result.Valid = result.SignatureValid && !result.Expired && !notYetValid
+	return result, nil
+}
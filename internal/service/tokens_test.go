@@ -0,0 +1,102 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestClaimUserIDRoundTrip(t *testing.T) {
+	env := newTestEnv(t, nil)
+	const userID = uint64(4242)
+
+	tests := []struct {
+		name   string
+		claim  any
+		parser *jwt.Parser
+	}{
+		{name: "float64", claim: float64(userID), parser: jwt.NewParser()},
+		{name: "json.Number", claim: json.Number("4242"), parser: jwt.NewParser(jwt.WithJSONNumber())},
+		{name: "string", claim: "4242", parser: jwt.NewParser()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+				"user_id": tt.claim,
+				"type":    "access",
+				"iss":     env.auth.Issuer(),
+				"exp":     time.Now().Add(time.Hour).Unix(),
+			})
+			signed, err := token.SignedString([]byte(env.cfg.JWTSecret))
+			if err != nil {
+				t.Fatalf("sign token: %v", err)
+			}
+
+			parsed, err := tt.parser.Parse(signed, env.auth.TokenKeyFunc)
+			if err != nil {
+				t.Fatalf("parse token: %v", err)
+			}
+			claims := parsed.Claims.(jwt.MapClaims)
+			got, ok := claimUserID(claims)
+			if !ok || got != userID {
+				t.Fatalf("claimUserID() = %d, %v, want %d, true (claim %T)", got, ok, userID, claims["user_id"])
+			}
+		})
+	}
+}
+
+func TestMembershipClaimNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   map[string]string
+		want    []string
+		missing []string
+	}{
+		{name: "defaults", want: []string{"organizations", "departments", "roles"}},
+		{
+			name:    "renamed",
+			names:   map[string]string{"organizations": "orgs", "roles": "groups"},
+			want:    []string{"orgs", "departments", "groups"},
+			missing: []string{"organizations", "roles"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, func(cfg *config.AuthConfig) { cfg.ClaimNames = tt.names })
+			org := env.createOrganization(t, "Acme", nil)
+			user := env.createUser(t, "member", nil)
+			env.addMember(t, user, org, models.OrganizationRole("CEO"))
+			dept := &models.Department{OrganizationID: org.ID, Name: "Engineering", IsActive: true}
+			if err := env.db.Create(dept).Error; err != nil {
+				t.Fatalf("create department: %v", err)
+			}
+			if err := env.db.Create(&models.UserDepartment{UserID: user.ID, DepartmentID: dept.ID, Role: "MEMBER"}).Error; err != nil {
+				t.Fatalf("add department member: %v", err)
+			}
+
+			resp, err := env.auth.Login(&models.LoginRequest{Username: user.Username, Password: testPassword, OrganizationID: org.ID})
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+			parsed, _, err := jwt.NewParser().ParseUnverified(resp.AccessToken, jwt.MapClaims{})
+			if err != nil {
+				t.Fatalf("parse token: %v", err)
+			}
+			claims := parsed.Claims.(jwt.MapClaims)
+			for _, name := range tt.want {
+				if _, ok := claims[name]; !ok {
+					t.Errorf("claim %q missing from %v", name, claims)
+				}
+			}
+			for _, name := range tt.missing {
+				if _, ok := claims[name]; ok {
+					t.Errorf("claim %q still emitted", name)
+				}
+			}
+		})
+	}
+}
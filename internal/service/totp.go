@@ -0,0 +1,68 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	totpDigits = 6
+	totpPeriod = 30 * time.Second
+	// totpSkew is the number of periods accepted on either side of the current one.
+	totpSkew = 1
+)
+
+// decodeTOTPSecret decodes a base32 secret as produced by authenticator enrollment.
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	normalized := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(secret), " ", ""))
+	return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(normalized, "="))
+}
+
+// totpCode computes the RFC 6238 code for the given counter.
+func totpCode(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
+
+// validateTOTP reports whether code matches the secret at the given time, allowing for clock skew.
+func validateTOTP(secret, code string, at time.Time) bool {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return false
+	}
+	key, err := decodeTOTPSecret(secret)
+	if err != nil || len(key) == 0 {
+		return false
+	}
+
+	counter := at.Unix() / int64(totpPeriod/time.Second)
+	for delta := -totpSkew; delta <= totpSkew; delta++ {
+		candidate := counter + int64(delta)
+		if candidate < 0 {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, uint64(candidate))), []byte(code)) == 1 {
+			return true
+		}
+	}
+	return false
+}
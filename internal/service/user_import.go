@@ -0,0 +1,183 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lee-tech/authentication/internal/models"
+	"github.com/lee-tech/authentication/internal/repository"
+	"github.com/lee-tech/core/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ImportUsers creates users from parsed import rows and assigns them to the organization on behalf of
+// the actor. Rows are processed independently so a failing row does not prevent the others from being
+// created. Each row must name a role the actor may grant; see importableRoles.
+func (s *AuthenticationService) ImportUsers(actorID, orgID uint64, rows []models.UserImportRow) ([]*models.UserImportResult, error) {
+	org, err := s.orgRepo.GetOrganizationByID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+	roles, err := s.importableRoles(actorID, org)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.UserImportResult, 0, len(rows))
+	for _, row := range rows {
+		result := &models.UserImportResult{
+			Line:     row.Line,
+			Email:    strings.TrimSpace(row.Email),
+			Username: strings.TrimSpace(row.Username),
+		}
+		if err := s.importUser(org, roles, row, result); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Success = true
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// importableRoles maps the organization's roles to whether the actor may grant them. The known roles are
+// ORG_ADMIN and the organization's role definitions, or the default role templates when it has none.
+// Super admins may grant all of them; other actors only roles of lower authority than the one they hold
+// in the organization. SYSTEM_ADMIN is never granted by an import.
+func (s *AuthenticationService) importableRoles(actorID uint64, org *models.Organization) (map[models.OrganizationRole]bool, error) {
+	levels := map[models.OrganizationRole]int{models.OrganizationRoleOrgAdmin: 0}
+	definitions, err := s.orgRepo.ListOrganizationRoles(org.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(definitions) == 0 {
+		for _, template := range models.DefaultOrganizationRoles {
+			levels[template.Code] = template.Level
+		}
+	}
+	for _, definition := range definitions {
+		levels[definition.Code] = definition.Level
+	}
+
+	actor, err := s.userRepo.GetByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+	actorLevel, granting := 0, actor != nil && actor.IsSuperAdmin
+	if actor != nil && !actor.IsSuperAdmin {
+		member, err := s.orgRepo.GetUserOrganization(actorID, org.ID)
+		if err != nil {
+			return nil, err
+		}
+		if member != nil {
+			if member.Role == models.OrganizationRoleSystemAdmin {
+				actorLevel, granting = -1, true
+			} else {
+				actorLevel, granting = levels[member.Role]
+			}
+		}
+	}
+
+	roles := make(map[models.OrganizationRole]bool, len(levels))
+	for role, level := range levels {
+		roles[role] = granting && (actor.IsSuperAdmin || level > actorLevel)
+	}
+	return roles, nil
+}
+
+// checkImportRole validates the role of an import row against the roles the actor may grant.
+func checkImportRole(role models.OrganizationRole, roles map[models.OrganizationRole]bool) error {
+	if role == "" {
+		return fmt.Errorf("role is required")
+	}
+	if role == models.OrganizationRoleSystemAdmin {
+		return fmt.Errorf("role %s cannot be imported", role)
+	}
+	grantable, known := roles[role]
+	if !known {
+		return fmt.Errorf("unknown role %q", role)
+	}
+	if !grantable {
+		return fmt.Errorf("not allowed to grant role %s", role)
+	}
+	return nil
+}
+
+func (s *AuthenticationService) importUser(org *models.Organization, roles map[models.OrganizationRole]bool, row models.UserImportRow, result *models.UserImportResult) error {
+	email := strings.ToLower(strings.TrimSpace(row.Email))
+	username := strings.TrimSpace(row.Username)
+	role := models.OrganizationRole(strings.ToUpper(strings.TrimSpace(string(row.Role))))
+
+	if email == "" || !utils.IsEmail(email) {
+		return fmt.Errorf("invalid email address")
+	}
+	if username == "" {
+		return fmt.Errorf("username is required")
+	}
+	if isReservedUsername(username) {
+		return fmt.Errorf("username %q is reserved", username)
+	}
+	if err := checkImportRole(role, roles); err != nil {
+		return err
+	}
+
+	exists, err := s.userRepo.ExistsByEmail(email)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("email already registered")
+	}
+	exists, err = s.userRepo.ExistsByUsername(username)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("username already taken")
+	}
+
+	length := temporaryPasswordLength
+	if s.config.PasswordMinLength > length {
+		length = s.config.PasswordMinLength
+	}
+	password, err := generateTemporaryPassword(length)
+	if err != nil {
+		return fmt.Errorf("generate temporary password: %w", err)
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), s.config.BCryptCost)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+
+	user := &models.User{
+		Email:                 email,
+		Username:              username,
+		Password:              string(hashedPassword),
+		FirstName:             strings.TrimSpace(row.FirstName),
+		LastName:              strings.TrimSpace(row.LastName),
+		IsActive:              true,
+		MustChangePassword:    true,
+		PrimaryOrganizationID: &org.ID,
+	}
+	// The user is only kept when the membership is stored too
+	err = s.userRepo.WithTx(func(repos *repository.TxRepositories) error {
+		if err := repos.Users.Create(user); err != nil {
+			return err
+		}
+		if err := repos.Organizations.UpsertUserOrganization(user.ID, org.ID, role, true); err != nil {
+			return fmt.Errorf("assign organization membership: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	result.UserID = user.ID
+	result.TemporaryPassword = password
+	return nil
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestImportUsersRows(t *testing.T) {
+	env := newTestEnv(t, func(cfg *config.AuthConfig) { cfg.PasswordMinLength = 20 })
+	org := env.createOrganization(t, "Acme", nil)
+	admin := env.createUser(t, "importer", func(u *models.User) { u.IsSuperAdmin = true })
+	env.createUser(t, "taken", nil)
+
+	rows := []models.UserImportRow{
+		{Line: 2, Email: "New.Hire@Example.com", Username: "newhire", FirstName: "New", LastName: "Hire", Role: "ceo"},
+		{Line: 3, Email: "not-an-email", Username: "bademail", Role: "CEO"},
+		{Line: 4, Email: "reserved@example.com", Username: "Admin", Role: "CEO"},
+		{Line: 5, Email: "taken@example.com", Username: "other", Role: "CEO"},
+		{Line: 6, Email: "dup@example.com", Username: "taken", Role: "CEO"},
+		{Line: 7, Email: "norole@example.com", Username: "norole"},
+		{Line: 8, Email: "unknown@example.com", Username: "unknown", Role: "JANITOR"},
+		{Line: 9, Email: "sysadmin@example.com", Username: "sysadmin", Role: models.OrganizationRoleSystemAdmin},
+		{Line: 10, Email: "orgadmin@example.com", Username: "orgadmin", Role: models.OrganizationRoleOrgAdmin},
+	}
+	wantErrors := map[int]string{
+		3: "invalid email address",
+		4: "reserved",
+		5: "email already registered",
+		6: "username already taken",
+		7: "role is required",
+		8: "unknown role",
+		9: "cannot be imported",
+	}
+
+	results, err := env.auth.ImportUsers(admin.ID, org.ID, rows)
+	if err != nil {
+		t.Fatalf("ImportUsers() error = %v", err)
+	}
+	if len(results) != len(rows) {
+		t.Fatalf("got %d results, want %d", len(results), len(rows))
+	}
+	for _, result := range results {
+		want, failed := wantErrors[result.Line]
+		if result.Success == failed {
+			t.Errorf("line %d: success = %v, error = %q", result.Line, result.Success, result.Error)
+			continue
+		}
+		if failed {
+			if !strings.Contains(result.Error, want) {
+				t.Errorf("line %d: error = %q, want it to mention %q", result.Line, result.Error, want)
+			}
+			if result.UserID != 0 || result.TemporaryPassword != "" {
+				t.Errorf("line %d: failed row reported user %d", result.Line, result.UserID)
+			}
+			continue
+		}
+
+		if len(result.TemporaryPassword) < env.cfg.PasswordMinLength {
+			t.Errorf("line %d: temporary password has %d characters, want at least %d", result.Line, len(result.TemporaryPassword), env.cfg.PasswordMinLength)
+		}
+		user := env.reloadUser(t, result.UserID)
+		if !user.MustChangePassword {
+			t.Errorf("line %d: imported user does not have to change the password", result.Line)
+		}
+		member, err := env.orgs.GetUserOrganization(user.ID, org.ID)
+		if err != nil || member == nil {
+			t.Fatalf("line %d: membership not stored: %v", result.Line, err)
+		}
+		response, err := env.auth.Login(&models.LoginRequest{Username: user.Username, Password: result.TemporaryPassword, OrganizationID: org.ID})
+		if err != nil {
+			t.Fatalf("line %d: login with temporary password: %v", result.Line, err)
+		}
+		if !response.User.MustChangePassword {
+			t.Errorf("line %d: login does not report must_change_password", result.Line)
+		}
+	}
+
+	imported := env.reloadUser(t, results[0].UserID)
+	if imported.Email != "new.hire@example.com" || imported.FirstName != "New" || imported.LastName != "Hire" {
+		t.Errorf("imported user = %q %q %q", imported.Email, imported.FirstName, imported.LastName)
+	}
+}
+
+func TestImportUsersGrantableRoles(t *testing.T) {
+	tests := []struct {
+		name       string
+		superAdmin bool
+		role       models.OrganizationRole
+		allowed    []models.OrganizationRole
+	}{
+		{name: "super admin", superAdmin: true, allowed: []models.OrganizationRole{models.OrganizationRoleOrgAdmin, "CHAIRMAN", "CEO"}},
+		{name: "system admin", role: models.OrganizationRoleSystemAdmin, allowed: []models.OrganizationRole{models.OrganizationRoleOrgAdmin, "CHAIRMAN", "CEO"}},
+		{name: "org admin", role: models.OrganizationRoleOrgAdmin, allowed: []models.OrganizationRole{"CHAIRMAN", "CEO"}},
+		{name: "chairman", role: "CHAIRMAN", allowed: []models.OrganizationRole{"CEO"}},
+		{name: "lowest role", role: "CEO"},
+		{name: "not a member"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, nil)
+			org := env.createOrganization(t, "Acme", nil)
+			actor := env.createUser(t, "importer", func(u *models.User) { u.IsSuperAdmin = tt.superAdmin })
+			if tt.role != "" {
+				env.addMember(t, actor, org, tt.role)
+			}
+
+			roles := []models.OrganizationRole{models.OrganizationRoleOrgAdmin, "CHAIRMAN", "CEO"}
+			rows := make([]models.UserImportRow, len(roles))
+			for i, role := range roles {
+				username := "user" + strings.ToLower(string(role))
+				rows[i] = models.UserImportRow{Line: i + 2, Email: username + "@example.com", Username: username, Role: role}
+			}
+			results, err := env.auth.ImportUsers(actor.ID, org.ID, rows)
+			if err != nil {
+				t.Fatalf("ImportUsers() error = %v", err)
+			}
+			for i, result := range results {
+				allowed := false
+				for _, role := range tt.allowed {
+					allowed = allowed || role == roles[i]
+				}
+				if result.Success != allowed {
+					t.Errorf("role %s: success = %v, want %v (%s)", roles[i], result.Success, allowed, result.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestImportUsersUnknownOrganization(t *testing.T) {
+	env := newTestEnv(t, nil)
+	admin := env.createUser(t, "importer", func(u *models.User) { u.IsSuperAdmin = true })
+
+	_, err := env.auth.ImportUsers(admin.ID, 9999, []models.UserImportRow{{Line: 1, Email: "a@example.com", Username: "a", Role: "CEO"}})
+	if !errors.Is(err, ErrOrganizationNotFound) {
+		t.Fatalf("ImportUsers() error = %v, want %v", err, ErrOrganizationNotFound)
+	}
+}
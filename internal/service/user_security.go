@@ -0,0 +1,94 @@
+package service
+
+import (
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// recentSecurityEventLimit caps the notable audit events returned with a security posture.
+const recentSecurityEventLimit = 10
+
+// GetUserSecurityPosture composes the account's MFA, login, password and session state with its recent
+// notable audit events. It returns nil when the user does not exist. Without a queryable audit log the
+// password change time, session estimate and events are left empty.
+func (s *AuthenticationService) GetUserSecurityPosture(userID uint64) (*models.UserSecurityPosture, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, nil
+	}
+
+	now := s.now()
+	posture := &models.UserSecurityPosture{
+		UserID: user.ID,
+		MFA: models.UserSecurityMFA{
+			Enabled:                user.MFAEnabled,
+			RecoveryCodesRemaining: len(user.MFARecoveryCodes),
+			FailedAttempts:         user.MFAFailedAttempts,
+		},
+		Login: models.UserSecurityLogin{
+			IsActive:       user.IsActive,
+			LastLoginAt:    user.LastLogin,
+			FailedAttempts: user.LoginAttempts,
+			Locked:         user.LockedUntil != nil && user.LockedUntil.After(now),
+			LockedUntil:    user.LockedUntil,
+		},
+		Password: models.UserSecurityPassword{
+			MustChange:       user.MustChangePassword,
+			ResetRequestedAt: user.LastPasswordResetRequestedAt,
+		},
+		Sessions: models.UserSecuritySessions{
+			CountedSince:     now.Add(-s.config.RefreshExpiration),
+			TokensValidAfter: user.TokensValidAfter,
+		},
+		RecentEvents: make([]*models.AuditEvent, 0),
+	}
+	if user.TokensValidAfter != nil && user.TokensValidAfter.After(posture.Sessions.CountedSince) {
+		posture.Sessions.CountedSince = *user.TokensValidAfter
+	}
+
+	reader, ok := s.audit.(AuditReader)
+	if !ok {
+		return posture, nil
+	}
+	target := models.AuditUserRef(user.ID)
+
+	changes, _, err := reader.List(models.AuditEventFilter{
+		Target:     target,
+		Actions:    models.PasswordAuditActions,
+		Limit:      1,
+		Descending: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) > 0 {
+		changedAt := changes[0].Timestamp
+		posture.Password.ChangedAt = &changedAt
+	}
+
+	countedSince := posture.Sessions.CountedSince
+	_, sessions, err := reader.List(models.AuditEventFilter{
+		Target:  target,
+		Actions: []string{models.AuditActionLoginSuccess},
+		From:    &countedSince,
+		Limit:   1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	posture.Sessions.Active = sessions
+
+	events, _, err := reader.List(models.AuditEventFilter{
+		Target:     target,
+		Actions:    models.SecurityAuditActions,
+		Limit:      recentSecurityEventLimit,
+		Descending: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	posture.RecentEvents = events
+	return posture, nil
+}
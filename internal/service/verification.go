@@ -0,0 +1,139 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+// verificationResendPageSize bounds how many unverified users are loaded at a time during a bulk resend.
+const verificationResendPageSize = 200
+
+var (
+	// ErrVerificationDeliveryUnavailable is returned when no verification sender is configured.
+	ErrVerificationDeliveryUnavailable = errors.New("verification delivery is not configured")
+	// ErrInvalidVerificationToken is returned for unknown, used or expired verification tokens.
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+)
+
+// VerificationSender delivers a verification token to a user, typically by email.
+type VerificationSender interface {
+	SendVerification(user *models.User, token string) error
+}
+
+// WithVerificationSender sets the channel used to deliver verification tokens.
+func (s *AuthenticationService) WithVerificationSender(sender VerificationSender) *AuthenticationService {
+	s.verification = sender
+	return s
+}
+
+// ListUnverifiedUsers returns a page of users that have not verified their email.
+func (s *AuthenticationService) ListUnverifiedUsers(offset, limit int) ([]*models.UnverifiedUser, int64, error) {
+	users, total, err := s.userRepo.ListUnverified(offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]*models.UnverifiedUser, 0, len(users))
+	for _, user := range users {
+		result = append(result, user.ToUnverifiedUser())
+	}
+	return result, total, nil
+}
+
+// ResendVerification issues a fresh verification token to every active unverified user. Users who were
+// sent one within VerificationResendInterval are skipped and counted as throttled.
+func (s *AuthenticationService) ResendVerification(actorID uint64) (*models.VerificationResendResult, error) {
+	if s.verification == nil {
+		return nil, ErrVerificationDeliveryUnavailable
+	}
+
+	now := s.now()
+	result := &models.VerificationResendResult{}
+	for offset := 0; ; offset += verificationResendPageSize {
+		users, _, err := s.userRepo.ListUnverified(offset, verificationResendPageSize)
+		if err != nil {
+			return result, fmt.Errorf("list unverified users: %w", err)
+		}
+
+		for _, user := range users {
+			if !user.IsActive {
+				continue
+			}
+			if user.VerificationSentAt != nil && now.Sub(*user.VerificationSentAt) < s.config.VerificationResendInterval {
+				result.Throttled++
+				continue
+			}
+			if err := s.resendVerification(user); err != nil {
+				log.Printf("failed to resend verification to user %d: %v", user.ID, err)
+				result.Failed++
+				continue
+			}
+			result.Sent++
+		}
+
+		if len(users) < verificationResendPageSize {
+			break
+		}
+	}
+
+	s.recordAudit(&models.AuditEvent{
+		Actor:     models.AuditUserRef(actorID),
+		Action:    models.AuditActionVerificationResend,
+		Success:   result.Failed == 0,
+		Timestamp: now,
+		Metadata: map[string]any{
+			"sent":      result.Sent,
+			"throttled": result.Throttled,
+			"failed":    result.Failed,
+		},
+	})
+	return result, nil
+}
+
+// VerifyEmail consumes a verification token, marking its user's email as verified.
+func (s *AuthenticationService) VerifyEmail(token string) error {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return ErrInvalidVerificationToken
+	}
+
+	user, err := s.userRepo.GetByVerificationToken(hashToken(token))
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrInvalidVerificationToken
+	}
+	// Tokens issued before expiries were recorded have none and stay valid until used
+	if user.VerificationExpiry != nil && !s.now().Before(*user.VerificationExpiry) {
+		return ErrInvalidVerificationToken
+	}
+
+	return s.userRepo.MarkVerified(user.ID)
+}
+
+func (s *AuthenticationService) resendVerification(user *models.User) error {
+	token, err := generateVerificationToken()
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	if err := s.userRepo.UpdateVerificationToken(user.ID, hashToken(token), now, now.Add(s.config.VerificationTokenTTL)); err != nil {
+		return err
+	}
+	return s.verification.SendVerification(user, token)
+}
+
+func generateVerificationToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate verification token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
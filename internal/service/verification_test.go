@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lee-tech/authentication/config"
+	"github.com/lee-tech/authentication/internal/models"
+)
+
+func TestRegisterStoresVerificationDigest(t *testing.T) {
+	env := newTestEnv(t, func(cfg *config.AuthConfig) { cfg.RegistrationEnabled = true })
+	sender := newRecordingSender()
+	env.auth.WithVerificationSender(sender)
+
+	user, err := env.auth.Register(&models.RegisterRequest{Email: "new@example.com", Username: "new", Password: testPassword})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	token := sender.verifications[user.ID]
+	if token == "" {
+		t.Fatal("Register() did not deliver a verification token")
+	}
+	stored := env.reloadUser(t, user.ID)
+	if stored.VerificationToken == nil || *stored.VerificationToken != hashToken(token) {
+		t.Fatalf("stored verification token = %v, want the digest of the delivered token", stored.VerificationToken)
+	}
+
+	if err := env.auth.VerifyEmail(*stored.VerificationToken); !errors.Is(err, ErrInvalidVerificationToken) {
+		t.Fatalf("VerifyEmail(digest) error = %v, want %v", err, ErrInvalidVerificationToken)
+	}
+	if err := env.auth.VerifyEmail(token); err != nil {
+		t.Fatalf("VerifyEmail(token) error = %v", err)
+	}
+	if !env.reloadUser(t, user.ID).IsVerified {
+		t.Fatal("VerifyEmail() did not mark the user verified")
+	}
+	if err := env.auth.VerifyEmail(token); !errors.Is(err, ErrInvalidVerificationToken) {
+		t.Fatalf("second VerifyEmail() error = %v, want %v", err, ErrInvalidVerificationToken)
+	}
+}
+
+func TestResendVerificationStoresDigest(t *testing.T) {
+	env := newTestEnv(t, nil)
+	sender := newRecordingSender()
+	env.auth.WithVerificationSender(sender)
+	user := env.createUser(t, "pending", func(u *models.User) { u.IsVerified = false })
+
+	if _, err := env.auth.ResendVerification(user.ID); err != nil {
+		t.Fatalf("ResendVerification() error = %v", err)
+	}
+	token := sender.verifications[user.ID]
+	stored := env.reloadUser(t, user.ID)
+	if token == "" || stored.VerificationToken == nil || *stored.VerificationToken != hashToken(token) {
+		t.Fatalf("stored verification token = %v, want the digest of %q", stored.VerificationToken, token)
+	}
+}
+
+func TestLoginRequiresVerifiedEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		require  bool
+		verified bool
+		password string
+		wantErr  error
+	}{
+		{name: "unverified refused", require: true, verified: false, password: testPassword, wantErr: ErrEmailNotVerified},
+		{name: "wrong password still invalid", require: true, verified: false, password: "wrong-password", wantErr: ErrInvalidCredentials},
+		{name: "verified allowed", require: true, verified: true, password: testPassword},
+		{name: "unverified allowed when not required", require: false, verified: false, password: testPassword},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, func(cfg *config.AuthConfig) { cfg.RequireEmailVerification = tt.require })
+			org := env.createOrganization(t, "Acme", nil)
+			user := env.createUser(t, "ada", func(u *models.User) { u.IsVerified = tt.verified })
+			env.addMember(t, user, org, models.OrganizationRoleOrgAdmin)
+
+			resp, err := env.auth.Login(&models.LoginRequest{Username: "ada", Password: tt.password})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Login() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+			if resp.AccessToken == "" {
+				t.Fatal("Login() returned no access token")
+			}
+		})
+	}
+}
+
+func TestListUnverifiedUsersExcludesVerified(t *testing.T) {
+	env := newTestEnv(t, nil)
+	env.createUser(t, "verified", nil)
+	var unverified []string
+	for _, name := range []string{"pending-a", "pending-b", "pending-c"} {
+		user := env.createUser(t, name, nil)
+		if err := env.db.Model(user).Update("is_verified", false).Error; err != nil {
+			t.Fatalf("mark %s unverified: %v", name, err)
+		}
+		unverified = append(unverified, name)
+	}
+
+	users, total, err := env.auth.ListUnverifiedUsers(0, 10)
+	if err != nil {
+		t.Fatalf("ListUnverifiedUsers() error = %v", err)
+	}
+	if total != int64(len(unverified)) || len(users) != len(unverified) {
+		t.Fatalf("ListUnverifiedUsers() = %d users of %d, want %d", len(users), total, len(unverified))
+	}
+	for i, user := range users {
+		if user.Username != unverified[i] {
+			t.Errorf("user %d = %q, want %q", i, user.Username, unverified[i])
+		}
+	}
+
+	page, total, err := env.auth.ListUnverifiedUsers(2, 2)
+	if err != nil {
+		t.Fatalf("ListUnverifiedUsers(page 2) error = %v", err)
+	}
+	if total != int64(len(unverified)) || len(page) != 1 || page[0].Username != "pending-c" {
+		t.Errorf("ListUnverifiedUsers(page 2) = %+v of %d, want pending-c of %d", page, total, len(unverified))
+	}
+}
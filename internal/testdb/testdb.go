@@ -0,0 +1,94 @@
+// Package testdb opens throwaway SQLite databases with the service schema for tests.
+package testdb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/lee-tech/authentication/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+var databases atomic.Uint64
+
+// Open returns an in-memory database migrated with every model. Postgres fills bigint primary keys
+// from a sequence while SQLite does not, so zero IDs are numbered on insert.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:testdb%d?mode=memory&cache=shared", databases.Add(1))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	// A single connection keeps the in-memory database alive and serialises transactions
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	var lastID atomic.Uint64
+	err = db.Callback().Create().Before("gorm:create").Register("testdb:assign_id", func(tx *gorm.DB) {
+		if tx.Statement.Schema == nil {
+			return
+		}
+		field := tx.Statement.Schema.PrioritizedPrimaryField
+		if field == nil || field.FieldType.Kind() != reflect.Uint64 {
+			return
+		}
+		ctx := tx.Statement.Context
+		rv := tx.Statement.ReflectValue
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < rv.Len(); i++ {
+				assignID(ctx, field, rv.Index(i), &lastID)
+			}
+		case reflect.Struct:
+			assignID(ctx, field, rv, &lastID)
+		}
+	})
+	if err != nil {
+		t.Fatalf("register id callback: %v", err)
+	}
+
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.Organization{},
+		&models.Department{},
+		&models.OrganizationRoleDefinition{},
+		&models.UserOrganization{},
+		&models.UserDepartment{},
+		&models.RevokedToken{},
+		&models.IssuedToken{},
+		&models.APIKey{},
+		&models.PasswordHistory{},
+		&models.AuditEvent{},
+		&models.OAuthAuthorization{},
+	); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+	return db
+}
+
+func assignID(ctx context.Context, field *schema.Field, value reflect.Value, lastID *atomic.Uint64) {
+	value = reflect.Indirect(value)
+	if current, zero := field.ValueOf(ctx, value); zero {
+		_ = field.Set(ctx, value, lastID.Add(1))
+	} else if id, ok := current.(uint64); ok {
+		// Keep generated IDs clear of explicitly chosen ones
+		for {
+			last := lastID.Load()
+			if id <= last || lastID.CompareAndSwap(last, id) {
+				break
+			}
+		}
+	}
+}